@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+// AddressListCmd manages the deny/allow list of known bots and routers
+var AddressListCmd = &cobra.Command{
+	Use:   "addressList",
+	Short: "manage the deny/allow list of addresses",
+}
+
+var addressListAddCmd = &cobra.Command{
+	Run:   runAddressListAdd,
+	Use:   "add <address>",
+	Short: "add or update an address in the deny/allow list",
+	Args:  cobra.ExactArgs(1),
+}
+
+var addressListRemoveCmd = &cobra.Command{
+	Run:   runAddressListRemove,
+	Use:   "remove <address>",
+	Short: "remove an address from the lists",
+	Args:  cobra.ExactArgs(1),
+}
+
+var addressListShowCmd = &cobra.Command{
+	Run:   runAddressListShow,
+	Use:   "show",
+	Short: "print the list entries",
+}
+
+var (
+	addressList  string
+	addressLabel string
+)
+
+func init() {
+	addressListAddCmd.Flags().StringVar(&addressList, "list", constants.AddressListDeny, "deny or allow")
+	addressListAddCmd.Flags().StringVar(&addressLabel, "label", "", "note of the address, e.g. mev bot")
+	addressListShowCmd.Flags().StringVar(&addressList, "list", "", "deny or allow, both when empty")
+
+	AddressListCmd.AddCommand(addressListAddCmd, addressListRemoveCmd, addressListShowCmd)
+}
+
+func runAddressListAdd(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.AddressInfo.SetListEntry(context.TODO(), args[0], addressList, addressLabel); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runAddressListRemove(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.AddressInfo.RemoveListEntry(context.TODO(), args[0]); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runAddressListShow(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	entries, err := s.AddressInfo.GetList(context.TODO(), addressList)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("%s\t%s\t%s\n", entry.Address, entry.List, entry.Label)
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+// AllocationCmd turns the points of snapshot campaigns into token allocations with vesting
+var AllocationCmd = &cobra.Command{
+	Use:   "allocation",
+	Short: "allocate a token budget to the users of a snapshot campaign",
+}
+
+var allocationCreateCmd = &cobra.Command{
+	Run:   runAllocationCreate,
+	Use:   "create <campaignId>",
+	Short: "split a token budget across the snapshot users of a campaign by their points",
+	Args:  cobra.ExactArgs(1),
+}
+
+var allocationExportCmd = &cobra.Command{
+	Run:   runAllocationExport,
+	Use:   "export <campaignId>",
+	Short: "print the allocations of a campaign with their unlocks",
+	Args:  cobra.ExactArgs(1),
+}
+
+var (
+	allocationBudget  string
+	allocationStart   string
+	allocationTge     string
+	allocationCliff   int
+	allocationVesting int
+	allocationOutput  string
+)
+
+func init() {
+	allocationCreateCmd.Flags().StringVar(&allocationBudget, "budget", "", "tokens to allocate")
+	allocationCreateCmd.Flags().StringVar(&allocationStart, "start", "", "day of the token generation event, e.g. 2024-11-01")
+	allocationCreateCmd.Flags().StringVar(&allocationTge, "tge", "0", "share unlocked at the start, between 0 and 1")
+	allocationCreateCmd.Flags().IntVar(&allocationCliff, "cliff", 0, "months before the first vested unlock")
+	allocationCreateCmd.Flags().IntVar(&allocationVesting, "vesting", 0, "months the rest vests linearly over, unlocked at the start when 0")
+
+	allocationExportCmd.Flags().StringVar(&allocationOutput, "output", "csv", "csv or json")
+
+	AllocationCmd.AddCommand(allocationCreateCmd, allocationExportCmd)
+}
+
+func runAllocationCreate(_ *cobra.Command, args []string) {
+	budget, err := decimal.NewFromString(allocationBudget)
+	if err != nil {
+		log.Panicf("invalid budget: %v", err)
+	}
+	start, err := time.Parse("2006-01-02", allocationStart)
+	if err != nil {
+		log.Panicf("invalid start: %v", err)
+	}
+	tge, err := decimal.NewFromString(allocationTge)
+	if err != nil {
+		log.Panicf("invalid tge: %v", err)
+	}
+
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	if err := db.Upgrade(d, "migrations"); err != nil {
+		panic(err)
+	}
+
+	s := service.NewService(d)
+	plan, err := s.Allocation.CreatePlan(context.TODO(), args[0], budget, model.VestingConfig{
+		Start:         start,
+		Tge:           tge,
+		CliffMonths:   allocationCliff,
+		VestingMonths: allocationVesting,
+	})
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	fmt.Printf("%s\t%d users\t%s tokens\n", plan.ID, plan.Users, plan.Budget)
+}
+
+func runAllocationExport(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	allocations, err := s.Allocation.GetPlanAllocations(context.TODO(), args[0])
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	if err := printAllocations(os.Stdout, allocations, allocationOutput); err != nil {
+		log.Panicln(err)
+	}
+}
+
+// printAllocations writes the allocations as JSON, or as CSV with a row per unlock.
+func printAllocations(w io.Writer, allocations []model.Allocation, output string) error {
+	switch output {
+	case "json":
+		return json.NewEncoder(w).Encode(allocations)
+	case "csv":
+	default:
+		return fmt.Errorf("invalid output %q, csv or json", output)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"address", "point", "amount", "unlockAt", "unlock", "cumulative"})
+	for _, a := range allocations {
+		for _, u := range a.Unlocks {
+			cw.Write([]string{
+				a.UserAddress, a.Point.String(), a.Amount.String(),
+				u.At.Format(time.DateOnly), u.Amount.String(), u.Cumulative.String(),
+			})
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+// AnalyzeSandwichCmd marks sandwich swaps of already ingested blocks
+var AnalyzeSandwichCmd = &cobra.Command{
+	Run:   runAnalyzeSandwich,
+	Use:   "analyzeSandwich",
+	Short: "mark front-run, victim and back-run swaps of a pair in a block range",
+}
+
+var (
+	sandwichPair      string
+	sandwichFromBlock uint64
+	sandwichToBlock   uint64
+)
+
+func init() {
+	AnalyzeSandwichCmd.Flags().StringVar(&sandwichPair, "pair", "", "pair address")
+	AnalyzeSandwichCmd.Flags().Uint64Var(&sandwichFromBlock, "from", 0, "first block")
+	AnalyzeSandwichCmd.Flags().Uint64Var(&sandwichToBlock, "to", 0, "last block")
+	AnalyzeSandwichCmd.MarkFlagRequired("pair")
+	AnalyzeSandwichCmd.MarkFlagRequired("to")
+}
+
+func runAnalyzeSandwich(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	count, err := s.Transaction.MarkSandwiches(context.TODO(), sandwichPair, sandwichFromBlock, sandwichToBlock)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	log.Printf("found %d sandwiches", count)
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"tradingAce/pkg/core/claim"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+// CampaignCmd manages the campaigns grouping tasks under a season and budget
+var CampaignCmd = &cobra.Command{
+	Use:   "campaign",
+	Short: "manage campaigns and their tasks",
+}
+
+var campaignCreateCmd = &cobra.Command{
+	Run:   runCampaignCreate,
+	Use:   "create",
+	Short: "create a campaign",
+}
+
+var campaignUpdateCmd = &cobra.Command{
+	Run:   runCampaignUpdate,
+	Use:   "update <campaignId>",
+	Short: "update the name, season and budget of a campaign",
+	Args:  cobra.ExactArgs(1),
+}
+
+var campaignDeleteCmd = &cobra.Command{
+	Run:   runCampaignDelete,
+	Use:   "delete <campaignId>",
+	Short: "delete a campaign, its tasks are kept",
+	Args:  cobra.ExactArgs(1),
+}
+
+var campaignListCmd = &cobra.Command{
+	Run:   runCampaignList,
+	Use:   "list",
+	Short: "print the campaigns and their tasks",
+}
+
+var campaignAttachCmd = &cobra.Command{
+	Run:   runCampaignAttach,
+	Use:   "attach <campaignId> <taskId>",
+	Short: "add a task to a campaign",
+	Args:  cobra.ExactArgs(2),
+}
+
+var campaignDetachCmd = &cobra.Command{
+	Run:   runCampaignDetach,
+	Use:   "detach <campaignId> <taskId>",
+	Short: "remove a task from a campaign",
+	Args:  cobra.ExactArgs(2),
+}
+
+var campaignSnapshotCmd = &cobra.Command{
+	Run:   runCampaignSnapshot,
+	Use:   "snapshot <campaignId>",
+	Short: "freeze the points of an ended campaign and print the Merkle root of its claims",
+	Args:  cobra.ExactArgs(1),
+}
+
+var campaignDecimals int32
+
+var (
+	campaignName   string
+	campaignStart  string
+	campaignEnd    string
+	campaignBudget string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{campaignCreateCmd, campaignUpdateCmd} {
+		c.Flags().StringVar(&campaignName, "name", "", "name of the campaign")
+		c.Flags().StringVar(&campaignStart, "start", "", "first day of the season, e.g. 2024-07-01")
+		c.Flags().StringVar(&campaignEnd, "end", "", "day after the season, e.g. 2024-10-01")
+		c.Flags().StringVar(&campaignBudget, "budget", "", "points the tasks can award, unlimited when empty")
+	}
+
+	campaignSnapshotCmd.Flags().Int32Var(&campaignDecimals, "decimals", claim.DefaultDecimals, "decimals of the claimed token, one token per point")
+
+	CampaignCmd.AddCommand(campaignCreateCmd, campaignUpdateCmd, campaignDeleteCmd, campaignListCmd, campaignAttachCmd, campaignDetachCmd, campaignSnapshotCmd)
+}
+
+func campaignFromFlags() model.Campaign {
+	startAt, err := time.Parse("2006-01-02", campaignStart)
+	if err != nil {
+		log.Panicf("invalid start: %v", err)
+	}
+	endAt, err := time.Parse("2006-01-02", campaignEnd)
+	if err != nil {
+		log.Panicf("invalid end: %v", err)
+	}
+
+	c := model.Campaign{Name: campaignName, StartAt: startAt, EndAt: endAt}
+	if len(campaignBudget) != 0 {
+		budget, err := decimal.NewFromString(campaignBudget)
+		if err != nil {
+			log.Panicf("invalid budget: %v", err)
+		}
+		c.Budget = &budget
+	}
+
+	return c
+}
+
+func runCampaignCreate(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	c, err := s.Campaign.CreateCampaign(context.TODO(), campaignFromFlags())
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	fmt.Println(c.ID)
+}
+
+func runCampaignUpdate(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	c := campaignFromFlags()
+	c.ID = args[0]
+
+	s := service.NewService(d)
+	if err := s.Campaign.UpdateCampaign(context.TODO(), c); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runCampaignDelete(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.Campaign.DeleteCampaign(context.TODO(), args[0]); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runCampaignList(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	campaigns, err := s.Campaign.GetCampaigns(context.TODO())
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	for _, c := range campaigns {
+		budget := "unlimited"
+		if c.Budget != nil {
+			budget = c.Budget.String()
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%v\n",
+			c.ID, c.Name, c.StartAt.Format(time.DateOnly), c.EndAt.Format(time.DateOnly), budget, c.TaskIDs)
+	}
+}
+
+func runCampaignAttach(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.Campaign.AttachTask(context.TODO(), args[0], args[1]); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runCampaignDetach(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.Campaign.DetachTask(context.TODO(), args[0], args[1]); err != nil {
+		log.Panicln(err)
+	}
+}
+
+func runCampaignSnapshot(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	if err := db.Upgrade(d, "migrations"); err != nil {
+		panic(err)
+	}
+
+	s := service.NewService(d)
+	snapshot, err := s.Claim.CreateSnapshot(context.TODO(), args[0], campaignDecimals)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	fmt.Printf("%s\t%d users\t%s points\t%s\n", snapshot.Root, snapshot.Users, snapshot.TotalPoint, snapshot.TotalAmount)
+}
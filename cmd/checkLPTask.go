@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+var CheckLPTaskCmd = &cobra.Command{
+	Run: runCheckLPTaskCmd,
+	Use: "checkLPTask",
+}
+
+func runCheckLPTaskCmd(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	if err := db.Upgrade(d, "migrations"); err != nil {
+		panic(err)
+	}
+
+	ctx := context.TODO()
+	s := service.NewService(d)
+	if forceSettlement {
+		tasks, err := s.Task.GetLPTasks(ctx)
+		if err := forceSettle(ctx, s, tasks, err); err != nil {
+			log.Panicln(err)
+		}
+		return
+	}
+
+	if err := s.UserTask.CheckLPTasks(ctx); err != nil {
+		log.Panicln(err)
+	}
+}
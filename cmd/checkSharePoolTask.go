@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"log"
+	"os"
 	"tradingAce/pkg/core/db"
 	"tradingAce/pkg/service"
 
@@ -15,6 +16,16 @@ var CheckSharePoolTaskCmd = &cobra.Command{
 	Use: "checkSharePoolTask",
 }
 
+var (
+	dryRun       bool
+	dryRunOutput string
+)
+
+func init() {
+	CheckSharePoolTaskCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what the settlement would change per user, without writing it")
+	CheckSharePoolTaskCmd.Flags().StringVar(&dryRunOutput, "output", "table", "format of the dry run, table or json")
+}
+
 func runCheckSharePoolTaskCmd(_ *cobra.Command, _ []string) {
 	d, err := db.SetupDB()
 	if err != nil {
@@ -26,8 +37,33 @@ func runCheckSharePoolTaskCmd(_ *cobra.Command, _ []string) {
 		panic(err)
 	}
 
+	ctx := context.TODO()
 	s := service.NewService(d)
-	if err := s.UserTask.CheckSharePoolTasks(context.TODO()); err != nil {
+	if dryRun {
+		tasks, err := s.Task.GetSharePoolTask(ctx)
+		if err != nil {
+			log.Panicln(err)
+		}
+		for _, task := range tasks {
+			diff, err := s.UserTask.DiffSharePoolTask(ctx, task)
+			if err != nil {
+				log.Panicln(err)
+			}
+			if err := printSettlementDiff(os.Stdout, diff, dryRunOutput); err != nil {
+				log.Panicln(err)
+			}
+		}
+		return
+	}
+	if forceSettlement {
+		tasks, err := s.Task.GetSharePoolTask(ctx)
+		if err := forceSettle(ctx, s, tasks, err); err != nil {
+			log.Panicln(err)
+		}
+		return
+	}
+
+	if err := s.UserTask.CheckSharePoolTasks(ctx); err != nil {
 		log.Panicln(err)
 	}
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+var CheckStreakTaskCmd = &cobra.Command{
+	Run: runCheckStreakTaskCmd,
+	Use: "checkStreakTask",
+}
+
+func runCheckStreakTaskCmd(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	if err := db.Upgrade(d, "migrations"); err != nil {
+		panic(err)
+	}
+
+	ctx := context.TODO()
+	s := service.NewService(d)
+	if forceSettlement {
+		tasks, err := s.Task.GetStreakTasks(ctx)
+		if err := forceSettle(ctx, s, tasks, err); err != nil {
+			log.Panicln(err)
+		}
+		return
+	}
+
+	if err := s.UserTask.CheckStreakTasks(ctx); err != nil {
+		log.Panicln(err)
+	}
+}
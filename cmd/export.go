@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/core/export"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd dumps a dataset as it is read from the database
+var ExportCmd = &cobra.Command{
+	Run:   runExport,
+	Use:   "export <userPoint|userTask|transaction|leaderboard>",
+	Short: "export user points, user tasks, transactions or the leaderboard as csv, jsonl or ndjson",
+	Args:  cobra.ExactArgs(1),
+}
+
+var (
+	exportFormat   string
+	exportTask     string
+	exportCampaign string
+	exportFrom     string
+	exportTo       string
+	exportFile     string
+)
+
+func init() {
+	ExportCmd.Flags().StringVar(&exportFormat, "format", export.FormatCSV, "csv, jsonl or ndjson")
+	ExportCmd.Flags().StringVar(&exportTask, "task", "", "only the rows of the task")
+	ExportCmd.Flags().StringVar(&exportCampaign, "campaign", "", "only the rows of the tasks of the campaign")
+	ExportCmd.Flags().StringVar(&exportFrom, "from", "", "first day of the rows, e.g. 2024-07-01")
+	ExportCmd.Flags().StringVar(&exportTo, "to", "", "day after the rows, e.g. 2024-10-01")
+	ExportCmd.Flags().StringVar(&exportFile, "file", "", "file to write, stdout when empty")
+}
+
+func runExport(_ *cobra.Command, args []string) {
+	opt := option.ExportOptions{Dataset: args[0], TaskID: exportTask, CampaignID: exportCampaign}
+	for dest, value := range map[**time.Time]string{&opt.From: exportFrom, &opt.To: exportTo} {
+		if len(value) != 0 {
+			day, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				log.Panicf("invalid date: %v", err)
+			}
+			*dest = &day
+		}
+	}
+
+	out := os.Stdout
+	if len(exportFile) != 0 {
+		f, err := os.Create(exportFile)
+		if err != nil {
+			log.Panicln(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w, err := export.NewWriter(out, exportFormat)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	if err := s.Export.Export(context.TODO(), opt, w); err != nil {
+		log.Panicln(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Panicln(err)
+	}
+}
@@ -52,8 +52,17 @@ func initFirstTask(d *sql.DB) error {
 		return err
 	}
 
+	if _, err := d.Exec(
+		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt")
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM task WHERE name = 'referral');`,
+		utils.GenDBID(), time.Now(), "referral", nil, startAt,
+	); err != nil {
+		return err
+	}
+
 	_, err := d.Exec(
-		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("pairAddress") DO NOTHING;`,
+		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("name", "pairAddress") DO NOTHING;`,
 		utils.GenDBID(), time.Now(), "share_pool", "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", startAt,
 	)
 
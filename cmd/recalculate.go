@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+// RecalculateCmd replays the settlement of tasks under a rule version and prints the points changed per user
+var RecalculateCmd = &cobra.Command{
+	Run:   runRecalculate,
+	Use:   "recalculate",
+	Short: "settle epochs of tasks again under a rule version, recording the points before and after",
+}
+
+// TaskRuleCmd manages the rule versions of tasks
+var TaskRuleCmd = &cobra.Command{
+	Use:   "taskRule",
+	Short: "manage the rule versions of tasks",
+}
+
+var taskRuleCreateCmd = &cobra.Command{
+	Run:   runTaskRuleCreate,
+	Use:   "create <taskId> <config.json>",
+	Short: "add a rule version to a task, effective for the epochs starting at or after --effective",
+	Args:  cobra.ExactArgs(2),
+}
+
+var taskRuleListCmd = &cobra.Command{
+	Run:   runTaskRuleList,
+	Use:   "list <taskId>",
+	Short: "print the rule versions of a task",
+	Args:  cobra.ExactArgs(1),
+}
+
+var (
+	recalculateTasks   []string
+	recalculateEpochs  []int
+	recalculateVersion int
+	recalculateReason  string
+
+	taskRuleEffective string
+	taskRuleNote      string
+)
+
+func init() {
+	RecalculateCmd.Flags().StringSliceVar(&recalculateTasks, "task", nil, "ids of the recalculated tasks")
+	RecalculateCmd.Flags().IntSliceVar(&recalculateEpochs, "epochs", nil, "recalculated epochs, every settled epoch when empty")
+	RecalculateCmd.Flags().IntVar(&recalculateVersion, "version", 0, "rule version applied to the epochs, the versions in effect when 0")
+	RecalculateCmd.Flags().StringVar(&recalculateReason, "reason", "", "why the tasks are recalculated, kept in the audit record")
+	RecalculateCmd.MarkFlagRequired("task")
+
+	taskRuleCreateCmd.Flags().StringVar(&taskRuleEffective, "effective", "", "start of the first epoch the version applies to, RFC 3339, now when empty")
+	taskRuleCreateCmd.Flags().StringVar(&taskRuleNote, "note", "", "what the version changes")
+	TaskRuleCmd.AddCommand(taskRuleCreateCmd, taskRuleListCmd)
+}
+
+func runRecalculate(_ *cobra.Command, _ []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	if err := db.Upgrade(d, "migrations"); err != nil {
+		panic(err)
+	}
+
+	ctx := context.TODO()
+	s := service.NewService(d)
+	for _, taskID := range recalculateTasks {
+		task, err := s.Task.GetTask(ctx, taskID)
+		if err != nil {
+			log.Panicf("get task %s: %v", taskID, err)
+		}
+		rec, err := s.UserTask.Recalculate(ctx, task, option.RecalculateOptions{
+			Epochs:      recalculateEpochs,
+			RuleVersion: recalculateVersion,
+			Reason:      recalculateReason,
+		})
+		if err != nil {
+			log.Panicln(err)
+		}
+
+		fmt.Printf("recalculation %s of task %s, epochs %v\n", rec.ID, rec.TaskID, rec.Epochs)
+		for _, e := range rec.Entries {
+			fmt.Printf("%s\t%s\t%s\n", e.UserAddress, e.Before, e.After)
+		}
+	}
+}
+
+func runTaskRuleCreate(_ *cobra.Command, args []string) {
+	raw, err := os.ReadFile(args[1])
+	if err != nil {
+		log.Panicln(err)
+	}
+	var config model.TaskConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		log.Panicf("invalid config: %v", err)
+	}
+	effectiveAt := time.Now()
+	if len(taskRuleEffective) != 0 {
+		if effectiveAt, err = time.Parse(time.RFC3339, taskRuleEffective); err != nil {
+			log.Panicf("invalid effective: %v", err)
+		}
+	}
+
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	v, err := s.Task.CreateRuleVersion(context.TODO(), args[0], config, effectiveAt, taskRuleNote)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	fmt.Println(v.Version)
+}
+
+func runTaskRuleList(_ *cobra.Command, args []string) {
+	d, err := db.SetupDB()
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	s := service.NewService(d)
+	versions, err := s.Task.GetRuleVersions(context.TODO(), args[0])
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	for _, v := range versions {
+		fmt.Printf("%d\t%s\t%s\n", v.Version, v.EffectiveAt.Format(time.RFC3339), v.Note)
+	}
+}
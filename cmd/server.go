@@ -22,12 +22,53 @@ func runServer(_ *cobra.Command, _ []string) {
 	defer d.Close()
 
 	s := service.NewService(d)
-	server := rest.NewRestServer(s.Task, s.UserPoint, s.UserTask)
+	server := rest.NewRestServer(s.Task, s.UserPoint, s.UserTask, s.TradeFlag, s.AddressInfo, s.Referral, s.Multiplier, s.Campaign, s.Leaderboard, s.Schedule, s.Settlement, s.Recalculation, s.Claim, s.Voucher, s.Allocation, s.Export)
 
 	r := gin.Default()
 	r.GET("/userTasks/:address", server.GetUserTasks)
 	r.GET("/userPoints/*taskId", server.GetUserPoints)
+	r.GET("/pointLedger/:address", server.GetPointLedger)
 	r.POST("/sharePoolTask", server.CreateSharePoolTask)
+	r.POST("/streakTask", server.CreateStreakTask)
+	r.POST("/lpTask", server.CreateLPTask)
+	r.GET("/tasks/:taskId/epochs", server.GetTaskEpochs)
+	r.GET("/tasks/:taskId/settlements", server.GetTaskSettlements)
+	r.GET("/tasks/:taskId/settlementRuns", server.GetSettlementRuns)
+	r.GET("/tasks/:taskId/settlementDiff", server.GetSettlementDiff)
+	r.POST("/tasks/:taskId/epochs/:epoch/finalize", server.FinalizeEpoch)
+	r.GET("/tasks/:taskId/ruleVersions", server.GetRuleVersions)
+	r.POST("/tasks/:taskId/ruleVersions", server.CreateRuleVersion)
+	r.GET("/tasks/:taskId/recalculations", server.GetRecalculations)
+	r.GET("/recalculations/:recalculationId", server.GetRecalculation)
+	r.GET("/tradeFlags", server.GetTradeFlags)
+	r.GET("/addressList", server.GetAddressList)
+	r.POST("/addressList", server.SetAddressListEntry)
+	r.DELETE("/addressList/:address", server.RemoveAddressListEntry)
+	r.POST("/referrals", server.RegisterReferral)
+	r.GET("/referrals/:address", server.GetReferralStats)
+	r.GET("/multipliers", server.GetMultiplierRules)
+	r.POST("/multipliers", server.CreateMultiplierRule)
+	r.DELETE("/multipliers/:ruleId", server.DeleteMultiplierRule)
+	r.GET("/campaigns", server.GetCampaigns)
+	r.POST("/campaigns", server.CreateCampaign)
+	r.GET("/campaigns/:campaignId", server.GetCampaign)
+	r.PUT("/campaigns/:campaignId", server.UpdateCampaign)
+	r.DELETE("/campaigns/:campaignId", server.DeleteCampaign)
+	r.PUT("/campaigns/:campaignId/tasks/:taskId", server.AttachCampaignTask)
+	r.DELETE("/campaigns/:campaignId/tasks/:taskId", server.DetachCampaignTask)
+	r.GET("/campaigns/:campaignId/points", server.GetCampaignPoints)
+	r.POST("/campaigns/:campaignId/snapshot", server.CreateClaimSnapshot)
+	r.GET("/campaigns/:campaignId/snapshot", server.GetClaimSnapshot)
+	r.GET("/claims/:address", server.GetClaims)
+	r.POST("/campaigns/:campaignId/vouchers/:address", server.IssueVoucher)
+	r.GET("/vouchers/:address", server.GetVouchers)
+	r.POST("/vouchers/:voucherId/redeem", server.RedeemVoucher)
+	r.POST("/campaigns/:campaignId/allocationPlan", server.CreateAllocationPlan)
+	r.GET("/campaigns/:campaignId/allocationPlan", server.GetAllocationPlan)
+	r.GET("/allocations/:address", server.GetAllocations)
+	r.GET("/export/:dataset", server.Export)
+	r.GET("/leaderboard", server.GetLeaderboard)
+	r.GET("/leaderboard/:address", server.GetLeaderboardRank)
 
 	r.Run(":8080")
 }
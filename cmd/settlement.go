@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service"
+
+	"github.com/spf13/cobra"
+)
+
+// forceSettlement settles the committed epochs of the checked tasks again
+var forceSettlement bool
+
+func init() {
+	for _, c := range []*cobra.Command{CheckSharePoolTaskCmd, CheckStreakTaskCmd, CheckLPTaskCmd} {
+		c.Flags().BoolVar(&forceSettlement, "force", false, "settle committed epochs again, finalized epochs are kept")
+	}
+}
+
+// forceSettle settles every closed epoch of the tasks again, except the finalized ones.
+func forceSettle(ctx context.Context, s *service.Service, tasks []model.Task, err error) error {
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if err := s.UserTask.SettleTask(ctx, task, option.SettleTaskOptions{Force: true}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printSettlementDiff writes the diff of a dry run as a table or as JSON.
+func printSettlementDiff(w io.Writer, diff model.SettlementDiff, output string) error {
+	switch output {
+	case "json":
+		return json.NewEncoder(w).Encode(diff)
+	case "table":
+	default:
+		return fmt.Errorf("invalid output %q, table or json", output)
+	}
+
+	fmt.Fprintf(w, "task %s: %d added, %d changed, %d removed, %d unchanged, total %s -> %s\n",
+		diff.TaskID, diff.Added, diff.Changed, diff.Removed, diff.Unchanged, diff.CurrentTotal, diff.NewTotal)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tCHANGE\tSTATE\tAMOUNT\tPOINT")
+	for _, u := range diff.Users {
+		fmt.Fprintf(tw, "%s\t%s\t%s -> %s\t%s -> %s\t%s -> %s\n",
+			u.UserAddress, u.Change, u.State, u.NewState, u.Amount, u.NewAmount, u.Point, u.NewPoint)
+	}
+
+	return tw.Flush()
+}
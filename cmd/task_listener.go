@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"tradingAce/internal/listener"
+	"tradingAce/internal/scheduler"
 	"tradingAce/pkg/core/db"
 	"tradingAce/pkg/service"
 
@@ -24,6 +26,10 @@ func runTaskListener(_ *cobra.Command, _ []string) {
 
 	s := service.NewService(d)
 
-	taskListener := listener.NewTaskListener(s.Task, s.Transaction, s.UserTask)
+	// epochs are settled in the background once the listener has ingested past their end
+	settlementScheduler := scheduler.NewSettlementScheduler(s.Task, s.UserTask, s.Ingestion, s.Schedule)
+	go settlementScheduler.Run(context.Background())
+
+	taskListener := listener.NewTaskListener(s.Task, s.Transaction, s.UserTask, s.AddressInfo, s.Referral, s.Liquidity, s.Ingestion)
 	taskListener.Listen()
 }
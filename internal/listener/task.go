@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/epoch"
 	iface "tradingAce/pkg/interface"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
@@ -26,6 +27,10 @@ type SwapEventTask struct {
 	TaskMgr        iface.TaskManager
 	TransactionMgr iface.TransactionManager
 	UserTaskMgr    iface.UserTaskManager
+	AddressInfoMgr iface.AddressInfoManager
+	ReferralMgr    iface.ReferralManager
+	LiquidityMgr   iface.LiquidityManager
+	IngestionMgr   iface.IngestionManager
 	client         *ethclient.Client
 }
 
@@ -36,14 +41,24 @@ type swapEvent struct {
 	Amount1Out *big.Int
 }
 
+// liquidityEvent holds the non-indexed fields of Mint and Burn
+type liquidityEvent struct {
+	Amount0 *big.Int
+	Amount1 *big.Int
+}
+
+type transferEvent struct {
+	Value *big.Int
+}
+
 func (t *SwapEventTask) Listen() {
 	ctx := context.Background()
 
 	cachedTaskIDs := make(map[string]struct{})
 	var mu sync.Mutex
 
-	// Parse ABI for Swap event
-	contractABI, err := abi.JSON(strings.NewReader(constants.UniswapSwapEventABI))
+	// Parse ABI for pair events
+	contractABI, err := abi.JSON(strings.NewReader(constants.UniswapPairEventABI))
 	if err != nil {
 		log.Fatalf("Failed to parse contract ABI: %v", err)
 	}
@@ -57,7 +72,7 @@ func (t *SwapEventTask) Listen() {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			tasks, listErr := t.TaskMgr.GetSharePoolTask(ctx)
+			tasks, listErr := t.getPairTasks(ctx)
 			if listErr != nil {
 				log.Printf("list task pair address failed: %s", listErr)
 				continue
@@ -88,6 +103,24 @@ func (t *SwapEventTask) Listen() {
 	}
 }
 
+// getPairTasks returns the tasks whose pair events are ingested: share pool, LP provider and streak tasks.
+func (t *SwapEventTask) getPairTasks(ctx context.Context) ([]model.Task, error) {
+	tasks, err := t.TaskMgr.GetSharePoolTask(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lpTasks, err := t.TaskMgr.GetLPTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	streakTasks, err := t.TaskMgr.GetStreakTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(tasks, lpTasks...), streakTasks...), nil
+}
+
 func (t *SwapEventTask) subscribeToPool(
 	ctx context.Context, contractABI abi.ABI, task model.Task,
 ) error {
@@ -99,7 +132,7 @@ func (t *SwapEventTask) subscribeToPool(
 	}
 	log.Println("finish syncing history")
 
-	if t.getTaskEndAt(task.StartAt).Before(time.Now()) {
+	if t.getTaskEndAt(task).Before(time.Now()) {
 		// task was finished
 		return nil
 	}
@@ -120,28 +153,65 @@ func (t *SwapEventTask) subscribeByWS(
 	startBlock *big.Int,
 ) {
 
-	endAt := t.getTaskEndAt(task.StartAt)
+	endAt := t.getTaskEndAt(task)
 
-	// Filter query for Swap events in the Uniswap pool
+	// Filter query for Swap and liquidity events in the Uniswap pool
 	query := ethereum.FilterQuery{
 		Addresses: []common.Address{common.HexToAddress(task.PairAddress.String)},
-		Topics:    [][]common.Hash{{contractABI.Events["Swap"].ID}},
+		Topics:    pairEventTopics(contractABI),
 		FromBlock: startBlock,
 	}
 
-	// Subscribe to Swap events
+	// Subscribe to pair events
 	logs := make(chan types.Log)
 	sub, err := t.client.SubscribeFilterLogs(ctx, query, logs)
 	if err != nil {
 		log.Fatalf("Failed to subscribe to logs: %v", err)
 	}
 
-	log.Printf("Listening Swap events for target pool: %s", task.PairAddress.String)
+	// new heads confirm the blocks before them, the cursor only moves once the logs up to a head are persisted
+	heads := make(chan *types.Header)
+	headSub, err := t.client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		log.Fatalf("Failed to subscribe to new heads: %v", err)
+	}
+
+	log.Printf("Listening pair events for target pool: %s", task.PairAddress.String)
 
+	// the first block whose logs are not confirmed yet and the logs persisted from the subscription since then
+	next := startBlock.Uint64()
+	persisted := make(map[logKey]bool)
+	// the previous head, its logs are confirmed once the next head arrives
+	var previous *types.Header
 	for {
 		select {
 		case err := <-sub.Err():
 			log.Fatalf("Subscription error: %v", err)
+		case err := <-headSub.Err():
+			log.Fatalf("Head subscription error: %v", err)
+		case header := <-heads:
+			if stop, err := t.isStopTask(ctx, header, endAt); err != nil {
+				log.Fatalf("Subscription isStopTask error: %v", err)
+			} else if stop {
+				if err := t.backfill(ctx, contractABI, task, persisted, next, header); err != nil {
+					log.Printf("failed to backfill pair events: %v", err)
+				}
+				return
+			}
+
+			if previous != nil && previous.Number.Uint64() >= next {
+				if err := t.backfill(ctx, contractABI, task, persisted, next, previous); err != nil {
+					log.Printf("failed to backfill pair events: %v", err)
+				} else {
+					next = previous.Number.Uint64() + 1
+					for key := range persisted {
+						if key.block < next {
+							delete(persisted, key)
+						}
+					}
+				}
+			}
+			previous = header
 		case vLog := <-logs:
 			log.Printf("websocket subscriber received log, pair address: %s, block: %d \n", task.PairAddress.String, vLog.BlockNumber)
 			block, err := t.client.BlockByNumber(ctx, big.NewInt(int64(vLog.BlockNumber)))
@@ -150,19 +220,73 @@ func (t *SwapEventTask) subscribeByWS(
 				continue
 			}
 
-			if stop, err := t.isStopTask(ctx, block, endAt); err != nil {
+			if stop, err := t.isStopTask(ctx, block.Header(), endAt); err != nil {
 				log.Fatalf("Subscription isStopTask error: %v", err)
 			} else if stop {
+				// the task has ended before this block, the blocks of the task are ingested once the logs before it are
+				if err := t.backfill(ctx, contractABI, task, persisted, next, block.Header()); err != nil {
+					log.Printf("failed to backfill pair events: %v", err)
+				}
 				return
 			}
 
+			// a log that failed is persisted by the backfill of the next head
 			if err := t.handleEvent(ctx, vLog, block, contractABI); err != nil {
 				log.Printf("failed to handle event: %v", err)
+				continue
 			}
+			persisted[newLogKey(vLog)] = true
 		}
 	}
 }
 
+// logKey identifies a log of the chain
+type logKey struct {
+	block  uint64
+	txHash common.Hash
+	index  uint
+}
+
+func newLogKey(vLog types.Log) logKey {
+	return logKey{block: vLog.BlockNumber, txHash: vLog.TxHash, index: vLog.Index}
+}
+
+// backfill persists the logs of the pair from the block fromBlock through the block of the header that are not
+// persisted yet, the subscription may have dropped them, and moves the cursor to the header once all of them are.
+func (t *SwapEventTask) backfill(
+	ctx context.Context, contractABI abi.ABI, task model.Task, persisted map[logKey]bool, fromBlock uint64, header *types.Header,
+) error {
+
+	logs, err := t.client.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{common.HexToAddress(task.PairAddress.String)},
+		Topics:    pairEventTopics(contractABI),
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   header.Number,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter logs: %v", err)
+	}
+
+	for _, vLog := range logs {
+		key := newLogKey(vLog)
+		if persisted[key] {
+			continue
+		}
+		log.Printf("backfill received log, pair address: %s, block: %d \n", task.PairAddress.String, vLog.BlockNumber)
+		block, err := t.client.BlockByNumber(ctx, big.NewInt(int64(vLog.BlockNumber)))
+		if err != nil {
+			return fmt.Errorf("failed to get block: %v", err)
+		}
+		if err := t.handleEvent(ctx, vLog, block, contractABI); err != nil {
+			return fmt.Errorf("failed to handle event: %v", err)
+		}
+		persisted[key] = true
+	}
+
+	t.setCursor(ctx, task, header)
+	return nil
+}
+
 func (t *SwapEventTask) subscribeByHTTP(
 	ctx context.Context,
 	contractABI abi.ABI,
@@ -170,7 +294,7 @@ func (t *SwapEventTask) subscribeByHTTP(
 	startBlock *big.Int,
 ) {
 
-	endAt := t.getTaskEndAt(task.StartAt)
+	endAt := t.getTaskEndAt(task)
 	for {
 		latestBlock, err := t.client.BlockByNumber(context.Background(), nil)
 		if err != nil {
@@ -183,7 +307,7 @@ func (t *SwapEventTask) subscribeByHTTP(
 			FromBlock: startBlock,
 			// ToBlock:   endBlock,
 			Addresses: []common.Address{common.HexToAddress(task.PairAddress.String)},
-			Topics:    [][]common.Hash{{contractABI.Events["Swap"].ID}},
+			Topics:    pairEventTopics(contractABI),
 		}
 
 		logs, err := t.client.FilterLogs(context.Background(), query)
@@ -198,9 +322,11 @@ func (t *SwapEventTask) subscribeByHTTP(
 				log.Printf("failed to get block: %v", err)
 				continue
 			}
-			if stop, err := t.isStopTask(ctx, block, endAt); err != nil {
+			if stop, err := t.isStopTask(ctx, block.Header(), endAt); err != nil {
 				log.Fatalf("Subscription isStopTask error: %v", err)
 			} else if stop {
+				// the task has ended before this block, every block of the task is ingested
+				t.setCursor(ctx, task, block.Header())
 				return
 			}
 			if err := t.handleEvent(ctx, vLog, block, contractABI); err != nil {
@@ -209,6 +335,14 @@ func (t *SwapEventTask) subscribeByHTTP(
 
 		}
 
+		if stop, err := t.isStopTask(ctx, latestBlock.Header(), endAt); err != nil {
+			log.Fatalf("Subscription isStopTask error: %v", err)
+		} else if stop {
+			t.setCursor(ctx, task, latestBlock.Header())
+			return
+		}
+		t.setCursor(ctx, task, latestBlock.Header())
+
 		// Update startBlock to the latest block number, so that the next query will continue to query new events
 		if len(logs) != 0 {
 			startBlock = endBlock.Add(endBlock, big.NewInt(1))
@@ -238,7 +372,14 @@ func (t *SwapEventTask) syncHistoryEvent(
 		return big.NewInt(0), startBlockErr
 	}
 
-	endAt := t.getTaskEndAt(task.StartAt)
+	// liquidity-seconds need the LP token balances providers held when the task started
+	if task.Name.String == "lp_provider" {
+		if err := t.syncOpeningTransfers(ctx, client, contractABI, poolAddress, fromBlock); err != nil {
+			return big.NewInt(0), err
+		}
+	}
+
+	endAt := t.getTaskEndAt(task)
 	endBlock := big.NewInt(0)
 	if endAt.After(time.Now()) {
 		latestBlock, err := client.BlockByNumber(ctx, nil)
@@ -257,8 +398,9 @@ func (t *SwapEventTask) syncHistoryEvent(
 		endBlock = b
 	}
 
-	// Filter query for Swap events in the Uniswap pool
+	// Filter query for Swap and liquidity events in the Uniswap pool
 	batch := int64(10000)
+	var synced *types.Header
 	for fromBlock.Cmp(endBlock) < 0 {
 		toBlock := new(big.Int).Add(fromBlock, big.NewInt(batch))
 
@@ -268,7 +410,7 @@ func (t *SwapEventTask) syncHistoryEvent(
 
 		query := ethereum.FilterQuery{
 			Addresses: []common.Address{poolAddress},
-			Topics:    [][]common.Hash{{contractABI.Events["Swap"].ID}},
+			Topics:    pairEventTopics(contractABI),
 			FromBlock: fromBlock,
 			ToBlock:   toBlock,
 		}
@@ -291,26 +433,126 @@ func (t *SwapEventTask) syncHistoryEvent(
 			}
 		}
 
+		header, err := client.HeaderByNumber(ctx, toBlock)
+		if err != nil {
+			return endBlock, fmt.Errorf("failed to get header: %v", err)
+		}
+		t.setCursor(ctx, task, header)
+		synced = header
+
 		fromBlock.Set(toBlock)
 	}
+	if synced == nil {
+		return endBlock, nil
+	}
 
-	if err := t.UserTaskMgr.CheckSharePoolTasks(ctx); err != nil {
+	// only the epochs of this task the sync has ingested are settled, the pairs of other tasks may still be syncing
+	if err := t.UserTaskMgr.SettleTask(ctx, task, option.SettleTaskOptions{
+		Until: time.Unix(int64(synced.Time), 0),
+	}); err != nil {
 		return endBlock, err
 	}
 
 	return endBlock, nil
 }
 
-func (t *SwapEventTask) getTaskEndAt(startAt time.Time) time.Time {
-	fourWeeks := 4 * 7
-	return startAt.AddDate(0, 0, fourWeeks)
+// syncOpeningTransfers ingests the LP token transfers of the pair from its creation until the start block,
+// the balances before the task started are built from them. Transfers saved by a previous sync are kept as is.
+func (t *SwapEventTask) syncOpeningTransfers(
+	ctx context.Context, client *ethclient.Client, contractABI abi.ABI, poolAddress common.Address, startBlock *big.Int,
+) error {
+
+	fromBlock, err := t.getCreationBlock(ctx, client, poolAddress, startBlock)
+	if err != nil {
+		return fmt.Errorf("failed to get creation block: %v", err)
+	}
+
+	batch := int64(10000)
+	for fromBlock.Cmp(startBlock) < 0 {
+		toBlock := new(big.Int).Add(fromBlock, big.NewInt(batch))
+		if toBlock.Cmp(startBlock) > 0 {
+			toBlock.Set(startBlock)
+		}
+
+		query := ethereum.FilterQuery{
+			Addresses: []common.Address{poolAddress},
+			Topics:    [][]common.Hash{{contractABI.Events["Transfer"].ID}},
+			FromBlock: fromBlock,
+			ToBlock:   toBlock,
+		}
+
+		log.Printf("sync opening lp transfers from blockNum: %d~%d", fromBlock, toBlock)
+		logs, err := client.FilterLogs(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to filter logs: %v", err)
+		}
+
+		for _, vLog := range logs {
+			block, err := t.client.BlockByNumber(ctx, big.NewInt(int64(vLog.BlockNumber)))
+			if err != nil {
+				return fmt.Errorf("failed to get block: %v", err)
+			}
+			if err := t.handleTransferEvent(ctx, vLog, block, contractABI); err != nil {
+				return err
+			}
+		}
+
+		fromBlock.Set(toBlock)
+	}
+
+	return nil
+}
+
+// getCreationBlock returns the first block at or before endBlock where the pair contract has code.
+func (t *SwapEventTask) getCreationBlock(
+	ctx context.Context, client *ethclient.Client, poolAddress common.Address, endBlock *big.Int,
+) (*big.Int, error) {
+
+	start := big.NewInt(0)
+	end := new(big.Int).Set(endBlock)
+
+	for start.Cmp(end) < 0 {
+		mid := new(big.Int).Add(start, end)
+		mid = mid.Div(mid, big.NewInt(2))
+
+		code, err := client.CodeAt(ctx, poolAddress, mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(code) == 0 {
+			start = mid.Add(mid, big.NewInt(1))
+		} else {
+			end = mid
+		}
+	}
+
+	return start, nil
+}
+
+// setCursor records the block as ingested for the task, the settlement scheduler waits for it.
+func (t *SwapEventTask) setCursor(ctx context.Context, task model.Task, header *types.Header) {
+	blockAt := time.Unix(int64(header.Time), 0)
+	if err := t.IngestionMgr.SetCursor(ctx, task.ID, task.PairAddress.String, header.Number.Uint64(), blockAt); err != nil {
+		log.Printf("failed to set ingestion cursor: %v", err)
+	}
+}
+
+func (t *SwapEventTask) getTaskEndAt(task model.Task) time.Time {
+	endAt, err := epoch.EndAt(task)
+	if err != nil {
+		log.Printf("task %s has invalid epoch config: %v", task.ID, err)
+		return task.StartAt
+	}
+
+	return endAt
 }
 
 func (t *SwapEventTask) isStopTask(
-	_ context.Context, block *types.Block, endAt time.Time,
+	_ context.Context, header *types.Header, endAt time.Time,
 ) (bool, error) {
 
-	blockTime := int64(block.Time())
+	blockTime := int64(header.Time)
 	if blockTime >= endAt.Unix() {
 		return true, nil
 	}
@@ -318,7 +560,32 @@ func (t *SwapEventTask) isStopTask(
 	return false, nil
 }
 
+// pairEventTopics matches the Swap, Mint, Burn and Transfer events of a pair
+func pairEventTopics(contractABI abi.ABI) [][]common.Hash {
+	return [][]common.Hash{{
+		contractABI.Events["Swap"].ID,
+		contractABI.Events["Mint"].ID,
+		contractABI.Events["Burn"].ID,
+		contractABI.Events["Transfer"].ID,
+	}}
+}
+
 func (t *SwapEventTask) handleEvent(ctx context.Context, vLog types.Log, block *types.Block, contractABI abi.ABI) error {
+	if len(vLog.Topics) == 0 {
+		return fmt.Errorf("log without topics, tx: %s", vLog.TxHash.Hex())
+	}
+
+	switch vLog.Topics[0] {
+	case contractABI.Events["Mint"].ID, contractABI.Events["Burn"].ID:
+		return t.handleLiquidityEvent(ctx, vLog, block, contractABI)
+	case contractABI.Events["Transfer"].ID:
+		return t.handleTransferEvent(ctx, vLog, block, contractABI)
+	default:
+		return t.handleSwapEvent(ctx, vLog, block, contractABI)
+	}
+}
+
+func (t *SwapEventTask) handleSwapEvent(ctx context.Context, vLog types.Log, block *types.Block, contractABI abi.ABI) error {
 	sender := common.HexToAddress(vLog.Topics[1].Hex())
 	to := common.HexToAddress(vLog.Topics[2].Hex())
 
@@ -358,15 +625,102 @@ func (t *SwapEventTask) handleEvent(ctx context.Context, vLog types.Log, block *
 		Amount1Out:      amount1Out,
 		ReceiverAddress: to.Hex(),
 		TransactionAt:   time.Unix(int64(block.Time()), 0),
+		TxHash:          vLog.TxHash.Hex(),
+		LogIndex:        vLog.Index,
 	}
 	err = t.TransactionMgr.Upsert(ctx, opt)
 	if err != nil {
 		return fmt.Errorf("upsert transaction: %v", err)
 	}
 
+	// the block is analyzed again on every swap, so a sandwich is marked once its back-run arrives
+	if _, err := t.TransactionMgr.MarkSandwiches(ctx, opt.PairAddress, opt.BlockNum, opt.BlockNum); err != nil {
+		return fmt.Errorf("mark sandwiches: %v", err)
+	}
+
+	// cache whether the sender is a contract before checking any task
+	if _, err := t.AddressInfoMgr.EnsureAddressInfo(ctx, sender.Hex(), t.client); err != nil {
+		return fmt.Errorf("handle event EnsureAddressInfo fail: %v", err)
+	}
+
 	if err := t.UserTaskMgr.CheckOnboardingTask(ctx, sender.Hex()); err != nil {
 		return fmt.Errorf("handle event CheckOnboardingTask fail: %v", err)
 	}
+	// the referrer of the sender earns points from onboarding and volume tiers
+	if err := t.ReferralMgr.CheckReferee(ctx, sender.Hex()); err != nil {
+		return fmt.Errorf("handle event CheckReferee fail: %v", err)
+	}
+
+	return nil
+}
+
+// handleLiquidityEvent saves a Mint or Burn event, only burns have a receiver.
+func (t *SwapEventTask) handleLiquidityEvent(ctx context.Context, vLog types.Log, block *types.Block, contractABI abi.ABI) error {
+	name, kind := "Mint", constants.LiquidityEventMint
+	if vLog.Topics[0] == contractABI.Events["Burn"].ID {
+		name, kind = "Burn", constants.LiquidityEventBurn
+	}
+
+	event := liquidityEvent{}
+	if err := contractABI.UnpackIntoInterface(&event, name, vLog.Data); err != nil {
+		return fmt.Errorf("failed to unpack log: %v", err)
+	}
+
+	amount0, err := utils.BigIntToDecimal(event.Amount0)
+	if err != nil {
+		return fmt.Errorf("failed to big int to decimal: %v", err)
+	}
+	amount1, err := utils.BigIntToDecimal(event.Amount1)
+	if err != nil {
+		return fmt.Errorf("failed to big int to decimal: %v", err)
+	}
+
+	opt := option.LiquidityEventUpsertOptions{
+		BlockNum:      vLog.BlockNumber,
+		LogIndex:      vLog.Index,
+		TxHash:        vLog.TxHash.Hex(),
+		PairAddress:   vLog.Address.Hex(),
+		Kind:          kind,
+		SenderAddress: common.HexToAddress(vLog.Topics[1].Hex()).Hex(),
+		Amount0:       amount0,
+		Amount1:       amount1,
+		EventAt:       time.Unix(int64(block.Time()), 0),
+	}
+	if kind == constants.LiquidityEventBurn {
+		opt.ToAddress = common.HexToAddress(vLog.Topics[2].Hex()).Hex()
+	}
+
+	if err := t.LiquidityMgr.InsertEvent(ctx, opt); err != nil {
+		return fmt.Errorf("insert liquidity event: %v", err)
+	}
+
+	return nil
+}
+
+// handleTransferEvent saves a Transfer of the LP token, provider balances are built from them.
+func (t *SwapEventTask) handleTransferEvent(ctx context.Context, vLog types.Log, block *types.Block, contractABI abi.ABI) error {
+	event := transferEvent{}
+	if err := contractABI.UnpackIntoInterface(&event, "Transfer", vLog.Data); err != nil {
+		return fmt.Errorf("failed to unpack log: %v", err)
+	}
+
+	value, err := utils.BigIntToDecimal(event.Value)
+	if err != nil {
+		return fmt.Errorf("failed to big int to decimal: %v", err)
+	}
+
+	if err := t.LiquidityMgr.InsertTransfer(ctx, option.LPTransferUpsertOptions{
+		BlockNum:    vLog.BlockNumber,
+		LogIndex:    vLog.Index,
+		TxHash:      vLog.TxHash.Hex(),
+		PairAddress: vLog.Address.Hex(),
+		FromAddress: common.HexToAddress(vLog.Topics[1].Hex()).Hex(),
+		ToAddress:   common.HexToAddress(vLog.Topics[2].Hex()).Hex(),
+		Value:       value,
+		TransferAt:  time.Unix(int64(block.Time()), 0),
+	}); err != nil {
+		return fmt.Errorf("insert lp transfer: %v", err)
+	}
 
 	return nil
 }
@@ -424,12 +778,20 @@ func NewTaskListener(
 	taskMgr iface.TaskManager,
 	transactionMgr iface.TransactionManager,
 	userTaskMgr iface.UserTaskManager,
+	addressInfoMgr iface.AddressInfoManager,
+	referralMgr iface.ReferralManager,
+	liquidityMgr iface.LiquidityManager,
+	ingestionMgr iface.IngestionManager,
 ) *SwapEventTask {
 
 	s := &SwapEventTask{
 		TaskMgr:        taskMgr,
 		TransactionMgr: transactionMgr,
 		UserTaskMgr:    userTaskMgr,
+		AddressInfoMgr: addressInfoMgr,
+		ReferralMgr:    referralMgr,
+		LiquidityMgr:   liquidityMgr,
+		IngestionMgr:   ingestionMgr,
 	}
 
 	s.newClient()
@@ -9,7 +9,16 @@ import (
 	"tradingAce/internal/testutils"
 	"tradingAce/pkg/constants"
 	"tradingAce/pkg/core/db"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/referral"
+	"tradingAce/pkg/service/settlement"
 	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
 	"tradingAce/pkg/service/transaction"
 	"tradingAce/pkg/service/userpoint"
 	"tradingAce/pkg/service/usertask"
@@ -33,16 +42,22 @@ func TestSwapEventTask_handleEvent(t *testing.T) {
 	}
 	defer d.Close()
 
-	contractABI, err := abi.JSON(strings.NewReader(constants.UniswapSwapEventABI))
+	contractABI, err := abi.JSON(strings.NewReader(constants.UniswapPairEventABI))
 	if err != nil {
 		t.Errorf("contractABI err: %v", err)
 		return
 	}
 
+	taskMgr := task.NewManager(d)
 	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	addressInfoMgr := addressinfo.NewManager(d)
+	userTaskMgr := usertask.NewManager(d, taskMgr, trMgr, userPointMgr, tradeflag.NewManager(d), addressInfoMgr, liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d))
 	listener := SwapEventTask{
 		TransactionMgr: transaction.NewManager(d),
-		UserTaskMgr:    usertask.NewManager(d, task.NewManager(d), trMgr, userpoint.NewManager(d)),
+		UserTaskMgr:    userTaskMgr,
+		AddressInfoMgr: addressInfoMgr,
+		ReferralMgr:    referral.NewManager(d, taskMgr, trMgr, userTaskMgr, userPointMgr),
 	}
 	listener.newClient()
 
@@ -118,7 +133,7 @@ func TestSwapEventTask_isStopTask(t *testing.T) {
 		return
 	}
 
-	result1, err := listener.isStopTask(ctx, block, time.Now())
+	result1, err := listener.isStopTask(ctx, block.Header(), time.Now())
 	if err != nil {
 		t.Errorf("isStopTask err: %v", err)
 		return
@@ -130,7 +145,7 @@ func TestSwapEventTask_isStopTask(t *testing.T) {
 		t.Errorf("parse time err: %v", parseErr)
 		return
 	}
-	result2, err := listener.isStopTask(context.Background(), block, endAt)
+	result2, err := listener.isStopTask(context.Background(), block.Header(), endAt)
 	if err != nil {
 		t.Errorf("isStopTask err: %v", err)
 		return
@@ -141,7 +156,7 @@ func TestSwapEventTask_isStopTask(t *testing.T) {
 func TestSwapEventTask_getTaskEndAt(t *testing.T) {
 	listener := SwapEventTask{}
 
-	endAt, parseErr := time.Parse("2006-01-02", "2024-06-02")
+	startAt, parseErr := time.Parse("2006-01-02", "2024-06-02")
 	if parseErr != nil {
 		t.Errorf("parse time err: %v", parseErr)
 		return
@@ -153,7 +168,13 @@ func TestSwapEventTask_getTaskEndAt(t *testing.T) {
 		return
 	}
 
-	result := listener.getTaskEndAt(endAt)
+	result := listener.getTaskEndAt(model.Task{StartAt: startAt})
 
 	assert.True(t, result.Equal(expected))
+
+	daily := listener.getTaskEndAt(model.Task{
+		StartAt: startAt,
+		Config:  model.TaskConfig{Epoch: model.EpochConfig{Unit: "day", Count: 3}},
+	})
+	assert.True(t, daily.Equal(startAt.AddDate(0, 0, 3)))
 }
@@ -2,17 +2,57 @@ package rest
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/allocation"
+	"tradingAce/pkg/core/campaign"
+	"tradingAce/pkg/core/claim"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/export"
+	"tradingAce/pkg/core/multiplier"
+	"tradingAce/pkg/core/prerequisite"
+	"tradingAce/pkg/core/referral"
+	"tradingAce/pkg/core/settlement"
+	"tradingAce/pkg/core/streak"
+	"tradingAce/pkg/core/taskrule"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/core/voucher"
+	"tradingAce/pkg/core/washtrade"
 	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type RestServer struct {
-	TaskMgr      iface.TaskManager
-	UserPointMgr iface.UserPointManager
-	UserTaskMgr  iface.UserTaskManager
+	TaskMgr          iface.TaskManager
+	UserPointMgr     iface.UserPointManager
+	UserTaskMgr      iface.UserTaskManager
+	TradeFlagMgr     iface.TradeFlagManager
+	AddressInfoMgr   iface.AddressInfoManager
+	ReferralMgr      iface.ReferralManager
+	MultiplierMgr    iface.MultiplierManager
+	CampaignMgr      iface.CampaignManager
+	LeaderboardMgr   iface.LeaderboardManager
+	ScheduleMgr      iface.ScheduleManager
+	SettlementMgr    iface.SettlementManager
+	RecalculationMgr iface.RecalculationManager
+	ClaimMgr         iface.ClaimManager
+	VoucherMgr       iface.VoucherManager
+	AllocationMgr    iface.AllocationManager
+	ExportMgr        iface.ExportManager
 }
 
 func (s *RestServer) GetUserTasks(c *gin.Context) {
@@ -29,7 +69,7 @@ func (s *RestServer) GetUserTasks(c *gin.Context) {
 
 func (s *RestServer) GetUserPoints(c *gin.Context) {
 	ctx := context.Background()
-	taskID := c.Param("taskID")
+	taskID := strings.TrimPrefix(c.Param("taskId"), "/")
 
 	result, err := s.UserPointMgr.GetUserPointsForTask(ctx, taskID)
 	if err != nil {
@@ -39,10 +79,327 @@ func (s *RestServer) GetUserPoints(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-func (s *RestServer) CreateSharePoolTask(c *gin.Context) {
+func (s *RestServer) GetPointLedger(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+	taskID := c.Query("taskId")
+
+	result, err := s.UserPointMgr.GetLedger(ctx, address, taskID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetTradeFlags(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Query("address")
+
+	result, err := s.TradeFlagMgr.GetFlags(ctx, address)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetAddressList(c *gin.Context) {
+	ctx := context.Background()
+	list := c.Query("list")
+
+	result, err := s.AddressInfoMgr.GetList(ctx, list)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) SetAddressListEntry(c *gin.Context) {
 	type body struct {
 		Address string `json:"address"`
-		StartAt string `json:"startAt"`
+		List    string `json:"list"`
+		Label   string `json:"label"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(b.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+	if b.List != constants.AddressListDeny && b.List != constants.AddressListAllow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "list must be deny or allow"})
+		return
+	}
+
+	if err := s.AddressInfoMgr.SetListEntry(ctx, b.Address, b.List, b.Label); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) RemoveAddressListEntry(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if err := s.AddressInfoMgr.RemoveListEntry(ctx, address); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) GetMultiplierRules(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.MultiplierMgr.GetRules(ctx)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateMultiplierRule creates a rule boosting the points of share pool epochs and onboarding awards it matches.
+func (s *RestServer) CreateMultiplierRule(c *gin.Context) {
+	type body struct {
+		Name        string     `json:"name"`
+		Multiplier  string     `json:"multiplier"`
+		StartAt     *time.Time `json:"startAt"`
+		EndAt       *time.Time `json:"endAt"`
+		TaskID      string     `json:"taskId"`
+		PairAddress string     `json:"pairAddress"`
+		Addresses   []string   `json:"addresses"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	value, err := decimal.NewFromString(b.Multiplier)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid multiplier"})
+		return
+	}
+	for _, address := range b.Addresses {
+		if !common.IsHexAddress(address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address: " + address})
+			return
+		}
+	}
+
+	rule := model.MultiplierRule{
+		Name:        b.Name,
+		Multiplier:  value,
+		StartAt:     b.StartAt,
+		EndAt:       b.EndAt,
+		TaskID:      b.TaskID,
+		PairAddress: b.PairAddress,
+		Addresses:   b.Addresses,
+	}
+	if err := multiplier.Validate(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.MultiplierMgr.CreateRule(ctx, rule)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) DeleteMultiplierRule(c *gin.Context) {
+	ctx := context.Background()
+	ruleID := c.Param("ruleId")
+
+	if err := s.MultiplierMgr.DeleteRule(ctx, ruleID); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) CreateSharePoolTask(c *gin.Context) {
+	type body struct {
+		Address        string                   `json:"address"`
+		StartAt        string                   `json:"startAt"`
+		Epoch          model.EpochConfig        `json:"epoch"`
+		PointPrecision int32                    `json:"pointPrecision"`
+		EpochPoints    *decimal.Decimal         `json:"epochPoints"`
+		Distribution   model.DistributionConfig `json:"distribution"`
+		VolumeMode     string                   `json:"volumeMode"`
+		WashTrading    model.WashTradingConfig  `json:"washTrading"`
+		AllowContracts bool                     `json:"allowContracts"`
+		Prerequisites  model.PrerequisiteConfig `json:"prerequisites"`
+		ExcludeMev     bool                     `json:"excludeMev"`
+		Activity       model.ActivityConfig     `json:"activity"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := epoch.Validate(b.Epoch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := prerequisite.Validate(b.Prerequisites); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := distribution.Validate(b.Distribution); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if b.EpochPoints != nil && b.EpochPoints.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "epoch points can not be negative"})
+		return
+	}
+	if err := volume.Validate(b.VolumeMode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := washtrade.Validate(b.WashTrading); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := volume.ValidateActivity(b.Activity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, locErr := epoch.Location(b.Epoch)
+	if locErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": locErr.Error()})
+		return
+	}
+
+	// startAt is a date in the task timezone
+	startAt, parseErr := time.ParseInLocation("2006-01-02", b.StartAt, loc)
+	if parseErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+		return
+	}
+
+	if err := s.TaskMgr.CreateSharePoolTask(ctx, b.Address, startAt, model.TaskConfig{
+		Epoch:          b.Epoch,
+		PointPrecision: b.PointPrecision,
+		EpochPoints:    b.EpochPoints,
+		Distribution:   b.Distribution,
+		VolumeMode:     b.VolumeMode,
+		WashTrading:    b.WashTrading,
+		AllowContracts: b.AllowContracts,
+		Prerequisites:  b.Prerequisites,
+		ExcludeMev:     b.ExcludeMev,
+		Activity:       b.Activity,
+	}); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) CreateStreakTask(c *gin.Context) {
+	type body struct {
+		Address        string                   `json:"address"`
+		StartAt        string                   `json:"startAt"`
+		Epoch          model.EpochConfig        `json:"epoch"`
+		Streak         model.StreakConfig       `json:"streak"`
+		VolumeMode     string                   `json:"volumeMode"`
+		Activity       model.ActivityConfig     `json:"activity"`
+		AllowContracts bool                     `json:"allowContracts"`
+		Prerequisites  model.PrerequisiteConfig `json:"prerequisites"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := epoch.Validate(b.Epoch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := prerequisite.Validate(b.Prerequisites); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := streak.Validate(b.Streak); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := volume.Validate(b.VolumeMode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := volume.ValidateActivity(b.Activity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, locErr := epoch.Location(b.Epoch)
+	if locErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": locErr.Error()})
+		return
+	}
+
+	// startAt is a date in the task timezone
+	startAt, parseErr := time.ParseInLocation("2006-01-02", b.StartAt, loc)
+	if parseErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+		return
+	}
+
+	if err := s.TaskMgr.CreateStreakTask(ctx, b.Address, startAt, model.TaskConfig{
+		Epoch:          b.Epoch,
+		Streak:         b.Streak,
+		VolumeMode:     b.VolumeMode,
+		Activity:       b.Activity,
+		AllowContracts: b.AllowContracts,
+		Prerequisites:  b.Prerequisites,
+	}); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+// CreateLPTask creates a liquidity provider task of the pair, epoch points are distributed by liquidity-seconds.
+func (s *RestServer) CreateLPTask(c *gin.Context) {
+	type body struct {
+		Address        string                   `json:"address"`
+		StartAt        string                   `json:"startAt"`
+		Epoch          model.EpochConfig        `json:"epoch"`
+		PointPrecision int32                    `json:"pointPrecision"`
+		EpochPoints    *decimal.Decimal         `json:"epochPoints"`
+		Distribution   model.DistributionConfig `json:"distribution"`
+		AllowContracts bool                     `json:"allowContracts"`
+		Prerequisites  model.PrerequisiteConfig `json:"prerequisites"`
 	}
 	ctx := context.Background()
 
@@ -52,13 +409,74 @@ func (s *RestServer) CreateSharePoolTask(c *gin.Context) {
 		return
 	}
 
-	startAt, parseErr := time.Parse("2006-01-02", b.StartAt)
+	if err := epoch.Validate(b.Epoch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := prerequisite.Validate(b.Prerequisites); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := distribution.Validate(b.Distribution); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if b.EpochPoints != nil && b.EpochPoints.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "epoch points can not be negative"})
+		return
+	}
+	loc, locErr := epoch.Location(b.Epoch)
+	if locErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": locErr.Error()})
+		return
+	}
+
+	// startAt is a date in the task timezone
+	startAt, parseErr := time.ParseInLocation("2006-01-02", b.StartAt, loc)
 	if parseErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
 		return
 	}
 
-	if err := s.TaskMgr.CreateSharePoolTask(ctx, b.Address, startAt); err != nil {
+	if err := s.TaskMgr.CreateLPTask(ctx, b.Address, startAt, model.TaskConfig{
+		Epoch:          b.Epoch,
+		PointPrecision: b.PointPrecision,
+		EpochPoints:    b.EpochPoints,
+		Distribution:   b.Distribution,
+		AllowContracts: b.AllowContracts,
+		Prerequisites:  b.Prerequisites,
+	}); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+// RegisterReferral registers the referrer of the referee, signature is the referee's personal_sign of the referral message.
+func (s *RestServer) RegisterReferral(c *gin.Context) {
+	type body struct {
+		Referee   string `json:"referee"`
+		Referrer  string `json:"referrer"`
+		Signature string `json:"signature"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.ReferralMgr.Register(ctx, option.RegisterReferralOptions{
+		Referee:   b.Referee,
+		Referrer:  b.Referrer,
+		Signature: b.Signature,
+	})
+	if errors.Is(err, referral.ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
@@ -66,15 +484,815 @@ func (s *RestServer) CreateSharePoolTask(c *gin.Context) {
 	c.JSON(http.StatusOK, "ok")
 }
 
+func (s *RestServer) GetReferralStats(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+
+	stats, err := s.ReferralMgr.GetStats(ctx, address)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (s *RestServer) GetTaskEpochs(c *gin.Context) {
+	ctx := context.Background()
+	taskID := c.Param("taskId")
+
+	task, err := s.TaskMgr.GetTask(ctx, taskID)
+	if err == sql.ErrNoRows {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"taskId":   task.ID,
+		"timezone": epoch.Normalize(task.Config.Epoch).Timezone,
+		"epochs":   epochs,
+	})
+}
+
+// GetTaskSettlements returns the settlement runs the scheduler made for the epochs of the task.
+func (s *RestServer) GetTaskSettlements(c *gin.Context) {
+	ctx := context.Background()
+	taskID := c.Param("taskId")
+
+	if _, err := s.TaskMgr.GetTask(ctx, taskID); err == sql.ErrNoRows {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := s.ScheduleMgr.GetRuns(ctx, taskID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSettlementRuns returns the settlement runs of the epochs of the task, the latest run of every epoch first.
+func (s *RestServer) GetSettlementRuns(c *gin.Context) {
+	ctx := context.Background()
+	taskID := c.Param("taskId")
+
+	if _, err := s.TaskMgr.GetTask(ctx, taskID); err == sql.ErrNoRows {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := s.SettlementMgr.GetRuns(ctx, taskID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSettlementDiff returns what settling every closed epoch of the share pool task would change per user, without writing it.
+func (s *RestServer) GetSettlementDiff(c *gin.Context) {
+	ctx := context.Background()
+
+	task, err := s.TaskMgr.GetTask(ctx, c.Param("taskId"))
+	if err == sql.ErrNoRows {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if task.Name.String != "share_pool" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "not a share pool task"})
+		return
+	}
+
+	result, err := s.UserTaskMgr.DiffSharePoolTask(ctx, task)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FinalizeEpoch locks the committed settlement of an epoch, it is not settled again even when forced.
+func (s *RestServer) FinalizeEpoch(c *gin.Context) {
+	ctx := context.Background()
+
+	epochIndex, err := strconv.Atoi(c.Param("epoch"))
+	if err != nil || epochIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epoch"})
+		return
+	}
+
+	result, err := s.SettlementMgr.Finalize(ctx, c.Param("taskId"), epochIndex)
+	if errors.Is(err, settlement.ErrTransition) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "settlement run not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRuleVersions returns the rule versions of the task, the first version first.
+func (s *RestServer) GetRuleVersions(c *gin.Context) {
+	ctx := context.Background()
+	taskID := c.Param("taskId")
+
+	if _, err := s.TaskMgr.GetTask(ctx, taskID); err == sql.ErrNoRows {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := s.TaskMgr.GetRuleVersions(ctx, taskID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateRuleVersion adds a rule version to the task, it applies to the epochs starting at or after effectiveAt.
+// Settled epochs keep their points until they are recalculated.
+func (s *RestServer) CreateRuleVersion(c *gin.Context) {
+	type body struct {
+		Config model.TaskConfig `json:"config"`
+		// now when empty
+		EffectiveAt time.Time `json:"effectiveAt"`
+		Note        string    `json:"note"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if b.EffectiveAt.IsZero() {
+		b.EffectiveAt = time.Now()
+	}
+
+	result, err := s.TaskMgr.CreateRuleVersion(ctx, c.Param("taskId"), b.Config, b.EffectiveAt, b.Note)
+	if errors.Is(err, taskrule.ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRecalculations returns the recalculations of the task, the latest first, without their entries.
+func (s *RestServer) GetRecalculations(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.RecalculationMgr.GetRecalculations(ctx, c.Param("taskId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRecalculation returns a recalculation with the points of every user before and after it.
+func (s *RestServer) GetRecalculation(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.RecalculationMgr.GetRecalculation(ctx, c.Param("recalculationId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "recalculation not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type campaignBody struct {
+	Name    string    `json:"name"`
+	StartAt time.Time `json:"startAt"`
+	EndAt   time.Time `json:"endAt"`
+	// points the tasks of the campaign can award, unlimited when empty
+	Budget string `json:"budget"`
+}
+
+func bindCampaign(c *gin.Context) (model.Campaign, bool) {
+	var b campaignBody
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return model.Campaign{}, false
+	}
+
+	result := model.Campaign{Name: b.Name, StartAt: b.StartAt, EndAt: b.EndAt}
+	if len(b.Budget) != 0 {
+		budget, err := decimal.NewFromString(b.Budget)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget"})
+			return result, false
+		}
+		result.Budget = &budget
+	}
+	if err := campaign.Validate(result); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return result, false
+	}
+
+	return result, true
+}
+
+func (s *RestServer) GetCampaigns(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.CampaignMgr.GetCampaigns(ctx)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetCampaign(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.CampaignMgr.GetCampaign(ctx, c.Param("campaignId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateCampaign creates a season grouping tasks under an optional points budget.
+func (s *RestServer) CreateCampaign(c *gin.Context) {
+	ctx := context.Background()
+
+	body, ok := bindCampaign(c)
+	if !ok {
+		return
+	}
+
+	result, err := s.CampaignMgr.CreateCampaign(ctx, body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) UpdateCampaign(c *gin.Context) {
+	ctx := context.Background()
+
+	body, ok := bindCampaign(c)
+	if !ok {
+		return
+	}
+	body.ID = c.Param("campaignId")
+
+	err := s.CampaignMgr.UpdateCampaign(ctx, body)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) DeleteCampaign(c *gin.Context) {
+	ctx := context.Background()
+
+	err := s.CampaignMgr.DeleteCampaign(ctx, c.Param("campaignId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+// AttachCampaignTask adds a task starting within the campaign to it.
+func (s *RestServer) AttachCampaignTask(c *gin.Context) {
+	ctx := context.Background()
+
+	err := s.CampaignMgr.AttachTask(ctx, c.Param("campaignId"), c.Param("taskId"))
+	if errors.Is(err, campaign.ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign or task not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *RestServer) DetachCampaignTask(c *gin.Context) {
+	ctx := context.Background()
+
+	err := s.CampaignMgr.DetachTask(ctx, c.Param("campaignId"), c.Param("taskId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "task not found in campaign"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+// GetCampaignPoints returns the total points of every user over the tasks of the campaign.
+func (s *RestServer) GetCampaignPoints(c *gin.Context) {
+	ctx := context.Background()
+	campaignID := c.Param("campaignId")
+
+	if _, err := s.CampaignMgr.GetCampaign(ctx, campaignID); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := s.UserPointMgr.GetUserPointsForCampaign(ctx, campaignID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateClaimSnapshot freezes the points of the ended campaign and returns the Merkle root of its claims.
+func (s *RestServer) CreateClaimSnapshot(c *gin.Context) {
+	type body struct {
+		// decimals of the claimed token, 18 when empty
+		Decimals *int32 `json:"decimals"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	decimals := int32(claim.DefaultDecimals)
+	if b.Decimals != nil {
+		if *b.Decimals < 0 || *b.Decimals > 36 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid decimals"})
+			return
+		}
+		decimals = *b.Decimals
+	}
+
+	result, err := s.ClaimMgr.CreateSnapshot(ctx, c.Param("campaignId"), decimals)
+	if errors.Is(err, claim.ErrNotEnded) || errors.Is(err, claim.ErrExists) || errors.Is(err, claim.ErrNotSettled) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetClaimSnapshot(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.ClaimMgr.GetSnapshot(ctx, c.Param("campaignId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "snapshot not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetClaims returns what the address can claim in every campaign snapshot, with the proof the claim contract verifies.
+func (s *RestServer) GetClaims(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+
+	result, err := s.ClaimMgr.GetClaims(ctx, address)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// IssueVoucher returns the EIP-712 voucher of the claim of the address in the campaign snapshot,
+// signing a new one when the user has none that can be redeemed.
+func (s *RestServer) IssueVoucher(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+
+	result, err := s.VoucherMgr.IssueVoucher(ctx, c.Param("campaignId"), address)
+	if errors.Is(err, voucher.ErrRedeemed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "claim not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetVouchers(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+
+	result, err := s.VoucherMgr.GetVouchers(ctx, address)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RedeemVoucher records the transaction that redeemed the voucher on chain, once its receipt is checked.
+func (s *RestServer) RedeemVoucher(c *gin.Context) {
+	type body struct {
+		TxHash string `json:"txHash"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if hash, err := hexutil.Decode(b.TxHash); err != nil || len(hash) != common.HashLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid txHash"})
+		return
+	}
+
+	result, err := s.VoucherMgr.Redeem(ctx, c.Param("voucherId"), b.TxHash)
+	if errors.Is(err, voucher.ErrRedeemed) || errors.Is(err, voucher.ErrNotRedeemed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "voucher not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateAllocationPlan splits a token budget across the users of the campaign snapshot by their points, with a vesting schedule.
+func (s *RestServer) CreateAllocationPlan(c *gin.Context) {
+	type body struct {
+		// tokens to allocate
+		Budget  string              `json:"budget"`
+		Vesting model.VestingConfig `json:"vesting"`
+	}
+	ctx := context.Background()
+
+	var b body
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget, err := decimal.NewFromString(b.Budget)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget"})
+		return
+	}
+
+	result, err := s.AllocationMgr.CreatePlan(ctx, c.Param("campaignId"), budget, b.Vesting)
+	if errors.Is(err, allocation.ErrInvalid) || errors.Is(err, allocation.ErrExists) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "snapshot not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *RestServer) GetAllocationPlan(c *gin.Context) {
+	ctx := context.Background()
+
+	result, err := s.AllocationMgr.GetPlan(ctx, c.Param("campaignId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "allocation plan not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAllocations returns the token allocations of the address with when they unlock.
+func (s *RestServer) GetAllocations(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+
+	result, err := s.AllocationMgr.GetAllocations(ctx, address)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Export streams a dataset as csv, jsonl or ndjson, of a task or a campaign and from a day until the day before to.
+func (s *RestServer) Export(c *gin.Context) {
+	// the export query is cancelled when the client goes away
+	ctx := c.Request.Context()
+	opt := option.ExportOptions{
+		Dataset:    c.Param("dataset"),
+		TaskID:     c.Query("taskId"),
+		CampaignID: c.Query("campaignId"),
+	}
+	format := c.DefaultQuery("format", export.FormatCSV)
+
+	for name, dest := range map[string]**time.Time{"from": &opt.From, "to": &opt.To} {
+		if value := c.Query(name); len(value) != 0 {
+			day, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a date, e.g. 2024-07-01"})
+				return
+			}
+			*dest = &day
+		}
+	}
+
+	var err error
+	if len(opt.TaskID) != 0 {
+		_, err = s.TaskMgr.GetTask(ctx, opt.TaskID)
+	} else if len(opt.CampaignID) != 0 {
+		_, err = s.CampaignMgr.GetCampaign(ctx, opt.CampaignID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "task or campaign not found"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	w, err := export.NewWriter(c.Writer, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// rows are written to the response as they are read, the buffer of the writer is only sent once full
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, opt.Dataset, format))
+	err = s.ExportMgr.Export(ctx, opt, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		if errors.Is(err, export.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	} else if err != nil {
+		// the status is sent already, the client sees a truncated export
+		log.Printf("Export %s fail: %v", opt.Dataset, err)
+		c.Abort()
+	}
+}
+
+// bindLeaderboard reads the scope and page of a leaderboard from the query, it writes the response when the scope is invalid.
+func (s *RestServer) bindLeaderboard(c *gin.Context) (option.LeaderboardOptions, bool) {
+	ctx := context.Background()
+	opt := option.LeaderboardOptions{
+		TaskID:     c.Query("taskId"),
+		CampaignID: c.Query("campaignId"),
+		Limit:      constants.LeaderboardDefaultLimit,
+	}
+
+	if len(opt.TaskID) != 0 && len(opt.CampaignID) != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "taskId and campaignId can not be combined"})
+		return opt, false
+	}
+	if value := c.Query("epoch"); len(value) != 0 {
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || len(opt.TaskID) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "epoch must be a non-negative index of a task"})
+			return opt, false
+		}
+		opt.Epoch = &index
+	}
+	if value := c.Query("limit"); len(value) != 0 {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > constants.LeaderboardMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(constants.LeaderboardMaxLimit)})
+			return opt, false
+		}
+		opt.Limit = limit
+	}
+	if value := c.Query("offset"); len(value) != 0 {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return opt, false
+		}
+		opt.Offset = offset
+	}
+
+	var err error
+	if len(opt.TaskID) != 0 {
+		_, err = s.TaskMgr.GetTask(ctx, opt.TaskID)
+	} else if len(opt.CampaignID) != 0 {
+		_, err = s.CampaignMgr.GetCampaign(ctx, opt.CampaignID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "task or campaign not found"})
+		return opt, false
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return opt, false
+	}
+
+	return opt, true
+}
+
+// GetLeaderboard ranks the users of a task, an epoch of a task, a campaign or of all tasks.
+func (s *RestServer) GetLeaderboard(c *gin.Context) {
+	ctx := context.Background()
+
+	opt, ok := s.bindLeaderboard(c)
+	if !ok {
+		return
+	}
+
+	result, err := s.LeaderboardMgr.GetLeaderboard(ctx, opt)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetLeaderboardRank returns the standing of one user in the same scopes as GetLeaderboard.
+func (s *RestServer) GetLeaderboardRank(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Param("address")
+
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+		return
+	}
+	opt, ok := s.bindLeaderboard(c)
+	if !ok {
+		return
+	}
+
+	result, err := s.LeaderboardMgr.GetRank(ctx, opt, address)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"message": "address not ranked"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func NewRestServer(
 	taskMgr iface.TaskManager,
 	userPointMgr iface.UserPointManager,
 	userTaskMgr iface.UserTaskManager,
+	tradeFlagMgr iface.TradeFlagManager,
+	addressInfoMgr iface.AddressInfoManager,
+	referralMgr iface.ReferralManager,
+	multiplierMgr iface.MultiplierManager,
+	campaignMgr iface.CampaignManager,
+	leaderboardMgr iface.LeaderboardManager,
+	scheduleMgr iface.ScheduleManager,
+	settlementMgr iface.SettlementManager,
+	recalculationMgr iface.RecalculationManager,
+	claimMgr iface.ClaimManager,
+	voucherMgr iface.VoucherManager,
+	allocationMgr iface.AllocationManager,
+	exportMgr iface.ExportManager,
 ) *RestServer {
 
 	return &RestServer{
-		TaskMgr:      taskMgr,
-		UserPointMgr: userPointMgr,
-		UserTaskMgr:  userTaskMgr,
+		TaskMgr:          taskMgr,
+		UserPointMgr:     userPointMgr,
+		UserTaskMgr:      userTaskMgr,
+		TradeFlagMgr:     tradeFlagMgr,
+		AddressInfoMgr:   addressInfoMgr,
+		ReferralMgr:      referralMgr,
+		MultiplierMgr:    multiplierMgr,
+		CampaignMgr:      campaignMgr,
+		LeaderboardMgr:   leaderboardMgr,
+		ScheduleMgr:      scheduleMgr,
+		SettlementMgr:    settlementMgr,
+		RecalculationMgr: recalculationMgr,
+		ClaimMgr:         claimMgr,
+		VoucherMgr:       voucherMgr,
+		AllocationMgr:    allocationMgr,
+		ExportMgr:        exportMgr,
 	}
 }
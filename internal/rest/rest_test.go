@@ -9,13 +9,27 @@ import (
 	"testing"
 	"time"
 	"tradingAce/internal/testutils"
+	"tradingAce/pkg/constants"
+	coreReferral "tradingAce/pkg/core/referral"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/leaderboard"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/referral"
+	"tradingAce/pkg/service/settlement"
 	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
 	"tradingAce/pkg/service/transaction"
 	"tradingAce/pkg/service/userpoint"
 	"tradingAce/pkg/service/usertask"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/shopspring/decimal"
@@ -39,7 +53,7 @@ func Test_GetUserTasks(t *testing.T) {
 	server := &RestServer{
 		TaskMgr:      taskMgr,
 		UserPointMgr: userPointMgr,
-		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr),
+		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d)),
 	}
 
 	// Register the endpoint
@@ -59,7 +73,7 @@ func Test_GetUserTasks(t *testing.T) {
 					UserAddress: "0x12345",
 					State:       "completed",
 					Amount:      decimal.NewFromInt(10),
-					Point:       1,
+					Point:       decimal.NewFromInt(1),
 					TaskName:    "share_pool",
 					PairAddress: "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
 				},
@@ -70,7 +84,7 @@ func Test_GetUserTasks(t *testing.T) {
 
 	ctx := context.TODO()
 
-	if err := taskMgr.CreateSharePoolTask(ctx, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", time.Now()); err != nil {
+	if err := taskMgr.CreateSharePoolTask(ctx, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", time.Now(), model.TaskConfig{}); err != nil {
 		t.Errorf("create share pool task err: %v", err)
 		return
 	}
@@ -83,7 +97,7 @@ func Test_GetUserTasks(t *testing.T) {
 		t.Errorf("upsert user task err: %v", err)
 		return
 	}
-	if err := userPointMgr.UpsertForUserTask(ctx, "0x12345", tasks[0].ID, 1); err != nil {
+	if err := userPointMgr.UpsertForUserTask(ctx, "0x12345", tasks[0].ID, decimal.NewFromInt(1)); err != nil {
 		t.Errorf("upsert user point err: %v", err)
 		return
 	}
@@ -114,7 +128,7 @@ func Test_GetUserTasks(t *testing.T) {
 
 			assert.Equal(t, tt.expected[0].UserAddress, responseBody[0].UserAddress)
 			assert.Equal(t, tt.expected[0].State, responseBody[0].State)
-			assert.Equal(t, tt.expected[0].Point, responseBody[0].Point)
+			assert.True(t, tt.expected[0].Point.Equal(responseBody[0].Point))
 			assert.Equal(t, tt.expected[0].TaskName, responseBody[0].TaskName)
 			assert.Equal(t, tt.expected[0].PairAddress, responseBody[0].PairAddress)
 			assert.True(t, tt.expected[0].Amount.Equal(responseBody[0].Amount))
@@ -139,7 +153,7 @@ func Test_GetUserPoints(t *testing.T) {
 	server := &RestServer{
 		TaskMgr:      taskMgr,
 		UserPointMgr: userPointMgr,
-		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr),
+		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d)),
 	}
 
 	// Register the endpoint
@@ -158,14 +172,14 @@ func Test_GetUserPoints(t *testing.T) {
 				{
 					UserAddress: "0xabc",
 					TaskID:      "123",
-					Point:       100,
+					Point:       decimal.NewFromInt(100),
 				},
 			},
 			statusCode: http.StatusOK,
 		},
 	}
 
-	if err := userPointMgr.UpsertForUserTask(context.TODO(), "0xabc", "123", 100); err != nil {
+	if err := userPointMgr.UpsertForUserTask(context.TODO(), "0xabc", "123", decimal.NewFromInt(100)); err != nil {
 		t.Errorf("UpsertForUserTask() error = %v", err)
 		return
 	}
@@ -193,7 +207,7 @@ func Test_GetUserPoints(t *testing.T) {
 
 			assert.Equal(t, tt.expected[0].UserAddress, responseBody[0].UserAddress)
 			assert.Equal(t, tt.expected[0].TaskID, responseBody[0].TaskID)
-			assert.Equal(t, tt.expected[0].Point, responseBody[0].Point)
+			assert.True(t, tt.expected[0].Point.Equal(responseBody[0].Point))
 		})
 	}
 }
@@ -215,7 +229,7 @@ func Test_CreateSharePoolTask(t *testing.T) {
 	server := &RestServer{
 		TaskMgr:      taskMgr,
 		UserPointMgr: userPointMgr,
-		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr),
+		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d)),
 	}
 
 	// Register the endpoint
@@ -242,6 +256,15 @@ func Test_CreateSharePoolTask(t *testing.T) {
 			},
 			statusCode: http.StatusBadRequest,
 		},
+		{
+			name: "Invalid prerequisite mode",
+			body: map[string]interface{}{
+				"address":       "0x67890",
+				"startAt":       "2024-08-25",
+				"prerequisites": map[string]interface{}{"taskIds": []string{"123"}, "mode": "xor"},
+			},
+			statusCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -269,3 +292,874 @@ func Test_CreateSharePoolTask(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreateStreakTask(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	server := &RestServer{TaskMgr: task.NewManager(d)}
+	r.POST("/streakTask", server.CreateStreakTask)
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name: "Valid request",
+			body: map[string]interface{}{
+				"address": "0x12345",
+				"startAt": "2024-08-25",
+				"streak": map[string]interface{}{
+					"milestones": []map[string]interface{}{{"length": 3, "points": "100"}},
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "No milestone",
+			body: map[string]interface{}{
+				"address": "0x12345",
+				"startAt": "2024-08-25",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/streakTask", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func Test_CreateLPTask(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	server := &RestServer{TaskMgr: task.NewManager(d)}
+	r.POST("/lpTask", server.CreateLPTask)
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name: "Valid request",
+			body: map[string]interface{}{
+				"address": "0x12345",
+				"startAt": "2024-08-25",
+				"epoch":   map[string]interface{}{"unit": "day", "count": 7},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid distribution",
+			body: map[string]interface{}{
+				"address":      "0x12345",
+				"startAt":      "2024-08-25",
+				"distribution": map[string]interface{}{"strategy": "unknown"},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/lpTask", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func Test_GetTaskEpochs(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	taskMgr := task.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	server := &RestServer{
+		TaskMgr:      taskMgr,
+		UserPointMgr: userPointMgr,
+		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d)),
+	}
+
+	// Register the endpoint
+	r.GET("/tasks/:taskId/epochs", server.GetTaskEpochs)
+
+	ctx := context.TODO()
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-03")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "day", Count: 3}}
+	if err := taskMgr.CreateSharePoolTask(ctx, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", startAt, config); err != nil {
+		t.Errorf("create share pool task err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil {
+		t.Errorf("get share pool task err: %v", err)
+		return
+	}
+
+	tests := []struct {
+		name       string
+		taskID     string
+		epochs     int
+		statusCode int
+	}{
+		{
+			name:       "Valid request",
+			taskID:     tasks[0].ID,
+			epochs:     3,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Task not found",
+			taskID:     "notExist",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/tasks/"+tt.taskID+"/epochs", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			// Create a response recorder
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			// Assert the status code
+			assert.Equal(t, tt.statusCode, w.Code)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var responseBody struct {
+				TaskID   string        `json:"taskId"`
+				Timezone string        `json:"timezone"`
+				Epochs   []model.Epoch `json:"epochs"`
+			}
+			err = json.Unmarshal(w.Body.Bytes(), &responseBody)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+
+			assert.Equal(t, tt.taskID, responseBody.TaskID)
+			assert.Equal(t, "UTC", responseBody.Timezone)
+			assert.Equal(t, tt.epochs, len(responseBody.Epochs))
+			assert.True(t, startAt.Equal(responseBody.Epochs[0].StartAt))
+		})
+	}
+}
+
+func Test_GetPointLedger(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	taskMgr := task.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	server := &RestServer{
+		TaskMgr:      taskMgr,
+		UserPointMgr: userPointMgr,
+		UserTaskMgr:  usertask.NewManager(d, taskMgr, transaction.NewManager(d), userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d)),
+	}
+
+	// Register the endpoint
+	r.GET("/pointLedger/:address", server.GetPointLedger)
+
+	if err := userPointMgr.UpsertForUserTask(context.TODO(), "0xabc", "123", decimal.NewFromInt(100)); err != nil {
+		t.Errorf("UpsertForUserTask() error = %v", err)
+		return
+	}
+	if err := userPointMgr.UpsertForUserTask(context.TODO(), "0xabc", "456", decimal.NewFromInt(30)); err != nil {
+		t.Errorf("UpsertForUserTask() error = %v", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/pointLedger/0xabc?taskId=123", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	// Create a response recorder
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	// Assert the status code
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody []model.PointLedgerEntry
+	err = json.Unmarshal(w.Body.Bytes(), &responseBody)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+
+	assert.Equal(t, 1, len(responseBody))
+	assert.Equal(t, "123", responseBody[0].TaskID)
+	assert.True(t, decimal.NewFromInt(100).Equal(responseBody[0].Point))
+}
+
+func Test_GetTradeFlags(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	tradeFlagMgr := tradeflag.NewManager(d)
+	server := &RestServer{
+		TradeFlagMgr: tradeFlagMgr,
+	}
+
+	// Register the endpoint
+	r.GET("/tradeFlags", server.GetTradeFlags)
+
+	if err := tradeFlagMgr.Record(context.TODO(), []model.TradeFlag{
+		{UserAddress: "0xabc", TransactionID: "tx1", Reason: constants.FlagReasonRoundTrip},
+		{UserAddress: "0xdef", Reason: constants.FlagReasonFundingCluster},
+	}); err != nil {
+		t.Errorf("Record() error = %v", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/tradeFlags?address=0xabc", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	// Create a response recorder
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	// Assert the status code
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody []model.TradeFlag
+	err = json.Unmarshal(w.Body.Bytes(), &responseBody)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+
+	assert.Equal(t, 1, len(responseBody))
+	assert.Equal(t, "tx1", responseBody[0].TransactionID)
+	assert.Equal(t, constants.FlagReasonRoundTrip, responseBody[0].Reason)
+}
+
+func Test_AddressList(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	server := &RestServer{
+		AddressInfoMgr: addressinfo.NewManager(d),
+	}
+
+	// Register the endpoint
+	r.GET("/addressList", server.GetAddressList)
+	r.POST("/addressList", server.SetAddressListEntry)
+	r.DELETE("/addressList/:address", server.RemoveAddressListEntry)
+
+	router := "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name: "Valid request",
+			body: map[string]interface{}{
+				"address": router,
+				"list":    constants.AddressListDeny,
+				"label":   "router",
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid address",
+			body: map[string]interface{}{
+				"address": "0x12345",
+				"list":    constants.AddressListDeny,
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid list",
+			body: map[string]interface{}{
+				"address": router,
+				"list":    "grey",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/addressList", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/addressList?list=deny", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody []model.AddressListEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &responseBody); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	assert.Equal(t, 1, len(responseBody))
+	assert.Equal(t, router, responseBody[0].Address)
+
+	req, err = http.NewRequest(http.MethodDelete, "/addressList/"+router, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_Referrals(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	userTaskMgr := usertask.NewManager(d, taskMgr, trMgr, userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d))
+	server := &RestServer{
+		ReferralMgr: referral.NewManager(d, taskMgr, trMgr, userTaskMgr, userPointMgr),
+	}
+
+	r.POST("/referrals", server.RegisterReferral)
+	r.GET("/referrals/:address", server.GetReferralStats)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	referee := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	referrer := "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+	sig, err := crypto.Sign(accounts.TextHash([]byte(coreReferral.Message(referee, referrer))), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name:       "Valid request",
+			body:       map[string]interface{}{"referee": referee, "referrer": referrer, "signature": hexutil.Encode(sig)},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Already registered",
+			body:       map[string]interface{}{"referee": referee, "referrer": referrer, "signature": hexutil.Encode(sig)},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid signature",
+			body:       map[string]interface{}{"referee": referrer, "referrer": referee, "signature": hexutil.Encode(sig)},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/referrals", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/referrals/"+referrer, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stats model.ReferralStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	assert.Equal(t, 1, stats.Referees)
+}
+
+func Test_MultiplierRules(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	r := gin.Default()
+
+	server := &RestServer{
+		MultiplierMgr: multiplier.NewManager(d),
+	}
+
+	// Register the endpoint
+	r.GET("/multipliers", server.GetMultiplierRules)
+	r.POST("/multipliers", server.CreateMultiplierRule)
+	r.DELETE("/multipliers/:ruleId", server.DeleteMultiplierRule)
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name: "Valid request",
+			body: map[string]interface{}{
+				"name":       "double points weekend",
+				"multiplier": "2",
+				"startAt":    "2024-07-06T00:00:00Z",
+				"endAt":      "2024-07-08T00:00:00Z",
+				"addresses":  []string{"0x000000000000000000000000000000000000dEaD"},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid multiplier",
+			body: map[string]interface{}{
+				"name":       "negative",
+				"multiplier": "-1",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid address",
+			body: map[string]interface{}{
+				"name":       "partner",
+				"multiplier": "1.5",
+				"addresses":  []string{"0x12345"},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/multipliers", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/multipliers", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody []model.MultiplierRule
+	if err := json.Unmarshal(w.Body.Bytes(), &responseBody); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if !assert.Equal(t, 1, len(responseBody)) {
+		return
+	}
+	assert.True(t, decimal.NewFromInt(2).Equal(responseBody[0].Multiplier))
+	assert.Equal(t, 1, len(responseBody[0].Addresses))
+
+	req, err = http.NewRequest(http.MethodDelete, "/multipliers/"+responseBody[0].ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_Campaigns(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	r := gin.Default()
+
+	taskMgr := task.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	server := &RestServer{
+		TaskMgr:      taskMgr,
+		UserPointMgr: userPointMgr,
+		CampaignMgr:  campaign.NewManager(d, taskMgr),
+	}
+
+	// Register the endpoint
+	r.GET("/campaigns", server.GetCampaigns)
+	r.POST("/campaigns", server.CreateCampaign)
+	r.GET("/campaigns/:campaignId", server.GetCampaign)
+	r.PUT("/campaigns/:campaignId/tasks/:taskId", server.AttachCampaignTask)
+	r.GET("/campaigns/:campaignId/points", server.GetCampaignPoints)
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		statusCode int
+	}{
+		{
+			name: "Valid request",
+			body: map[string]interface{}{
+				"name":    "season 1",
+				"startAt": "2024-07-01T00:00:00Z",
+				"endAt":   "2024-10-01T00:00:00Z",
+				"budget":  "100000",
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "Invalid season",
+			body: map[string]interface{}{
+				"name":    "season 2",
+				"startAt": "2024-10-01T00:00:00Z",
+				"endAt":   "2024-07-01T00:00:00Z",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid budget",
+			body: map[string]interface{}{
+				"name":    "season 3",
+				"startAt": "2024-07-01T00:00:00Z",
+				"endAt":   "2024-10-01T00:00:00Z",
+				"budget":  "lots",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/campaigns", bytes.NewBuffer(jsonData))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/campaigns", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var campaigns []model.Campaign
+	if err := json.Unmarshal(w.Body.Bytes(), &campaigns); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if !assert.Equal(t, 1, len(campaigns)) {
+		return
+	}
+	campaignID := campaigns[0].ID
+
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	if err := taskMgr.CreateSharePoolTask(ctx, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", startAt, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil || !assert.Equal(t, 1, len(tasks)) {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+
+	for _, tt := range []struct {
+		path       string
+		statusCode int
+	}{
+		{path: "/campaigns/" + campaignID + "/tasks/" + tasks[0].ID, statusCode: http.StatusOK},
+		{path: "/campaigns/" + campaignID + "/tasks/notExist", statusCode: http.StatusNotFound},
+		{path: "/campaigns/notExist/tasks/" + tasks[0].ID, statusCode: http.StatusNotFound},
+	} {
+		req, err := http.NewRequest(http.MethodPut, tt.path, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.statusCode, w.Code, tt.path)
+	}
+
+	if err := userPointMgr.UpsertForUserTask(ctx, "0x0000000000000000000000000000000000000001", tasks[0].ID, decimal.NewFromInt(10)); err != nil {
+		t.Errorf("UpsertForUserTask err: %v", err)
+		return
+	}
+
+	req, err = http.NewRequest(http.MethodGet, "/campaigns/"+campaignID+"/points", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var points []model.UserPoint
+	if err := json.Unmarshal(w.Body.Bytes(), &points); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if assert.Equal(t, 1, len(points)) {
+		assert.True(t, decimal.NewFromInt(10).Equal(points[0].Point))
+		assert.Equal(t, campaignID, points[0].CampaignID)
+	}
+
+	req, err = http.NewRequest(http.MethodGet, "/campaigns/notExist", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Leaderboard(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	r := gin.Default()
+
+	taskMgr := task.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	server := &RestServer{
+		TaskMgr:        taskMgr,
+		CampaignMgr:    campaign.NewManager(d, taskMgr),
+		LeaderboardMgr: leaderboard.NewManager(d),
+	}
+
+	// Register the endpoint
+	r.GET("/leaderboard", server.GetLeaderboard)
+	r.GET("/leaderboard/:address", server.GetLeaderboardRank)
+
+	if err := taskMgr.CreateSharePoolTask(ctx, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", time.Now(), model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil || !assert.Equal(t, 1, len(tasks)) {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+
+	leader := "0x0000000000000000000000000000000000000001"
+	follower := "0x0000000000000000000000000000000000000002"
+	for address, point := range map[string]int64{leader: 30, follower: 10} {
+		if err := userPointMgr.UpsertForUserTask(ctx, address, tasks[0].ID, decimal.NewFromInt(point)); err != nil {
+			t.Errorf("UpsertForUserTask err: %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		statusCode int
+	}{
+		{name: "Global", path: "/leaderboard", statusCode: http.StatusOK},
+		{name: "Task epoch", path: "/leaderboard?taskId=" + tasks[0].ID + "&epoch=0&limit=10", statusCode: http.StatusOK},
+		{name: "Epoch without task", path: "/leaderboard?epoch=0", statusCode: http.StatusBadRequest},
+		{name: "Invalid limit", path: "/leaderboard?limit=0", statusCode: http.StatusBadRequest},
+		{name: "Task not found", path: "/leaderboard?taskId=notExist", statusCode: http.StatusNotFound},
+		{name: "Campaign not found", path: "/leaderboard?campaignId=notExist", statusCode: http.StatusNotFound},
+		{name: "My rank", path: "/leaderboard/" + follower + "?taskId=" + tasks[0].ID, statusCode: http.StatusOK},
+		{name: "Not ranked", path: "/leaderboard/0x0000000000000000000000000000000000000003", statusCode: http.StatusNotFound},
+		{name: "Invalid address", path: "/leaderboard/0x12345", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/leaderboard?taskId="+tasks[0].ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var responseBody model.Leaderboard
+	if err := json.Unmarshal(w.Body.Bytes(), &responseBody); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	assert.Equal(t, 2, responseBody.Total)
+	if assert.Equal(t, 2, len(responseBody.Entries)) {
+		assert.Equal(t, leader, responseBody.Entries[0].UserAddress)
+		assert.Equal(t, 2, responseBody.Entries[1].Rank)
+	}
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/schedule"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+)
+
+// tasks settled by epoch
+var settledTasks = map[string]bool{
+	"share_pool":  true,
+	"streak":      true,
+	"lp_provider": true,
+}
+
+// SettlementScheduler settles the epochs of tasks once they close and the listener has ingested past their end.
+type SettlementScheduler struct {
+	TaskMgr      iface.TaskManager
+	UserTaskMgr  iface.UserTaskManager
+	IngestionMgr iface.IngestionManager
+	ScheduleMgr  iface.ScheduleManager
+	Policy       schedule.Policy
+	// how often due epochs are looked up
+	Interval time.Duration
+}
+
+func (s *SettlementScheduler) Run(ctx context.Context) {
+	log.Printf("settlement scheduler started, delay: %s, retry interval: %s, max attempts: %d",
+		s.Policy.Delay, s.Policy.RetryInterval, s.Policy.MaxAttempts)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx, time.Now())
+		}
+	}
+}
+
+// tick settles the due epochs of every task, a failing task does not hold back the others.
+func (s *SettlementScheduler) tick(ctx context.Context, now time.Time) {
+	tasks, err := s.TaskMgr.GetTasks(ctx)
+	if err != nil {
+		log.Printf("settlement scheduler list tasks fail: %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		if !settledTasks[task.Name.String] || !task.PairAddress.Valid {
+			continue
+		}
+		if err := s.settle(ctx, task, now); err != nil {
+			log.Printf("settlement scheduler task %s: %v", task.ID, err)
+		}
+	}
+}
+
+// settle runs the settlement of the task once for all of its due epochs and records the outcome for each of them.
+func (s *SettlementScheduler) settle(ctx context.Context, task model.Task, now time.Time) error {
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		return fmt.Errorf("schedule epochs: %v", err)
+	}
+
+	cursor, err := s.IngestionMgr.GetCursor(ctx, task.ID)
+	if err == sql.ErrNoRows {
+		// the pair is not ingested yet
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	latest, err := s.ScheduleMgr.GetLatestRuns(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	due := schedule.DueEpochs(epochs, latest, cursor.BlockAt, now, s.Policy)
+	if len(due) == 0 {
+		return nil
+	}
+
+	runIDs := make([]string, 0, len(due))
+	for _, d := range due {
+		runID, err := s.ScheduleMgr.StartRun(ctx, task.ID, d.Epoch.Index, d.Attempt)
+		if err != nil {
+			return err
+		}
+		runIDs = append(runIDs, runID)
+		log.Printf("settling task %s epoch %d, attempt %d", task.ID, d.Epoch.Index, d.Attempt)
+	}
+
+	// epochs that are not due yet are left unsettled, they would not be settled again once committed
+	settleErr := s.UserTaskMgr.SettleTask(ctx, task, option.SettleTaskOptions{
+		Until: minTime(cursor.BlockAt, now.Add(-s.Policy.Delay)),
+	})
+	for i, runID := range runIDs {
+		if err := s.ScheduleMgr.FinishRun(ctx, runID, settleErr); err != nil {
+			return err
+		}
+		if settleErr != nil && due[i].Attempt >= s.Policy.MaxAttempts {
+			log.Printf("giving up settling task %s epoch %d after %d attempts", task.ID, due[i].Epoch.Index, due[i].Attempt)
+		}
+	}
+
+	return settleErr
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+// policyFromEnv reads SETTLEMENT_DELAY, SETTLEMENT_RETRY_INTERVAL and SETTLEMENT_MAX_ATTEMPTS, unset or invalid values keep the default.
+func policyFromEnv() schedule.Policy {
+	policy := schedule.DefaultPolicy
+
+	if value := os.Getenv("SETTLEMENT_DELAY"); len(value) != 0 {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			policy.Delay = d
+		} else {
+			log.Printf("invalid SETTLEMENT_DELAY %q, using %s", value, policy.Delay)
+		}
+	}
+	if value := os.Getenv("SETTLEMENT_RETRY_INTERVAL"); len(value) != 0 {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			policy.RetryInterval = d
+		} else {
+			log.Printf("invalid SETTLEMENT_RETRY_INTERVAL %q, using %s", value, policy.RetryInterval)
+		}
+	}
+	if value := os.Getenv("SETTLEMENT_MAX_ATTEMPTS"); len(value) != 0 {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			policy.MaxAttempts = n
+		} else {
+			log.Printf("invalid SETTLEMENT_MAX_ATTEMPTS %q, using %d", value, policy.MaxAttempts)
+		}
+	}
+
+	return policy
+}
+
+func NewSettlementScheduler(
+	taskMgr iface.TaskManager,
+	userTaskMgr iface.UserTaskManager,
+	ingestionMgr iface.IngestionManager,
+	scheduleMgr iface.ScheduleManager,
+) *SettlementScheduler {
+
+	return &SettlementScheduler{
+		TaskMgr:      taskMgr,
+		UserTaskMgr:  userTaskMgr,
+		IngestionMgr: ingestionMgr,
+		ScheduleMgr:  scheduleMgr,
+		Policy:       policyFromEnv(),
+		Interval:     time.Minute,
+	}
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/schedule"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/ingestion"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	scheduleSvc "tradingAce/pkg/service/schedule"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
+	"tradingAce/pkg/service/transaction"
+	"tradingAce/pkg/service/userpoint"
+	"tradingAce/pkg/service/usertask"
+	"tradingAce/pkg/utils"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementScheduler_tick(t *testing.T) {
+	godotenv.Load("../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	taskMgr := task.NewManager(d)
+	ingestionMgr := ingestion.NewManager(d)
+	scheduleMgr := scheduleSvc.NewManager(d)
+	userTaskMgr := usertask.NewManager(d, taskMgr, transaction.NewManager(d), userpoint.NewManager(d), tradeflag.NewManager(d),
+		addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d))
+	s := SettlementScheduler{
+		TaskMgr:      taskMgr,
+		UserTaskMgr:  userTaskMgr,
+		IngestionMgr: ingestionMgr,
+		ScheduleMgr:  scheduleMgr,
+		Policy:       schedule.DefaultPolicy,
+		Interval:     time.Minute,
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "createdAt", "name", "startAt") VALUES ($1, $2, 'onboarding', $3)
+	`, utils.GenDBID(), time.Now(), startAt); err != nil {
+		t.Errorf("insert onboarding task err: %v", err)
+		return
+	}
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "day", Length: 1, Count: 2}}
+	if err := taskMgr.CreateSharePoolTask(ctx, pair, startAt, config); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil || !assert.Equal(t, 1, len(tasks)) {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+	taskID := tasks[0].ID
+
+	// the pair is not ingested yet
+	s.tick(ctx, time.Now())
+	runs, err := scheduleMgr.GetRuns(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, len(runs))
+
+	// ingestion passed the end of the first epoch only
+	if err := ingestionMgr.SetCursor(ctx, taskID, pair, 100, startAt.AddDate(0, 0, 1)); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+	s.tick(ctx, time.Now())
+	runs, err = scheduleMgr.GetRuns(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(runs)) {
+		assert.Equal(t, 0, runs[0].Epoch)
+		assert.Equal(t, schedule.StateSucceeded, runs[0].State)
+	}
+
+	if err := ingestionMgr.SetCursor(ctx, taskID, pair, 200, startAt.AddDate(0, 0, 3)); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+	s.tick(ctx, time.Now())
+	// settled epochs are not settled again
+	s.tick(ctx, time.Now())
+	runs, err = scheduleMgr.GetRuns(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	if assert.Equal(t, 2, len(runs)) {
+		assert.Equal(t, 1, runs[1].Epoch)
+		assert.Equal(t, 1, runs[1].Attempt)
+		assert.Equal(t, schedule.StateSucceeded, runs[1].State)
+	}
+}
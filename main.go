@@ -14,7 +14,7 @@ var rootCmd = &cobra.Command{Use: "server cli"}
 func main() {
 	godotenv.Load(".env/.env")
 
-	rootCmd.AddCommand(cmd.MigrateCmd, cmd.TaskListenerCmd, cmd.DownCmd, cmd.ServerCmd, cmd.CheckSharePoolTaskCmd)
+	rootCmd.AddCommand(cmd.MigrateCmd, cmd.TaskListenerCmd, cmd.DownCmd, cmd.ServerCmd, cmd.CheckSharePoolTaskCmd, cmd.CheckStreakTaskCmd, cmd.CheckLPTaskCmd, cmd.AddressListCmd, cmd.AnalyzeSandwichCmd, cmd.CampaignCmd, cmd.RecalculateCmd, cmd.TaskRuleCmd, cmd.AllocationCmd, cmd.ExportCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
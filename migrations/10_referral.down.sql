@@ -0,0 +1,4 @@
+-- 10_referral.down.sql
+
+DROP TABLE IF EXISTS "referral";
+DELETE FROM task WHERE "name" = 'referral';
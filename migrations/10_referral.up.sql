@@ -0,0 +1,16 @@
+-- 10_referral.up.sql
+
+-- every referee registers one referrer with a signed message
+CREATE TABLE "referral" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "refereeAddress" VARCHAR(120) NOT NULL,
+    "referrerAddress" VARCHAR(120) NOT NULL,
+    "signature" VARCHAR(132) NOT NULL,
+    "onboarded" BOOLEAN NOT NULL DEFAULT FALSE,
+    "volumeUsd" NUMERIC(38, 8) NOT NULL DEFAULT 0,
+    "point" NUMERIC(38, 8) NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX "idx_unique_referral_refereeaddress" ON "referral" ("refereeAddress");
+CREATE INDEX "idx_referral_referreraddress" ON "referral" ("referrerAddress");
@@ -0,0 +1,5 @@
+-- 11_liquidity.down.sql
+
+DROP TABLE IF EXISTS "lpTransfer";
+DROP TABLE IF EXISTS "liquidityEvent";
+DELETE FROM task WHERE "name" = 'lp_provider';
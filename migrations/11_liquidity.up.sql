@@ -0,0 +1,34 @@
+-- 11_liquidity.up.sql
+
+-- Mint and Burn events of the pairs
+CREATE TABLE "liquidityEvent" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "blockNum" BIGINT NOT NULL,
+    "logIndex" INT NOT NULL,
+    "txHash" VARCHAR(66) NOT NULL,
+    "pairAddress" VARCHAR(120) NOT NULL,
+    "kind" VARCHAR(10) NOT NULL,
+    "senderAddress" VARCHAR(120) NOT NULL,
+    "toAddress" VARCHAR(120) NOT NULL DEFAULT '',
+    "amount0" NUMERIC(78, 0) NOT NULL,
+    "amount1" NUMERIC(78, 0) NOT NULL,
+    "eventAt" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Transfer events of the LP tokens, balances of providers are built from them
+CREATE TABLE "lpTransfer" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "blockNum" BIGINT NOT NULL,
+    "logIndex" INT NOT NULL,
+    "txHash" VARCHAR(66) NOT NULL,
+    "pairAddress" VARCHAR(120) NOT NULL,
+    "fromAddress" VARCHAR(120) NOT NULL,
+    "toAddress" VARCHAR(120) NOT NULL,
+    "value" NUMERIC(78, 0) NOT NULL,
+    "transferAt" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX "idx_unique_liquidityevent_blocknum_pairaddress_logindex" ON "liquidityEvent" ("blockNum", "pairAddress", "logIndex");
+CREATE UNIQUE INDEX "idx_unique_lptransfer_blocknum_pairaddress_logindex" ON "lpTransfer" ("blockNum", "pairAddress", "logIndex");
@@ -0,0 +1,5 @@
+-- 12_multiplier.down.sql
+
+ALTER TABLE "pointLedger" DROP COLUMN IF EXISTS "multiplier";
+DROP TABLE IF EXISTS "multiplierAddress";
+DROP TABLE IF EXISTS "multiplierRule";
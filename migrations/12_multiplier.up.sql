@@ -0,0 +1,23 @@
+-- 12_multiplier.up.sql
+
+-- multiplier rules boost the awarded points, empty fields match everything
+CREATE TABLE "multiplierRule" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "name" VARCHAR(100) NOT NULL,
+    "multiplier" NUMERIC(10, 4) NOT NULL,
+    "startAt" TIMESTAMP WITH TIME ZONE NULL,
+    "endAt" TIMESTAMP WITH TIME ZONE NULL,
+    "taskId" VARCHAR(32) NOT NULL DEFAULT '',
+    "pairAddress" VARCHAR(120) NOT NULL DEFAULT ''
+);
+
+-- members of the rules that only apply to listed addresses
+CREATE TABLE "multiplierAddress" (
+    "ruleId" VARCHAR(32) NOT NULL REFERENCES "multiplierRule" ("id") ON DELETE CASCADE,
+    "address" VARCHAR(120) NOT NULL,
+    PRIMARY KEY ("ruleId", "address")
+);
+
+-- the multiplier applied to every ledger entry, 1 for entries before the rules
+ALTER TABLE "pointLedger" ADD COLUMN "multiplier" NUMERIC(10, 4) NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- 13_prerequisite.down.sql
+
+ALTER TABLE "userTask" DROP COLUMN IF EXISTS "completedAt";
@@ -0,0 +1,5 @@
+-- 13_prerequisite.up.sql
+
+-- when the user task was completed, prerequisites can require completion before an epoch starts
+ALTER TABLE "userTask" ADD COLUMN "completedAt" TIMESTAMP WITH TIME ZONE NULL;
+-- the completion time of existing tasks is unknown and left NULL, it is set from the chain when they are processed again
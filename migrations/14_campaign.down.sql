@@ -0,0 +1,5 @@
+-- 14_campaign.down.sql
+
+DROP INDEX IF EXISTS "idx_task_campaignid";
+ALTER TABLE "task" DROP COLUMN IF EXISTS "campaignId";
+DROP TABLE IF EXISTS "campaign";
@@ -0,0 +1,14 @@
+-- 14_campaign.up.sql
+
+-- a campaign (season) bundles tasks, their settled points are capped by its budget
+CREATE TABLE "campaign" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "name" VARCHAR(100) NOT NULL,
+    "startAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "endAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "budget" NUMERIC(38, 8) NULL
+);
+
+ALTER TABLE "task" ADD COLUMN "campaignId" VARCHAR(32) NULL REFERENCES "campaign" ("id") ON DELETE SET NULL;
+CREATE INDEX "idx_task_campaignid" ON "task" ("campaignId");
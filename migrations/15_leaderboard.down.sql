@@ -0,0 +1,5 @@
+-- 15_leaderboard.down.sql
+
+DROP INDEX IF EXISTS "idx_pointledger_taskid_epoch_useraddress";
+DROP INDEX IF EXISTS "idx_userpoint_taskid_useraddress";
+DROP TABLE IF EXISTS "epochVolume";
@@ -0,0 +1,16 @@
+-- 15_leaderboard.up.sql
+
+-- USD volume of every user in the settled epochs of share pool and streak tasks
+CREATE TABLE "epochVolume" (
+    "userAddress" VARCHAR(120) NOT NULL,
+    "taskId" VARCHAR(32) NOT NULL,
+    "epoch" INT NOT NULL,
+    "volume" NUMERIC(38, 8) NOT NULL DEFAULT 0,
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX "idx_unique_epochvolume_taskid_epoch_useraddress" ON "epochVolume" ("taskId", "epoch", "userAddress");
+
+-- leaderboards aggregate the points of a task or an epoch by user
+CREATE INDEX "idx_userpoint_taskid_useraddress" ON "userPoint" ("taskId", "userAddress") INCLUDE ("point");
+CREATE INDEX "idx_pointledger_taskid_epoch_useraddress" ON "pointLedger" ("taskId", "epoch", "userAddress") INCLUDE ("point");
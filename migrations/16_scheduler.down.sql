@@ -0,0 +1,4 @@
+-- 16_scheduler.down.sql
+
+DROP TABLE IF EXISTS "scheduleRun";
+DROP TABLE IF EXISTS "ingestionCursor";
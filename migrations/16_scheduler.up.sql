@@ -0,0 +1,23 @@
+-- 16_scheduler.up.sql
+
+-- latest block the listener has ingested for every pair, settlement waits until it passes the end of an epoch
+CREATE TABLE "ingestionCursor" (
+    "pairAddress" VARCHAR(120) NOT NULL PRIMARY KEY,
+    "blockNum" BIGINT NOT NULL,
+    "blockAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- history of the settlements triggered by the scheduler, one row per attempt of a task epoch
+CREATE TABLE "scheduleRun" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "taskId" VARCHAR(32) NOT NULL,
+    "epoch" INT NOT NULL,
+    "attempt" INT NOT NULL,
+    "state" VARCHAR(15) NOT NULL,
+    "error" TEXT NOT NULL DEFAULT '',
+    "startedAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "finishedAt" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX "idx_schedulerun_taskid_epoch_attempt" ON "scheduleRun" ("taskId", "epoch", "attempt");
@@ -0,0 +1,3 @@
+-- 17_settlementRun.down.sql
+
+DROP TABLE IF EXISTS "settlementRun";
@@ -0,0 +1,17 @@
+-- 17_settlementRun.up.sql
+
+-- settlement of one epoch of a task, every epoch is committed at most once unless the settlement is forced
+CREATE TABLE "settlementRun" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "taskId" VARCHAR(32) NOT NULL,
+    "epoch" INT NOT NULL,
+    "state" VARCHAR(15) NOT NULL,
+    "forced" BOOLEAN NOT NULL DEFAULT FALSE,
+    "error" TEXT NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "committedAt" TIMESTAMP WITH TIME ZONE NULL,
+    "finalizedAt" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX "idx_settlementrun_taskid_epoch_createdat" ON "settlementRun" ("taskId", "epoch", "createdAt");
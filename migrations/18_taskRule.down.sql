@@ -0,0 +1,5 @@
+-- 18_taskRule.down.sql
+
+DROP TABLE IF EXISTS "recalculationEntry";
+DROP TABLE IF EXISTS "recalculation";
+DROP TABLE IF EXISTS "taskRuleVersion";
@@ -0,0 +1,41 @@
+-- 18_taskRule.up.sql
+
+-- rules of a task by version, an epoch is settled with the latest version in effect when it starts
+CREATE TABLE "taskRuleVersion" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "taskId" VARCHAR(32) NOT NULL REFERENCES "task" ("id") ON DELETE CASCADE,
+    "version" INT NOT NULL,
+    "config" JSONB NOT NULL DEFAULT '{}',
+    "effectiveAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "note" TEXT NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX "idx_taskruleversion_taskid_version" ON "taskRuleVersion" ("taskId", "version");
+
+-- the current rules of the existing tasks are their first version
+INSERT INTO "taskRuleVersion" ("id", "taskId", "version", "config", "effectiveAt", "createdAt")
+SELECT "id", "id", 1, "config", "startAt", "createdAt"
+FROM "task";
+
+-- every recalculation of past epochs, with the points of every user before and after it
+CREATE TABLE "recalculation" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "taskId" VARCHAR(32) NOT NULL,
+    "ruleVersion" INT NULL,
+    "epochs" INT[] NOT NULL,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX "idx_recalculation_taskid_createdat" ON "recalculation" ("taskId", "createdAt");
+
+CREATE TABLE "recalculationEntry" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "recalculationId" VARCHAR(32) NOT NULL REFERENCES "recalculation" ("id") ON DELETE CASCADE,
+    "userAddress" VARCHAR(120) NOT NULL,
+    "before" NUMERIC(38, 8) NOT NULL,
+    "after" NUMERIC(38, 8) NOT NULL
+);
+
+CREATE INDEX "idx_recalculationentry_recalculationid" ON "recalculationEntry" ("recalculationId");
@@ -0,0 +1,4 @@
+-- 19_claim.down.sql
+
+DROP TABLE IF EXISTS "claim";
+DROP TABLE IF EXISTS "claimSnapshot";
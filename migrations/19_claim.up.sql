@@ -0,0 +1,28 @@
+-- 19_claim.up.sql
+
+-- the frozen points of a campaign and the Merkle root of its claims, a campaign is snapshot once
+CREATE TABLE "claimSnapshot" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "campaignId" VARCHAR(32) NOT NULL REFERENCES "campaign" ("id"),
+    "root" VARCHAR(66) NOT NULL,
+    "decimals" INT NOT NULL,
+    "users" INT NOT NULL,
+    "totalPoint" NUMERIC(38, 8) NOT NULL,
+    "totalAmount" NUMERIC(78, 0) NOT NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX "idx_unique_claimsnapshot_campaignid" ON "claimSnapshot" ("campaignId");
+
+-- the (address, amount) leaf of every user with the proof of it
+CREATE TABLE "claim" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "snapshotId" VARCHAR(32) NOT NULL REFERENCES "claimSnapshot" ("id") ON DELETE CASCADE,
+    "userAddress" VARCHAR(120) NOT NULL,
+    "point" NUMERIC(38, 8) NOT NULL,
+    "amount" NUMERIC(78, 0) NOT NULL,
+    "proof" TEXT[] NOT NULL
+);
+
+CREATE UNIQUE INDEX "idx_unique_claim_snapshotid_useraddress" ON "claim" ("snapshotId", "userAddress");
+CREATE INDEX "idx_claim_useraddress" ON "claim" ("userAddress");
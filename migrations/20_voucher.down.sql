@@ -0,0 +1,3 @@
+-- 20_voucher.down.sql
+
+DROP TABLE IF EXISTS "voucher";
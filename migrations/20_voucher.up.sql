@@ -0,0 +1,20 @@
+-- 20_voucher.up.sql
+
+-- signed vouchers of the claims of campaign snapshots, the nonce of a user increases with every voucher
+CREATE TABLE "voucher" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "campaignId" VARCHAR(32) NOT NULL REFERENCES "campaign" ("id"),
+    "userAddress" VARCHAR(120) NOT NULL,
+    "amount" NUMERIC(78, 0) NOT NULL,
+    "nonce" BIGINT NOT NULL,
+    "deadline" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "signer" VARCHAR(42) NOT NULL,
+    "signature" VARCHAR(132) NOT NULL,
+    "state" VARCHAR(20) NOT NULL DEFAULT 'issued',
+    "txHash" VARCHAR(66) NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "redeemedAt" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX "idx_unique_voucher_useraddress_nonce" ON "voucher" ("userAddress", "nonce");
+CREATE INDEX "idx_voucher_campaignid_useraddress" ON "voucher" ("campaignId", "userAddress");
@@ -0,0 +1,4 @@
+-- 21_allocation.down.sql
+
+DROP TABLE IF EXISTS "allocation";
+DROP TABLE IF EXISTS "allocationPlan";
@@ -0,0 +1,26 @@
+-- 21_allocation.up.sql
+
+-- the token budget of the snapshot of a campaign and its vesting, a campaign is allocated once
+CREATE TABLE "allocationPlan" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "campaignId" VARCHAR(32) NOT NULL REFERENCES "campaign" ("id"),
+    "snapshotId" VARCHAR(32) NOT NULL REFERENCES "claimSnapshot" ("id"),
+    "budget" NUMERIC(78, 18) NOT NULL,
+    "decimals" INT NOT NULL,
+    "vesting" JSONB NOT NULL,
+    "users" INT NOT NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX "idx_unique_allocationplan_campaignid" ON "allocationPlan" ("campaignId");
+
+CREATE TABLE "allocation" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "planId" VARCHAR(32) NOT NULL REFERENCES "allocationPlan" ("id") ON DELETE CASCADE,
+    "userAddress" VARCHAR(120) NOT NULL,
+    "point" NUMERIC(38, 8) NOT NULL,
+    "amount" NUMERIC(78, 0) NOT NULL
+);
+
+CREATE UNIQUE INDEX "idx_unique_allocation_planid_useraddress" ON "allocation" ("planId", "userAddress");
+CREATE INDEX "idx_allocation_useraddress" ON "allocation" ("userAddress");
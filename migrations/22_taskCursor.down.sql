@@ -0,0 +1,6 @@
+-- 22_taskCursor.down.sql
+
+DELETE FROM "ingestionCursor";
+ALTER TABLE "ingestionCursor" DROP CONSTRAINT "ingestionCursor_pkey";
+ALTER TABLE "ingestionCursor" DROP COLUMN IF EXISTS "taskId";
+ALTER TABLE "ingestionCursor" ADD PRIMARY KEY ("pairAddress");
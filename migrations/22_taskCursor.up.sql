@@ -0,0 +1,7 @@
+-- 22_taskCursor.up.sql
+
+-- the cursor is kept per task, a task still syncing the history of a pair must not inherit the blocks another task of the pair has ingested.
+-- the cursors of the pairs can not be split by task, they are rebuilt by the listener when it syncs the history of every task again
+DELETE FROM "ingestionCursor";
+ALTER TABLE "ingestionCursor" DROP CONSTRAINT "ingestionCursor_pkey";
+ALTER TABLE "ingestionCursor" ADD COLUMN "taskId" VARCHAR(32) NOT NULL PRIMARY KEY;
@@ -0,0 +1,3 @@
+-- 2_taskConfig.down.sql
+
+ALTER TABLE "task" DROP COLUMN IF EXISTS "config";
@@ -0,0 +1,3 @@
+-- 2_taskConfig.up.sql
+
+ALTER TABLE "task" ADD COLUMN "config" JSONB NOT NULL DEFAULT '{}';
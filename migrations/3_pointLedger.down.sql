@@ -0,0 +1,3 @@
+-- 3_pointLedger.down.sql
+
+DROP TABLE IF EXISTS "pointLedger";
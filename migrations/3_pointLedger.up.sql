@@ -0,0 +1,21 @@
+-- 3_pointLedger.up.sql
+
+CREATE TABLE "pointLedger" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "userAddress" VARCHAR(120) NOT NULL,
+    "taskId" VARCHAR(32) NOT NULL,
+    "epoch" INT NOT NULL DEFAULT 0,
+    "point" NUMERIC(38, 8) NOT NULL,
+    "reason" VARCHAR(50) NOT NULL,
+    "settlementRunId" VARCHAR(32) NULL
+);
+
+CREATE INDEX "idx_pointledger_useraddress_taskid_epoch" ON "pointLedger" ("userAddress", "taskId", "epoch");
+CREATE INDEX "idx_pointledger_taskid" ON "pointLedger" ("taskId");
+
+-- keep the existing balances as the opening entries of the ledger
+INSERT INTO "pointLedger" ("id", "createdAt", "userAddress", "taskId", "epoch", "point", "reason")
+SELECT "id", "createdAt", "userAddress", "taskId", 0, "point", 'migration'
+FROM "userPoint"
+WHERE "point" <> 0;
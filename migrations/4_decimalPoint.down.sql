@@ -0,0 +1,3 @@
+-- 4_decimalPoint.down.sql
+
+ALTER TABLE "userPoint" ALTER COLUMN "point" TYPE INT USING ROUND("point");
@@ -0,0 +1,3 @@
+-- 4_decimalPoint.up.sql
+
+ALTER TABLE "userPoint" ALTER COLUMN "point" TYPE NUMERIC(38, 8);
@@ -0,0 +1,11 @@
+-- 5_tradeFlag.down.sql
+
+DROP TABLE IF EXISTS "tradeFlag";
+
+DROP INDEX IF EXISTS "idx_unique_blocknum_pairaddress_logindex";
+DELETE FROM transaction t USING transaction o
+WHERE t."blockNum" = o."blockNum" AND t."pairAddress" = o."pairAddress" AND t."logIndex" > o."logIndex";
+CREATE UNIQUE INDEX "idx_unique_blocknum_pairaddress" ON transaction ("blockNum", "pairAddress");
+
+ALTER TABLE "transaction" DROP COLUMN IF EXISTS "logIndex";
+ALTER TABLE "transaction" DROP COLUMN IF EXISTS "txHash";
@@ -0,0 +1,19 @@
+-- 5_tradeFlag.up.sql
+
+-- a transaction can emit several swaps of the same pair, identify them by log index
+ALTER TABLE "transaction" ADD COLUMN "txHash" VARCHAR(66) NOT NULL DEFAULT '';
+ALTER TABLE "transaction" ADD COLUMN "logIndex" INT NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS "idx_unique_blocknum_pairaddress";
+CREATE UNIQUE INDEX "idx_unique_blocknum_pairaddress_logindex" ON transaction ("blockNum", "pairAddress", "logIndex");
+
+CREATE TABLE "tradeFlag" (
+    "id" VARCHAR(32) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "userAddress" VARCHAR(120) NOT NULL,
+    "transactionId" VARCHAR(32) NOT NULL DEFAULT '',
+    "reason" VARCHAR(50) NOT NULL,
+    "detail" TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX "idx_unique_tradeflag_useraddress_transactionid_reason" ON "tradeFlag" ("userAddress", "transactionId", "reason");
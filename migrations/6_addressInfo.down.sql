@@ -0,0 +1,4 @@
+-- 6_addressInfo.down.sql
+
+DROP TABLE IF EXISTS "addressList";
+DROP TABLE IF EXISTS "addressInfo";
@@ -0,0 +1,16 @@
+-- 6_addressInfo.up.sql
+
+-- cached eth_getCode result of every trader
+CREATE TABLE "addressInfo" (
+    "address" VARCHAR(120) NOT NULL PRIMARY KEY,
+    "isContract" BOOLEAN NOT NULL,
+    "checkedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- maintained deny/allow list of known bots and routers
+CREATE TABLE "addressList" (
+    "address" VARCHAR(120) NOT NULL PRIMARY KEY,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "list" VARCHAR(10) NOT NULL,
+    "label" VARCHAR(100) NOT NULL DEFAULT ''
+);
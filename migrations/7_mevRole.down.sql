@@ -0,0 +1,3 @@
+-- 7_mevRole.down.sql
+
+ALTER TABLE "transaction" DROP COLUMN IF EXISTS "mevRole";
@@ -0,0 +1,4 @@
+-- 7_mevRole.up.sql
+
+-- role of the swap in a sandwich: front_run, victim, back_run or empty
+ALTER TABLE "transaction" ADD COLUMN "mevRole" VARCHAR(20) NOT NULL DEFAULT '';
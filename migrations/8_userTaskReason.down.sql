@@ -0,0 +1,4 @@
+-- 8_userTaskReason.down.sql
+
+UPDATE "userTask" SET "state" = 'pending' WHERE "state" = 'ineligible';
+ALTER TABLE "userTask" DROP COLUMN IF EXISTS "reason";
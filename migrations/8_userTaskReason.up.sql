@@ -0,0 +1,4 @@
+-- 8_userTaskReason.up.sql
+
+-- why a user is ineligible for the task, e.g. min_volume or min_trade_count
+ALTER TABLE "userTask" ADD COLUMN "reason" VARCHAR(30) NOT NULL DEFAULT '';
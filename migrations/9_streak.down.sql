@@ -0,0 +1,8 @@
+-- 9_streak.down.sql
+
+DELETE FROM task WHERE "name" = 'streak';
+DROP INDEX IF EXISTS "unique_name_pairAddress";
+CREATE UNIQUE INDEX "unique_pairAddress" ON task("pairAddress");
+
+ALTER TABLE "userTask" DROP COLUMN IF EXISTS "longestStreak";
+ALTER TABLE "userTask" DROP COLUMN IF EXISTS "currentStreak";
@@ -0,0 +1,9 @@
+-- 9_streak.up.sql
+
+-- streak of the user in epochs, only for streak tasks
+ALTER TABLE "userTask" ADD COLUMN "currentStreak" INT NOT NULL DEFAULT 0;
+ALTER TABLE "userTask" ADD COLUMN "longestStreak" INT NOT NULL DEFAULT 0;
+
+-- a pair can have one task of every type
+DROP INDEX IF EXISTS "unique_pairAddress";
+CREATE UNIQUE INDEX "unique_name_pairAddress" ON task("name", "pairAddress");
@@ -2,8 +2,8 @@ package constants
 
 import "github.com/shopspring/decimal"
 
-// Uniswap V2 Swap event ABI
-const UniswapSwapEventABI = `
+// Uniswap V2 pair event ABI: Swap, Mint, Burn and Transfer of the LP token
+const UniswapPairEventABI = `
 [
     {
         "anonymous": false,
@@ -41,6 +41,77 @@ const UniswapSwapEventABI = `
         ],
         "name": "Swap",
         "type": "event"
+    },
+    {
+        "anonymous": false,
+        "inputs": [
+            {
+                "indexed": true,
+                "name": "sender",
+                "type": "address"
+            },
+            {
+                "indexed": false,
+                "name": "amount0",
+                "type": "uint256"
+            },
+            {
+                "indexed": false,
+                "name": "amount1",
+                "type": "uint256"
+            }
+        ],
+        "name": "Mint",
+        "type": "event"
+    },
+    {
+        "anonymous": false,
+        "inputs": [
+            {
+                "indexed": true,
+                "name": "sender",
+                "type": "address"
+            },
+            {
+                "indexed": false,
+                "name": "amount0",
+                "type": "uint256"
+            },
+            {
+                "indexed": false,
+                "name": "amount1",
+                "type": "uint256"
+            },
+            {
+                "indexed": true,
+                "name": "to",
+                "type": "address"
+            }
+        ],
+        "name": "Burn",
+        "type": "event"
+    },
+    {
+        "anonymous": false,
+        "inputs": [
+            {
+                "indexed": true,
+                "name": "from",
+                "type": "address"
+            },
+            {
+                "indexed": true,
+                "name": "to",
+                "type": "address"
+            },
+            {
+                "indexed": false,
+                "name": "value",
+                "type": "uint256"
+            }
+        ],
+        "name": "Transfer",
+        "type": "event"
     }
 ]
 `
@@ -52,8 +123,89 @@ var (
 	// USDC and ETH precision
 	UsdcPrecision = decimal.NewFromFloat(1e6)
 	EthPrecision  = decimal.NewFromFloat(1e18)
+	// Uniswap V2 LP tokens have 18 decimals
+	LPTokenPrecision = decimal.NewFromFloat(1e18)
 )
 
 var PointsPerWeek = decimal.NewFromInt(10000)
 
 const OnboardingPoint = 100
+
+// default points a referrer earns when the referee completes onboarding
+const ReferralOnboardingPoint = 50
+
+// reason codes of point ledger entries
+const (
+	PointReasonOnboarding = "onboarding"
+	PointReasonSharePool  = "share_pool_epoch"
+	PointReasonStreak     = "streak_milestone"
+	PointReasonReferral   = "referral"
+	PointReasonLPProvider = "lp_provider_epoch"
+	PointReasonAdjustment = "adjustment"
+)
+
+// LP tokens are minted from and burned to the zero address
+const ZeroAddress = "0x0000000000000000000000000000000000000000"
+
+// kinds of liquidity events
+const (
+	LiquidityEventMint = "mint"
+	LiquidityEventBurn = "burn"
+)
+
+// reasons of trade flags
+const (
+	FlagReasonRoundTrip      = "same_block_round_trip"
+	FlagReasonLoop           = "swap_loop"
+	FlagReasonSelfSwap       = "self_swap"
+	FlagReasonPingPong       = "ping_pong"
+	FlagReasonFundingCluster = "funding_cluster"
+)
+
+// address lists
+const (
+	AddressListDeny  = "deny"
+	AddressListAllow = "allow"
+)
+
+// reasons an address can not take part in a task
+const (
+	IneligibleContract = "contract"
+	IneligibleDenylist = "denylist"
+	// below the minimum activity of a share pool task in every settled epoch
+	IneligibleMinVolume     = "min_volume"
+	IneligibleMinTradeCount = "min_trade_count"
+)
+
+// roles of swaps in a sandwich
+const (
+	MevRoleFrontRun = "front_run"
+	MevRoleVictim   = "victim"
+	MevRoleBackRun  = "back_run"
+)
+
+// tokens priced in USD
+const (
+	TokenUSDC = "USDC"
+	TokenETH  = "ETH"
+)
+
+// SupportedPairs maps the address of every supported pair to its token0 and token1
+var SupportedPairs = map[string][2]string{
+	"0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc": {TokenUSDC, TokenETH},
+}
+
+// TokenPrecision and TokenPrice of every supported token
+var (
+	TokenPrecision = map[string]decimal.Decimal{TokenUSDC: UsdcPrecision, TokenETH: EthPrecision}
+	TokenPrice     = map[string]decimal.Decimal{TokenUSDC: UsdcPrice, TokenETH: EthPrice}
+)
+
+// page size of a leaderboard when the limit is not set, and the largest page
+const (
+	LeaderboardDefaultLimit = 100
+	LeaderboardMaxLimit     = 1000
+)
+
+// default USD volume to complete the onboarding task
+var OnboardingThresholdUSD = decimal.NewFromInt(1000)
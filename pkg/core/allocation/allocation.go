@@ -0,0 +1,82 @@
+package allocation
+
+import (
+	"errors"
+	"fmt"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrInvalid is wrapped by every error of a plan that can not be created
+	ErrInvalid = errors.New("invalid allocation plan")
+	// ErrExists is returned when a campaign is allocated again
+	ErrExists = errors.New("allocation plan exists")
+)
+
+// Validate checks the budget in tokens of the decimals and the vesting of a plan.
+func Validate(budget decimal.Decimal, decimals int32, v model.VestingConfig) error {
+	if !budget.IsPositive() {
+		return fmt.Errorf("%w: budget must be positive", ErrInvalid)
+	}
+	if !budget.Shift(decimals).IsInteger() {
+		return fmt.Errorf("%w: budget has more than %d decimals", ErrInvalid, decimals)
+	}
+	if v.Start.IsZero() {
+		return fmt.Errorf("%w: vesting start is required", ErrInvalid)
+	}
+	if v.Tge.IsNegative() || v.Tge.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%w: tge must be between 0 and 1", ErrInvalid)
+	}
+	if v.CliffMonths < 0 || v.VestingMonths < 0 {
+		return fmt.Errorf("%w: months must not be negative", ErrInvalid)
+	}
+	if v.CliffMonths > v.VestingMonths {
+		return fmt.Errorf("%w: the cliff must not be longer than the vesting", ErrInvalid)
+	}
+
+	return nil
+}
+
+// Allocate splits the budget, in the smallest unit of the token, across the users by their points.
+// The amounts are whole units and always sum up to the budget.
+func Allocate(budget decimal.Decimal, points map[string]decimal.Decimal) map[string]decimal.Decimal {
+	return distribution.Allocate(budget, points, 0)
+}
+
+// Schedule returns when the amount, in the smallest unit of the token, unlocks under the vesting.
+// The vested amount of every month is rounded down, so the last unlock completes the amount exactly.
+func Schedule(amount decimal.Decimal, v model.VestingConfig) []model.Unlock {
+	unlocks := make([]model.Unlock, 0)
+	cumulative := decimal.Zero
+	unlock := func(month int, vested decimal.Decimal) {
+		if vested.GreaterThan(cumulative) {
+			unlocks = append(unlocks, model.Unlock{
+				At:         v.Start.AddDate(0, month, 0),
+				Amount:     vested.Sub(cumulative),
+				Cumulative: vested,
+			})
+			cumulative = vested
+		}
+	}
+
+	tge := amount.Mul(v.Tge).Truncate(0)
+	rest := amount.Sub(tge)
+	if v.VestingMonths == 0 {
+		unlock(0, amount)
+		return unlocks
+	}
+
+	unlock(0, tge)
+	for month := v.CliffMonths; month <= v.VestingMonths; month++ {
+		if month == 0 {
+			continue
+		}
+		vested, _ := rest.Mul(decimal.NewFromInt(int64(month))).QuoRem(decimal.NewFromInt(int64(v.VestingMonths)), 0)
+		unlock(month, tge.Add(vested))
+	}
+
+	return unlocks
+}
@@ -0,0 +1,100 @@
+package allocation
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	start := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	budget := decimal.NewFromInt(1000000)
+
+	tests := []struct {
+		name    string
+		budget  decimal.Decimal
+		vesting model.VestingConfig
+		wantErr bool
+	}{
+		{name: "valid", budget: budget, vesting: model.VestingConfig{Start: start, Tge: decimal.RequireFromString("0.1"), CliffMonths: 3, VestingMonths: 12}},
+		{name: "unlocked at start", budget: budget, vesting: model.VestingConfig{Start: start}},
+		{name: "zero budget", budget: decimal.Zero, vesting: model.VestingConfig{Start: start}, wantErr: true},
+		{name: "budget finer than the token", budget: decimal.RequireFromString("0.001"), vesting: model.VestingConfig{Start: start}, wantErr: true},
+		{name: "no start", budget: budget, vesting: model.VestingConfig{VestingMonths: 12}, wantErr: true},
+		{name: "tge above 1", budget: budget, vesting: model.VestingConfig{Start: start, Tge: decimal.RequireFromString("1.5")}, wantErr: true},
+		{name: "cliff after vesting", budget: budget, vesting: model.VestingConfig{Start: start, CliffMonths: 13, VestingMonths: 12}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.budget, 2, tt.vesting)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Allocate(t *testing.T) {
+	result := Allocate(decimal.NewFromInt(100), map[string]decimal.Decimal{
+		"0x1": decimal.NewFromInt(1),
+		"0x2": decimal.NewFromInt(1),
+		"0x3": decimal.NewFromInt(1),
+	})
+
+	total := decimal.Zero
+	for _, amount := range result {
+		assert.True(t, amount.IsInteger())
+		total = total.Add(amount)
+	}
+	assert.True(t, decimal.NewFromInt(100).Equal(total), "total: %v", total)
+	assert.True(t, decimal.NewFromInt(34).Equal(result["0x1"]), "0x1: %v", result["0x1"])
+}
+
+func Test_Schedule(t *testing.T) {
+	start := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	// 10% at start, the rest vests over 12 months after a cliff of 3 months
+	unlocks := Schedule(decimal.NewFromInt(1000), model.VestingConfig{
+		Start: start, Tge: decimal.RequireFromString("0.1"), CliffMonths: 3, VestingMonths: 12,
+	})
+	if assert.Equal(t, 11, len(unlocks)) {
+		assert.Equal(t, start, unlocks[0].At)
+		assert.True(t, decimal.NewFromInt(100).Equal(unlocks[0].Amount), "tge: %v", unlocks[0].Amount)
+		assert.Equal(t, start.AddDate(0, 3, 0), unlocks[1].At)
+		assert.True(t, decimal.NewFromInt(225).Equal(unlocks[1].Amount), "cliff: %v", unlocks[1].Amount)
+		assert.True(t, decimal.NewFromInt(75).Equal(unlocks[2].Amount), "month 4: %v", unlocks[2].Amount)
+		assert.Equal(t, start.AddDate(0, 12, 0), unlocks[10].At)
+		assert.True(t, decimal.NewFromInt(1000).Equal(unlocks[10].Cumulative), "cumulative: %v", unlocks[10].Cumulative)
+	}
+
+	// amounts not divisible by the months still unlock in full
+	unlocks = Schedule(decimal.NewFromInt(100), model.VestingConfig{Start: start, VestingMonths: 3})
+	if assert.Equal(t, 3, len(unlocks)) {
+		assert.True(t, decimal.NewFromInt(33).Equal(unlocks[0].Amount), "month 1: %v", unlocks[0].Amount)
+		assert.True(t, decimal.NewFromInt(33).Equal(unlocks[1].Amount), "month 2: %v", unlocks[1].Amount)
+		assert.True(t, decimal.NewFromInt(34).Equal(unlocks[2].Amount), "month 3: %v", unlocks[2].Amount)
+		assert.True(t, decimal.NewFromInt(100).Equal(unlocks[2].Cumulative))
+	}
+
+	// without vesting everything unlocks at start
+	unlocks = Schedule(decimal.NewFromInt(100), model.VestingConfig{Start: start})
+	if assert.Equal(t, 1, len(unlocks)) {
+		assert.Equal(t, start, unlocks[0].At)
+		assert.True(t, decimal.NewFromInt(100).Equal(unlocks[0].Amount))
+	}
+
+	// large amounts of 18 decimals are exact
+	amount := decimal.RequireFromString("123456789012345678901234567")
+	unlocks = Schedule(amount, model.VestingConfig{Start: start, Tge: decimal.RequireFromString("0.15"), VestingMonths: 7})
+	total := decimal.Zero
+	for _, u := range unlocks {
+		assert.True(t, u.Amount.IsInteger())
+		total = total.Add(u.Amount)
+	}
+	assert.True(t, amount.Equal(total), "total: %v", total)
+}
@@ -0,0 +1,90 @@
+package campaign
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalid is wrapped by every error of a task that can not be attached to a campaign
+var ErrInvalid = errors.New("invalid campaign task")
+
+func Validate(c model.Campaign) error {
+	if len(strings.TrimSpace(c.Name)) == 0 {
+		return fmt.Errorf("name is required")
+	}
+	if c.StartAt.IsZero() || c.EndAt.IsZero() {
+		return fmt.Errorf("startAt and endAt are required")
+	}
+	if !c.EndAt.After(c.StartAt) {
+		return fmt.Errorf("endAt must be after startAt")
+	}
+	if c.Budget != nil && c.Budget.IsNegative() {
+		return fmt.Errorf("budget must not be negative")
+	}
+
+	return nil
+}
+
+// Contains reports whether the task starts within the campaign.
+func Contains(c model.Campaign, startAt time.Time) bool {
+	return !startAt.Before(c.StartAt) && startAt.Before(c.EndAt)
+}
+
+// Budgeted reports whether the settlements of the task are capped by the budget of its campaign.
+// Onboarding and referral tasks award points as they happen and can not be in a campaign.
+func Budgeted(taskName string) bool {
+	switch taskName {
+	case "share_pool", "streak", "lp_provider":
+		return true
+	}
+
+	return false
+}
+
+// Cap limits the points of a task, by user and epoch index, to the remaining budget of its campaign.
+// Epochs are paid in order, the epoch exceeding the budget is scaled down to what is left and later epochs get nothing.
+// It returns the points left in the budget.
+func Cap(remaining decimal.Decimal, points map[string]map[int]decimal.Decimal, places int32) decimal.Decimal {
+	epochPoints := make(map[int]map[string]decimal.Decimal)
+	for address, epochs := range points {
+		for index, point := range epochs {
+			if _, ok := epochPoints[index]; !ok {
+				epochPoints[index] = make(map[string]decimal.Decimal)
+			}
+			epochPoints[index][address] = point
+		}
+	}
+
+	indexes := make([]int, 0, len(epochPoints))
+	for index := range epochPoints {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		total := decimal.Zero
+		for _, point := range epochPoints[index] {
+			total = total.Add(point)
+		}
+
+		if total.LessThanOrEqual(remaining) {
+			remaining = remaining.Sub(total)
+			continue
+		}
+
+		pool := decimal.Max(remaining, decimal.Zero)
+		for address, point := range distribution.Allocate(pool, epochPoints[index], places) {
+			points[address][index] = point
+		}
+		remaining = decimal.Zero
+	}
+
+	return remaining
+}
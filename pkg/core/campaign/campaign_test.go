@@ -0,0 +1,78 @@
+package campaign
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	endAt := startAt.AddDate(0, 3, 0)
+	budget := decimal.NewFromInt(100000)
+	negative := decimal.NewFromInt(-1)
+
+	tests := []struct {
+		name     string
+		campaign model.Campaign
+		wantErr  bool
+	}{
+		{"valid", model.Campaign{Name: "season 1", StartAt: startAt, EndAt: endAt, Budget: &budget}, false},
+		{"unlimited", model.Campaign{Name: "season 1", StartAt: startAt, EndAt: endAt}, false},
+		{"no name", model.Campaign{StartAt: startAt, EndAt: endAt}, true},
+		{"no end", model.Campaign{Name: "season 1", StartAt: startAt}, true},
+		{"reversed", model.Campaign{Name: "season 1", StartAt: endAt, EndAt: startAt}, true},
+		{"negative budget", model.Campaign{Name: "season 1", StartAt: startAt, EndAt: endAt, Budget: &negative}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, Validate(tt.campaign) != nil)
+		})
+	}
+}
+
+func Test_Budgeted(t *testing.T) {
+	for _, name := range []string{"share_pool", "streak", "lp_provider"} {
+		assert.True(t, Budgeted(name), name)
+	}
+	for _, name := range []string{"onboarding", "referral", ""} {
+		assert.False(t, Budgeted(name), name)
+	}
+}
+
+func Test_Cap(t *testing.T) {
+	points := map[string]map[int]decimal.Decimal{
+		"0xa": {0: decimal.NewFromInt(600), 1: decimal.NewFromInt(300), 2: decimal.NewFromInt(100)},
+		"0xb": {0: decimal.NewFromInt(400), 1: decimal.NewFromInt(700)},
+	}
+
+	// epoch 0 is paid in full, epoch 1 is scaled to 500 and epoch 2 gets nothing
+	left := Cap(decimal.NewFromInt(1500), points, 0)
+
+	assert.True(t, left.IsZero())
+	want := map[string]map[int]int64{
+		"0xa": {0: 600, 1: 150, 2: 0},
+		"0xb": {0: 400, 1: 350},
+	}
+	for address, epochs := range want {
+		for index, w := range epochs {
+			got := points[address][index]
+			assert.True(t, decimal.NewFromInt(w).Equal(got), "%s epoch %d want: %d, got: %v", address, index, w, got)
+		}
+	}
+}
+
+func Test_CapWithinBudget(t *testing.T) {
+	points := map[string]map[int]decimal.Decimal{
+		"0xa": {0: decimal.NewFromInt(600)},
+	}
+
+	left := Cap(decimal.NewFromInt(1000), points, 0)
+
+	assert.True(t, decimal.NewFromInt(400).Equal(left))
+	assert.True(t, decimal.NewFromInt(600).Equal(points["0xa"][0]))
+}
@@ -0,0 +1,26 @@
+package claim
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrNotEnded is returned when the points of a campaign are snapshot before its season ends
+	ErrNotEnded = errors.New("campaign has not ended")
+	// ErrExists is returned when the points of a campaign are snapshot again, the root may be deployed already
+	ErrExists = errors.New("campaign snapshot exists")
+	// ErrNotSettled is returned when an epoch of a task of the campaign is not committed yet
+	ErrNotSettled = errors.New("campaign epochs are not settled")
+)
+
+// DefaultDecimals is the decimals of most ERC-20 tokens
+const DefaultDecimals = 18
+
+// Amount converts points into the claimable amount of a token with the decimals, one token per point.
+// Fractions below the smallest unit are dropped.
+func Amount(point decimal.Decimal, decimals int32) *big.Int {
+	return point.Shift(decimals).Truncate(0).BigInt()
+}
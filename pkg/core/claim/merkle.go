@@ -0,0 +1,119 @@
+package claim
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Leaf is the (address, amount) pair a claim contract verifies.
+type Leaf struct {
+	Address common.Address
+	Amount  *big.Int
+}
+
+var leafArguments = func() abi.Arguments {
+	addressType, _ := abi.NewType("address", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	return abi.Arguments{{Type: addressType}, {Type: uint256Type}}
+}()
+
+// LeafHash hashes the leaf as OpenZeppelin's StandardMerkleTree does,
+// keccak256(bytes.concat(keccak256(abi.encode(address, uint256)))).
+func LeafHash(leaf Leaf) (common.Hash, error) {
+	encoded, err := leafArguments.Pack(leaf.Address, leaf.Amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded)), nil
+}
+
+// hashPair hashes the sorted pair, as MerkleProof.verify of OpenZeppelin does.
+func hashPair(a common.Hash, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// Tree is a Merkle tree laid out as OpenZeppelin's StandardMerkleTree, so it has the same root for the same leaves.
+// The nodes are stored in an array, the children of node i are 2i+1 and 2i+2 and the leaves sorted by hash are the last nodes in reverse order.
+type Tree struct {
+	nodes []common.Hash
+	// node of every leaf, in the order of the leaves given to NewTree
+	leafNodes []int
+}
+
+// NewTree builds the tree of the leaves, there must be at least one.
+func NewTree(leaves []Leaf) (Tree, error) {
+	if len(leaves) == 0 {
+		return Tree{}, errors.New("no leaf")
+	}
+
+	hashes := make([]common.Hash, len(leaves))
+	for i, leaf := range leaves {
+		hash, err := LeafHash(leaf)
+		if err != nil {
+			return Tree{}, err
+		}
+		hashes[i] = hash
+	}
+
+	order := make([]int, len(leaves))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return bytes.Compare(hashes[order[i]][:], hashes[order[j]][:]) < 0
+	})
+
+	t := Tree{nodes: make([]common.Hash, 2*len(leaves)-1), leafNodes: make([]int, len(leaves))}
+	for i, leafIndex := range order {
+		node := len(t.nodes) - 1 - i
+		t.nodes[node] = hashes[leafIndex]
+		t.leafNodes[leafIndex] = node
+	}
+	for i := len(t.nodes) - 1 - len(leaves); i >= 0; i-- {
+		t.nodes[i] = hashPair(t.nodes[2*i+1], t.nodes[2*i+2])
+	}
+
+	return t, nil
+}
+
+func (t Tree) Root() common.Hash {
+	return t.nodes[0]
+}
+
+// Proof returns the sibling hashes from the leaf to the root, index is the position of the leaf given to NewTree.
+func (t Tree) Proof(index int) []common.Hash {
+	proof := make([]common.Hash, 0)
+	for node := t.leafNodes[index]; node > 0; node = (node - 1) / 2 {
+		sibling := node + 1
+		if node%2 == 0 {
+			sibling = node - 1
+		}
+		proof = append(proof, t.nodes[sibling])
+	}
+
+	return proof
+}
+
+// Verify reports whether the proof leads from the leaf to the root.
+func Verify(root common.Hash, leaf Leaf, proof []common.Hash) bool {
+	hash, err := LeafHash(leaf)
+	if err != nil {
+		return false
+	}
+	for _, sibling := range proof {
+		hash = hashPair(hash, sibling)
+	}
+
+	return hash == root
+}
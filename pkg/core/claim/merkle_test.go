@@ -0,0 +1,101 @@
+package claim
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLeaves(n int) []Leaf {
+	leaves := make([]Leaf, n)
+	for i := range leaves {
+		leaves[i] = Leaf{
+			Address: common.BigToAddress(big.NewInt(int64(i + 1))),
+			Amount:  new(big.Int).Mul(big.NewInt(int64(i+1)), math.BigPow(10, 18)),
+		}
+	}
+
+	return leaves
+}
+
+func Test_LeafHash(t *testing.T) {
+	leaf := Leaf{Address: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"), Amount: big.NewInt(1000)}
+
+	// abi.encode pads both values to 32 bytes
+	encoded := append(common.LeftPadBytes(leaf.Address.Bytes(), 32), common.LeftPadBytes(leaf.Amount.Bytes(), 32)...)
+	hash, err := LeafHash(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, crypto.Keccak256Hash(crypto.Keccak256(encoded)), hash)
+}
+
+func Test_NewTree(t *testing.T) {
+	_, err := NewTree(nil)
+	assert.Error(t, err)
+
+	// a single leaf is the root
+	leaves := testLeaves(1)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := LeafHash(leaves[0])
+	assert.Equal(t, hash, tree.Root())
+	assert.Equal(t, 0, len(tree.Proof(0)))
+
+	// the leaves sorted by hash fill the array from its end
+	leaves = testLeaves(3)
+	tree, err = NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make([]common.Hash, 0, len(leaves))
+	for _, leaf := range leaves {
+		hash, _ := LeafHash(leaf)
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	assert.Equal(t, hashPair(hashPair(hashes[1], hashes[0]), hashes[2]), tree.Root())
+
+	// the example of the README of @openzeppelin/merkle-tree
+	tree, err = NewTree([]Leaf{
+		{Address: common.HexToAddress("0x1111111111111111111111111111111111111111"), Amount: math.MustParseBig256("5000000000000000000")},
+		{Address: common.HexToAddress("0x2222222222222222222222222222222222222222"), Amount: math.MustParseBig256("2500000000000000000")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77", tree.Root().Hex())
+}
+
+func Test_Proof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := testLeaves(n)
+		tree, err := NewTree(leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, leaf := range leaves {
+			assert.True(t, Verify(tree.Root(), leaf, tree.Proof(i)), "%d leaves, leaf %d", n, i)
+
+			other := Leaf{Address: leaf.Address, Amount: new(big.Int).Add(leaf.Amount, big.NewInt(1))}
+			assert.False(t, Verify(tree.Root(), other, tree.Proof(i)), "%d leaves, leaf %d", n, i)
+		}
+	}
+}
+
+func Test_Amount(t *testing.T) {
+	assert.Equal(t, "1500000000000000000", Amount(decimal.RequireFromString("1.5"), 18).String())
+	assert.Equal(t, "1", Amount(decimal.RequireFromString("1.99"), 0).String())
+	assert.Equal(t, "12345678", Amount(decimal.RequireFromString("0.123456789"), 8).String())
+}
@@ -0,0 +1,79 @@
+package distribution
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxPlaces is the finest point precision the ledger can store
+const MaxPlaces = 8
+
+// Allocate splits pool across the addresses proportionally to their weights, rounded down to `places` decimals.
+// The units lost by rounding are handed out one by one by largest remainder, ties broken by address,
+// so the shares always sum up to the pool and the result does not depend on map iteration order.
+// Addresses with a non-positive weight get zero. When no weight is positive nothing is allocated.
+func Allocate(pool decimal.Decimal, weights map[string]decimal.Decimal, places int32) map[string]decimal.Decimal {
+	result := make(map[string]decimal.Decimal, len(weights))
+
+	totalWeight := new(big.Rat)
+	for address, w := range weights {
+		result[address] = decimal.Zero
+		if w.IsPositive() {
+			totalWeight.Add(totalWeight, w.Rat())
+		}
+	}
+	if totalWeight.Sign() == 0 {
+		return result
+	}
+
+	// work in integer units of 10^-places
+	poolUnits := pool.Shift(places).Truncate(0).BigInt()
+	if poolUnits.Sign() <= 0 {
+		return result
+	}
+
+	type share struct {
+		address   string
+		units     *big.Int
+		remainder *big.Rat
+	}
+
+	shares := make([]share, 0, len(weights))
+	allocated := new(big.Int)
+	for address, w := range weights {
+		if !w.IsPositive() {
+			continue
+		}
+
+		quota := new(big.Rat).SetInt(poolUnits)
+		quota.Mul(quota, w.Rat())
+		quota.Quo(quota, totalWeight)
+
+		units := new(big.Int).Quo(quota.Num(), quota.Denom())
+		remainder := new(big.Rat).Sub(quota, new(big.Rat).SetInt(units))
+
+		allocated.Add(allocated, units)
+		shares = append(shares, share{address: address, units: units, remainder: remainder})
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if c := shares[i].remainder.Cmp(shares[j].remainder); c != 0 {
+			return c > 0
+		}
+		return shares[i].address < shares[j].address
+	})
+
+	// the leftover is always smaller than the number of shares
+	leftover := new(big.Int).Sub(poolUnits, allocated).Int64()
+	for i := int64(0); i < leftover; i++ {
+		shares[i].units.Add(shares[i].units, big.NewInt(1))
+	}
+
+	for _, s := range shares {
+		result[s.address] = decimal.NewFromBigInt(s.units, -places)
+	}
+
+	return result
+}
@@ -0,0 +1,153 @@
+package distribution
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func sum(shares map[string]decimal.Decimal) decimal.Decimal {
+	total := decimal.Zero
+	for _, s := range shares {
+		total = total.Add(s)
+	}
+
+	return total
+}
+
+func Test_AllocateExact(t *testing.T) {
+	weights := map[string]decimal.Decimal{
+		"0xa": decimal.NewFromInt(101000),
+		"0xb": decimal.NewFromInt(24000),
+	}
+
+	result := Allocate(decimal.NewFromInt(10000), weights, 0)
+
+	assert.True(t, decimal.NewFromInt(8080).Equal(result["0xa"]))
+	assert.True(t, decimal.NewFromInt(1920).Equal(result["0xb"]))
+}
+
+func Test_AllocateRemainder(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    decimal.Decimal
+		weights map[string]decimal.Decimal
+		places  int32
+		want    map[string]decimal.Decimal
+	}{
+		{
+			name: "thirds go to the smallest address on ties",
+			pool: decimal.NewFromInt(10000),
+			weights: map[string]decimal.Decimal{
+				"0xc": decimal.NewFromInt(1),
+				"0xa": decimal.NewFromInt(1),
+				"0xb": decimal.NewFromInt(1),
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(3334),
+				"0xb": decimal.NewFromInt(3333),
+				"0xc": decimal.NewFromInt(3333),
+			},
+		},
+		{
+			name: "largest remainder wins",
+			pool: decimal.NewFromInt(10),
+			weights: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(16), // 5.333..
+				"0xb": decimal.NewFromInt(11), // 3.666..
+				"0xc": decimal.NewFromInt(3),  // 1
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(5),
+				"0xb": decimal.NewFromInt(4),
+				"0xc": decimal.NewFromInt(1),
+			},
+		},
+		{
+			name: "fractional points",
+			pool: decimal.NewFromInt(100),
+			weights: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(1),
+				"0xb": decimal.NewFromInt(2),
+			},
+			places: 2,
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.RequireFromString("33.33"),
+				"0xb": decimal.RequireFromString("66.67"),
+			},
+		},
+		{
+			name: "non-positive weight gets nothing",
+			pool: decimal.NewFromInt(100),
+			weights: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(0),
+				"0xb": decimal.NewFromInt(-5),
+				"0xc": decimal.NewFromInt(7),
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.Zero,
+				"0xb": decimal.Zero,
+				"0xc": decimal.NewFromInt(100),
+			},
+		},
+		{
+			name: "nothing to allocate without volume",
+			pool: decimal.NewFromInt(100),
+			weights: map[string]decimal.Decimal{
+				"0xa": decimal.Zero,
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.Zero,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Allocate(tt.pool, tt.weights, tt.places)
+
+			assert.Equal(t, len(tt.want), len(result))
+			for address, want := range tt.want {
+				assert.True(t, want.Equal(result[address]), "%s want: %v, got: %v", address, want, result[address])
+			}
+		})
+	}
+}
+
+func Test_AllocateInvariants(t *testing.T) {
+	pool := decimal.NewFromInt(10000)
+
+	for _, places := range []int32{0, 2, MaxPlaces} {
+		weights := make(map[string]decimal.Decimal)
+		totalWeight := decimal.Zero
+		for i := 1; i <= 97; i++ {
+			// uneven weights with long fractions
+			w := decimal.NewFromInt(int64(i * i * 7919 % 100003)).Div(decimal.NewFromInt(int64(i + 3)))
+			weights[fmt.Sprintf("0x%040d", i)] = w
+			totalWeight = totalWeight.Add(w)
+		}
+
+		result := Allocate(pool, weights, places)
+		unit := decimal.New(1, -places)
+
+		// the pool is fully allocated
+		assert.True(t, pool.Equal(sum(result)), "places %d sum: %v", places, sum(result))
+
+		for address, share := range result {
+			// shares keep the precision and stay within one unit of the exact quota
+			assert.True(t, share.Equal(share.Truncate(places)))
+			quota := pool.Mul(weights[address]).DivRound(totalWeight, 20)
+			assert.True(t, share.Sub(quota).Abs().LessThan(unit), "address %s share %v quota %v", address, share, quota)
+		}
+
+		// deterministic across runs
+		for i := 0; i < 10; i++ {
+			again := Allocate(pool, weights, places)
+			for address, share := range result {
+				assert.True(t, share.Equal(again[address]))
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package distribution
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StrategyLinear = "linear"
+	StrategySqrt   = "sqrt"
+	StrategyTiered = "tiered"
+
+	sqrtPrecision = 256
+	sqrtPlaces    = 18
+)
+
+// DistributionStrategy turns the USD volume of every user into their points of an epoch pool.
+type DistributionStrategy interface {
+	// Weights returns the weight of every user, points are proportional to it
+	Weights(volumes map[string]decimal.Decimal) map[string]decimal.Decimal
+	Distribute(pool decimal.Decimal, volumes map[string]decimal.Decimal, places int32) map[string]decimal.Decimal
+}
+
+// New returns the strategy described by the task config, the default is linear pro-rata.
+func New(cfg model.DistributionConfig) (DistributionStrategy, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	var strategy DistributionStrategy
+	switch cfg.Strategy {
+	case StrategySqrt:
+		strategy = Sqrt{}
+	case StrategyTiered:
+		tiers := make([]model.TierConfig, len(cfg.Tiers))
+		copy(tiers, cfg.Tiers)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].From.LessThan(tiers[j].From) })
+		strategy = Tiered{Tiers: tiers}
+	default:
+		strategy = Linear{}
+	}
+
+	if cfg.MaxShare != nil {
+		strategy = Capped{Base: strategy, MaxShare: *cfg.MaxShare}
+	}
+
+	return strategy, nil
+}
+
+func Validate(cfg model.DistributionConfig) error {
+	switch cfg.Strategy {
+	case "", StrategyLinear, StrategySqrt:
+	case StrategyTiered:
+		if len(cfg.Tiers) == 0 {
+			return fmt.Errorf("tiered distribution needs at least one tier")
+		}
+		for _, tier := range cfg.Tiers {
+			if tier.From.IsNegative() || tier.Rate.IsNegative() {
+				return fmt.Errorf("tier from and rate can not be negative")
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported distribution strategy: %s", cfg.Strategy)
+	}
+
+	if cfg.MaxShare != nil && (!cfg.MaxShare.IsPositive() || cfg.MaxShare.GreaterThan(decimal.NewFromInt(1))) {
+		return fmt.Errorf("max share must be within (0, 1]: %s", cfg.MaxShare)
+	}
+
+	return nil
+}
+
+// Linear splits the pool pro-rata to volume.
+type Linear struct{}
+
+func (Linear) Weights(volumes map[string]decimal.Decimal) map[string]decimal.Decimal {
+	return volumes
+}
+
+func (l Linear) Distribute(pool decimal.Decimal, volumes map[string]decimal.Decimal, places int32) map[string]decimal.Decimal {
+	return Allocate(pool, l.Weights(volumes), places)
+}
+
+// Sqrt weights users by the square root of their volume, which dampens large traders.
+type Sqrt struct{}
+
+func (Sqrt) Weights(volumes map[string]decimal.Decimal) map[string]decimal.Decimal {
+	weights := make(map[string]decimal.Decimal, len(volumes))
+	for address, v := range volumes {
+		weights[address] = sqrt(v)
+	}
+
+	return weights
+}
+
+func (s Sqrt) Distribute(pool decimal.Decimal, volumes map[string]decimal.Decimal, places int32) map[string]decimal.Decimal {
+	return Allocate(pool, s.Weights(volumes), places)
+}
+
+// Tiered weights volume by brackets, like a progressive tax: the part of the volume between
+// two tiers is multiplied by the rate of the lower tier. Tiers must be sorted by From.
+type Tiered struct {
+	Tiers []model.TierConfig
+}
+
+func (t Tiered) Weights(volumes map[string]decimal.Decimal) map[string]decimal.Decimal {
+	weights := make(map[string]decimal.Decimal, len(volumes))
+	for address, v := range volumes {
+		w := decimal.Zero
+		for i, tier := range t.Tiers {
+			if v.LessThanOrEqual(tier.From) {
+				break
+			}
+
+			upper := v
+			if i+1 < len(t.Tiers) && t.Tiers[i+1].From.LessThan(v) {
+				upper = t.Tiers[i+1].From
+			}
+			w = w.Add(upper.Sub(tier.From).Mul(tier.Rate))
+		}
+		weights[address] = w
+	}
+
+	return weights
+}
+
+func (t Tiered) Distribute(pool decimal.Decimal, volumes map[string]decimal.Decimal, places int32) map[string]decimal.Decimal {
+	return Allocate(pool, t.Weights(volumes), places)
+}
+
+// Capped limits every user to MaxShare of the pool and redistributes the excess to the
+// uncapped users by their base weights. When every user is capped the excess stays unallocated.
+type Capped struct {
+	Base     DistributionStrategy
+	MaxShare decimal.Decimal
+}
+
+func (c Capped) Weights(volumes map[string]decimal.Decimal) map[string]decimal.Decimal {
+	return c.Base.Weights(volumes)
+}
+
+func (c Capped) Distribute(pool decimal.Decimal, volumes map[string]decimal.Decimal, places int32) map[string]decimal.Decimal {
+	weights := c.Weights(volumes)
+	maxPoints := pool.Mul(c.MaxShare)
+
+	// water-filling: cap users until nobody left would exceed the cap
+	capped := make(map[string]bool)
+	for {
+		remaining := pool.Sub(maxPoints.Mul(decimal.NewFromInt(int64(len(capped)))))
+		total := decimal.Zero
+		for address, w := range weights {
+			if !capped[address] && w.IsPositive() {
+				total = total.Add(w)
+			}
+		}
+		if total.IsZero() {
+			break
+		}
+
+		changed := false
+		for address, w := range weights {
+			// remaining * w / total > maxPoints
+			if !capped[address] && w.IsPositive() && remaining.Mul(w).GreaterThan(maxPoints.Mul(total)) {
+				capped[address] = true
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	maxUnits := maxPoints.RoundFloor(places)
+	rest := pool
+	uncapped := make(map[string]decimal.Decimal, len(weights))
+	for address, w := range weights {
+		if capped[address] {
+			rest = rest.Sub(maxUnits)
+			continue
+		}
+		uncapped[address] = w
+	}
+
+	result := Allocate(rest, uncapped, places)
+	for address := range capped {
+		result[address] = maxUnits
+	}
+
+	return result
+}
+
+func sqrt(d decimal.Decimal) decimal.Decimal {
+	if !d.IsPositive() {
+		return decimal.Zero
+	}
+
+	f, _, err := big.ParseFloat(d.String(), 10, sqrtPrecision, big.ToNearestEven)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	r, err := decimal.NewFromString(new(big.Float).SetPrec(sqrtPrecision).Sqrt(f).Text('f', sqrtPlaces))
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return r
+}
@@ -0,0 +1,151 @@
+package distribution
+
+import (
+	"testing"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	maxShare := decimal.RequireFromString("0.2")
+	tooLarge := decimal.NewFromInt(2)
+
+	tests := []struct {
+		name    string
+		cfg     model.DistributionConfig
+		want    DistributionStrategy
+		wantErr bool
+	}{
+		{name: "default", cfg: model.DistributionConfig{}, want: Linear{}},
+		{name: "sqrt", cfg: model.DistributionConfig{Strategy: StrategySqrt}, want: Sqrt{}},
+		{
+			name: "capped",
+			cfg:  model.DistributionConfig{Strategy: StrategyLinear, MaxShare: &maxShare},
+			want: Capped{Base: Linear{}, MaxShare: maxShare},
+		},
+		{name: "tiered without tiers", cfg: model.DistributionConfig{Strategy: StrategyTiered}, wantErr: true},
+		{name: "unknown", cfg: model.DistributionConfig{Strategy: "quadratic"}, wantErr: true},
+		{name: "max share too large", cfg: model.DistributionConfig{MaxShare: &tooLarge}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_Sqrt(t *testing.T) {
+	volumes := map[string]decimal.Decimal{
+		"0xa": decimal.NewFromInt(90000), // sqrt 300
+		"0xb": decimal.NewFromInt(10000), // sqrt 100
+	}
+
+	result := Sqrt{}.Distribute(decimal.NewFromInt(10000), volumes, 0)
+
+	assert.True(t, decimal.NewFromInt(7500).Equal(result["0xa"]))
+	assert.True(t, decimal.NewFromInt(2500).Equal(result["0xb"]))
+}
+
+func Test_Tiered(t *testing.T) {
+	tiered := Tiered{Tiers: []model.TierConfig{
+		{From: decimal.Zero, Rate: decimal.NewFromInt(1)},
+		{From: decimal.NewFromInt(1000), Rate: decimal.RequireFromString("0.5")},
+		{From: decimal.NewFromInt(10000), Rate: decimal.RequireFromString("0.1")},
+	}}
+
+	weights := tiered.Weights(map[string]decimal.Decimal{
+		"0xa": decimal.NewFromInt(500),
+		"0xb": decimal.NewFromInt(5000),
+		"0xc": decimal.NewFromInt(20000),
+	})
+
+	assert.True(t, decimal.NewFromInt(500).Equal(weights["0xa"]))
+	// 1000 + 4000 * 0.5
+	assert.True(t, decimal.NewFromInt(3000).Equal(weights["0xb"]))
+	// 1000 + 9000 * 0.5 + 10000 * 0.1
+	assert.True(t, decimal.NewFromInt(6500).Equal(weights["0xc"]))
+}
+
+func Test_Capped(t *testing.T) {
+	capped := Capped{Base: Linear{}, MaxShare: decimal.RequireFromString("0.5")}
+
+	tests := []struct {
+		name    string
+		volumes map[string]decimal.Decimal
+		want    map[string]decimal.Decimal
+	}{
+		{
+			name: "whale excess goes to the others",
+			volumes: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(900),
+				"0xb": decimal.NewFromInt(75),
+				"0xc": decimal.NewFromInt(25),
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(5000),
+				"0xb": decimal.NewFromInt(3750),
+				"0xc": decimal.NewFromInt(1250),
+			},
+		},
+		{
+			name: "nobody over the cap",
+			volumes: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(30),
+				"0xb": decimal.NewFromInt(30),
+				"0xc": decimal.NewFromInt(40),
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(3000),
+				"0xb": decimal.NewFromInt(3000),
+				"0xc": decimal.NewFromInt(4000),
+			},
+		},
+		{
+			name: "single user keeps only the cap",
+			volumes: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(100),
+			},
+			want: map[string]decimal.Decimal{
+				"0xa": decimal.NewFromInt(5000),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := capped.Distribute(decimal.NewFromInt(10000), tt.volumes, 0)
+			for address, want := range tt.want {
+				assert.True(t, want.Equal(result[address]), "%s want: %v, got: %v", address, want, result[address])
+			}
+		})
+	}
+}
+
+func Test_CappedCascade(t *testing.T) {
+	// capping 0xa pushes 0xb over the cap as well
+	capped := Capped{Base: Linear{}, MaxShare: decimal.RequireFromString("0.3")}
+	volumes := map[string]decimal.Decimal{
+		"0xa": decimal.NewFromInt(700),
+		"0xb": decimal.NewFromInt(200),
+		"0xc": decimal.NewFromInt(50),
+		"0xd": decimal.NewFromInt(50),
+	}
+
+	result := capped.Distribute(decimal.NewFromInt(10000), volumes, 0)
+
+	assert.True(t, decimal.NewFromInt(3000).Equal(result["0xa"]))
+	assert.True(t, decimal.NewFromInt(3000).Equal(result["0xb"]))
+	assert.True(t, decimal.NewFromInt(2000).Equal(result["0xc"]))
+	assert.True(t, decimal.NewFromInt(2000).Equal(result["0xd"]))
+	assert.True(t, decimal.NewFromInt(10000).Equal(sum(result)))
+}
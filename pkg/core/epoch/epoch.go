@@ -0,0 +1,181 @@
+package epoch
+
+import (
+	"fmt"
+	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	UnitHour = "hour"
+	UnitDay  = "day"
+	UnitWeek = "week"
+
+	defaultUnit     = UnitWeek
+	defaultLength   = 1
+	defaultCount    = 4
+	defaultTimezone = "UTC"
+
+	// upper bound to protect against misconfigured schedules
+	maxEpochs = 10000
+)
+
+// Normalize fills the default epoch settings: 4 weekly epochs in UTC.
+func Normalize(cfg model.EpochConfig) model.EpochConfig {
+	if cfg.Unit == "" {
+		cfg.Unit = defaultUnit
+	}
+	if cfg.Length == 0 {
+		cfg.Length = defaultLength
+	}
+	if cfg.Count == 0 && cfg.EndAt == nil {
+		cfg.Count = defaultCount
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = defaultTimezone
+	}
+
+	return cfg
+}
+
+func Validate(cfg model.EpochConfig) error {
+	cfg = Normalize(cfg)
+
+	switch cfg.Unit {
+	case UnitHour, UnitDay, UnitWeek:
+	default:
+		return fmt.Errorf("unsupported epoch unit: %s", cfg.Unit)
+	}
+	if cfg.Length < 0 {
+		return fmt.Errorf("epoch length must be positive: %d", cfg.Length)
+	}
+	if cfg.Count < 0 || cfg.Count > maxEpochs {
+		return fmt.Errorf("epoch count out of range: %d", cfg.Count)
+	}
+	if cfg.Count != 0 && cfg.EndAt != nil {
+		return fmt.Errorf("epoch count and endAt can not be set together")
+	}
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf("invalid epoch timezone %s: %v", cfg.Timezone, err)
+	}
+
+	return nil
+}
+
+func Location(cfg model.EpochConfig) (*time.Location, error) {
+	return time.LoadLocation(Normalize(cfg).Timezone)
+}
+
+// Schedule returns the epochs of the task in order, at most maxEpochs, an error when endAt is further away.
+// Day and week epochs follow the wall clock of the configured timezone, so they stay aligned across DST changes.
+func Schedule(task model.Task) ([]model.Epoch, error) {
+	cfg := Normalize(task.Config.Epoch)
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	start := task.StartAt.In(loc)
+	if cfg.EndAt != nil && !cfg.EndAt.After(start) {
+		return nil, fmt.Errorf("epoch endAt %s is not after task startAt %s", cfg.EndAt, start)
+	}
+
+	epochs := make([]model.Epoch, 0)
+	for i := 0; i < maxEpochs; i++ {
+		if cfg.EndAt == nil && i >= cfg.Count {
+			break
+		}
+
+		e := model.Epoch{
+			Index:   i,
+			StartAt: boundary(start, cfg, i),
+			EndAt:   boundary(start, cfg, i+1),
+		}
+
+		if cfg.EndAt != nil {
+			if !e.StartAt.Before(*cfg.EndAt) {
+				break
+			}
+			if e.EndAt.After(*cfg.EndAt) {
+				e.EndAt = cfg.EndAt.In(loc)
+			}
+		}
+
+		epochs = append(epochs, e)
+	}
+	// the schedule is not cut short before the configured end
+	if cfg.EndAt != nil && len(epochs) == maxEpochs && epochs[maxEpochs-1].EndAt.Before(*cfg.EndAt) {
+		return nil, fmt.Errorf("epoch endAt %s is more than %d epochs after task startAt %s", cfg.EndAt, maxEpochs, start)
+	}
+
+	return epochs, nil
+}
+
+// Points returns the points distributed in the epoch of the given index, epochPoints when configured, otherwise
+// constants.PointsPerWeek scaled by the nominal length of the epochs, so shorter epochs do not pay more per week.
+// The scaled pool is truncated to the point precision and what is cut off is carried into the next epoch,
+// the epochs up to the end of every week sum up to exactly constants.PointsPerWeek per week.
+func Points(cfg model.TaskConfig, index int) decimal.Decimal {
+	if cfg.EpochPoints != nil {
+		return *cfg.EpochPoints
+	}
+
+	e := Normalize(cfg.Epoch)
+	hours := int64(e.Length) * 24 * 7
+	switch e.Unit {
+	case UnitHour:
+		hours = int64(e.Length)
+	case UnitDay:
+		hours = int64(e.Length) * 24
+	}
+
+	// the pool of the epochs up to the end of epoch n, truncated
+	cumulative := func(n int) decimal.Decimal {
+		return constants.PointsPerWeek.Mul(decimal.NewFromInt(hours * int64(n))).
+			Div(decimal.NewFromInt(24 * 7)).Truncate(cfg.PointPrecision)
+	}
+
+	return cumulative(index + 1).Sub(cumulative(index))
+}
+
+// EndAt returns the end of the last epoch of the task.
+func EndAt(task model.Task) (time.Time, error) {
+	epochs, err := Schedule(task)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(epochs) == 0 {
+		return task.StartAt, nil
+	}
+
+	return epochs[len(epochs)-1].EndAt, nil
+}
+
+// Find returns the epoch containing t.
+func Find(epochs []model.Epoch, t time.Time) (model.Epoch, bool) {
+	for _, e := range epochs {
+		if !t.Before(e.StartAt) && t.Before(e.EndAt) {
+			return e, true
+		}
+	}
+
+	return model.Epoch{}, false
+}
+
+func boundary(start time.Time, cfg model.EpochConfig, n int) time.Time {
+	switch cfg.Unit {
+	case UnitHour:
+		return start.Add(time.Duration(n*cfg.Length) * time.Hour)
+	case UnitDay:
+		return start.AddDate(0, 0, n*cfg.Length)
+	default:
+		return start.AddDate(0, 0, n*cfg.Length*7)
+	}
+}
@@ -0,0 +1,225 @@
+package epoch
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ScheduleDefault(t *testing.T) {
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-03")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	epochs, err := Schedule(model.Task{StartAt: startAt})
+	if err != nil {
+		t.Errorf("Schedule err: %v", err)
+		return
+	}
+
+	// a task starting on Wednesday still gets four full weeks
+	assert.Equal(t, 4, len(epochs))
+	for i, e := range epochs {
+		assert.Equal(t, i, e.Index)
+		assert.True(t, e.StartAt.Equal(startAt.AddDate(0, 0, 7*i)))
+		assert.True(t, e.EndAt.Equal(startAt.AddDate(0, 0, 7*(i+1))))
+	}
+}
+
+func Test_ScheduleTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Errorf("load location err: %v", err)
+		return
+	}
+
+	// DST starts on 2024-03-10 in New York
+	startAt := time.Date(2024, 3, 8, 0, 0, 0, 0, loc)
+	task := model.Task{
+		StartAt: startAt,
+		Config: model.TaskConfig{Epoch: model.EpochConfig{
+			Unit:     UnitDay,
+			Count:    4,
+			Timezone: "America/New_York",
+		}},
+	}
+
+	epochs, err := Schedule(task)
+	if err != nil {
+		t.Errorf("Schedule err: %v", err)
+		return
+	}
+
+	assert.Equal(t, 4, len(epochs))
+	for _, e := range epochs {
+		assert.Equal(t, 0, e.StartAt.Hour())
+		assert.Equal(t, 0, e.EndAt.Hour())
+	}
+	assert.Equal(t, 23*time.Hour, epochs[2].EndAt.Sub(epochs[2].StartAt))
+}
+
+func Test_ScheduleEndAt(t *testing.T) {
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-01")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+	endAt := startAt.Add(30 * time.Hour)
+
+	epochs, err := Schedule(model.Task{
+		StartAt: startAt,
+		Config: model.TaskConfig{Epoch: model.EpochConfig{
+			Unit:   UnitHour,
+			Length: 12,
+			EndAt:  &endAt,
+		}},
+	})
+	if err != nil {
+		t.Errorf("Schedule err: %v", err)
+		return
+	}
+
+	assert.Equal(t, 3, len(epochs))
+	assert.True(t, epochs[2].StartAt.Equal(startAt.Add(24*time.Hour)))
+	assert.True(t, epochs[2].EndAt.Equal(endAt))
+
+	last, err := EndAt(model.Task{
+		StartAt: startAt,
+		Config:  model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitHour, Length: 12, EndAt: &endAt}},
+	})
+	if err != nil {
+		t.Errorf("EndAt err: %v", err)
+		return
+	}
+	assert.True(t, last.Equal(endAt))
+
+	// a schedule longer than the epoch limit is rejected instead of ending early
+	farEnd := startAt.Add((maxEpochs + 1) * time.Hour)
+	_, err = Schedule(model.Task{
+		StartAt: startAt,
+		Config:  model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitHour, Length: 1, EndAt: &farEnd}},
+	})
+	assert.Error(t, err)
+	limitEnd := startAt.Add(maxEpochs * time.Hour)
+	epochs, err = Schedule(model.Task{
+		StartAt: startAt,
+		Config:  model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitHour, Length: 1, EndAt: &limitEnd}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, maxEpochs, len(epochs))
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	endAt := time.Now()
+
+	tests := []struct {
+		name    string
+		cfg     model.EpochConfig
+		wantErr bool
+	}{
+		{name: "default", cfg: model.EpochConfig{}},
+		{name: "custom", cfg: model.EpochConfig{Unit: UnitDay, Length: 3, Count: 10, Timezone: "Asia/Taipei"}},
+		{name: "unknown unit", cfg: model.EpochConfig{Unit: "month"}, wantErr: true},
+		{name: "negative length", cfg: model.EpochConfig{Length: -1}, wantErr: true},
+		{name: "count and endAt", cfg: model.EpochConfig{Count: 2, EndAt: &endAt}, wantErr: true},
+		{name: "invalid timezone", cfg: model.EpochConfig{Timezone: "Mars/Base"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.cfg)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_Points(t *testing.T) {
+	pool := decimal.NewFromInt(500)
+
+	tests := []struct {
+		name   string
+		config model.TaskConfig
+		index  int
+		want   string
+	}{
+		{"default weekly", model.TaskConfig{}, 0, "10000"},
+		{"two weeks", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitWeek, Length: 2}}, 3, "20000"},
+		{"week of days", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay, Length: 7}}, 1, "10000"},
+		{"week of hours", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitHour, Length: 168}}, 0, "10000"},
+		{"first day", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay}}, 0, "1428"},
+		{"second day", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay}}, 1, "1429"},
+		{"first day in cents", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay}, PointPrecision: 2}, 0, "1428.57"},
+		{"configured", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay}, EpochPoints: &pool}, 0, "500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Points(tt.config, tt.index)
+			assert.True(t, decimal.RequireFromString(tt.want).Equal(got), "got: %v", got)
+		})
+	}
+}
+
+func Test_PointsPerWeek(t *testing.T) {
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		config model.TaskConfig
+	}{
+		{"daily", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay, Count: 14}}},
+		{"daily in cents", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitDay, Count: 14}, PointPrecision: 2}},
+		{"hourly", model.TaskConfig{Epoch: model.EpochConfig{Unit: UnitHour, Count: 336}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epochs, err := Schedule(model.Task{StartAt: startAt, Config: tt.config})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the epochs of every whole week sum up to exactly one weekly pool
+			weeks, weekEnd, total := 0, startAt.AddDate(0, 0, 7), decimal.Zero
+			for _, e := range epochs {
+				points := Points(tt.config, e.Index)
+				assert.True(t, points.Equal(points.Truncate(tt.config.PointPrecision)), "epoch %d: %v", e.Index, points)
+				total = total.Add(points)
+
+				if e.EndAt.Equal(weekEnd) {
+					assert.True(t, constants.PointsPerWeek.Equal(total), "week ending %s: %v", weekEnd, total)
+					weeks, weekEnd, total = weeks+1, weekEnd.AddDate(0, 0, 7), decimal.Zero
+				}
+			}
+			assert.Equal(t, 2, weeks)
+		})
+	}
+}
+
+func Test_Find(t *testing.T) {
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-01")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	epochs, err := Schedule(model.Task{StartAt: startAt})
+	if err != nil {
+		t.Errorf("Schedule err: %v", err)
+		return
+	}
+
+	e, found := Find(epochs, startAt.AddDate(0, 0, 8))
+	assert.True(t, found)
+	assert.Equal(t, 1, e.Index)
+
+	_, found = Find(epochs, startAt.AddDate(0, 0, -1))
+	assert.False(t, found)
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	// FormatNDJSON is the same as FormatJSONL, only served with its own content type
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	DatasetUserPoint   = "userPoint"
+	DatasetUserTask    = "userTask"
+	DatasetTransaction = "transaction"
+	DatasetLeaderboard = "leaderboard"
+)
+
+// ErrInvalid is wrapped by every error of an export that can not run
+var ErrInvalid = errors.New("invalid export")
+
+// ValidDataset reports whether the dataset can be exported.
+func ValidDataset(dataset string) bool {
+	switch dataset {
+	case DatasetUserPoint, DatasetUserTask, DatasetTransaction, DatasetLeaderboard:
+		return true
+	}
+
+	return false
+}
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+
+	return "application/jsonl"
+}
+
+// Writer encodes rows one at a time, as CSV with a header or as one JSON object per line.
+// Rows are buffered, Flush writes what is left once the last row is written.
+type Writer struct {
+	columns []string
+	csv     *csv.Writer
+	json    *bufio.Writer
+	line    bytes.Buffer
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &Writer{csv: csv.NewWriter(w)}, nil
+	case FormatJSONL, FormatNDJSON:
+		return &Writer{json: bufio.NewWriter(w)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: format %q, csv, jsonl or ndjson", ErrInvalid, format)
+}
+
+// Header sets the columns of the rows, it is written as the first line of CSV and keys the JSON objects.
+func (w *Writer) Header(columns ...string) error {
+	w.columns = columns
+	if w.csv != nil {
+		return w.csv.Write(columns)
+	}
+
+	return nil
+}
+
+// Write encodes a row with a value for every column.
+func (w *Writer) Write(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("export row has %d values for %d columns", len(values), len(w.columns))
+	}
+
+	if w.csv != nil {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvValue(v)
+		}
+		return w.csv.Write(record)
+	}
+
+	// the keys keep the order of the columns
+	w.line.Reset()
+	w.line.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.line.WriteByte(',')
+		}
+		key, _ := json.Marshal(w.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.line.Write(key)
+		w.line.WriteByte(':')
+		w.line.Write(value)
+	}
+	w.line.WriteString("}\n")
+	_, err := w.json.Write(w.line.Bytes())
+
+	return err
+}
+
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	return w.json.Flush()
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case decimal.Decimal:
+		return v.String()
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Writer(t *testing.T) {
+	at := time.Date(2024, 7, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*60*60))
+	rows := [][]interface{}{
+		{"0x01", decimal.RequireFromString("10.5"), at, &at},
+		{"a,\"b\"", decimal.Zero, at, (*time.Time)(nil)},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatCSV,
+			want: "userAddress,point,createdAt,completedAt\n" +
+				"0x01,10.5,2024-07-01T00:00:00Z,2024-07-01T00:00:00Z\n" +
+				"\"a,\"\"b\"\"\",0,2024-07-01T00:00:00Z,\n",
+		},
+		{
+			format: FormatJSONL,
+			want: `{"userAddress":"0x01","point":"10.5","createdAt":"2024-07-01T08:00:00+08:00","completedAt":"2024-07-01T08:00:00+08:00"}` + "\n" +
+				`{"userAddress":"a,\"b\"","point":"0","createdAt":"2024-07-01T08:00:00+08:00","completedAt":null}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.NoError(t, w.Header("userAddress", "point", "createdAt", "completedAt"))
+			for _, row := range rows {
+				assert.NoError(t, w.Write(row...))
+			}
+			assert.Error(t, w.Write("0x01"))
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	_, err := NewWriter(&bytes.Buffer{}, "xml")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
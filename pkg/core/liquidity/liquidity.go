@@ -0,0 +1,63 @@
+package liquidity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrIncompleteHistory is returned when a holder sends more LP tokens than it received,
+// the transfers since the creation of the pair are not all ingested.
+var ErrIncompleteHistory = errors.New("incomplete lp transfer history")
+
+// Seconds returns the liquidity-seconds of every holder in [start, end), the LP token balance multiplied by the seconds it was held.
+// Transfers must be ordered by block and log index and start at the creation of the pair, those before start only build the opening balances.
+// Excluded addresses hold no liquidity, e.g. the zero address LP tokens are minted from and the pair they are burned in.
+// It returns ErrIncompleteHistory when a balance goes negative instead of guessing the missing balance.
+func Seconds(transfers []model.LPTransfer, start time.Time, end time.Time, excluded []string) (map[string]decimal.Decimal, error) {
+	skip := make(map[string]bool, len(excluded))
+	for _, address := range excluded {
+		skip[strings.ToLower(address)] = true
+	}
+
+	balances := make(map[string]decimal.Decimal)
+	seconds := make(map[string]decimal.Decimal)
+
+	cursor := start
+	accrue := func(until time.Time) {
+		if !until.After(cursor) {
+			return
+		}
+		elapsed := decimal.NewFromFloat(until.Sub(cursor).Seconds())
+		for holder, balance := range balances {
+			if !balance.IsZero() {
+				seconds[holder] = seconds[holder].Add(balance.Mul(elapsed))
+			}
+		}
+		cursor = until
+	}
+
+	for _, transfer := range transfers {
+		if !transfer.TransferAt.Before(end) {
+			break
+		}
+		accrue(transfer.TransferAt)
+
+		if from := transfer.FromAddress; !skip[strings.ToLower(from)] {
+			balances[from] = balances[from].Sub(transfer.Value)
+			if balances[from].IsNegative() {
+				return nil, fmt.Errorf("%w: %s sends %v more than it holds in block %d", ErrIncompleteHistory, from, balances[from].Neg(), transfer.BlockNum)
+			}
+		}
+		if to := transfer.ToAddress; !skip[strings.ToLower(to)] {
+			balances[to] = balances[to].Add(transfer.Value)
+		}
+	}
+	accrue(end)
+
+	return seconds, nil
+}
@@ -0,0 +1,72 @@
+package liquidity
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	zero = "0x0000000000000000000000000000000000000000"
+	pair = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+)
+
+func Test_Seconds(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	transfer := func(seconds int, from string, to string, value int64) model.LPTransfer {
+		return model.LPTransfer{FromAddress: from, ToAddress: to, Value: decimal.NewFromInt(value), TransferAt: at(seconds)}
+	}
+
+	transfers := []model.LPTransfer{
+		// alice provides before the epoch
+		transfer(-100, zero, "0xalice", 10),
+		// bob provides in the middle of the epoch
+		transfer(50, zero, "0xbob", 20),
+		// alice sends half to carol
+		transfer(60, "0xalice", "0xcarol", 5),
+		// carol removes liquidity: LP tokens go to the pair, then are burned
+		transfer(80, "0xcarol", pair, 5),
+		transfer(80, pair, zero, 5),
+		// after the epoch
+		transfer(100, zero, "0xdave", 100),
+	}
+
+	got, err := Seconds(transfers, start, at(100), []string{zero, pair})
+	assert.NoError(t, err)
+
+	want := map[string]int64{
+		"0xalice": 10*60 + 5*40,
+		"0xbob":   20 * 50,
+		"0xcarol": 5 * 20,
+	}
+	assert.Equal(t, len(want), len(got))
+	for holder, w := range want {
+		assert.True(t, decimal.NewFromInt(w).Equal(got[holder]), "%s want: %d, got: %v", holder, w, got[holder])
+	}
+}
+
+func Test_SecondsIncompleteHistory(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// tokens provided before the history started are sent away
+	transfers := []model.LPTransfer{
+		{BlockNum: 1, FromAddress: "0xalice", ToAddress: "0xbob", Value: decimal.NewFromInt(10), TransferAt: start},
+	}
+
+	_, err := Seconds(transfers, start, start.Add(10*time.Second), nil)
+	assert.ErrorIs(t, err, ErrIncompleteHistory)
+
+	// the history from the creation of the pair holds the opening balance
+	transfers = append([]model.LPTransfer{
+		{FromAddress: zero, ToAddress: "0xalice", Value: decimal.NewFromInt(10), TransferAt: start.Add(-time.Hour)},
+	}, transfers...)
+
+	got, err := Seconds(transfers, start, start.Add(10*time.Second), []string{zero})
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100).Equal(got["0xbob"]))
+	assert.True(t, got["0xalice"].IsZero())
+}
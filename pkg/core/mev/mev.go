@@ -0,0 +1,119 @@
+package mev
+
+import (
+	"sort"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// the back-run must sell what the front-run bought within this tolerance
+var amountTolerance = decimal.RequireFromString("0.1")
+
+// Sandwich is a front-run and a back-run of the same attacker around one or more victim swaps.
+type Sandwich struct {
+	FrontRun model.Transaction
+	Victims  []model.Transaction
+	BackRun  model.Transaction
+}
+
+// DetectSandwiches finds sandwiches in the swaps of one pool. Swaps are grouped by block and ordered by log index,
+// a sandwich is a swap of the attacker, swaps of other senders in the same direction from other transactions,
+// then a swap of the attacker in the opposite direction selling what the front-run bought.
+// Every swap takes part in at most one sandwich.
+func DetectSandwiches(txs []model.Transaction) []Sandwich {
+	blocks := make(map[uint64][]model.Transaction)
+	var blockNums []uint64
+	for _, tx := range txs {
+		if _, ok := blocks[tx.BlockNum]; !ok {
+			blockNums = append(blockNums, tx.BlockNum)
+		}
+		blocks[tx.BlockNum] = append(blocks[tx.BlockNum], tx)
+	}
+	sort.Slice(blockNums, func(i, j int) bool { return blockNums[i] < blockNums[j] })
+
+	var sandwiches []Sandwich
+	for _, blockNum := range blockNums {
+		swaps := blocks[blockNum]
+		sort.SliceStable(swaps, func(i, j int) bool { return swaps[i].LogIndex < swaps[j].LogIndex })
+		sandwiches = append(sandwiches, detectInBlock(swaps)...)
+	}
+
+	return sandwiches
+}
+
+func detectInBlock(swaps []model.Transaction) []Sandwich {
+	used := make([]bool, len(swaps))
+
+	var sandwiches []Sandwich
+	for i, front := range swaps {
+		if used[i] {
+			continue
+		}
+
+		for k := i + 1; k < len(swaps); k++ {
+			back := swaps[k]
+			if used[k] || !isBackRun(front, back) {
+				continue
+			}
+
+			var victims []int
+			for j := i + 1; j < k; j++ {
+				if !used[j] && isVictim(front, back, swaps[j]) {
+					victims = append(victims, j)
+				}
+			}
+			if len(victims) == 0 {
+				continue
+			}
+
+			s := Sandwich{FrontRun: front, BackRun: back}
+			used[i], used[k] = true, true
+			for _, j := range victims {
+				used[j] = true
+				s.Victims = append(s.Victims, swaps[j])
+			}
+			sandwiches = append(sandwiches, s)
+			break
+		}
+	}
+
+	return sandwiches
+}
+
+func isBackRun(front model.Transaction, back model.Transaction) bool {
+	if back.SenderAddress != front.SenderAddress || back.TxHash == front.TxHash {
+		return false
+	}
+	if volume.IsBuy(back) == volume.IsBuy(front) {
+		return false
+	}
+
+	// what the front-run bought is what the back-run sells, on the side of the pair the token is
+	bought, sold := front.Amount1Out, back.Amount1In
+	if buysToken0(front) {
+		bought, sold = front.Amount0Out, back.Amount0In
+	}
+	if !bought.IsPositive() {
+		return false
+	}
+
+	return sold.Sub(bought).Abs().LessThanOrEqual(bought.Mul(amountTolerance))
+}
+
+// buysToken0 reports whether the swap buys token0 of its pair. A buy pays USDC for the other token
+// and a sell pays the other token for USDC, whichever side of the pair USDC is on.
+func buysToken0(tx model.Transaction) bool {
+	token0, _, _ := volume.PairTokens(tx.PairAddress)
+
+	return volume.IsBuy(tx) != (token0 == constants.TokenUSDC)
+}
+
+func isVictim(front model.Transaction, back model.Transaction, tx model.Transaction) bool {
+	return tx.SenderAddress != front.SenderAddress &&
+		tx.TxHash != front.TxHash &&
+		tx.TxHash != back.TxHash &&
+		volume.IsBuy(tx) == volume.IsBuy(front)
+}
@@ -0,0 +1,185 @@
+package mev
+
+import (
+	"testing"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// usdcEthPair is the supported pair with USDC as token0 and ETH as token1
+const usdcEthPair = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+// ethUsdcPair has ETH as token0 and USDC as token1, it is listed by Test_DetectSandwichesReversedPair only
+const ethUsdcPair = "0x0000000000000000000000000000000000000e7c"
+
+// swap buys eth ETH for usdc USDC when buy is true, otherwise sells eth ETH for usdc USDC
+func swap(id string, block uint64, logIndex uint, txHash string, sender string, buy bool, usdc int64, eth string) model.Transaction {
+	tx := model.Transaction{
+		PairAddress:   usdcEthPair,
+		ID:            id,
+		BlockNum:      block,
+		LogIndex:      logIndex,
+		TxHash:        txHash,
+		SenderAddress: sender,
+	}
+
+	usdcAmount := constants.UsdcPrecision.Mul(decimal.NewFromInt(usdc))
+	ethAmount := constants.EthPrecision.Mul(decimal.RequireFromString(eth))
+	if buy {
+		tx.Amount0In, tx.Amount1Out = usdcAmount, ethAmount
+	} else {
+		tx.Amount1In, tx.Amount0Out = ethAmount, usdcAmount
+	}
+
+	return tx
+}
+
+// reversed is swap on a pair with ETH as token0 and USDC as token1
+func reversed(id string, block uint64, logIndex uint, txHash string, sender string, buy bool, usdc int64, eth string) model.Transaction {
+	tx := swap(id, block, logIndex, txHash, sender, buy, usdc, eth)
+	tx.PairAddress = ethUsdcPair
+	tx.Amount0In, tx.Amount1In = tx.Amount1In, tx.Amount0In
+	tx.Amount0Out, tx.Amount1Out = tx.Amount1Out, tx.Amount0Out
+
+	return tx
+}
+
+func ids(sandwiches []Sandwich) [][]string {
+	result := make([][]string, 0, len(sandwiches))
+	for _, s := range sandwiches {
+		row := []string{s.FrontRun.ID}
+		for _, v := range s.Victims {
+			row = append(row, v.ID)
+		}
+		row = append(row, s.BackRun.ID)
+		result = append(result, row)
+	}
+
+	return result
+}
+
+func Test_DetectSandwiches(t *testing.T) {
+	tests := []struct {
+		name string
+		txs  []model.Transaction
+		want [][]string
+	}{
+		{
+			name: "classic sandwich",
+			txs: []model.Transaction{
+				swap("front", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				swap("victim", 10, 2, "0x02", "0xuser", true, 2000, "0.98"),
+				swap("back", 10, 3, "0x03", "0xbot", false, 10050, "5"),
+			},
+			want: [][]string{{"front", "victim", "back"}},
+		},
+		{
+			name: "sells are sandwiched as well, logs out of order",
+			txs: []model.Transaction{
+				swap("back", 10, 9, "0x03", "0xbot", true, 10000, "5"),
+				swap("victim2", 10, 6, "0x04", "0xother", false, 1900, "1"),
+				swap("front", 10, 2, "0x01", "0xbot", false, 10000, "5"),
+				swap("victim1", 10, 5, "0x02", "0xuser", false, 1950, "1"),
+			},
+			want: [][]string{{"front", "victim1", "victim2", "back"}},
+		},
+		{
+			name: "no victim in between",
+			txs: []model.Transaction{
+				swap("buy", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				swap("sell", 10, 2, "0x02", "0xbot", false, 10000, "5"),
+			},
+			want: [][]string{},
+		},
+		{
+			name: "victim trades the other way",
+			txs: []model.Transaction{
+				swap("buy", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				swap("other", 10, 2, "0x02", "0xuser", false, 2000, "1"),
+				swap("sell", 10, 3, "0x03", "0xbot", false, 10000, "5"),
+			},
+			want: [][]string{},
+		},
+		{
+			name: "different blocks",
+			txs: []model.Transaction{
+				swap("buy", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				swap("user", 11, 1, "0x02", "0xuser", true, 2000, "1"),
+				swap("sell", 11, 2, "0x03", "0xbot", false, 10000, "5"),
+			},
+			want: [][]string{},
+		},
+		{
+			name: "back-run does not sell what was bought",
+			txs: []model.Transaction{
+				swap("buy", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				swap("user", 10, 2, "0x02", "0xuser", true, 2000, "1"),
+				swap("sell", 10, 3, "0x03", "0xbot", false, 2000, "1"),
+			},
+			want: [][]string{},
+		},
+		{
+			name: "same transaction is not a sandwich",
+			txs: []model.Transaction{
+				swap("buy", 10, 1, "0x01", "0xrouter", true, 10000, "5"),
+				swap("user", 10, 2, "0x02", "0xuser", true, 2000, "1"),
+				swap("sell", 10, 3, "0x01", "0xrouter", false, 10000, "5"),
+			},
+			want: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(DetectSandwiches(tt.txs)))
+		})
+	}
+}
+
+func Test_DetectSandwichesReversedPair(t *testing.T) {
+	constants.SupportedPairs[ethUsdcPair] = [2]string{constants.TokenETH, constants.TokenUSDC}
+	t.Cleanup(func() { delete(constants.SupportedPairs, ethUsdcPair) })
+
+	tests := []struct {
+		name string
+		txs  []model.Transaction
+		want [][]string
+	}{
+		{
+			name: "buy sandwich",
+			txs: []model.Transaction{
+				reversed("front", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				reversed("victim", 10, 2, "0x02", "0xuser", true, 2000, "0.98"),
+				reversed("back", 10, 3, "0x03", "0xbot", false, 10050, "5"),
+			},
+			want: [][]string{{"front", "victim", "back"}},
+		},
+		{
+			name: "sell sandwich",
+			txs: []model.Transaction{
+				reversed("front", 10, 1, "0x01", "0xbot", false, 10000, "5"),
+				reversed("victim", 10, 2, "0x02", "0xuser", false, 1950, "1"),
+				reversed("back", 10, 3, "0x03", "0xbot", true, 10000, "5.1"),
+			},
+			want: [][]string{{"front", "victim", "back"}},
+		},
+		{
+			name: "back-run selling a different amount",
+			txs: []model.Transaction{
+				reversed("front", 10, 1, "0x01", "0xbot", true, 10000, "5"),
+				reversed("victim", 10, 2, "0x02", "0xuser", true, 2000, "1"),
+				reversed("back", 10, 3, "0x03", "0xbot", false, 2000, "1"),
+			},
+			want: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(DetectSandwiches(tt.txs)))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package multiplier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// the multiplier column holds NUMERIC(10, 4)
+var maxMultiplier = decimal.NewFromInt(1000)
+
+const places = 4
+
+func Validate(rule model.MultiplierRule) error {
+	if len(strings.TrimSpace(rule.Name)) == 0 {
+		return fmt.Errorf("name is required")
+	}
+	if !rule.Multiplier.IsPositive() || rule.Multiplier.GreaterThanOrEqual(maxMultiplier) {
+		return fmt.Errorf("multiplier must be between 0 and %s", maxMultiplier)
+	}
+	if rule.StartAt != nil && rule.EndAt != nil && !rule.EndAt.After(*rule.StartAt) {
+		return fmt.Errorf("endAt must be after startAt")
+	}
+
+	return nil
+}
+
+// Match reports whether the rule applies to an award of the task to the address at the given time.
+func Match(rule model.MultiplierRule, address string, task model.Task, at time.Time) bool {
+	if rule.StartAt != nil && at.Before(*rule.StartAt) {
+		return false
+	}
+	if rule.EndAt != nil && !at.Before(*rule.EndAt) {
+		return false
+	}
+	if len(rule.TaskID) != 0 && rule.TaskID != task.ID {
+		return false
+	}
+	if len(rule.PairAddress) != 0 && !strings.EqualFold(rule.PairAddress, task.PairAddress.String) {
+		return false
+	}
+	if len(rule.Addresses) == 0 {
+		return true
+	}
+	for _, member := range rule.Addresses {
+		if strings.EqualFold(member, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the product of the multipliers of every matching rule, 1 when none matches.
+func Resolve(rules []model.MultiplierRule, address string, task model.Task, at time.Time) decimal.Decimal {
+	result := decimal.NewFromInt(1)
+	for _, rule := range rules {
+		if Match(rule, address, task, at) {
+			result = result.Mul(rule.Multiplier)
+		}
+	}
+
+	return result
+}
+
+// Weighted returns the multiplier of every sender of the swaps, the multipliers matching at the transactionAt of every swap
+// averaged by the weight of the swap, e.g. its volume. A weekend window inside a weekly epoch only boosts the weekend swaps.
+// Swaps are weighted equally when all weights of a sender are zero, the result is rounded to the places of the ledger.
+func Weighted(
+	rules []model.MultiplierRule, task model.Task, swaps []model.Transaction, weight func(model.Transaction) decimal.Decimal,
+) map[string]decimal.Decimal {
+
+	weights := make(map[string]decimal.Decimal)
+	weighted := make(map[string]decimal.Decimal)
+	counts := make(map[string]int64)
+	sums := make(map[string]decimal.Decimal)
+
+	for _, swap := range swaps {
+		sender := swap.SenderAddress
+		boost := Resolve(rules, sender, task, swap.TransactionAt)
+		w := weight(swap)
+
+		weights[sender] = weights[sender].Add(w)
+		weighted[sender] = weighted[sender].Add(w.Mul(boost))
+		counts[sender]++
+		sums[sender] = sums[sender].Add(boost)
+	}
+
+	result := make(map[string]decimal.Decimal, len(counts))
+	for sender, count := range counts {
+		if weights[sender].IsZero() {
+			result[sender] = sums[sender].Div(decimal.NewFromInt(count)).Round(places)
+			continue
+		}
+		result[sender] = weighted[sender].Div(weights[sender]).Round(places)
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package multiplier
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	startAt := time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC)
+	endAt := startAt.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name    string
+		rule    model.MultiplierRule
+		wantErr bool
+	}{
+		{"valid", model.MultiplierRule{Name: "weekend", Multiplier: decimal.NewFromInt(2), StartAt: &startAt, EndAt: &endAt}, false},
+		{"no name", model.MultiplierRule{Multiplier: decimal.NewFromInt(2)}, true},
+		{"zero multiplier", model.MultiplierRule{Name: "zero"}, true},
+		{"too large", model.MultiplierRule{Name: "large", Multiplier: decimal.NewFromInt(1000)}, true},
+		{"window reversed", model.MultiplierRule{Name: "reversed", Multiplier: decimal.NewFromInt(2), StartAt: &endAt, EndAt: &startAt}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, Validate(tt.rule) != nil)
+		})
+	}
+}
+
+func Test_Resolve(t *testing.T) {
+	saturday := time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC)
+	monday := saturday.AddDate(0, 0, 2)
+	partner := "0x000000000000000000000000000000000000dEaD"
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+	rules := []model.MultiplierRule{
+		{Name: "double points weekend", Multiplier: decimal.NewFromInt(2), StartAt: &saturday, EndAt: &monday},
+		{Name: "partner community", Multiplier: decimal.NewFromFloat(1.5), Addresses: []string{partner}},
+		{Name: "other pair", Multiplier: decimal.NewFromInt(10), PairAddress: "0x0000000000000000000000000000000000000001"},
+		{Name: "other task", Multiplier: decimal.NewFromInt(10), TaskID: "other"},
+	}
+	task := model.Task{ID: "task", PairAddress: sql.NullString{String: pair, Valid: true}}
+
+	tests := []struct {
+		name    string
+		address string
+		at      time.Time
+		want    decimal.Decimal
+	}{
+		{"weekend", "0x0000000000000000000000000000000000000002", saturday, decimal.NewFromInt(2)},
+		{"weekend ended", "0x0000000000000000000000000000000000000002", monday, decimal.NewFromInt(1)},
+		// addresses are compared case-insensitively
+		{"partner on weekend", "0x000000000000000000000000000000000000dead", saturday.Add(time.Hour), decimal.NewFromInt(3)},
+		{"partner on weekday", partner, monday, decimal.NewFromFloat(1.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resolve(rules, tt.address, task, tt.at)
+			assert.True(t, tt.want.Equal(got), "want: %v, got: %v", tt.want, got)
+		})
+	}
+}
+
+func Test_Weighted(t *testing.T) {
+	// a weekly epoch from Monday with a double points weekend inside it
+	monday := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	saturday := monday.AddDate(0, 0, 5)
+	nextMonday := monday.AddDate(0, 0, 7)
+	rules := []model.MultiplierRule{
+		{Name: "double points weekend", Multiplier: decimal.NewFromInt(2), StartAt: &saturday, EndAt: &nextMonday},
+	}
+	task := model.Task{ID: "task", StartAt: monday}
+
+	swap := func(sender string, at time.Time, usd int64) model.Transaction {
+		return model.Transaction{SenderAddress: sender, TransactionAt: at, Amount0In: decimal.NewFromInt(usd)}
+	}
+	swaps := []model.Transaction{
+		// half of the volume on Wednesday, half on Saturday
+		swap("0xmixed", monday.AddDate(0, 0, 2), 1000),
+		swap("0xmixed", saturday.Add(time.Hour), 1000),
+		// all of it on the weekend
+		swap("0xweekend", saturday.Add(2*time.Hour), 500),
+		// none of it on the weekend, the epoch starting on Monday does not matter
+		swap("0xweekday", monday, 500),
+		// no volume, every swap counts the same
+		swap("0xdust", monday, 0),
+		swap("0xdust", saturday, 0),
+		swap("0xdust", saturday, 0),
+	}
+
+	got := Weighted(rules, task, swaps, func(tx model.Transaction) decimal.Decimal { return tx.Amount0In })
+
+	want := map[string]string{"0xmixed": "1.5", "0xweekend": "2", "0xweekday": "1", "0xdust": "1.6667"}
+	assert.Equal(t, len(want), len(got))
+	for sender, w := range want {
+		assert.True(t, decimal.RequireFromString(w).Equal(got[sender]), "%s want: %s, got: %v", sender, w, got[sender])
+	}
+}
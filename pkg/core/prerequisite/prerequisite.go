@@ -0,0 +1,119 @@
+package prerequisite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"tradingAce/pkg/model"
+)
+
+const (
+	ModeAll = "all"
+	ModeAny = "any"
+
+	TimingAnyTime     = "any_time"
+	TimingBeforeEpoch = "before_epoch"
+)
+
+func Validate(cfg model.PrerequisiteConfig) error {
+	switch cfg.Mode {
+	case "", ModeAll, ModeAny:
+	default:
+		return fmt.Errorf("unknown prerequisite mode: %s", cfg.Mode)
+	}
+	switch cfg.Timing {
+	case "", TimingAnyTime, TimingBeforeEpoch:
+	default:
+		return fmt.Errorf("unknown prerequisite timing: %s", cfg.Timing)
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range cfg.TaskIDs {
+		if len(id) == 0 {
+			return fmt.Errorf("prerequisite task id is required")
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate prerequisite task: %s", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// TaskIDs returns the prerequisite tasks, the default task when none is configured.
+func TaskIDs(cfg model.PrerequisiteConfig, defaultTaskID string) []string {
+	if len(cfg.TaskIDs) == 0 {
+		return []string{defaultTaskID}
+	}
+
+	return cfg.TaskIDs
+}
+
+// Met reports whether the tasks the user completed, with their completion time, unlock the epoch starting at epochStart.
+func Met(cfg model.PrerequisiteConfig, defaultTaskID string, completed map[string]time.Time, epochStart time.Time) bool {
+	done := func(id string) bool {
+		completedAt, ok := completed[id]
+		if !ok {
+			return false
+		}
+		return cfg.Timing != TimingBeforeEpoch || completedAt.Before(epochStart)
+	}
+
+	for _, id := range TaskIDs(cfg, defaultTaskID) {
+		if cfg.Mode == ModeAny && done(id) {
+			return true
+		}
+		if cfg.Mode != ModeAny && !done(id) {
+			return false
+		}
+	}
+
+	return cfg.Mode != ModeAny
+}
+
+// CheckCycle returns an error naming the tasks of a cycle in the graph of task ID to prerequisite task IDs.
+func CheckCycle(graph map[string][]string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if p == id {
+					start = i
+				}
+			}
+			return fmt.Errorf("prerequisite cycle: %s", strings.Join(append(path[start:], id), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+		for _, next := range graph[id] {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+
+		return nil
+	}
+
+	for id := range graph {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
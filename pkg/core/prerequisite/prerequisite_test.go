@@ -0,0 +1,73 @@
+package prerequisite
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     model.PrerequisiteConfig
+		wantErr bool
+	}{
+		{"empty", model.PrerequisiteConfig{}, false},
+		{"any before epoch", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}, Mode: ModeAny, Timing: TimingBeforeEpoch}, false},
+		{"unknown mode", model.PrerequisiteConfig{TaskIDs: []string{"a"}, Mode: "xor"}, true},
+		{"unknown timing", model.PrerequisiteConfig{TaskIDs: []string{"a"}, Timing: "later"}, true},
+		{"duplicate task", model.PrerequisiteConfig{TaskIDs: []string{"a", "a"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, Validate(tt.cfg) != nil)
+		})
+	}
+}
+
+func Test_Met(t *testing.T) {
+	epochStart := time.Date(2024, 7, 8, 0, 0, 0, 0, time.UTC)
+	before := epochStart.Add(-time.Hour)
+	after := epochStart.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		cfg       model.PrerequisiteConfig
+		completed map[string]time.Time
+		want      bool
+	}{
+		{"default task completed", model.PrerequisiteConfig{}, map[string]time.Time{"onboarding": after}, true},
+		{"default task not completed", model.PrerequisiteConfig{}, map[string]time.Time{"a": before}, false},
+		{"all completed", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}}, map[string]time.Time{"a": before, "b": after}, true},
+		{"all missing one", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}}, map[string]time.Time{"a": before}, false},
+		{"any completed", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}, Mode: ModeAny}, map[string]time.Time{"b": after}, true},
+		{"any none", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}, Mode: ModeAny}, map[string]time.Time{}, false},
+		{"before epoch too late", model.PrerequisiteConfig{TaskIDs: []string{"a"}, Timing: TimingBeforeEpoch}, map[string]time.Time{"a": after}, false},
+		{"before epoch in time", model.PrerequisiteConfig{TaskIDs: []string{"a"}, Timing: TimingBeforeEpoch}, map[string]time.Time{"a": before}, true},
+		{"any before epoch", model.PrerequisiteConfig{TaskIDs: []string{"a", "b"}, Mode: ModeAny, Timing: TimingBeforeEpoch}, map[string]time.Time{"a": after, "b": before}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Met(tt.cfg, "onboarding", tt.completed, epochStart))
+		})
+	}
+}
+
+func Test_CheckCycle(t *testing.T) {
+	assert.NoError(t, CheckCycle(map[string][]string{
+		"share_pool": {"onboarding"},
+		"streak":     {"onboarding", "share_pool"},
+		"lp":         {"share_pool"},
+	}))
+
+	assert.Error(t, CheckCycle(map[string][]string{"a": {"a"}}))
+	assert.Error(t, CheckCycle(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+	}))
+}
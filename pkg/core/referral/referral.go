@@ -0,0 +1,96 @@
+package referral
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalid is wrapped by every error of a referral that can not be registered
+var ErrInvalid = errors.New("invalid referral")
+
+// Message is the text the referee signs with personal_sign (EIP-191) to register the referrer.
+func Message(referee string, referrer string) string {
+	return fmt.Sprintf(
+		"tradingAce referral\nreferee: %s\nreferrer: %s",
+		common.HexToAddress(referee).Hex(), common.HexToAddress(referrer).Hex(),
+	)
+}
+
+// Verify checks the addresses and that the signature of Message is signed by the referee.
+func Verify(referee string, referrer string, signature string) error {
+	if !common.IsHexAddress(referee) || !common.IsHexAddress(referrer) {
+		return fmt.Errorf("%w: invalid address", ErrInvalid)
+	}
+	if common.HexToAddress(referee) == common.HexToAddress(referrer) {
+		return fmt.Errorf("%w: self referral", ErrInvalid)
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("%w: malformed signature", ErrInvalid)
+	}
+	// wallets sign with v of 27 or 28
+	sig = append([]byte{}, sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(Message(referee, referrer))), sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(referee) {
+		return fmt.Errorf("%w: signature is not signed by the referee", ErrInvalid)
+	}
+
+	return nil
+}
+
+func Validate(cfg model.ReferralConfig) error {
+	if cfg.OnboardingPoints != nil && cfg.OnboardingPoints.IsNegative() {
+		return fmt.Errorf("onboarding points must not be negative")
+	}
+	for _, tier := range cfg.Tiers {
+		if !tier.VolumeUSD.IsPositive() {
+			return fmt.Errorf("tier volume must be positive")
+		}
+		if tier.Points.IsNegative() {
+			return fmt.Errorf("tier points must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// Reward returns the points the referrer earns from one referee:
+// the onboarding points once the referee is onboarded, plus the points of every volume tier the referee reached.
+func Reward(cfg model.ReferralConfig, onboarded bool, volumeUSD decimal.Decimal) decimal.Decimal {
+	points := decimal.Zero
+	if onboarded {
+		points = decimal.NewFromInt(constants.ReferralOnboardingPoint)
+		if cfg.OnboardingPoints != nil {
+			points = *cfg.OnboardingPoints
+		}
+	}
+
+	tiers := make([]model.ReferralTier, len(cfg.Tiers))
+	copy(tiers, cfg.Tiers)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].VolumeUSD.LessThan(tiers[j].VolumeUSD) })
+	for _, tier := range tiers {
+		if volumeUSD.LessThan(tier.VolumeUSD) {
+			break
+		}
+		points = points.Add(tier.Points)
+	}
+
+	return points
+}
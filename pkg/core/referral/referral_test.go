@@ -0,0 +1,97 @@
+package referral
+
+import (
+	"errors"
+	"testing"
+	"tradingAce/pkg/model"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	referee := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	referrer := "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+
+	sign := func(message string) string {
+		sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig[crypto.RecoveryIDOffset] += 27
+		return hexutil.Encode(sig)
+	}
+
+	tests := []struct {
+		name      string
+		referee   string
+		referrer  string
+		signature string
+		wantErr   bool
+	}{
+		{name: "signed by referee", referee: referee, referrer: referrer, signature: sign(Message(referee, referrer))},
+		{name: "lower case addresses", referee: referee, referrer: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", signature: sign(Message(referee, referrer))},
+		{name: "self referral", referee: referee, referrer: referee, signature: sign(Message(referee, referee)), wantErr: true},
+		{name: "other referrer", referee: referee, referrer: referrer, signature: sign(Message(referee, referee)), wantErr: true},
+		{name: "not signed by referee", referee: referrer, referrer: referee, signature: sign(Message(referrer, referee)), wantErr: true},
+		{name: "malformed signature", referee: referee, referrer: referrer, signature: "0x1234", wantErr: true},
+		{name: "invalid address", referee: "0x123", referrer: referrer, signature: sign(Message(referee, referrer)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.referee, tt.referrer, tt.signature)
+			assert.Equal(t, tt.wantErr, err != nil, "err: %v", err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalid))
+			}
+		})
+	}
+}
+
+func Test_Reward(t *testing.T) {
+	cfg := model.ReferralConfig{
+		Tiers: []model.ReferralTier{
+			{VolumeUSD: decimal.NewFromInt(10000), Points: decimal.NewFromInt(200)},
+			{VolumeUSD: decimal.NewFromInt(1000), Points: decimal.NewFromInt(100)},
+		},
+	}
+	onboardingPoints := decimal.NewFromInt(10)
+
+	tests := []struct {
+		name      string
+		cfg       model.ReferralConfig
+		onboarded bool
+		volume    int64
+		want      int64
+	}{
+		{name: "nothing yet", cfg: cfg, volume: 500, want: 0},
+		{name: "onboarded", cfg: cfg, onboarded: true, volume: 500, want: 50},
+		{name: "first tier", cfg: cfg, onboarded: true, volume: 1000, want: 150},
+		{name: "every tier", cfg: cfg, onboarded: true, volume: 20000, want: 350},
+		{name: "custom onboarding points", cfg: model.ReferralConfig{OnboardingPoints: &onboardingPoints}, onboarded: true, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Reward(tt.cfg, tt.onboarded, decimal.NewFromInt(tt.volume))
+			assert.True(t, decimal.NewFromInt(tt.want).Equal(got), "got: %v", got)
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	negative := decimal.NewFromInt(-1)
+
+	assert.NoError(t, Validate(model.ReferralConfig{}))
+	assert.Error(t, Validate(model.ReferralConfig{OnboardingPoints: &negative}))
+	assert.Error(t, Validate(model.ReferralConfig{Tiers: []model.ReferralTier{{VolumeUSD: decimal.Zero}}}))
+	assert.Error(t, Validate(model.ReferralConfig{Tiers: []model.ReferralTier{{VolumeUSD: decimal.NewFromInt(1), Points: negative}}}))
+}
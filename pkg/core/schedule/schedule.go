@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"time"
+	"tradingAce/pkg/model"
+)
+
+// states of a scheduled run
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// Policy of the scheduler: how long to wait after an epoch closes and how to retry failed settlements.
+type Policy struct {
+	Delay         time.Duration
+	RetryInterval time.Duration
+	MaxAttempts   int
+}
+
+var DefaultPolicy = Policy{
+	Delay:         10 * time.Minute,
+	RetryInterval: 5 * time.Minute,
+	MaxAttempts:   5,
+}
+
+// Due is an epoch to settle with the number of the attempt.
+type Due struct {
+	Epoch   model.Epoch
+	Attempt int
+}
+
+// DueEpochs returns the closed epochs to settle now, given the latest run of every epoch and the block time ingestion has reached.
+// An epoch is due once the delay after its end has passed and a block at or after its end was ingested.
+// Failed runs, and runs left running by a stopped process, are retried with an exponential backoff until MaxAttempts.
+func DueEpochs(epochs []model.Epoch, latest map[int]model.ScheduleRun, ingestedAt time.Time, now time.Time, p Policy) []Due {
+	var due []Due
+	for _, e := range epochs {
+		if now.Before(e.EndAt.Add(p.Delay)) || ingestedAt.Before(e.EndAt) {
+			continue
+		}
+
+		run, ok := latest[e.Index]
+		if !ok {
+			due = append(due, Due{Epoch: e, Attempt: 1})
+			continue
+		}
+		if run.State == StateSucceeded || run.Attempt >= p.MaxAttempts {
+			continue
+		}
+		if now.Before(NextAttemptAt(run, p)) {
+			continue
+		}
+
+		due = append(due, Due{Epoch: e, Attempt: run.Attempt + 1})
+	}
+
+	return due
+}
+
+// NextAttemptAt returns when a run that did not succeed can be retried, the interval doubles with every attempt.
+func NextAttemptAt(run model.ScheduleRun, p Policy) time.Time {
+	at := run.StartedAt
+	if run.FinishedAt != nil {
+		at = *run.FinishedAt
+	}
+
+	return at.Add(p.RetryInterval * time.Duration(1<<(max(run.Attempt, 1)-1)))
+}
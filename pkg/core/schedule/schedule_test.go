@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DueEpochs(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	epochs := []model.Epoch{
+		{Index: 0, StartAt: start, EndAt: start.AddDate(0, 0, 1)},
+		{Index: 1, StartAt: start.AddDate(0, 0, 1), EndAt: start.AddDate(0, 0, 2)},
+	}
+	policy := Policy{Delay: 10 * time.Minute, RetryInterval: 5 * time.Minute, MaxAttempts: 3}
+	firstEnd := epochs[0].EndAt
+	failedAt := firstEnd.Add(20 * time.Minute)
+
+	tests := []struct {
+		name       string
+		latest     map[int]model.ScheduleRun
+		ingestedAt time.Time
+		now        time.Time
+		want       []Due
+	}{
+		{
+			name:       "within delay",
+			ingestedAt: firstEnd,
+			now:        firstEnd.Add(5 * time.Minute),
+		},
+		{
+			name:       "ingestion behind the boundary",
+			ingestedAt: firstEnd.Add(-time.Second),
+			now:        firstEnd.Add(time.Hour),
+		},
+		{
+			name:       "first attempt",
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        firstEnd.Add(time.Hour),
+			want:       []Due{{Epoch: epochs[0], Attempt: 1}},
+		},
+		{
+			name:       "settled",
+			latest:     map[int]model.ScheduleRun{0: {Attempt: 1, State: StateSucceeded}},
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        firstEnd.Add(time.Hour),
+		},
+		{
+			name:       "retry backoff",
+			latest:     map[int]model.ScheduleRun{0: {Attempt: 2, State: StateFailed, StartedAt: failedAt, FinishedAt: &failedAt}},
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        failedAt.Add(9 * time.Minute),
+		},
+		{
+			name:       "retry",
+			latest:     map[int]model.ScheduleRun{0: {Attempt: 2, State: StateFailed, StartedAt: failedAt, FinishedAt: &failedAt}},
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        failedAt.Add(10 * time.Minute),
+			want:       []Due{{Epoch: epochs[0], Attempt: 3}},
+		},
+		{
+			name:       "retry left running",
+			latest:     map[int]model.ScheduleRun{0: {Attempt: 1, State: StateRunning, StartedAt: failedAt}},
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        failedAt.Add(5 * time.Minute),
+			want:       []Due{{Epoch: epochs[0], Attempt: 2}},
+		},
+		{
+			name:       "out of attempts",
+			latest:     map[int]model.ScheduleRun{0: {Attempt: 3, State: StateFailed, StartedAt: failedAt, FinishedAt: &failedAt}},
+			ingestedAt: firstEnd.Add(time.Minute),
+			now:        failedAt.Add(time.Hour),
+		},
+		{
+			name:       "every closed epoch",
+			ingestedAt: epochs[1].EndAt,
+			now:        epochs[1].EndAt.Add(time.Hour),
+			want:       []Due{{Epoch: epochs[0], Attempt: 1}, {Epoch: epochs[1], Attempt: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DueEpochs(epochs, tt.latest, tt.ingestedAt, tt.now, policy))
+		})
+	}
+}
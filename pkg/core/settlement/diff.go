@@ -0,0 +1,77 @@
+package settlement
+
+import (
+	"sort"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// changes of a user in a settlement diff
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// Diff compares the computed settlement of the task with the current one, both keyed by user address.
+// Users only in next are added, users only in current are removed, the users are sorted by address.
+func Diff(taskID string, current map[string]model.UserSettlement, next map[string]model.UserSettlement) model.SettlementDiff {
+	diff := model.SettlementDiff{
+		TaskID:       taskID,
+		CurrentTotal: decimal.Zero,
+		NewTotal:     decimal.Zero,
+		Users:        make([]model.UserSettlementDiff, 0),
+	}
+
+	for address, c := range current {
+		diff.CurrentTotal = diff.CurrentTotal.Add(c.Point)
+		if _, ok := next[address]; !ok {
+			diff.Removed++
+			diff.Users = append(diff.Users, model.UserSettlementDiff{
+				UserAddress: address,
+				Change:      ChangeRemoved,
+				State:       c.State,
+				Amount:      c.Amount,
+				Point:       c.Point,
+				NewAmount:   decimal.Zero,
+				NewPoint:    decimal.Zero,
+			})
+		}
+	}
+
+	for address, n := range next {
+		diff.NewTotal = diff.NewTotal.Add(n.Point)
+
+		userDiff := model.UserSettlementDiff{
+			UserAddress: address,
+			NewState:    n.State,
+			NewAmount:   n.Amount,
+			NewPoint:    n.Point,
+			Amount:      decimal.Zero,
+			Point:       decimal.Zero,
+		}
+
+		c, ok := current[address]
+		switch {
+		case !ok:
+			diff.Added++
+			userDiff.Change = ChangeAdded
+		case c.State != n.State || !c.Amount.Equal(n.Amount) || !c.Point.Equal(n.Point):
+			diff.Changed++
+			userDiff.Change = ChangeChanged
+			userDiff.State, userDiff.Amount, userDiff.Point = c.State, c.Amount, c.Point
+		default:
+			diff.Unchanged++
+			continue
+		}
+
+		diff.Users = append(diff.Users, userDiff)
+	}
+
+	sort.Slice(diff.Users, func(i, j int) bool {
+		return diff.Users[i].UserAddress < diff.Users[j].UserAddress
+	})
+
+	return diff
+}
@@ -0,0 +1,50 @@
+package settlement
+
+import (
+	"testing"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	current := map[string]model.UserSettlement{
+		"0xa": {UserAddress: "0xa", State: "pending", Amount: decimal.NewFromInt(100), Point: decimal.NewFromInt(5000)},
+		"0xb": {UserAddress: "0xb", State: "pending", Amount: decimal.NewFromInt(100), Point: decimal.NewFromInt(5000)},
+		"0xc": {UserAddress: "0xc", State: "pending", Amount: decimal.NewFromInt(50), Point: decimal.NewFromInt(2500)},
+	}
+	next := map[string]model.UserSettlement{
+		"0xa": {UserAddress: "0xa", State: "pending", Amount: decimal.NewFromInt(100), Point: decimal.NewFromInt(5000)},
+		"0xb": {UserAddress: "0xb", State: "pending", Amount: decimal.NewFromInt(100), Point: decimal.NewFromInt(4000)},
+		"0xd": {UserAddress: "0xd", State: "pending", Amount: decimal.NewFromInt(25), Point: decimal.NewFromInt(1000)},
+	}
+
+	diff := Diff("task1", current, next)
+
+	assert.Equal(t, "task1", diff.TaskID)
+	assert.Equal(t, 1, diff.Added)
+	assert.Equal(t, 1, diff.Changed)
+	assert.Equal(t, 1, diff.Removed)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.True(t, decimal.NewFromInt(12500).Equal(diff.CurrentTotal))
+	assert.True(t, decimal.NewFromInt(10000).Equal(diff.NewTotal))
+
+	if !assert.Equal(t, 3, len(diff.Users)) {
+		return
+	}
+	assert.Equal(t, "0xb", diff.Users[0].UserAddress)
+	assert.Equal(t, ChangeChanged, diff.Users[0].Change)
+	assert.True(t, decimal.NewFromInt(5000).Equal(diff.Users[0].Point))
+	assert.True(t, decimal.NewFromInt(4000).Equal(diff.Users[0].NewPoint))
+	assert.Equal(t, ChangeRemoved, diff.Users[1].Change)
+	assert.Equal(t, "0xc", diff.Users[1].UserAddress)
+	assert.True(t, diff.Users[1].NewPoint.IsZero())
+	assert.Equal(t, ChangeAdded, diff.Users[2].Change)
+	assert.Equal(t, "", diff.Users[2].State)
+	assert.True(t, decimal.NewFromInt(1000).Equal(diff.Users[2].NewPoint))
+
+	empty := Diff("task2", nil, nil)
+	assert.Equal(t, 0, len(empty.Users))
+	assert.True(t, empty.NewTotal.IsZero())
+}
@@ -0,0 +1,62 @@
+package settlement
+
+import (
+	"errors"
+	"tradingAce/pkg/model"
+)
+
+// states of a settlement run
+const (
+	StatePending   = "pending"
+	StateComputing = "computing"
+	StateComputed  = "computed"
+	StateCommitted = "committed"
+	StateFinalized = "finalized"
+	StateFailed    = "failed"
+)
+
+// ErrTransition is returned when a run is moved to a state it cannot reach from its current one.
+var ErrTransition = errors.New("invalid settlement run transition")
+
+// transitions lists the states every state can move to, committed and finalized runs never fail.
+var transitions = map[string][]string{
+	StatePending:   {StateComputing, StateFailed},
+	StateComputing: {StateComputed, StateFailed},
+	StateComputed:  {StateCommitted, StateFailed},
+	StateCommitted: {StateFinalized},
+}
+
+// CanTransition reports whether a run in the state from can move to the state to.
+func CanTransition(from string, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Settled reports whether the points of the run are written.
+func Settled(state string) bool {
+	return state == StateCommitted || state == StateFinalized
+}
+
+// Unsettled returns the epochs that need a new run, given the latest run of every epoch.
+// Committed epochs are only settled again when forced, finalized epochs never are.
+func Unsettled(epochs []int, latest map[int]model.SettlementRun, force bool) []int {
+	var unsettled []int
+	for _, e := range epochs {
+		run, ok := latest[e]
+		if ok && run.State == StateFinalized {
+			continue
+		}
+		if ok && run.State == StateCommitted && !force {
+			continue
+		}
+
+		unsettled = append(unsettled, e)
+	}
+
+	return unsettled
+}
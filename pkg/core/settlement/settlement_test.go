@@ -0,0 +1,45 @@
+package settlement
+
+import (
+	"testing"
+	"tradingAce/pkg/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{StatePending, StateComputing, true},
+		{StateComputing, StateComputed, true},
+		{StateComputed, StateCommitted, true},
+		{StateCommitted, StateFinalized, true},
+		{StateComputing, StateFailed, true},
+		{StatePending, StateCommitted, false},
+		{StateComputing, StateCommitted, false},
+		{StateCommitted, StateFailed, false},
+		{StateFinalized, StateCommitted, false},
+		{StateFailed, StateComputing, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CanTransition(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func Test_Unsettled(t *testing.T) {
+	latest := map[int]model.SettlementRun{
+		0: {Epoch: 0, State: StateFinalized},
+		1: {Epoch: 1, State: StateCommitted},
+		2: {Epoch: 2, State: StateFailed},
+		3: {Epoch: 3, State: StateComputing},
+	}
+	epochs := []int{0, 1, 2, 3, 4}
+
+	assert.Equal(t, []int{2, 3, 4}, Unsettled(epochs, latest, false))
+	assert.Equal(t, []int{1, 2, 3, 4}, Unsettled(epochs, latest, true))
+	assert.Nil(t, Unsettled([]int{0, 1}, latest, false))
+}
@@ -0,0 +1,91 @@
+package streak
+
+import (
+	"fmt"
+	"sort"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Progress is the streak of a user over the settled epochs of a task.
+type Progress struct {
+	Current int
+	Longest int
+	// Points of the milestones reached in every epoch, by the position of the epoch
+	Points map[int]decimal.Decimal
+	// position of the epoch the last milestone is reached in, -1 until every milestone is reached
+	Completed int
+}
+
+func Validate(cfg model.StreakConfig) error {
+	if len(cfg.Milestones) == 0 {
+		return fmt.Errorf("at least one milestone is required")
+	}
+
+	lengths := make(map[int]bool)
+	for _, m := range cfg.Milestones {
+		if m.Length <= 0 {
+			return fmt.Errorf("milestone length must be positive")
+		}
+		if m.Points.IsNegative() {
+			return fmt.Errorf("milestone points must not be negative")
+		}
+		if lengths[m.Length] {
+			return fmt.Errorf("duplicate milestone length: %d", m.Length)
+		}
+		lengths[m.Length] = true
+	}
+
+	return nil
+}
+
+// Compute walks the epochs in order, active tells whether the user traded in the epoch.
+// A milestone is granted in the epoch the streak first reaches its length, a later streak does not grant it again.
+func Compute(active []bool, milestones []model.StreakMilestone) Progress {
+	sorted := sortMilestones(milestones)
+	progress := Progress{Points: make(map[int]decimal.Decimal), Completed: -1}
+
+	next := 0
+	for i, ok := range active {
+		if !ok {
+			progress.Current = 0
+			continue
+		}
+
+		progress.Current++
+		if progress.Current <= progress.Longest {
+			continue
+		}
+		progress.Longest = progress.Current
+
+		for next < len(sorted) && sorted[next].Length <= progress.Longest {
+			progress.Points[i] = progress.Points[i].Add(sorted[next].Points)
+			next++
+			if next == len(sorted) {
+				progress.Completed = i
+			}
+		}
+	}
+
+	return progress
+}
+
+// NextMilestone returns the length of the shortest milestone longer than the longest streak, 0 when all are reached.
+func NextMilestone(longest int, milestones []model.StreakMilestone) int {
+	for _, m := range sortMilestones(milestones) {
+		if m.Length > longest {
+			return m.Length
+		}
+	}
+
+	return 0
+}
+
+func sortMilestones(milestones []model.StreakMilestone) []model.StreakMilestone {
+	sorted := make([]model.StreakMilestone, len(milestones))
+	copy(sorted, milestones)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Length < sorted[j].Length })
+
+	return sorted
+}
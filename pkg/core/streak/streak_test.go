@@ -0,0 +1,96 @@
+package streak
+
+import (
+	"fmt"
+	"testing"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var milestones = []model.StreakMilestone{
+	{Length: 4, Points: decimal.NewFromInt(300)},
+	{Length: 2, Points: decimal.NewFromInt(100)},
+}
+
+func Test_Compute(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    []bool
+		current   int
+		longest   int
+		points    map[int]int64
+		completed int
+	}{
+		{
+			name:      "no epochs",
+			points:    map[int]int64{},
+			completed: -1,
+		},
+		{
+			name:      "one streak",
+			active:    []bool{true, true, true, true},
+			current:   4,
+			longest:   4,
+			points:    map[int]int64{1: 100, 3: 300},
+			completed: 3,
+		},
+		{
+			name:      "broken streak grants a milestone once",
+			active:    []bool{true, true, false, true, true, true},
+			current:   3,
+			longest:   3,
+			points:    map[int]int64{1: 100},
+			completed: -1,
+		},
+		{
+			name:      "current streak ends with a missed epoch",
+			active:    []bool{false, true, false},
+			current:   0,
+			longest:   1,
+			points:    map[int]int64{},
+			completed: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := Compute(tt.active, milestones)
+
+			assert.Equal(t, tt.current, progress.Current)
+			assert.Equal(t, tt.longest, progress.Longest)
+			assert.Equal(t, tt.completed, progress.Completed)
+			assert.Equal(t, len(tt.points), len(progress.Points))
+			for i, want := range tt.points {
+				assert.True(t, decimal.NewFromInt(want).Equal(progress.Points[i]), "epoch %d got: %v", i, progress.Points[i])
+			}
+		})
+	}
+}
+
+func Test_NextMilestone(t *testing.T) {
+	assert.Equal(t, 2, NextMilestone(0, milestones))
+	assert.Equal(t, 4, NextMilestone(2, milestones))
+	assert.Equal(t, 0, NextMilestone(4, milestones))
+}
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		cfg     model.StreakConfig
+		wantErr bool
+	}{
+		{cfg: model.StreakConfig{Milestones: milestones}},
+		{cfg: model.StreakConfig{}, wantErr: true},
+		{cfg: model.StreakConfig{Milestones: []model.StreakMilestone{{Length: 0}}}, wantErr: true},
+		{cfg: model.StreakConfig{Milestones: []model.StreakMilestone{{Length: 1, Points: decimal.NewFromInt(-1)}}}, wantErr: true},
+		{cfg: model.StreakConfig{Milestones: []model.StreakMilestone{{Length: 1}, {Length: 1}}}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			err := Validate(tt.cfg)
+			assert.Equal(t, tt.wantErr, err != nil, "err: %v", err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package taskrule
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+	"tradingAce/pkg/model"
+)
+
+var ErrInvalid = errors.New("invalid task rule version")
+
+// Resolve returns the rules of the latest version in effect at the time, fallback before the first version.
+func Resolve(versions []model.TaskRuleVersion, at time.Time, fallback model.TaskConfig) model.TaskConfig {
+	config, latest := fallback, 0
+	for _, v := range versions {
+		if v.EffectiveAt.After(at) || v.Version < latest {
+			continue
+		}
+		config, latest = v.Config, v.Version
+	}
+
+	return config
+}
+
+// Validate checks that the new rules keep the epoch schedule of the task, settled epochs must stay the same.
+func Validate(current model.TaskConfig, next model.TaskConfig) error {
+	if !reflect.DeepEqual(current.Epoch, next.Epoch) {
+		return fmt.Errorf("%w: the epoch schedule cannot change", ErrInvalid)
+	}
+
+	return nil
+}
+
+// Find returns the version of the number.
+func Find(versions []model.TaskRuleVersion, version int) (model.TaskRuleVersion, bool) {
+	for _, v := range versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+
+	return model.TaskRuleVersion{}, false
+}
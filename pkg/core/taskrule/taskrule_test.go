@@ -0,0 +1,49 @@
+package taskrule
+
+import (
+	"testing"
+	"time"
+	"tradingAce/pkg/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Resolve(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	fallback := model.TaskConfig{VolumeMode: "input"}
+	versions := []model.TaskRuleVersion{
+		{Version: 1, EffectiveAt: start, Config: model.TaskConfig{VolumeMode: "output"}},
+		{Version: 3, EffectiveAt: start.AddDate(0, 0, 7), Config: model.TaskConfig{VolumeMode: "net"}},
+		// a later version effective earlier overrides the ones before it
+		{Version: 2, EffectiveAt: start.AddDate(0, 0, 14), Config: model.TaskConfig{VolumeMode: "max"}},
+		{Version: 4, EffectiveAt: start.AddDate(0, 0, 3), Config: model.TaskConfig{VolumeMode: "usdc"}},
+	}
+
+	assert.Equal(t, "input", Resolve(versions, start.Add(-time.Hour), fallback).VolumeMode)
+	assert.Equal(t, "output", Resolve(versions, start, fallback).VolumeMode)
+	assert.Equal(t, "usdc", Resolve(versions, start.AddDate(0, 0, 7), fallback).VolumeMode)
+	assert.Equal(t, "usdc", Resolve(versions, start.AddDate(0, 0, 21), fallback).VolumeMode)
+	assert.Equal(t, "input", Resolve(nil, start, fallback).VolumeMode)
+}
+
+func Test_Validate(t *testing.T) {
+	current := model.TaskConfig{Epoch: model.EpochConfig{Unit: "week", Length: 1, Count: 4}}
+
+	assert.NoError(t, Validate(current, model.TaskConfig{
+		Epoch:      model.EpochConfig{Unit: "week", Length: 1, Count: 4},
+		VolumeMode: "net",
+	}))
+	assert.ErrorIs(t, Validate(current, model.TaskConfig{
+		Epoch: model.EpochConfig{Unit: "week", Length: 1, Count: 8},
+	}), ErrInvalid)
+}
+
+func Test_Find(t *testing.T) {
+	versions := []model.TaskRuleVersion{{Version: 1}, {Version: 2, Note: "fix pricing"}}
+
+	v, ok := Find(versions, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "fix pricing", v.Note)
+	_, ok = Find(versions, 3)
+	assert.False(t, ok)
+}
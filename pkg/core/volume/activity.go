@@ -0,0 +1,52 @@
+package volume
+
+import (
+	"fmt"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+func ValidateActivity(cfg model.ActivityConfig) error {
+	if cfg.MinVolumeUSD != nil && cfg.MinVolumeUSD.IsNegative() {
+		return fmt.Errorf("min volume must not be negative")
+	}
+	if cfg.MinTradeCount < 0 {
+		return fmt.Errorf("min trade count must not be negative")
+	}
+
+	return nil
+}
+
+// TradeCounts counts the swaps of every sender.
+func TradeCounts(txs []model.Transaction) map[string]int {
+	counts := make(map[string]int)
+	for _, tx := range txs {
+		counts[tx.SenderAddress]++
+	}
+
+	return counts
+}
+
+// Qualify keeps the volumes of the users with the minimum activity of the epoch,
+// the others are returned with the reason they do not qualify.
+func Qualify(
+	cfg model.ActivityConfig, volumes map[string]decimal.Decimal, trades map[string]int,
+) (map[string]decimal.Decimal, map[string]string) {
+	qualified := make(map[string]decimal.Decimal, len(volumes))
+	reasons := make(map[string]string)
+
+	for sender, v := range volumes {
+		switch {
+		case cfg.MinVolumeUSD != nil && v.LessThan(*cfg.MinVolumeUSD):
+			reasons[sender] = constants.IneligibleMinVolume
+		case trades[sender] < cfg.MinTradeCount:
+			reasons[sender] = constants.IneligibleMinTradeCount
+		default:
+			qualified[sender] = v
+		}
+	}
+
+	return qualified, reasons
+}
@@ -0,0 +1,53 @@
+package volume
+
+import (
+	"testing"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Qualify(t *testing.T) {
+	txs := []model.Transaction{
+		buyETH("0xwhale"),
+		sellETH("0xwhale"),
+		buyETH("0xonce"),
+		{PairAddress: usdcEthPair, SenderAddress: "0xdust", Amount0In: decimal.NewFromInt(1e6)},
+		{PairAddress: usdcEthPair, SenderAddress: "0xdust", Amount0In: decimal.NewFromInt(1e6)},
+	}
+	volumes := Volumes(ModeInput, txs)
+	trades := TradeCounts(txs)
+	assert.Equal(t, map[string]int{"0xwhale": 2, "0xonce": 1, "0xdust": 2}, trades)
+
+	minVolume := decimal.NewFromInt(100)
+	qualified, reasons := Qualify(model.ActivityConfig{MinVolumeUSD: &minVolume, MinTradeCount: 2}, volumes, trades)
+	assert.Equal(t, []string{"0xwhale"}, keys(qualified))
+	assert.Equal(t, map[string]string{
+		"0xonce": constants.IneligibleMinTradeCount,
+		"0xdust": constants.IneligibleMinVolume,
+	}, reasons)
+
+	// no minimum by default
+	qualified, reasons = Qualify(model.ActivityConfig{}, volumes, trades)
+	assert.Equal(t, 3, len(qualified))
+	assert.Empty(t, reasons)
+}
+
+func Test_ValidateActivity(t *testing.T) {
+	negative := decimal.NewFromInt(-1)
+
+	assert.NoError(t, ValidateActivity(model.ActivityConfig{}))
+	assert.Error(t, ValidateActivity(model.ActivityConfig{MinVolumeUSD: &negative}))
+	assert.Error(t, ValidateActivity(model.ActivityConfig{MinTradeCount: -1}))
+}
+
+func keys(m map[string]decimal.Decimal) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+
+	return result
+}
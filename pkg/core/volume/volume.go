@@ -0,0 +1,163 @@
+package volume
+
+import (
+	"fmt"
+	"strings"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Volume modes of share-pool tasks. The tokens of a swap are looked up by its pair, see PairTokens.
+const (
+	// ModeInput counts what the trader paid in, this is the default
+	ModeInput = "input"
+	// ModeOutput counts what the trader received
+	ModeOutput = "output"
+	// ModeMax counts the larger of both sides of every swap
+	ModeMax = "max"
+	// ModeUSDC counts only the USDC leg of every swap
+	ModeUSDC = "usdc"
+	// ModeNet counts the absolute net USDC flow of the trader over the period,
+	// a buy that is sold back within the period cancels out
+	ModeNet = "net"
+)
+
+func Validate(mode string) error {
+	switch mode {
+	case "", ModeInput, ModeOutput, ModeMax, ModeUSDC, ModeNet:
+		return nil
+	default:
+		return fmt.Errorf("unsupported volume mode: %s", mode)
+	}
+}
+
+// PairTokens returns token0 and token1 of the pair, the address is matched case-insensitively.
+// ok is false for pairs that are not in constants.SupportedPairs, their tokens are unknown.
+func PairTokens(pairAddress string) (token0 string, token1 string, ok bool) {
+	for address, tokens := range constants.SupportedPairs {
+		if strings.EqualFold(address, pairAddress) {
+			return tokens[0], tokens[1], true
+		}
+	}
+
+	return "", "", false
+}
+
+// ValidatePairs checks that the tokens of every pair are known.
+func ValidatePairs(pairs []string) error {
+	for _, pair := range pairs {
+		if _, _, ok := PairTokens(pair); !ok {
+			return fmt.Errorf("unsupported pair: %s", pair)
+		}
+	}
+
+	return nil
+}
+
+// TokenUSD converts a raw amount of the token to USD, unknown tokens are worth nothing.
+func TokenUSD(token string, amount decimal.Decimal) decimal.Decimal {
+	precision, ok := constants.TokenPrecision[token]
+	if !ok {
+		return decimal.Zero
+	}
+
+	return amount.Div(precision).Mul(constants.TokenPrice[token])
+}
+
+// SwapUSD returns the USD value of what went into and out of the pair in the swap.
+// Swaps of unsupported pairs are worth nothing, as their tokens are unknown.
+func SwapUSD(tx model.Transaction) (in decimal.Decimal, out decimal.Decimal) {
+	token0, token1, _ := PairTokens(tx.PairAddress)
+	in = TokenUSD(token0, tx.Amount0In).Add(TokenUSD(token1, tx.Amount1In))
+	out = TokenUSD(token0, tx.Amount0Out).Add(TokenUSD(token1, tx.Amount1Out))
+
+	return in, out
+}
+
+// InputUSD sums the USD value paid in by the swaps, counting only the legs of the given tokens.
+// Every token counts when tokens is empty.
+func InputUSD(txs []model.Transaction, tokens []string) decimal.Decimal {
+	counts := func(token string) bool {
+		if len(tokens) == 0 {
+			return true
+		}
+		for _, t := range tokens {
+			if strings.EqualFold(t, token) {
+				return true
+			}
+		}
+		return false
+	}
+
+	total := decimal.Zero
+	for _, tx := range txs {
+		token0, token1, _ := PairTokens(tx.PairAddress)
+		if counts(token0) {
+			total = total.Add(TokenUSD(token0, tx.Amount0In))
+		}
+		if counts(token1) {
+			total = total.Add(TokenUSD(token1, tx.Amount1In))
+		}
+	}
+
+	return total
+}
+
+// IsBuy reports whether the swap buys ETH, i.e. the USDC paid in is worth more than the ETH paid in.
+func IsBuy(tx model.Transaction) bool {
+	usdcIn, _ := usdcFlow(tx)
+	in, _ := SwapUSD(tx)
+
+	return usdcIn.GreaterThan(in.Sub(usdcIn))
+}
+
+// Volumes sums the USD volume of every sender according to the mode.
+func Volumes(mode string, txs []model.Transaction) map[string]decimal.Decimal {
+	volumes := make(map[string]decimal.Decimal)
+
+	for _, tx := range txs {
+		in, out := SwapUSD(tx)
+
+		var v decimal.Decimal
+		switch mode {
+		case ModeOutput:
+			v = out
+		case ModeMax:
+			v = decimal.Max(in, out)
+		case ModeUSDC:
+			usdcIn, usdcOut := usdcFlow(tx)
+			v = usdcIn.Add(usdcOut)
+		case ModeNet:
+			// signed, the absolute value is taken once all swaps are summed
+			usdcIn, usdcOut := usdcFlow(tx)
+			v = usdcIn.Sub(usdcOut)
+		default:
+			v = in
+		}
+
+		volumes[tx.SenderAddress] = volumes[tx.SenderAddress].Add(v)
+	}
+
+	if mode == ModeNet {
+		for sender, v := range volumes {
+			volumes[sender] = v.Abs()
+		}
+	}
+
+	return volumes
+}
+
+// usdcFlow returns the USD value of the USDC paid in and received in the swap
+func usdcFlow(tx model.Transaction) (in decimal.Decimal, out decimal.Decimal) {
+	token0, token1, _ := PairTokens(tx.PairAddress)
+	if token0 == constants.TokenUSDC {
+		in, out = in.Add(TokenUSD(token0, tx.Amount0In)), out.Add(TokenUSD(token0, tx.Amount0Out))
+	}
+	if token1 == constants.TokenUSDC {
+		in, out = in.Add(TokenUSD(token1, tx.Amount1In)), out.Add(TokenUSD(token1, tx.Amount1Out))
+	}
+
+	return in, out
+}
@@ -0,0 +1,125 @@
+package volume
+
+import (
+	"testing"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// usdcEthPair is the supported pair with USDC as token0 and ETH as token1
+const usdcEthPair = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+// buyETH pays 1000 USDC for 0.49 ETH (980 USD)
+func buyETH(sender string) model.Transaction {
+	return model.Transaction{
+		PairAddress:   usdcEthPair,
+		SenderAddress: sender,
+		Amount0In:     decimal.NewFromInt(1000e6),
+		Amount1Out:    decimal.NewFromInt(49e16),
+	}
+}
+
+// sellETH pays 0.5 ETH (1000 USD) for 990 USDC
+func sellETH(sender string) model.Transaction {
+	return model.Transaction{
+		PairAddress:   usdcEthPair,
+		SenderAddress: sender,
+		Amount1In:     decimal.NewFromInt(5e17),
+		Amount0Out:    decimal.NewFromInt(990e6),
+	}
+}
+
+func Test_SwapUSD(t *testing.T) {
+	in, out := SwapUSD(buyETH("0xa"))
+	assert.True(t, decimal.NewFromInt(1000).Equal(in))
+	assert.True(t, decimal.NewFromInt(980).Equal(out))
+
+	in, out = SwapUSD(sellETH("0xa"))
+	assert.True(t, decimal.NewFromInt(1000).Equal(in))
+	assert.True(t, decimal.NewFromInt(990).Equal(out))
+}
+
+func Test_Volumes(t *testing.T) {
+	txs := []model.Transaction{
+		buyETH("0xbuyer"),
+		sellETH("0xseller"),
+		buyETH("0xroundtrip"),
+		sellETH("0xroundtrip"),
+	}
+
+	tests := []struct {
+		mode string
+		want map[string]int64
+	}{
+		{mode: "", want: map[string]int64{"0xbuyer": 1000, "0xseller": 1000, "0xroundtrip": 2000}},
+		{mode: ModeInput, want: map[string]int64{"0xbuyer": 1000, "0xseller": 1000, "0xroundtrip": 2000}},
+		{mode: ModeOutput, want: map[string]int64{"0xbuyer": 980, "0xseller": 990, "0xroundtrip": 1970}},
+		{mode: ModeMax, want: map[string]int64{"0xbuyer": 1000, "0xseller": 1000, "0xroundtrip": 2000}},
+		{mode: ModeUSDC, want: map[string]int64{"0xbuyer": 1000, "0xseller": 990, "0xroundtrip": 1990}},
+		{mode: ModeNet, want: map[string]int64{"0xbuyer": 1000, "0xseller": 990, "0xroundtrip": 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got := Volumes(tt.mode, txs)
+
+			assert.Equal(t, len(tt.want), len(got))
+			for sender, want := range tt.want {
+				assert.True(t, decimal.NewFromInt(want).Equal(got[sender]), "%s want: %v, got: %v", sender, want, got[sender])
+			}
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	for _, mode := range []string{"", ModeInput, ModeOutput, ModeMax, ModeUSDC, ModeNet} {
+		assert.NoError(t, Validate(mode))
+	}
+	assert.Error(t, Validate("gross"))
+}
+
+func Test_PairTokens(t *testing.T) {
+	token0, token1, ok := PairTokens("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+	assert.True(t, ok)
+	assert.Equal(t, constants.TokenUSDC, token0)
+	assert.Equal(t, constants.TokenETH, token1)
+
+	// an unlisted pair has unknown tokens, its swaps are worth nothing
+	unlisted := buyETH("0xa")
+	unlisted.PairAddress = "0x0000000000000000000000000000000000000abc"
+	_, _, ok = PairTokens(unlisted.PairAddress)
+	assert.False(t, ok)
+	in, out := SwapUSD(unlisted)
+	assert.True(t, in.IsZero())
+	assert.True(t, out.IsZero())
+	assert.True(t, InputUSD([]model.Transaction{unlisted}, nil).IsZero())
+	assert.True(t, Volumes(ModeUSDC, []model.Transaction{unlisted})["0xa"].IsZero())
+	assert.NoError(t, ValidatePairs([]string{"0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"}))
+	assert.Error(t, ValidatePairs([]string{usdcEthPair, unlisted.PairAddress}))
+
+	// a pair with ETH as token0
+	constants.SupportedPairs["0x0000000000000000000000000000000000000e7c"] = [2]string{constants.TokenETH, constants.TokenUSDC}
+	defer delete(constants.SupportedPairs, "0x0000000000000000000000000000000000000e7c")
+
+	tx := model.Transaction{
+		PairAddress: "0x0000000000000000000000000000000000000E7C",
+		Amount0In:   decimal.NewFromInt(5e17),
+		Amount1Out:  decimal.NewFromInt(990e6),
+	}
+	in, out = SwapUSD(tx)
+	assert.True(t, decimal.NewFromInt(1000).Equal(in))
+	assert.True(t, decimal.NewFromInt(990).Equal(out))
+	assert.False(t, IsBuy(tx))
+}
+
+func Test_InputUSD(t *testing.T) {
+	txs := []model.Transaction{buyETH("0xa"), sellETH("0xa")}
+
+	assert.True(t, decimal.NewFromInt(2000).Equal(InputUSD(txs, nil)))
+	assert.True(t, decimal.NewFromInt(1000).Equal(InputUSD(txs, []string{constants.TokenUSDC})))
+	assert.True(t, decimal.NewFromInt(1000).Equal(InputUSD(txs, []string{"eth"})))
+	assert.True(t, decimal.Zero.Equal(InputUSD(txs, []string{"DAI"})))
+}
@@ -0,0 +1,258 @@
+package voucher
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var (
+	// ErrNoSigner is returned when vouchers are issued without a signing key configured
+	ErrNoSigner = errors.New("voucher signer is not configured")
+	// ErrRedeemed is returned when the voucher of a user for a campaign is redeemed already
+	ErrRedeemed = errors.New("voucher is redeemed")
+	// ErrNoContract is returned when the claim contract the vouchers are signed for is not configured
+	ErrNoContract = errors.New("voucher contract is not configured")
+	// ErrNoChain is returned when redemptions are checked without a chain RPC configured
+	ErrNoChain = errors.New("voucher chain rpc is not configured")
+	// ErrNotRedeemed is returned when a transaction did not redeem the voucher on chain
+	ErrNotRedeemed = errors.New("voucher is not redeemed by the transaction")
+)
+
+// ClaimedTopic is the topic of the event the claim contract emits for a redeemed voucher,
+// Claimed(address indexed user, uint256 indexed nonce, uint256 amount).
+var ClaimedTopic = crypto.Keccak256Hash([]byte("Claimed(address,uint256,uint256)"))
+
+const (
+	StateIssued   = "issued"
+	StateRedeemed = "redeemed"
+)
+
+// Domain separates the vouchers of a claim contract on a chain from any other EIP-712 signature.
+type Domain struct {
+	Name              string
+	Version           string
+	ChainID           int64
+	VerifyingContract common.Address
+}
+
+// Voucher lets User claim Amount of the campaign until Deadline, a unix time.
+// The nonce of a user increases with every voucher, it is global per user across campaigns and
+// the claim contract accepts a nonce once.
+type Voucher struct {
+	User     common.Address
+	Campaign string
+	Amount   *big.Int
+	Nonce    uint64
+	Deadline int64
+}
+
+var types = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"Voucher": {
+		{Name: "user", Type: "address"},
+		{Name: "campaign", Type: "string"},
+		{Name: "amount", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	},
+}
+
+// TypedData is what the signer signs, as eth_signTypedData_v4 would.
+func TypedData(domain Domain, v Voucher) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       types,
+		PrimaryType: "Voucher",
+		Domain: apitypes.TypedDataDomain{
+			Name:              domain.Name,
+			Version:           domain.Version,
+			ChainId:           math.NewHexOrDecimal256(domain.ChainID),
+			VerifyingContract: domain.VerifyingContract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"user":     v.User.Hex(),
+			"campaign": v.Campaign,
+			"amount":   v.Amount.String(),
+			"nonce":    strconv.FormatUint(v.Nonce, 10),
+			"deadline": strconv.FormatInt(v.Deadline, 10),
+		},
+	}
+}
+
+// Hash returns the EIP-712 digest of the voucher.
+func Hash(domain Domain, v Voucher) (common.Hash, error) {
+	hash, _, err := apitypes.TypedDataAndHash(TypedData(domain, v))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return common.BytesToHash(hash), nil
+}
+
+// Sign signs the voucher with v of 27 or 28, as ECDSA.recover of OpenZeppelin expects.
+func Sign(key *ecdsa.PrivateKey, domain Domain, v Voucher) ([]byte, error) {
+	hash, err := Hash(domain, v)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return sig, nil
+}
+
+// Recover returns the address that signed the voucher.
+func Recover(domain Domain, v Voucher, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("malformed signature")
+	}
+	hash, err := Hash(domain, v)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	sig = append([]byte{}, sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifyReceipt checks that the transaction of the receipt succeeded and that the claim contract of the domain
+// emitted Claimed for the user, nonce and amount of the voucher in it, ErrNotRedeemed otherwise.
+func VerifyReceipt(domain Domain, receipt *ethTypes.Receipt, v Voucher) error {
+	if receipt.Status != ethTypes.ReceiptStatusSuccessful {
+		return fmt.Errorf("%w: transaction %s reverted", ErrNotRedeemed, receipt.TxHash.Hex())
+	}
+
+	nonce := common.BigToHash(new(big.Int).SetUint64(v.Nonce))
+	for _, l := range receipt.Logs {
+		if l.Address != domain.VerifyingContract || len(l.Topics) != 3 || l.Topics[0] != ClaimedTopic {
+			continue
+		}
+		if common.BytesToAddress(l.Topics[1].Bytes()) != v.User || l.Topics[2] != nonce {
+			continue
+		}
+		if len(l.Data) != common.HashLength || new(big.Int).SetBytes(l.Data).Cmp(v.Amount) != 0 {
+			return fmt.Errorf("%w: claimed amount %s is not %s", ErrNotRedeemed, new(big.Int).SetBytes(l.Data), v.Amount)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: no Claimed event of %s nonce %d in transaction %s", ErrNotRedeemed, v.User.Hex(), v.Nonce, receipt.TxHash.Hex())
+}
+
+// ClaimedQuery filters the Claimed events of the claim contract of the domain for the user and nonce,
+// from fromBlock on.
+func ClaimedQuery(domain Domain, user common.Address, nonce uint64, fromBlock uint64) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		Addresses: []common.Address{domain.VerifyingContract},
+		Topics: [][]common.Hash{
+			{ClaimedTopic},
+			{common.BytesToHash(user.Bytes())},
+			{common.BigToHash(new(big.Int).SetUint64(nonce))},
+		},
+	}
+}
+
+// Config is where the signing key is and what the vouchers are signed for.
+type Config struct {
+	// keystore file of the signing key and its password, vouchers are not issued without it
+	Keystore string
+	Password string
+	Domain   Domain
+	// RPC of the chain of the claim contract, the transactions redeeming vouchers are checked on it
+	RPCURL string
+	// block the claim contract is deployed at, the Claimed events are searched from it
+	FromBlock uint64
+	// how long a voucher can be redeemed
+	TTL time.Duration
+}
+
+var DefaultConfig = Config{
+	Domain: Domain{Name: "tradingAce", Version: "1", ChainID: 1},
+	TTL:    7 * 24 * time.Hour,
+}
+
+// ConfigFromEnv reads VOUCHER_KEYSTORE, VOUCHER_PASSWORD, VOUCHER_CHAIN_ID, VOUCHER_CONTRACT, VOUCHER_RPC_URL,
+// VOUCHER_FROM_BLOCK and VOUCHER_TTL,
+// unset or invalid values keep the default. The config is returned with ErrNoContract when VOUCHER_CONTRACT is not
+// a non-zero address, vouchers signed without it could be redeemed by any contract.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+	cfg.Keystore = os.Getenv("VOUCHER_KEYSTORE")
+	cfg.Password = os.Getenv("VOUCHER_PASSWORD")
+	cfg.RPCURL = os.Getenv("VOUCHER_RPC_URL")
+
+	if value := os.Getenv("VOUCHER_CHAIN_ID"); len(value) != 0 {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil && id > 0 {
+			cfg.Domain.ChainID = id
+		}
+	}
+	if value := os.Getenv("VOUCHER_CONTRACT"); common.IsHexAddress(value) {
+		cfg.Domain.VerifyingContract = common.HexToAddress(value)
+	}
+	if value := os.Getenv("VOUCHER_FROM_BLOCK"); len(value) != 0 {
+		if block, err := strconv.ParseUint(value, 10, 64); err == nil {
+			cfg.FromBlock = block
+		}
+	}
+	if value := os.Getenv("VOUCHER_TTL"); len(value) != 0 {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			cfg.TTL = d
+		}
+	}
+
+	if cfg.Domain.VerifyingContract == (common.Address{}) {
+		return cfg, ErrNoContract
+	}
+	return cfg, nil
+}
+
+// LoadKey decrypts the signing key of the keystore file, it returns ErrNoContract when the domain has no claim contract.
+func LoadKey(cfg Config) (*ecdsa.PrivateKey, error) {
+	if cfg.Domain.VerifyingContract == (common.Address{}) {
+		return nil, ErrNoContract
+	}
+	if len(cfg.Keystore) == 0 {
+		return nil, ErrNoSigner
+	}
+
+	keyJSON, err := os.ReadFile(cfg.Keystore)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %v", err)
+	}
+
+	return key.PrivateKey, nil
+}
@@ -0,0 +1,201 @@
+package voucher
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SignRecover(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := DefaultConfig.Domain
+	domain.VerifyingContract = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	v := Voucher{
+		User:     common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		Campaign: "campaign1",
+		Amount:   big.NewInt(1000),
+		Nonce:    3,
+		Deadline: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}
+
+	sig, err := Sign(key, domain, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+	signer, err := Recover(domain, v, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+	// a changed amount or another chain does not recover the signer
+	changed := v
+	changed.Amount = big.NewInt(1001)
+	signer, err = Recover(domain, changed, sig)
+	assert.True(t, err != nil || signer != crypto.PubkeyToAddress(key.PublicKey))
+
+	otherChain := domain
+	otherChain.ChainID = 5
+	signer, err = Recover(otherChain, v, sig)
+	assert.True(t, err != nil || signer != crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = Recover(domain, v, sig[:64])
+	assert.Error(t, err)
+}
+
+func Test_LoadKey(t *testing.T) {
+	domain := Domain{VerifyingContract: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")}
+	_, err := LoadKey(Config{Domain: domain})
+	assert.ErrorIs(t, err, ErrNoSigner)
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if err := os.WriteFile(path, keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := LoadKey(Config{Keystore: path, Password: "secret", Domain: domain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = LoadKey(Config{Keystore: path, Password: "wrong", Domain: domain})
+	assert.Error(t, err)
+	// the key is not used for vouchers of no contract
+	_, err = LoadKey(Config{Keystore: path, Password: "secret"})
+	assert.ErrorIs(t, err, ErrNoContract)
+}
+
+func Test_ConfigFromEnv(t *testing.T) {
+	t.Setenv("VOUCHER_KEYSTORE", "/keystore.json")
+	t.Setenv("VOUCHER_CHAIN_ID", "8453")
+	t.Setenv("VOUCHER_CONTRACT", "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	t.Setenv("VOUCHER_RPC_URL", "https://base.example")
+	t.Setenv("VOUCHER_FROM_BLOCK", "1200000")
+	t.Setenv("VOUCHER_TTL", "invalid")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/keystore.json", cfg.Keystore)
+	assert.Equal(t, int64(8453), cfg.Domain.ChainID)
+	assert.Equal(t, common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"), cfg.Domain.VerifyingContract)
+	assert.Equal(t, "https://base.example", cfg.RPCURL)
+	assert.Equal(t, uint64(1200000), cfg.FromBlock)
+	assert.Equal(t, DefaultConfig.TTL, cfg.TTL)
+
+	for _, contract := range []string{"", "invalid", "0x0000000000000000000000000000000000000000"} {
+		t.Setenv("VOUCHER_CONTRACT", contract)
+		_, err := ConfigFromEnv()
+		assert.ErrorIs(t, err, ErrNoContract, contract)
+	}
+}
+
+func Test_VerifyReceipt(t *testing.T) {
+	contract := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	domain := Domain{Name: "tradingAce", Version: "1", ChainID: 1, VerifyingContract: contract}
+	v := Voucher{
+		User:     common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		Campaign: "campaign1",
+		Amount:   big.NewInt(1000),
+		Nonce:    3,
+	}
+	claimed := func(address common.Address, user common.Address, nonce int64, amount int64) *ethTypes.Log {
+		return &ethTypes.Log{
+			Address: address,
+			Topics:  []common.Hash{ClaimedTopic, common.BytesToHash(user.Bytes()), common.BigToHash(big.NewInt(nonce))},
+			Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+		}
+	}
+	receipt := func(status uint64, logs ...*ethTypes.Log) *ethTypes.Receipt {
+		return &ethTypes.Receipt{Status: status, Logs: logs}
+	}
+	other := common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	tests := []struct {
+		name    string
+		receipt *ethTypes.Receipt
+		wantErr bool
+	}{
+		{"claimed", receipt(ethTypes.ReceiptStatusSuccessful, claimed(other, v.User, 3, 1000), claimed(contract, v.User, 3, 1000)), false},
+		{"reverted", receipt(ethTypes.ReceiptStatusFailed, claimed(contract, v.User, 3, 1000)), true},
+		{"no event", receipt(ethTypes.ReceiptStatusSuccessful), true},
+		{"other contract", receipt(ethTypes.ReceiptStatusSuccessful, claimed(other, v.User, 3, 1000)), true},
+		{"other user", receipt(ethTypes.ReceiptStatusSuccessful, claimed(contract, other, 3, 1000)), true},
+		{"other nonce", receipt(ethTypes.ReceiptStatusSuccessful, claimed(contract, v.User, 2, 1000)), true},
+		{"other amount", receipt(ethTypes.ReceiptStatusSuccessful, claimed(contract, v.User, 3, 999)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyReceipt(domain, tt.receipt, v)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNotRedeemed)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Hash(t *testing.T) {
+	domain := Domain{Name: "tradingAce", Version: "1", ChainID: 1, VerifyingContract: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")}
+	v := Voucher{
+		User:     common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		Campaign: "campaign1",
+		Amount:   big.NewInt(1000),
+		Nonce:    3,
+		Deadline: 1719792000,
+	}
+
+	// the digest a claim contract computes with abi.encode
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+	domainSeparator := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256([]byte(domain.Name)),
+		crypto.Keccak256([]byte(domain.Version)),
+		word(big.NewInt(domain.ChainID).Bytes()),
+		word(domain.VerifyingContract.Bytes()),
+	)
+	structHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("Voucher(address user,string campaign,uint256 amount,uint256 nonce,uint256 deadline)")),
+		word(v.User.Bytes()),
+		crypto.Keccak256([]byte(v.Campaign)),
+		word(v.Amount.Bytes()),
+		word(new(big.Int).SetUint64(v.Nonce).Bytes()),
+		word(big.NewInt(v.Deadline).Bytes()),
+	)
+
+	hash, err := Hash(domain, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, crypto.Keccak256Hash([]byte("\x19\x01"), domainSeparator, structHash), hash)
+}
@@ -0,0 +1,375 @@
+package washtrade
+
+import (
+	"fmt"
+	"sort"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPingPongCount  = 4
+	defaultClusterSize    = 3
+	defaultSelfSwapBlocks = 100
+)
+
+var defaultTinyTradeUSD = decimal.NewFromInt(10)
+
+// Normalize fills the default thresholds.
+func Normalize(cfg model.WashTradingConfig) model.WashTradingConfig {
+	if cfg.TinyTradeUSD == nil {
+		tiny := defaultTinyTradeUSD
+		cfg.TinyTradeUSD = &tiny
+	}
+	if cfg.PingPongCount == 0 {
+		cfg.PingPongCount = defaultPingPongCount
+	}
+	if cfg.ClusterSize == 0 {
+		cfg.ClusterSize = defaultClusterSize
+	}
+	if cfg.SelfSwapBlocks == 0 {
+		cfg.SelfSwapBlocks = defaultSelfSwapBlocks
+	}
+
+	return cfg
+}
+
+func Validate(cfg model.WashTradingConfig) error {
+	if cfg.TinyTradeUSD != nil && cfg.TinyTradeUSD.IsNegative() {
+		return fmt.Errorf("tiny trade usd can not be negative")
+	}
+	if cfg.PingPongCount < 0 || cfg.PingPongCount == 1 {
+		return fmt.Errorf("ping pong count must be at least 2")
+	}
+	if cfg.ClusterSize < 0 || cfg.ClusterSize == 1 {
+		return fmt.Errorf("cluster size must be at least 2")
+	}
+	if cfg.SelfSwapBlocks < 0 {
+		return fmt.Errorf("self swap blocks can not be negative")
+	}
+
+	return nil
+}
+
+// Detect runs every rule over the swaps of one pair and returns the flags sorted by address, transaction and reason.
+// Only swap events are indexed, so funding between addresses is inferred from swap outputs sent to another trader.
+func Detect(txs []model.Transaction, cfg model.WashTradingConfig) []model.TradeFlag {
+	cfg = Normalize(cfg)
+
+	ordered := make([]model.Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].BlockNum != ordered[j].BlockNum {
+			return ordered[i].BlockNum < ordered[j].BlockNum
+		}
+		return ordered[i].LogIndex < ordered[j].LogIndex
+	})
+
+	var flags []model.TradeFlag
+	flags = append(flags, roundTrips(ordered)...)
+	flags = append(flags, loops(ordered, uint64(cfg.SelfSwapBlocks))...)
+	flags = append(flags, pingPongs(ordered, *cfg.TinyTradeUSD, cfg.PingPongCount)...)
+	flags = append(flags, fundingClusters(ordered, cfg.ClusterSize)...)
+
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].UserAddress != flags[j].UserAddress {
+			return flags[i].UserAddress < flags[j].UserAddress
+		}
+		if flags[i].TransactionID != flags[j].TransactionID {
+			return flags[i].TransactionID < flags[j].TransactionID
+		}
+		return flags[i].Reason < flags[j].Reason
+	})
+
+	return flags
+}
+
+// Exclude drops the flagged swaps and every swap of a flagged address.
+func Exclude(txs []model.Transaction, flags []model.TradeFlag) []model.Transaction {
+	flaggedTx := make(map[string]bool)
+	flaggedAddress := make(map[string]bool)
+	for _, f := range flags {
+		if len(f.TransactionID) == 0 {
+			flaggedAddress[f.UserAddress] = true
+		} else {
+			flaggedTx[f.TransactionID] = true
+		}
+	}
+
+	result := make([]model.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if flaggedTx[tx.ID] || flaggedAddress[tx.SenderAddress] {
+			continue
+		}
+		result = append(result, tx)
+	}
+
+	return result
+}
+
+// roundTrips flags senders that buy and sell in the same block.
+func roundTrips(txs []model.Transaction) []model.TradeFlag {
+	type key struct {
+		sender string
+		block  uint64
+	}
+
+	groups := make(map[key][]model.Transaction)
+	var keys []key
+	for _, tx := range txs {
+		k := key{tx.SenderAddress, tx.BlockNum}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], tx)
+	}
+
+	var flags []model.TradeFlag
+	for _, k := range keys {
+		var buy, sell bool
+		for _, tx := range groups[k] {
+			if volume.IsBuy(tx) {
+				buy = true
+			} else {
+				sell = true
+			}
+		}
+		if !buy || !sell {
+			continue
+		}
+
+		for _, tx := range groups[k] {
+			flags = append(flags, model.TradeFlag{
+				UserAddress:   tx.SenderAddress,
+				TransactionID: tx.ID,
+				Reason:        constants.FlagReasonRoundTrip,
+				Detail:        fmt.Sprintf("buy and sell in block %d", k.block),
+			})
+		}
+	}
+
+	return flags
+}
+
+// loops flags swaps whose output goes around a cycle of addresses back to the sender, A -> B -> ... -> A,
+// and swaps whose output goes straight back to the sender, A -> A, when the sender swaps the other way
+// within blocks of it. A single swap to the sender itself is a plain trade.
+func loops(txs []model.Transaction, blocks uint64) []model.TradeFlag {
+	edges := make(map[string][]string)
+	swaps := make(map[string][]model.Transaction)
+	for _, tx := range txs {
+		if tx.SenderAddress != tx.ReceiverAddress {
+			edges[tx.SenderAddress] = append(edges[tx.SenderAddress], tx.ReceiverAddress)
+		}
+		swaps[tx.SenderAddress] = append(swaps[tx.SenderAddress], tx)
+	}
+
+	component := stronglyConnected(edges)
+	size := make(map[int]int)
+	for _, c := range component {
+		size[c]++
+	}
+
+	var flags []model.TradeFlag
+	for _, tx := range txs {
+		if tx.SenderAddress == tx.ReceiverAddress {
+			if back, ok := swapBack(tx, swaps[tx.SenderAddress], blocks); ok {
+				flags = append(flags, model.TradeFlag{
+					UserAddress:   tx.SenderAddress,
+					TransactionID: tx.ID,
+					Reason:        constants.FlagReasonSelfSwap,
+					Detail:        fmt.Sprintf("output to the sender, swapped back by %s in block %d", back.ID, back.BlockNum),
+				})
+			}
+			continue
+		}
+		c, ok := component[tx.SenderAddress]
+		if !ok || c != component[tx.ReceiverAddress] || size[c] < 2 {
+			continue
+		}
+
+		flags = append(flags, model.TradeFlag{
+			UserAddress:   tx.SenderAddress,
+			TransactionID: tx.ID,
+			Reason:        constants.FlagReasonLoop,
+			Detail:        fmt.Sprintf("output to %s returns to the sender through %d addresses", tx.ReceiverAddress, size[c]),
+		})
+	}
+
+	return flags
+}
+
+// swapBack returns the first swap of the sender in the other direction within blocks of the swap.
+func swapBack(tx model.Transaction, swaps []model.Transaction, blocks uint64) (model.Transaction, bool) {
+	for _, other := range swaps {
+		if volume.IsBuy(other) == volume.IsBuy(tx) {
+			continue
+		}
+		if max(other.BlockNum, tx.BlockNum)-min(other.BlockNum, tx.BlockNum) <= blocks {
+			return other, true
+		}
+	}
+
+	return model.Transaction{}, false
+}
+
+// pingPongs flags the tiny swaps of senders that trade back and forth at least count times in a row.
+func pingPongs(txs []model.Transaction, tinyUSD decimal.Decimal, count int) []model.TradeFlag {
+	tiny := make(map[string][]model.Transaction)
+	var senders []string
+	for _, tx := range txs {
+		in, out := volume.SwapUSD(tx)
+		if !decimal.Max(in, out).LessThan(tinyUSD) {
+			continue
+		}
+		if _, ok := tiny[tx.SenderAddress]; !ok {
+			senders = append(senders, tx.SenderAddress)
+		}
+		tiny[tx.SenderAddress] = append(tiny[tx.SenderAddress], tx)
+	}
+
+	var flags []model.TradeFlag
+	for _, sender := range senders {
+		swaps := tiny[sender]
+
+		// longest run of alternating directions
+		longest, run := 1, 1
+		for i := 1; i < len(swaps); i++ {
+			if volume.IsBuy(swaps[i]) != volume.IsBuy(swaps[i-1]) {
+				run++
+			} else {
+				run = 1
+			}
+			if run > longest {
+				longest = run
+			}
+		}
+		if longest < count {
+			continue
+		}
+
+		for _, tx := range swaps {
+			flags = append(flags, model.TradeFlag{
+				UserAddress:   sender,
+				TransactionID: tx.ID,
+				Reason:        constants.FlagReasonPingPong,
+				Detail:        fmt.Sprintf("%d alternating swaps below %s USD", longest, tinyUSD),
+			})
+		}
+	}
+
+	return flags
+}
+
+// fundingClusters flags every address of a group of traders linked by swap outputs sent to each other,
+// when the group has at least size addresses.
+func fundingClusters(txs []model.Transaction, size int) []model.TradeFlag {
+	traders := make(map[string]bool)
+	for _, tx := range txs {
+		traders[tx.SenderAddress] = true
+	}
+
+	parent := make(map[string]string)
+	var find func(a string) string
+	find = func(a string) string {
+		if p, ok := parent[a]; ok && p != a {
+			parent[a] = find(p)
+			return parent[a]
+		}
+		parent[a] = a
+		return a
+	}
+
+	for _, tx := range txs {
+		if tx.SenderAddress == tx.ReceiverAddress || !traders[tx.ReceiverAddress] {
+			continue
+		}
+		a, b := find(tx.SenderAddress), find(tx.ReceiverAddress)
+		if a != b {
+			if b < a {
+				a, b = b, a
+			}
+			parent[b] = a
+		}
+	}
+
+	members := make(map[string][]string)
+	for address := range parent {
+		root := find(address)
+		members[root] = append(members[root], address)
+	}
+
+	var flags []model.TradeFlag
+	for root, addresses := range members {
+		if len(addresses) < size {
+			continue
+		}
+		for _, address := range addresses {
+			flags = append(flags, model.TradeFlag{
+				UserAddress: address,
+				Reason:      constants.FlagReasonFundingCluster,
+				Detail:      fmt.Sprintf("funded together with %d addresses, cluster %s", len(addresses)-1, root),
+			})
+		}
+	}
+
+	return flags
+}
+
+// stronglyConnected returns the strongly connected component of every address (Tarjan).
+func stronglyConnected(edges map[string][]string) map[string]int {
+	var nodes []string
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	component := make(map[string]int)
+	var stack []string
+	next, count := 0, 0
+
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range edges[v] {
+			if _, seen := index[w]; !seen {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component[w] = count
+				if w == v {
+					break
+				}
+			}
+			count++
+		}
+	}
+
+	for _, v := range nodes {
+		if _, seen := index[v]; !seen {
+			visit(v)
+		}
+	}
+
+	return component
+}
@@ -0,0 +1,205 @@
+package washtrade
+
+import (
+	"fmt"
+	"testing"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// usdcEthPair is the supported pair with USDC as token0 and ETH as token1
+const usdcEthPair = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+// swap buys ETH with usd USDC when buy is true, otherwise sells ETH worth usd
+func swap(id string, block uint64, sender string, receiver string, buy bool, usd int64) model.Transaction {
+	tx := model.Transaction{
+		PairAddress:     usdcEthPair,
+		ID:              id,
+		BlockNum:        block,
+		SenderAddress:   sender,
+		ReceiverAddress: receiver,
+	}
+
+	usdc := constants.UsdcPrecision.Mul(decimal.NewFromInt(usd))
+	eth := constants.EthPrecision.Mul(decimal.NewFromInt(usd)).Div(constants.EthPrice)
+	if buy {
+		tx.Amount0In, tx.Amount1Out = usdc, eth
+	} else {
+		tx.Amount1In, tx.Amount0Out = eth, usdc
+	}
+
+	return tx
+}
+
+func reasons(flags []model.TradeFlag) map[string][]string {
+	result := make(map[string][]string)
+	for _, f := range flags {
+		key := f.UserAddress + "/" + f.TransactionID
+		result[key] = append(result[key], f.Reason)
+	}
+
+	return result
+}
+
+func Test_Detect(t *testing.T) {
+	tests := []struct {
+		name string
+		txs  []model.Transaction
+		want map[string][]string
+	}{
+		{
+			name: "same block round trip",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xwallet", true, 1000),
+				swap("2", 10, "0xa", "0xwallet", false, 1000),
+				// other blocks are fine
+				swap("3", 11, "0xa", "0xwallet", true, 1000),
+				swap("4", 12, "0xa", "0xwallet", false, 1000),
+			},
+			want: map[string][]string{
+				"0xa/1": {constants.FlagReasonRoundTrip},
+				"0xa/2": {constants.FlagReasonRoundTrip},
+			},
+		},
+		{
+			name: "loop between two addresses",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xb", true, 1000),
+				swap("2", 11, "0xb", "0xa", false, 1000),
+				// no way back from 0xc
+				swap("3", 12, "0xa", "0xc", true, 1000),
+			},
+			want: map[string][]string{
+				"0xa/1": {constants.FlagReasonLoop},
+				"0xb/2": {constants.FlagReasonLoop},
+			},
+		},
+		{
+			name: "tiny ping pong",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xwallet", true, 5),
+				swap("2", 11, "0xa", "0xwallet", false, 5),
+				swap("3", 12, "0xa", "0xwallet", true, 5),
+				swap("4", 13, "0xa", "0xwallet", false, 5),
+				// big trades are not tiny
+				swap("5", 14, "0xa", "0xwallet", true, 1000),
+				// not enough alternations
+				swap("6", 10, "0xb", "0xwallet", true, 5),
+				swap("7", 11, "0xb", "0xwallet", false, 5),
+				swap("8", 12, "0xb", "0xwallet", false, 5),
+			},
+			want: map[string][]string{
+				"0xa/1": {constants.FlagReasonPingPong},
+				"0xa/2": {constants.FlagReasonPingPong},
+				"0xa/3": {constants.FlagReasonPingPong},
+				"0xa/4": {constants.FlagReasonPingPong},
+			},
+		},
+		{
+			name: "self swap",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xa", true, 1000),
+				swap("2", 11, "0xa", "0xwallet", false, 1000),
+			},
+			want: map[string][]string{
+				"0xa/1": {constants.FlagReasonSelfSwap},
+			},
+		},
+		{
+			name: "single self swap",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xa", true, 1000),
+			},
+			want: map[string][]string{},
+		},
+		{
+			name: "self swap without swapping back",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xa", true, 1000),
+				swap("2", 11, "0xa", "0xwallet", true, 1000),
+				// the other direction after the window
+				swap("3", 200, "0xa", "0xwallet", false, 1000),
+			},
+			want: map[string][]string{},
+		},
+		{
+			name: "funding cluster",
+			txs: []model.Transaction{
+				swap("1", 10, "0xfunder", "0xb", true, 1000),
+				swap("2", 11, "0xfunder", "0xc", true, 1000),
+				swap("3", 12, "0xb", "0xwallet", false, 1000),
+				swap("4", 13, "0xc", "0xwallet", false, 1000),
+				// receivers that never trade do not join a cluster
+				swap("5", 14, "0xd", "0xe", true, 1000),
+			},
+			want: map[string][]string{
+				"0xb/":      {constants.FlagReasonFundingCluster},
+				"0xc/":      {constants.FlagReasonFundingCluster},
+				"0xfunder/": {constants.FlagReasonFundingCluster},
+			},
+		},
+		{
+			name: "normal trading",
+			txs: []model.Transaction{
+				swap("1", 10, "0xa", "0xwallet", true, 1000),
+				swap("2", 11, "0xb", "0xwallet", false, 1000),
+				swap("3", 12, "0xa", "0xwallet", false, 500),
+			},
+			want: map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := Detect(tt.txs, model.WashTradingConfig{Enabled: true})
+			assert.Equal(t, tt.want, reasons(flags))
+		})
+	}
+}
+
+func Test_Exclude(t *testing.T) {
+	txs := []model.Transaction{
+		swap("1", 10, "0xa", "0xwallet", true, 1000),
+		swap("2", 11, "0xa", "0xwallet", false, 1000),
+		swap("3", 12, "0xb", "0xwallet", true, 1000),
+		swap("4", 13, "0xc", "0xwallet", true, 1000),
+	}
+	flags := []model.TradeFlag{
+		{UserAddress: "0xa", TransactionID: "1", Reason: constants.FlagReasonRoundTrip},
+		{UserAddress: "0xb", Reason: constants.FlagReasonFundingCluster},
+	}
+
+	result := Exclude(txs, flags)
+
+	var ids []string
+	for _, tx := range result {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{"2", "4"}, ids)
+}
+
+func Test_Validate(t *testing.T) {
+	negative := decimal.NewFromInt(-1)
+
+	tests := []struct {
+		cfg     model.WashTradingConfig
+		wantErr bool
+	}{
+		{cfg: model.WashTradingConfig{}},
+		{cfg: model.WashTradingConfig{Enabled: true, PingPongCount: 6, ClusterSize: 5}},
+		{cfg: model.WashTradingConfig{TinyTradeUSD: &negative}, wantErr: true},
+		{cfg: model.WashTradingConfig{PingPongCount: 1}, wantErr: true},
+		{cfg: model.WashTradingConfig{ClusterSize: -1}, wantErr: true},
+		{cfg: model.WashTradingConfig{SelfSwapBlocks: -1}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			err := Validate(tt.cfg)
+			assert.Equal(t, tt.wantErr, err != nil, "err: %v", err)
+		})
+	}
+}
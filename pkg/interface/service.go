@@ -2,32 +2,166 @@ package iface
 
 import (
 	"context"
+	"database/sql"
+	"math/big"
 	"time"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/shopspring/decimal"
 )
 
 type TaskManager interface {
 	GetOnboardingTask(ctx context.Context) (model.Task, error)
+	GetReferralTask(ctx context.Context) (model.Task, error)
 	GetSharePoolTask(ctx context.Context) ([]model.Task, error)
-	CreateSharePoolTask(ctx context.Context, pairAddress string, startAt time.Time) error
+	GetTask(ctx context.Context, taskID string) (model.Task, error)
+	GetTasks(ctx context.Context) ([]model.Task, error)
+	CreateSharePoolTask(ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig) error
+	GetStreakTasks(ctx context.Context) ([]model.Task, error)
+	CreateStreakTask(ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig) error
+	GetLPTasks(ctx context.Context) ([]model.Task, error)
+	CreateLPTask(ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig) error
+	CreateRuleVersion(ctx context.Context, taskID string, config model.TaskConfig, effectiveAt time.Time, note string) (model.TaskRuleVersion, error)
+	GetRuleVersions(ctx context.Context, taskID string) ([]model.TaskRuleVersion, error)
 }
 
 type UserTaskManager interface {
 	CheckOnboardingTask(ctx context.Context, address string) error
 	CheckSharePoolTasks(ctx context.Context) error
+	CheckStreakTasks(ctx context.Context) error
+	CheckLPTasks(ctx context.Context) error
 	Upsert(ctx context.Context, address string, taskId string, state string, amount decimal.Decimal) error
 	GetUserTasks(ctx context.Context, address string) ([]option.GetUserTaskPoint, error)
+	SettleTask(ctx context.Context, task model.Task, opt option.SettleTaskOptions) error
+	DiffSharePoolTask(ctx context.Context, task model.Task) (model.SettlementDiff, error)
+	Recalculate(ctx context.Context, task model.Task, opt option.RecalculateOptions) (model.Recalculation, error)
 }
 
 type TransactionManager interface {
 	Upsert(ctx context.Context, opt option.TransactionUpsertOptions) error
-	GetUserUSDC(ctx context.Context, address string) (decimal.Decimal, error)
+	GetUserSwaps(ctx context.Context, opt option.GetUserSwapsOptions) ([]model.Transaction, error)
+	MarkSandwiches(ctx context.Context, pairAddress string, fromBlock uint64, toBlock uint64) (int, error)
 }
 
 type UserPointManager interface {
-	UpsertForUserTask(ctx context.Context, address string, taskId string, point int) error
+	UpsertForUserTask(ctx context.Context, address string, taskId string, point decimal.Decimal) error
+	SetEpochPoints(ctx context.Context, opt option.SetEpochPointsOptions) error
+	SetEpochPointsTx(ctx context.Context, tx *sql.Tx, opt option.SetEpochPointsOptions) error
 	GetUserPointsForTask(ctx context.Context, taskID string) ([]model.UserPoint, error)
+	GetUserPointsForCampaign(ctx context.Context, campaignID string) ([]model.UserPoint, error)
+	GetLedger(ctx context.Context, address string, taskID string) ([]model.PointLedgerEntry, error)
+}
+
+type TradeFlagManager interface {
+	Record(ctx context.Context, flags []model.TradeFlag) error
+	GetFlags(ctx context.Context, address string) ([]model.TradeFlag, error)
+}
+
+type AddressInfoManager interface {
+	EnsureAddressInfo(ctx context.Context, address string, reader CodeReader) (model.AddressInfo, error)
+	GetIneligible(ctx context.Context, addresses []string, allowContracts bool) (map[string]string, error)
+	SetListEntry(ctx context.Context, address string, list string, label string) error
+	RemoveListEntry(ctx context.Context, address string) error
+	GetList(ctx context.Context, list string) ([]model.AddressListEntry, error)
+}
+
+type ReferralManager interface {
+	Register(ctx context.Context, opt option.RegisterReferralOptions) error
+	CheckReferee(ctx context.Context, referee string) error
+	GetStats(ctx context.Context, address string) (model.ReferralStats, error)
+}
+
+type LiquidityManager interface {
+	InsertEvent(ctx context.Context, opt option.LiquidityEventUpsertOptions) error
+	InsertTransfer(ctx context.Context, opt option.LPTransferUpsertOptions) error
+	GetTransfers(ctx context.Context, pairAddress string, endAt time.Time) ([]model.LPTransfer, error)
+}
+
+type MultiplierManager interface {
+	CreateRule(ctx context.Context, rule model.MultiplierRule) (model.MultiplierRule, error)
+	GetRules(ctx context.Context) ([]model.MultiplierRule, error)
+	DeleteRule(ctx context.Context, ruleID string) error
+}
+
+type CampaignManager interface {
+	CreateCampaign(ctx context.Context, campaign model.Campaign) (model.Campaign, error)
+	GetCampaign(ctx context.Context, campaignID string) (model.Campaign, error)
+	GetCampaigns(ctx context.Context) ([]model.Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign model.Campaign) error
+	DeleteCampaign(ctx context.Context, campaignID string) error
+	AttachTask(ctx context.Context, campaignID string, taskID string) error
+	DetachTask(ctx context.Context, campaignID string, taskID string) error
+	GetSpent(ctx context.Context, campaignID string, excludeTaskID string) (decimal.Decimal, error)
+	GetSpentTx(ctx context.Context, tx *sql.Tx, campaignID string, excludeTaskID string) (decimal.Decimal, error)
+}
+
+type LeaderboardManager interface {
+	GetLeaderboard(ctx context.Context, opt option.LeaderboardOptions) (model.Leaderboard, error)
+	GetRank(ctx context.Context, opt option.LeaderboardOptions, address string) (model.LeaderboardEntry, error)
+	EachRank(ctx context.Context, opt option.LeaderboardOptions, fn func(model.LeaderboardEntry) error) error
+}
+
+type IngestionManager interface {
+	SetCursor(ctx context.Context, taskID string, pairAddress string, blockNum uint64, blockAt time.Time) error
+	GetCursor(ctx context.Context, taskID string) (model.IngestionCursor, error)
+}
+
+type ScheduleManager interface {
+	StartRun(ctx context.Context, taskID string, epoch int, attempt int) (string, error)
+	FinishRun(ctx context.Context, runID string, runErr error) error
+	GetRuns(ctx context.Context, taskID string) ([]model.ScheduleRun, error)
+	GetLatestRuns(ctx context.Context, taskID string) (map[int]model.ScheduleRun, error)
+}
+
+type RecalculationManager interface {
+	RecordTx(ctx context.Context, tx *sql.Tx, rec model.Recalculation) error
+	GetRecalculations(ctx context.Context, taskID string) ([]model.Recalculation, error)
+	GetRecalculation(ctx context.Context, recalculationID string) (model.Recalculation, error)
+}
+
+type SettlementManager interface {
+	StartRuns(ctx context.Context, taskID string, epochs []int, force bool) ([]model.SettlementRun, error)
+	SetState(ctx context.Context, runs []model.SettlementRun, state string) error
+	Fail(ctx context.Context, runs []model.SettlementRun, runErr error) error
+	Commit(ctx context.Context, runs []model.SettlementRun, write func(tx *sql.Tx) error) error
+	Finalize(ctx context.Context, taskID string, epoch int) (model.SettlementRun, error)
+	GetRuns(ctx context.Context, taskID string) ([]model.SettlementRun, error)
+}
+
+// CodeReader reads the code of an account, *ethclient.Client implements it
+type CodeReader interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+type ClaimManager interface {
+	CreateSnapshot(ctx context.Context, campaignID string, decimals int32) (model.ClaimSnapshot, error)
+	GetSnapshot(ctx context.Context, campaignID string) (model.ClaimSnapshot, error)
+	GetClaims(ctx context.Context, address string) ([]model.Claim, error)
+	GetClaim(ctx context.Context, campaignID string, address string) (model.Claim, error)
+	GetSnapshotClaims(ctx context.Context, campaignID string) ([]model.Claim, error)
+}
+
+type VoucherManager interface {
+	IssueVoucher(ctx context.Context, campaignID string, address string) (model.Voucher, error)
+	GetVouchers(ctx context.Context, address string) ([]model.Voucher, error)
+	Redeem(ctx context.Context, voucherID string, txHash string) (model.Voucher, error)
+}
+
+type AllocationManager interface {
+	CreatePlan(ctx context.Context, campaignID string, budget decimal.Decimal, vesting model.VestingConfig) (model.AllocationPlan, error)
+	GetPlan(ctx context.Context, campaignID string) (model.AllocationPlan, error)
+	GetAllocations(ctx context.Context, address string) ([]model.Allocation, error)
+	GetPlanAllocations(ctx context.Context, campaignID string) ([]model.Allocation, error)
+}
+
+// RowWriter receives the rows of an export, *export.Writer implements it
+type RowWriter interface {
+	Header(columns ...string) error
+	Write(values ...interface{}) error
+}
+
+type ExportManager interface {
+	Export(ctx context.Context, opt option.ExportOptions, w RowWriter) error
 }
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TaskConfig holds the per-task settings stored in the "config" column of "task".
+type TaskConfig struct {
+	Epoch EpochConfig `json:"epoch"`
+	// decimal places of distributed points, 0 means whole points
+	PointPrecision int32 `json:"pointPrecision,omitempty"`
+	// points distributed in every epoch of share pool and LP provider tasks,
+	// 10,000 per week scaled by the length of the epochs when unset
+	EpochPoints  *decimal.Decimal   `json:"epochPoints,omitempty"`
+	Distribution DistributionConfig `json:"distribution"`
+	// how the USD volume of a user is measured: input, output, max, usdc or net, default input
+	VolumeMode  string            `json:"volumeMode,omitempty"`
+	WashTrading WashTradingConfig `json:"washTrading"`
+	// contract accounts are excluded unless allowed, allowlisted addresses always take part
+	AllowContracts bool `json:"allowContracts,omitempty"`
+	// front-run and back-run swaps of sandwiches earn no volume, the victims still count
+	ExcludeMev bool           `json:"excludeMev,omitempty"`
+	Activity   ActivityConfig `json:"activity"`
+	// milestones of streak tasks, ignored by other tasks
+	Streak StreakConfig `json:"streak"`
+	// rewards of the referral task, ignored by other tasks
+	Referral ReferralConfig `json:"referral"`
+	// criteria of the onboarding task, ignored by other tasks
+	Onboarding OnboardingConfig `json:"onboarding"`
+	// tasks to complete before taking part in share pool, streak and LP provider tasks, onboarding when empty
+	Prerequisites PrerequisiteConfig `json:"prerequisites"`
+}
+
+// PrerequisiteConfig lists the tasks a user has to complete to unlock a task.
+// Mode is all (every task, default) or any (one of them). Timing is any_time (default) or
+// before_epoch, where only tasks completed before the epoch starts unlock the epoch.
+type PrerequisiteConfig struct {
+	TaskIDs []string `json:"taskIds,omitempty"`
+	Mode    string   `json:"mode,omitempty"`
+	Timing  string   `json:"timing,omitempty"`
+}
+
+// EpochConfig defines how a task is split into epochs.
+// Epochs are `Length` units long and start at the task's startAt, evaluated in `Timezone`.
+// The schedule ends after `Count` epochs or at `EndAt`, whichever is configured.
+type EpochConfig struct {
+	Unit     string     `json:"unit,omitempty"` // hour, day or week
+	Length   int        `json:"length,omitempty"`
+	Count    int        `json:"count,omitempty"`
+	EndAt    *time.Time `json:"endAt,omitempty"`
+	Timezone string     `json:"timezone,omitempty"`
+}
+
+// DistributionConfig selects how the points of an epoch are split between users.
+type DistributionConfig struct {
+	Strategy string       `json:"strategy,omitempty"` // linear, sqrt or tiered
+	Tiers    []TierConfig `json:"tiers,omitempty"`
+	// MaxShare caps the share of the epoch pool a single user can get, e.g. 0.1 for 10%.
+	// The excess is redistributed to the other users.
+	MaxShare *decimal.Decimal `json:"maxShare,omitempty"`
+}
+
+// TierConfig weights the USD volume above From (up to the next tier) by Rate.
+type TierConfig struct {
+	From decimal.Decimal `json:"from"`
+	Rate decimal.Decimal `json:"rate"`
+}
+
+// WashTradingConfig enables wash-trading detection in settlement, flagged volume earns no points.
+type WashTradingConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// swaps below this USD value count as tiny, default 10
+	TinyTradeUSD *decimal.Decimal `json:"tinyTradeUsd,omitempty"`
+	// number of alternating tiny swaps that make a ping-pong, default 4
+	PingPongCount int `json:"pingPongCount,omitempty"`
+	// number of addresses funded together that make a cluster, default 3
+	ClusterSize int `json:"clusterSize,omitempty"`
+	// blocks around a swap to the sender itself within which swapping the other way flags it, default 100
+	SelfSwapBlocks int `json:"selfSwapBlocks,omitempty"`
+}
+
+// ActivityConfig is the minimum activity of a user in an epoch to earn points of the epoch.
+// The share of a single user is capped by DistributionConfig.MaxShare.
+type ActivityConfig struct {
+	MinVolumeUSD  *decimal.Decimal `json:"minVolumeUsd,omitempty"`
+	MinTradeCount int              `json:"minTradeCount,omitempty"`
+}
+
+// StreakConfig grants points when the streak of a user reaches the length of a milestone.
+// A streak is the number of consecutive epochs the user traded in, with the minimum activity of the task.
+type StreakConfig struct {
+	Milestones []StreakMilestone `json:"milestones"`
+}
+
+// StreakMilestone grants Points once when the streak reaches Length epochs for the first time.
+type StreakMilestone struct {
+	Length int             `json:"length"`
+	Points decimal.Decimal `json:"points"`
+}
+
+// ReferralConfig defines the points a referrer earns from every referee.
+type ReferralConfig struct {
+	// points when the referee completes onboarding, default 50
+	OnboardingPoints *decimal.Decimal `json:"onboardingPoints,omitempty"`
+	// points of every tier the USD volume of the referee reaches after registration
+	Tiers []ReferralTier `json:"tiers,omitempty"`
+}
+
+type ReferralTier struct {
+	VolumeUSD decimal.Decimal `json:"volumeUsd"`
+	Points    decimal.Decimal `json:"points"`
+}
+
+// OnboardingConfig defines the USD volume a user has to trade to complete onboarding.
+// Only swaps from the task's startAt (until EndAt) on the listed pairs are counted.
+type OnboardingConfig struct {
+	// pair addresses, default every supported pair
+	Pairs []string `json:"pairs,omitempty"`
+	// symbols of the tokens paid in that count, default every token of the pair
+	Tokens []string `json:"tokens,omitempty"`
+	// USD volume to complete the task, default 1000
+	ThresholdUSD *decimal.Decimal `json:"thresholdUsd,omitempty"`
+	EndAt        *time.Time       `json:"endAt,omitempty"`
+}
+
+func (c TaskConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *TaskConfig) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = TaskConfig{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("unsupported task config type: %T", src)
+	}
+}
+
+// VestingConfig unlocks Tge, a share of the allocation, at Start. The rest vests monthly over VestingMonths from Start,
+// nothing of it unlocks before CliffMonths, when what has vested so far unlocks at once.
+type VestingConfig struct {
+	Start         time.Time       `json:"start"`
+	Tge           decimal.Decimal `json:"tge"`
+	CliffMonths   int             `json:"cliffMonths"`
+	VestingMonths int             `json:"vestingMonths"`
+}
+
+func (c VestingConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *VestingConfig) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("unsupported vesting config type: %T", src)
+	}
+}
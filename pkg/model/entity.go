@@ -14,14 +14,63 @@ type UserTask struct {
 	TaskID      string          `json:"taskId"`
 	State       string          `json:"state"`
 	Amount      decimal.Decimal `json:"amount"` // unit: usd
+	// why the user is ineligible, empty otherwise
+	Reason string `json:"reason,omitempty"`
+	// epochs in a row of streak tasks
+	CurrentStreak int `json:"currentStreak,omitempty"`
+	LongestStreak int `json:"longestStreak,omitempty"`
+	// when the task was completed: the time of the swap or the end of the epoch that completed it
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// StreakProgress is the streak of a user in a streak task.
+// NextMilestone is the length of the next milestone to reach, 0 when all are reached.
+type StreakProgress struct {
+	Current       int `json:"current"`
+	Longest       int `json:"longest"`
+	NextMilestone int `json:"nextMilestone"`
 }
 
 type UserPoint struct {
-	ID          string    `json:"id"`
-	UserAddress string    `json:"userAddress"`
-	CreatedAt   time.Time `json:"createdAt"`
-	TaskID      string    `json:"taskId"`
-	Point       int       `json:"point"`
+	ID          string           `json:"id"`
+	UserAddress string           `json:"userAddress"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	TaskID      string           `json:"taskId"`
+	CampaignID  string           `json:"campaignId,omitempty"`
+	Point       decimal.Decimal  `json:"point"`
+	Epochs      []UserPointEpoch `json:"epochs,omitempty"`
+}
+
+type UserPointEpoch struct {
+	Epoch int             `json:"epoch"`
+	Point decimal.Decimal `json:"point"`
+}
+
+type PointLedgerEntry struct {
+	ID              string          `json:"id"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UserAddress     string          `json:"userAddress"`
+	TaskID          string          `json:"taskId"`
+	Epoch           int             `json:"epoch"`
+	Point           decimal.Decimal `json:"point"`
+	Reason          string          `json:"reason"`
+	SettlementRunID sql.NullString  `json:"settlementRunId"`
+	// product of the multiplier rules applied to the award, 1 without any
+	Multiplier decimal.Decimal `json:"multiplier"`
+}
+
+// MultiplierRule boosts the points awarded in its window [StartAt, EndAt), empty fields match every award.
+// Addresses limit the rule to its members, TaskID and PairAddress to the awards of a task or pair.
+type MultiplierRule struct {
+	ID          string          `json:"id"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Name        string          `json:"name"`
+	Multiplier  decimal.Decimal `json:"multiplier"`
+	StartAt     *time.Time      `json:"startAt,omitempty"`
+	EndAt       *time.Time      `json:"endAt,omitempty"`
+	TaskID      string          `json:"taskId,omitempty"`
+	PairAddress string          `json:"pairAddress,omitempty"`
+	Addresses   []string        `json:"addresses,omitempty"`
 }
 
 type Task struct {
@@ -30,6 +79,256 @@ type Task struct {
 	Name        sql.NullString `json:"name"`
 	PairAddress sql.NullString `json:"pairAddress"`
 	StartAt     time.Time      `json:"startAt"`
+	Config      TaskConfig     `json:"config"`
+	CampaignID  sql.NullString `json:"campaignId"`
+}
+
+// TaskRuleVersion is a version of the rules of a task, it applies to the epochs starting at or after EffectiveAt.
+type TaskRuleVersion struct {
+	ID          string     `json:"id"`
+	TaskID      string     `json:"taskId"`
+	Version     int        `json:"version"`
+	Config      TaskConfig `json:"config"`
+	EffectiveAt time.Time  `json:"effectiveAt"`
+	Note        string     `json:"note,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// Recalculation is a replay of settled epochs of a task, under RuleVersion or the versions in effect when it is nil.
+type Recalculation struct {
+	ID          string               `json:"id"`
+	TaskID      string               `json:"taskId"`
+	RuleVersion *int                 `json:"ruleVersion,omitempty"`
+	Epochs      []int                `json:"epochs"`
+	Reason      string               `json:"reason"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	Entries     []RecalculationEntry `json:"entries"`
+}
+
+// RecalculationEntry is the total points of a user over the recalculated epochs before and after the recalculation.
+type RecalculationEntry struct {
+	UserAddress string          `json:"userAddress"`
+	Before      decimal.Decimal `json:"before"`
+	After       decimal.Decimal `json:"after"`
+}
+
+// Campaign is a season bundling tasks, Budget caps the points settled for its tasks, unlimited when nil.
+type Campaign struct {
+	ID        string           `json:"id"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Name      string           `json:"name"`
+	StartAt   time.Time        `json:"startAt"`
+	EndAt     time.Time        `json:"endAt"`
+	Budget    *decimal.Decimal `json:"budget,omitempty"`
+	TaskIDs   []string         `json:"taskIds"`
+}
+
+// ClaimSnapshot freezes the points of a campaign once it ends, Root is the Merkle root of the (address, amount) claims.
+// Amounts are in the smallest unit of a token with Decimals, one token per point.
+type ClaimSnapshot struct {
+	ID          string          `json:"id"`
+	CampaignID  string          `json:"campaignId"`
+	Root        string          `json:"root"`
+	Decimals    int32           `json:"decimals"`
+	Users       int             `json:"users"`
+	TotalPoint  decimal.Decimal `json:"totalPoint"`
+	TotalAmount decimal.Decimal `json:"totalAmount"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// Claim is the amount a user can claim from the snapshot of a campaign, with the proof of its leaf.
+type Claim struct {
+	SnapshotID  string          `json:"snapshotId"`
+	CampaignID  string          `json:"campaignId"`
+	Root        string          `json:"root"`
+	UserAddress string          `json:"userAddress"`
+	Point       decimal.Decimal `json:"point"`
+	Amount      decimal.Decimal `json:"amount"`
+	Proof       []string        `json:"proof"`
+}
+
+// Voucher is an EIP-712 signature letting a user claim the amount of a campaign until Deadline.
+type Voucher struct {
+	ID          string          `json:"id"`
+	CampaignID  string          `json:"campaignId"`
+	UserAddress string          `json:"userAddress"`
+	Amount      decimal.Decimal `json:"amount"`
+	Nonce       uint64          `json:"nonce"`
+	Deadline    time.Time       `json:"deadline"`
+	Signer      string          `json:"signer"`
+	Signature   string          `json:"signature"`
+	State       string          `json:"state"`
+	TxHash      string          `json:"txHash,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	RedeemedAt  *time.Time      `json:"redeemedAt,omitempty"`
+}
+
+// AllocationPlan splits Budget tokens across the users of the snapshot of a campaign by their points.
+// The allocated amounts are in the smallest unit of the token, with the decimals of the snapshot.
+type AllocationPlan struct {
+	ID         string          `json:"id"`
+	CampaignID string          `json:"campaignId"`
+	SnapshotID string          `json:"snapshotId"`
+	Budget     decimal.Decimal `json:"budget"`
+	Decimals   int32           `json:"decimals"`
+	Vesting    VestingConfig   `json:"vesting"`
+	Users      int             `json:"users"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type Allocation struct {
+	PlanID      string          `json:"planId"`
+	CampaignID  string          `json:"campaignId"`
+	UserAddress string          `json:"userAddress"`
+	Point       decimal.Decimal `json:"point"`
+	Amount      decimal.Decimal `json:"amount"`
+	Unlocks     []Unlock        `json:"unlocks"`
+}
+
+// Unlock is the amount of an allocation unlocking at At, Cumulative is what has unlocked by then.
+type Unlock struct {
+	At         time.Time       `json:"at"`
+	Amount     decimal.Decimal `json:"amount"`
+	Cumulative decimal.Decimal `json:"cumulative"`
+}
+
+// LeaderboardEntry is the standing of a user, users with the same points share the same dense rank.
+// Percentile is the share of ranked users with fewer points, the leaders are at 100.
+type LeaderboardEntry struct {
+	Rank        int             `json:"rank"`
+	Percentile  decimal.Decimal `json:"percentile"`
+	UserAddress string          `json:"userAddress"`
+	Point       decimal.Decimal `json:"point"`
+	Volume      decimal.Decimal `json:"volume"` // unit: usd
+}
+
+type Leaderboard struct {
+	// number of ranked users
+	Total   int                `json:"total"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// LiquidityEvent is a Mint or Burn event of a pair, ToAddress is only set for burns.
+type LiquidityEvent struct {
+	ID            string          `json:"id"`
+	BlockNum      uint64          `json:"blockNum"`
+	LogIndex      uint            `json:"logIndex"`
+	TxHash        string          `json:"txHash"`
+	PairAddress   string          `json:"pairAddress"`
+	Kind          string          `json:"kind"`
+	SenderAddress string          `json:"senderAddress"`
+	ToAddress     string          `json:"toAddress"`
+	Amount0       decimal.Decimal `json:"amount0"`
+	Amount1       decimal.Decimal `json:"amount1"`
+	EventAt       time.Time       `json:"eventAt"`
+}
+
+// LPTransfer is a Transfer event of the LP token of a pair, mints are from and burns are to the zero address.
+type LPTransfer struct {
+	ID          string          `json:"id"`
+	BlockNum    uint64          `json:"blockNum"`
+	LogIndex    uint            `json:"logIndex"`
+	TxHash      string          `json:"txHash"`
+	PairAddress string          `json:"pairAddress"`
+	FromAddress string          `json:"fromAddress"`
+	ToAddress   string          `json:"toAddress"`
+	Value       decimal.Decimal `json:"value"`
+	TransferAt  time.Time       `json:"transferAt"`
+}
+
+// Referral is the referrer registered by a referee, with what the referee has earned for the referrer so far.
+type Referral struct {
+	ID              string          `json:"id"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	RefereeAddress  string          `json:"refereeAddress"`
+	ReferrerAddress string          `json:"referrerAddress"`
+	Signature       string          `json:"signature"`
+	Onboarded       bool            `json:"onboarded"`
+	VolumeUSD       decimal.Decimal `json:"volumeUsd"`
+	Point           decimal.Decimal `json:"point"`
+}
+
+// ReferralStats sums up the referrals of a user, Referrer is who referred the user.
+type ReferralStats struct {
+	Address           string          `json:"address"`
+	Referrer          string          `json:"referrer,omitempty"`
+	Referees          int             `json:"referees"`
+	OnboardedReferees int             `json:"onboardedReferees"`
+	VolumeUSD         decimal.Decimal `json:"volumeUsd"`
+	Point             decimal.Decimal `json:"point"`
+}
+
+// IngestionCursor is the latest block of the pair of a task the listener has ingested for the task.
+type IngestionCursor struct {
+	TaskID      string    `json:"taskId"`
+	PairAddress string    `json:"pairAddress"`
+	BlockNum    uint64    `json:"blockNum"`
+	BlockAt     time.Time `json:"blockAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ScheduleRun is one attempt of the scheduler to settle an epoch of a task.
+type ScheduleRun struct {
+	ID         string     `json:"id"`
+	TaskID     string     `json:"taskId"`
+	Epoch      int        `json:"epoch"`
+	Attempt    int        `json:"attempt"`
+	State      string     `json:"state"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// SettlementRun settles one epoch of a task, its points are written in one transaction when it is committed.
+type SettlementRun struct {
+	ID          string     `json:"id"`
+	TaskID      string     `json:"taskId"`
+	Epoch       int        `json:"epoch"`
+	State       string     `json:"state"`
+	Forced      bool       `json:"forced"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CommittedAt *time.Time `json:"committedAt,omitempty"`
+	FinalizedAt *time.Time `json:"finalizedAt,omitempty"`
+}
+
+// UserSettlement is the state, amount and total points of a user in a task.
+type UserSettlement struct {
+	UserAddress string          `json:"userAddress"`
+	State       string          `json:"state"`
+	Amount      decimal.Decimal `json:"amount"`
+	Point       decimal.Decimal `json:"point"`
+}
+
+// SettlementDiff compares a computed settlement of a task with its current user tasks and points, unchanged users are left out.
+type SettlementDiff struct {
+	TaskID       string               `json:"taskId"`
+	Added        int                  `json:"added"`
+	Changed      int                  `json:"changed"`
+	Removed      int                  `json:"removed"`
+	Unchanged    int                  `json:"unchanged"`
+	CurrentTotal decimal.Decimal      `json:"currentTotal"`
+	NewTotal     decimal.Decimal      `json:"newTotal"`
+	Users        []UserSettlementDiff `json:"users"`
+}
+
+// UserSettlementDiff is the change of one user, Change is added, changed or removed.
+type UserSettlementDiff struct {
+	UserAddress string          `json:"userAddress"`
+	Change      string          `json:"change"`
+	State       string          `json:"state"`
+	NewState    string          `json:"newState"`
+	Amount      decimal.Decimal `json:"amount"`
+	NewAmount   decimal.Decimal `json:"newAmount"`
+	Point       decimal.Decimal `json:"point"`
+	NewPoint    decimal.Decimal `json:"newPoint"`
+}
+
+type Epoch struct {
+	Index   int       `json:"index"`
+	StartAt time.Time `json:"startAt"`
+	EndAt   time.Time `json:"endAt"`
 }
 
 type Transaction struct {
@@ -44,4 +343,31 @@ type Transaction struct {
 	Amount1Out      decimal.Decimal `json:"amount1Out"`
 	ReceiverAddress string          `json:"receiverAddress"`
 	TransactionAt   time.Time       `json:"transactionAt"`
+	TxHash          string          `json:"txHash"`
+	LogIndex        uint            `json:"logIndex"`
+	MevRole         string          `json:"mevRole"`
+}
+
+// TradeFlag marks a suspicious swap, or the whole address when TransactionID is empty.
+type TradeFlag struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UserAddress   string    `json:"userAddress"`
+	TransactionID string    `json:"transactionId"`
+	Reason        string    `json:"reason"`
+	Detail        string    `json:"detail"`
+}
+
+// AddressInfo caches whether an address has contract code
+type AddressInfo struct {
+	Address    string    `json:"address"`
+	IsContract bool      `json:"isContract"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+type AddressListEntry struct {
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+	List      string    `json:"list"` // deny or allow
+	Label     string    `json:"label"`
 }
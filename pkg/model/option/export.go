@@ -0,0 +1,13 @@
+package option
+
+import "time"
+
+// ExportOptions selects the rows of a dataset, of one task or of the tasks of a campaign when set.
+// From is inclusive and To exclusive, both are optional.
+type ExportOptions struct {
+	Dataset    string
+	TaskID     string
+	CampaignID string
+	From       *time.Time
+	To         *time.Time
+}
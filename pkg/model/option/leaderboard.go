@@ -0,0 +1,11 @@
+package option
+
+// LeaderboardOptions selects the scope of a leaderboard, all tasks when TaskID and CampaignID are empty.
+// Epoch narrows a task leaderboard down to one of its epochs.
+type LeaderboardOptions struct {
+	TaskID     string
+	Epoch      *int
+	CampaignID string
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,31 @@
+package option
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type LiquidityEventUpsertOptions struct {
+	BlockNum      uint64
+	LogIndex      uint
+	TxHash        string
+	PairAddress   string
+	Kind          string
+	SenderAddress string
+	ToAddress     string
+	Amount0       decimal.Decimal
+	Amount1       decimal.Decimal
+	EventAt       time.Time
+}
+
+type LPTransferUpsertOptions struct {
+	BlockNum    uint64
+	LogIndex    uint
+	TxHash      string
+	PairAddress string
+	FromAddress string
+	ToAddress   string
+	Value       decimal.Decimal
+	TransferAt  time.Time
+}
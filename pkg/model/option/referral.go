@@ -0,0 +1,8 @@
+package option
+
+// RegisterReferralOptions registers the referrer of the referee, Signature is the referee's personal_sign of the referral message.
+type RegisterReferralOptions struct {
+	Referee   string
+	Referrer  string
+	Signature string
+}
@@ -16,4 +16,14 @@ type TransactionUpsertOptions struct {
 	Amount1Out      decimal.Decimal
 	ReceiverAddress string
 	TransactionAt   time.Time
+	TxHash          string
+	LogIndex        uint
+}
+
+// GetUserSwapsOptions selects the swaps of a user on the pairs from StartAt, EndAt is exclusive and optional.
+type GetUserSwapsOptions struct {
+	Address string
+	Pairs   []string
+	StartAt time.Time
+	EndAt   *time.Time
 }
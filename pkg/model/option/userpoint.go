@@ -0,0 +1,18 @@
+package option
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// SetEpochPointsOptions sets the total points of a user for one epoch of a task.
+// The difference to the current ledger total is appended as a new entry.
+type SetEpochPointsOptions struct {
+	Address         string
+	TaskID          string
+	Epoch           int
+	Point           decimal.Decimal
+	Reason          string
+	SettlementRunID string
+	// applied multiplier recorded on the entry, zero is recorded as 1
+	Multiplier decimal.Decimal
+}
@@ -2,6 +2,7 @@ package option
 
 import (
 	"time"
+	"tradingAce/pkg/model"
 
 	"github.com/shopspring/decimal"
 )
@@ -12,8 +13,35 @@ type GetUserTaskPoint struct {
 	UserAddress string          `json:"userAddress"`
 	TaskID      string          `json:"taskId"`
 	State       string          `json:"state"`
+	Reason      string          `json:"reason,omitempty"`
 	Amount      decimal.Decimal `json:"amount"`
-	Point       int             `json:"point"`
+	Point       decimal.Decimal `json:"point"`
 	TaskName    string          `json:"taskName,omitempty"`
 	PairAddress string          `json:"pairAddress,omitempty"`
+	// only for streak tasks
+	Streak *model.StreakProgress `json:"streak,omitempty"`
+	// only for tasks with prerequisites, tasks the user has not started are in the state locked or unlocked
+	Locked        *bool    `json:"locked,omitempty"`
+	Prerequisites []string `json:"prerequisites,omitempty"`
+}
+
+// SettleTaskOptions settles the epochs of a task that ended by Until, by now when it is zero.
+// Committed epochs are settled again only with Force.
+type SettleTaskOptions struct {
+	Until time.Time
+	Force bool
+	// only these epochs are settled, every ended epoch when empty
+	Epochs []int
+	// rules of the settled epochs instead of the rule versions in effect when they start
+	Config *model.TaskConfig
+	// set to record the points of every user before and after the settlement, in the same transaction
+	Recalculation *model.Recalculation
+}
+
+// RecalculateOptions replays the settlement of Epochs, every ended epoch when empty,
+// under RuleVersion or the rule versions in effect when it is 0.
+type RecalculateOptions struct {
+	Epochs      []int
+	RuleVersion int
+	Reason      string
 }
@@ -0,0 +1,149 @@
+package addressinfo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/constants"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// EnsureAddressInfo returns the cached info of the address, the code is read by eth_getCode only the first time.
+func (m *Manager) EnsureAddressInfo(ctx context.Context, address string, reader iface.CodeReader) (model.AddressInfo, error) {
+	info := model.AddressInfo{Address: address}
+
+	err := m.db.QueryRowContext(ctx, `
+		SELECT "isContract", "checkedAt" FROM "addressInfo" WHERE "address" = $1
+	`, address).Scan(&info.IsContract, &info.CheckedAt)
+	if err == nil {
+		return info, nil
+	}
+	if err != sql.ErrNoRows {
+		return info, fmt.Errorf("EnsureAddressInfo query fail: %v", err)
+	}
+
+	code, err := reader.CodeAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return info, fmt.Errorf("EnsureAddressInfo get code fail: %v", err)
+	}
+	info.IsContract = len(code) != 0
+	info.CheckedAt = time.Now()
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "addressInfo" ("address", "isContract", "checkedAt")
+		VALUES ($1, $2, $3)
+		ON CONFLICT ("address") DO NOTHING
+	`, info.Address, info.IsContract, info.CheckedAt); err != nil {
+		return info, fmt.Errorf("EnsureAddressInfo insert fail: %v", err)
+	}
+
+	return info, nil
+}
+
+// GetIneligible returns the addresses that can not take part in a task with their reason.
+// Allowlisted addresses are always eligible, denylisted ones never, contracts only when allowContracts.
+// Addresses whose code was never checked are treated as externally owned accounts.
+func (m *Manager) GetIneligible(ctx context.Context, addresses []string, allowContracts bool) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(addresses) == 0 {
+		return result, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT a."address", l."list", COALESCE(i."isContract", FALSE)
+		FROM UNNEST($1::VARCHAR[]) AS a("address")
+		LEFT JOIN "addressList" l ON l."address" = a."address"
+		LEFT JOIN "addressInfo" i ON i."address" = a."address"
+	`, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("GetIneligible query fail: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var list sql.NullString
+		var isContract bool
+		if err := rows.Scan(&address, &list, &isContract); err != nil {
+			return nil, fmt.Errorf("GetIneligible scan fail: %v", err)
+		}
+
+		switch {
+		case list.String == constants.AddressListAllow:
+		case list.String == constants.AddressListDeny:
+			result[address] = constants.IneligibleDenylist
+		case isContract && !allowContracts:
+			result[address] = constants.IneligibleContract
+		}
+	}
+
+	return result, rows.Err()
+}
+
+func (m *Manager) SetListEntry(ctx context.Context, address string, list string, label string) error {
+	if list != constants.AddressListDeny && list != constants.AddressListAllow {
+		return fmt.Errorf("unsupported address list: %s", list)
+	}
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "addressList" ("address", "createdAt", "list", "label")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("address")
+		DO UPDATE SET "list" = EXCLUDED."list", "label" = EXCLUDED."label"
+	`, common.HexToAddress(address).Hex(), time.Now(), list, label); err != nil {
+		return fmt.Errorf("SetListEntry fail: %v", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) RemoveListEntry(ctx context.Context, address string) error {
+	if _, err := m.db.ExecContext(ctx, `
+		DELETE FROM "addressList" WHERE "address" = $1
+	`, common.HexToAddress(address).Hex()); err != nil {
+		return fmt.Errorf("RemoveListEntry fail: %v", err)
+	}
+
+	return nil
+}
+
+// GetList returns the entries of the list, or of both lists when list is empty.
+func (m *Manager) GetList(ctx context.Context, list string) ([]model.AddressListEntry, error) {
+	query := `SELECT "address", "createdAt", "list", "label" FROM "addressList"`
+
+	var args []interface{}
+	if len(list) != 0 {
+		query += ` WHERE "list" = $1`
+		args = append(args, list)
+	}
+	query += ` ORDER BY "createdAt", "address"`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetList query fail: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.AddressListEntry, 0)
+	for rows.Next() {
+		var entry model.AddressListEntry
+		if err := rows.Scan(&entry.Address, &entry.CreatedAt, &entry.List, &entry.Label); err != nil {
+			return nil, fmt.Errorf("GetList scan fail: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
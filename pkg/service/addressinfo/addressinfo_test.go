@@ -0,0 +1,166 @@
+package addressinfo
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/constants"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type codeReader struct {
+	contracts map[common.Address]bool
+	calls     int
+}
+
+func (r *codeReader) CodeAt(_ context.Context, account common.Address, _ *big.Int) ([]byte, error) {
+	r.calls++
+	if r.contracts[account] {
+		return []byte{0x60, 0x80}, nil
+	}
+	return nil, nil
+}
+
+func TestManager_EnsureAddressInfo(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	contract := "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+	account := "0x0000000000000000000000000000000000000001"
+	reader := &codeReader{contracts: map[common.Address]bool{common.HexToAddress(contract): true}}
+
+	info, err := mgr.EnsureAddressInfo(ctx, contract, reader)
+	if err != nil {
+		t.Errorf("EnsureAddressInfo err: %v", err)
+		return
+	}
+	assert.True(t, info.IsContract)
+
+	info, err = mgr.EnsureAddressInfo(ctx, account, reader)
+	if err != nil {
+		t.Errorf("EnsureAddressInfo err: %v", err)
+		return
+	}
+	assert.False(t, info.IsContract)
+
+	// cached, the code is not read again
+	if _, err := mgr.EnsureAddressInfo(ctx, contract, reader); err != nil {
+		t.Errorf("EnsureAddressInfo err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, reader.calls)
+}
+
+func TestManager_GetIneligible(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	contract := "0x0000000000000000000000000000000000000001"
+	allowedContract := "0x0000000000000000000000000000000000000002"
+	bot := "0x0000000000000000000000000000000000000003"
+	account := "0x0000000000000000000000000000000000000004"
+	unknown := "0x0000000000000000000000000000000000000005"
+
+	reader := &codeReader{contracts: map[common.Address]bool{
+		common.HexToAddress(contract):        true,
+		common.HexToAddress(allowedContract): true,
+	}}
+	for _, address := range []string{contract, allowedContract, bot, account} {
+		if _, err := mgr.EnsureAddressInfo(ctx, address, reader); err != nil {
+			t.Errorf("EnsureAddressInfo err: %v", err)
+			return
+		}
+	}
+	if err := mgr.SetListEntry(ctx, allowedContract, constants.AddressListAllow, "aggregator"); err != nil {
+		t.Errorf("SetListEntry err: %v", err)
+		return
+	}
+	if err := mgr.SetListEntry(ctx, bot, constants.AddressListDeny, "mev bot"); err != nil {
+		t.Errorf("SetListEntry err: %v", err)
+		return
+	}
+
+	addresses := []string{contract, allowedContract, bot, account, unknown}
+
+	result, err := mgr.GetIneligible(ctx, addresses, false)
+	if err != nil {
+		t.Errorf("GetIneligible err: %v", err)
+		return
+	}
+	assert.Equal(t, map[string]string{
+		contract: constants.IneligibleContract,
+		bot:      constants.IneligibleDenylist,
+	}, result)
+
+	result, err = mgr.GetIneligible(ctx, addresses, true)
+	if err != nil {
+		t.Errorf("GetIneligible err: %v", err)
+		return
+	}
+	assert.Equal(t, map[string]string{bot: constants.IneligibleDenylist}, result)
+}
+
+func TestManager_AddressList(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	// addresses are stored checksummed
+	router := "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	if err := mgr.SetListEntry(ctx, router, constants.AddressListDeny, "router"); err != nil {
+		t.Errorf("SetListEntry err: %v", err)
+		return
+	}
+	assert.Error(t, mgr.SetListEntry(ctx, router, "grey", ""))
+	assert.Error(t, mgr.SetListEntry(ctx, "0x123", constants.AddressListDeny, ""))
+
+	entries, err := mgr.GetList(ctx, constants.AddressListDeny)
+	if err != nil {
+		t.Errorf("GetList err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D", entries[0].Address)
+	assert.Equal(t, "router", entries[0].Label)
+
+	if err := mgr.RemoveListEntry(ctx, router); err != nil {
+		t.Errorf("RemoveListEntry err: %v", err)
+		return
+	}
+	entries, err = mgr.GetList(ctx, "")
+	if err != nil {
+		t.Errorf("GetList err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, len(entries))
+}
@@ -0,0 +1,12 @@
+package addressinfo
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.AddressInfoManager {
+	return &Manager{
+		db,
+	}
+}
@@ -0,0 +1,169 @@
+package allocation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/allocation"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+type Manager struct {
+	db       *sql.DB
+	claimMgr iface.ClaimManager
+}
+
+// CreatePlan splits the budget, in tokens, across the users of the snapshot of the campaign by their frozen points.
+// It returns sql.ErrNoRows when the campaign is not snapshot and allocation.ErrExists when it is allocated already.
+func (m *Manager) CreatePlan(
+	ctx context.Context, campaignID string, budget decimal.Decimal, vesting model.VestingConfig,
+) (model.AllocationPlan, error) {
+
+	plan := model.AllocationPlan{
+		ID:         utils.GenDBID(),
+		CampaignID: campaignID,
+		Budget:     budget,
+		Vesting:    vesting,
+		CreatedAt:  time.Now(),
+	}
+
+	snapshot, err := m.claimMgr.GetSnapshot(ctx, campaignID)
+	if err != nil {
+		return plan, err
+	}
+	plan.SnapshotID = snapshot.ID
+	plan.Decimals = snapshot.Decimals
+	if err := allocation.Validate(budget, snapshot.Decimals, vesting); err != nil {
+		return plan, err
+	}
+	if _, err := m.GetPlan(ctx, campaignID); err == nil {
+		return plan, allocation.ErrExists
+	} else if err != sql.ErrNoRows {
+		return plan, err
+	}
+
+	claims, err := m.claimMgr.GetSnapshotClaims(ctx, campaignID)
+	if err != nil {
+		return plan, err
+	}
+	points := make(map[string]decimal.Decimal, len(claims))
+	for _, cl := range claims {
+		points[cl.UserAddress] = cl.Point
+	}
+	amounts := allocation.Allocate(budget.Shift(snapshot.Decimals), points)
+
+	// users whose share rounds down to nothing are left out
+	allocated := make([]model.Claim, 0, len(claims))
+	for _, cl := range claims {
+		if amounts[cl.UserAddress].IsPositive() {
+			allocated = append(allocated, cl)
+		}
+	}
+	plan.Users = len(allocated)
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return plan, fmt.Errorf("CreatePlan begin fail: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "allocationPlan" ("id", "campaignId", "snapshotId", "budget", "decimals", "vesting", "users", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, plan.ID, campaignID, plan.SnapshotID, budget, plan.Decimals, vesting, plan.Users, plan.CreatedAt); err != nil {
+		return plan, fmt.Errorf("CreatePlan insert plan fail: %v", err)
+	}
+	for _, cl := range allocated {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "allocation" ("id", "planId", "userAddress", "point", "amount")
+			VALUES ($1, $2, $3, $4, $5)
+		`, utils.GenDBID(), plan.ID, cl.UserAddress, cl.Point, amounts[cl.UserAddress]); err != nil {
+			return plan, fmt.Errorf("CreatePlan insert allocation fail: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return plan, fmt.Errorf("CreatePlan commit fail: %v", err)
+	}
+
+	return plan, nil
+}
+
+// GetPlan returns the plan of the campaign, sql.ErrNoRows when it is not allocated yet.
+func (m *Manager) GetPlan(ctx context.Context, campaignID string) (model.AllocationPlan, error) {
+	var plan model.AllocationPlan
+	err := m.db.QueryRowContext(ctx, `
+		SELECT "id", "campaignId", "snapshotId", "budget", "decimals", "vesting", "users", "createdAt"
+		FROM "allocationPlan"
+		WHERE "campaignId" = $1
+	`, campaignID).Scan(
+		&plan.ID, &plan.CampaignID, &plan.SnapshotID, &plan.Budget, &plan.Decimals, &plan.Vesting, &plan.Users, &plan.CreatedAt,
+	)
+
+	return plan, err
+}
+
+// GetAllocations returns the allocations of the address in every plan with their unlocks, the latest plan first.
+func (m *Manager) GetAllocations(ctx context.Context, address string) ([]model.Allocation, error) {
+	rows, err := m.db.QueryContext(ctx, selectAllocation+`
+		WHERE a."userAddress" = $1
+		ORDER BY p."createdAt" DESC, p."id"
+	`, common.HexToAddress(address).Hex())
+	if err != nil {
+		return nil, fmt.Errorf("GetAllocations query fail: %v", err)
+	}
+	defer rows.Close()
+
+	allocations, err := scanAllocations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetAllocations scan fail: %v", err)
+	}
+
+	return allocations, nil
+}
+
+// GetPlanAllocations returns every allocation of the plan of the campaign with their unlocks, by address.
+func (m *Manager) GetPlanAllocations(ctx context.Context, campaignID string) ([]model.Allocation, error) {
+	rows, err := m.db.QueryContext(ctx, selectAllocation+`
+		WHERE p."campaignId" = $1
+		ORDER BY a."userAddress"
+	`, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPlanAllocations query fail: %v", err)
+	}
+	defer rows.Close()
+
+	allocations, err := scanAllocations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetPlanAllocations scan fail: %v", err)
+	}
+
+	return allocations, nil
+}
+
+const selectAllocation = `
+	SELECT p."id", p."campaignId", p."vesting", a."userAddress", a."point", a."amount"
+	FROM "allocation" a
+	JOIN "allocationPlan" p ON p."id" = a."planId"
+`
+
+func scanAllocations(rows *sql.Rows) ([]model.Allocation, error) {
+	allocations := make([]model.Allocation, 0)
+	for rows.Next() {
+		var a model.Allocation
+		var vesting model.VestingConfig
+		if err := rows.Scan(&a.PlanID, &a.CampaignID, &vesting, &a.UserAddress, &a.Point, &a.Amount); err != nil {
+			return nil, err
+		}
+		a.Unlocks = allocation.Schedule(a.Amount, vesting)
+		allocations = append(allocations, a)
+	}
+
+	return allocations, rows.Err()
+}
@@ -0,0 +1,131 @@
+package allocation
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/allocation"
+	"tradingAce/pkg/core/claim"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service/campaign"
+	claimSvc "tradingAce/pkg/service/claim"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_CreatePlan(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	userPointMgr := userpoint.NewManager(d)
+	taskMgr := task.NewManager(d)
+	claimMgr := claimSvc.NewManager(d, campaign.NewManager(d, taskMgr), taskMgr, userPointMgr, settlement.NewManager(d))
+	mgr := Manager{db: d, claimMgr: claimMgr}
+
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES
+		('campaign1', 'season 1', $1, $2),
+		('campaign2', 'season 2', $1, $2)
+	`, now.AddDate(0, -3, 0), now.AddDate(0, 0, -1)); err != nil {
+		t.Errorf("insert campaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId", "config") VALUES
+		('task1', 'share_pool', $1, 'campaign1', '{"epoch": {"unit": "day", "length": 1, "count": 1}}')
+	`, now.AddDate(0, -3, 0)); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+	// the only epoch of the task is settled
+	if _, err := d.Exec(`
+		INSERT INTO "settlementRun" ("id", "taskId", "epoch", "state", "createdAt", "updatedAt", "committedAt")
+		VALUES ('run1', 'task1', 0, 'committed', $1, $1, $1)
+	`, now); err != nil {
+		t.Errorf("insert settlement run err: %v", err)
+		return
+	}
+
+	user1 := "0x0000000000000000000000000000000000000001"
+	user2 := "0x0000000000000000000000000000000000000002"
+	for address, point := range map[string]string{user1: "10", user2: "20"} {
+		if err := userPointMgr.UpsertForUserTask(ctx, address, "task1", decimal.RequireFromString(point)); err != nil {
+			t.Errorf("UpsertForUserTask err: %v", err)
+			return
+		}
+	}
+	if _, err := claimMgr.CreateSnapshot(ctx, "campaign1", claim.DefaultDecimals); err != nil {
+		t.Errorf("CreateSnapshot err: %v", err)
+		return
+	}
+
+	start := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	vesting := model.VestingConfig{Start: start, Tge: decimal.RequireFromString("0.1"), CliffMonths: 3, VestingMonths: 12}
+
+	_, err = mgr.CreatePlan(ctx, "campaign2", decimal.NewFromInt(300), vesting)
+	assert.Equal(t, sql.ErrNoRows, err)
+	_, err = mgr.CreatePlan(ctx, "campaign1", decimal.NewFromInt(300), model.VestingConfig{})
+	assert.ErrorIs(t, err, allocation.ErrInvalid)
+
+	plan, err := mgr.CreatePlan(ctx, "campaign1", decimal.NewFromInt(300), vesting)
+	if err != nil {
+		t.Errorf("CreatePlan err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, plan.Users)
+	assert.Equal(t, claim.DefaultDecimals, plan.Decimals)
+
+	// a campaign is allocated once
+	_, err = mgr.CreatePlan(ctx, "campaign1", decimal.NewFromInt(300), vesting)
+	assert.ErrorIs(t, err, allocation.ErrExists)
+
+	saved, err := mgr.GetPlan(ctx, "campaign1")
+	if err != nil {
+		t.Errorf("GetPlan err: %v", err)
+		return
+	}
+	assert.Equal(t, plan.ID, saved.ID)
+	assert.True(t, start.Equal(saved.Vesting.Start))
+
+	allocations, err := mgr.GetPlanAllocations(ctx, "campaign1")
+	if err != nil {
+		t.Errorf("GetPlanAllocations err: %v", err)
+		return
+	}
+	if assert.Equal(t, 2, len(allocations)) {
+		assert.Equal(t, user1, allocations[0].UserAddress)
+		assert.Equal(t, "100000000000000000000", allocations[0].Amount.String())
+		assert.Equal(t, "200000000000000000000", allocations[1].Amount.String())
+	}
+
+	userAllocations, err := mgr.GetAllocations(ctx, user2)
+	if err != nil {
+		t.Errorf("GetAllocations err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(userAllocations)) {
+		unlocks := userAllocations[0].Unlocks
+		// the tge, the cliff and a month after it up to the end of the vesting
+		if assert.Equal(t, 11, len(unlocks)) {
+			assert.Equal(t, "20000000000000000000", unlocks[0].Amount.String())
+			assert.True(t, start.AddDate(0, 3, 0).Equal(unlocks[1].At))
+			assert.Equal(t, "45000000000000000000", unlocks[1].Amount.String())
+			assert.Equal(t, "200000000000000000000", unlocks[10].Cumulative.String())
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package allocation
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB, claimMgr iface.ClaimManager) iface.AllocationManager {
+	return &Manager{
+		db,
+		claimMgr,
+	}
+}
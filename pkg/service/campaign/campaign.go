@@ -0,0 +1,233 @@
+package campaign
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/campaign"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+type Manager struct {
+	db      *sql.DB
+	taskMgr iface.TaskManager
+}
+
+// CreateCampaign saves the campaign, the saved campaign is returned with its ID.
+func (m *Manager) CreateCampaign(ctx context.Context, c model.Campaign) (model.Campaign, error) {
+	if err := campaign.Validate(c); err != nil {
+		return c, fmt.Errorf("invalid campaign: %w", err)
+	}
+
+	c.ID = utils.GenDBID()
+	c.CreatedAt = time.Now()
+	c.TaskIDs = []string{}
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "campaign" ("id", "createdAt", "name", "startAt", "endAt", "budget")
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, c.ID, c.CreatedAt, c.Name, c.StartAt, c.EndAt, c.Budget); err != nil {
+		return c, fmt.Errorf("CreateCampaign fail: %v", err)
+	}
+
+	return c, nil
+}
+
+// GetCampaign returns the campaign with its tasks, sql.ErrNoRows when it does not exist.
+func (m *Manager) GetCampaign(ctx context.Context, campaignID string) (model.Campaign, error) {
+	var c model.Campaign
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT "id", "createdAt", "name", "startAt", "endAt", "budget"
+		FROM "campaign"
+		WHERE "id" = $1
+	`, campaignID).Scan(&c.ID, &c.CreatedAt, &c.Name, &c.StartAt, &c.EndAt, &c.Budget); err != nil {
+		return c, err
+	}
+
+	campaigns := []model.Campaign{c}
+	if err := m.setTaskIDs(ctx, campaigns); err != nil {
+		return c, err
+	}
+
+	return campaigns[0], nil
+}
+
+// GetCampaigns returns every campaign with its tasks, the latest season first.
+func (m *Manager) GetCampaigns(ctx context.Context) ([]model.Campaign, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "createdAt", "name", "startAt", "endAt", "budget"
+		FROM "campaign"
+		ORDER BY "startAt" DESC, "id"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("GetCampaigns query fail: %v", err)
+	}
+	defer rows.Close()
+
+	campaigns := make([]model.Campaign, 0)
+	for rows.Next() {
+		var c model.Campaign
+		if err := rows.Scan(&c.ID, &c.CreatedAt, &c.Name, &c.StartAt, &c.EndAt, &c.Budget); err != nil {
+			return nil, fmt.Errorf("GetCampaigns scan fail: %v", err)
+		}
+		campaigns = append(campaigns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := m.setTaskIDs(ctx, campaigns); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates the name, season and budget of the campaign, sql.ErrNoRows when it does not exist.
+func (m *Manager) UpdateCampaign(ctx context.Context, c model.Campaign) error {
+	if err := campaign.Validate(c); err != nil {
+		return fmt.Errorf("invalid campaign: %w", err)
+	}
+
+	result, err := m.db.ExecContext(ctx, `
+		UPDATE "campaign" SET "name" = $2, "startAt" = $3, "endAt" = $4, "budget" = $5
+		WHERE "id" = $1
+	`, c.ID, c.Name, c.StartAt, c.EndAt, c.Budget)
+	if err != nil {
+		return fmt.Errorf("UpdateCampaign fail: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+// DeleteCampaign removes the campaign, its tasks are kept without campaign.
+func (m *Manager) DeleteCampaign(ctx context.Context, campaignID string) error {
+	result, err := m.db.ExecContext(ctx, `DELETE FROM "campaign" WHERE "id" = $1`, campaignID)
+	if err != nil {
+		return fmt.Errorf("DeleteCampaign fail: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+// AttachTask adds the task to the campaign, the task has to start within the campaign and be capped by its budget.
+func (m *Manager) AttachTask(ctx context.Context, campaignID string, taskID string) error {
+	c, err := m.GetCampaign(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	task, err := m.taskMgr.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	if !campaign.Budgeted(task.Name.String) {
+		return fmt.Errorf("%w: %s awards are not capped by the campaign budget", campaign.ErrInvalid, task.Name.String)
+	}
+	if task.CampaignID.Valid && task.CampaignID.String != campaignID {
+		return fmt.Errorf("%w: task belongs to campaign %s", campaign.ErrInvalid, task.CampaignID.String)
+	}
+	if !campaign.Contains(c, task.StartAt) {
+		return fmt.Errorf("%w: task starts outside of the campaign", campaign.ErrInvalid)
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		UPDATE "task" SET "campaignId" = $1 WHERE "id" = $2
+	`, campaignID, taskID); err != nil {
+		return fmt.Errorf("AttachTask fail: %v", err)
+	}
+
+	return nil
+}
+
+// DetachTask removes the task from the campaign, sql.ErrNoRows when the task is not in it.
+func (m *Manager) DetachTask(ctx context.Context, campaignID string, taskID string) error {
+	result, err := m.db.ExecContext(ctx, `
+		UPDATE "task" SET "campaignId" = NULL WHERE "id" = $1 AND "campaignId" = $2
+	`, taskID, campaignID)
+	if err != nil {
+		return fmt.Errorf("DetachTask fail: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+// GetSpent returns the points on the ledger of the campaign tasks, except the given task.
+func (m *Manager) GetSpent(ctx context.Context, campaignID string, excludeTaskID string) (decimal.Decimal, error) {
+	return getSpent(ctx, m.db, campaignID, excludeTaskID)
+}
+
+// GetSpentTx is GetSpent within the commit transaction of a settlement run. It locks the budget of the campaign
+// until the transaction ends, so the settlements of its tasks are capped one after another and never overspend it.
+func (m *Manager) GetSpentTx(ctx context.Context, tx *sql.Tx, campaignID string, excludeTaskID string) (decimal.Decimal, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "campaign:"+campaignID); err != nil {
+		return decimal.Zero, fmt.Errorf("GetSpentTx lock fail: %v", err)
+	}
+
+	return getSpent(ctx, tx, campaignID, excludeTaskID)
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getSpent(ctx context.Context, q rowQuerier, campaignID string, excludeTaskID string) (decimal.Decimal, error) {
+	var spent decimal.Decimal
+	if err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(pl."point"), 0)
+		FROM "pointLedger" pl
+		JOIN "task" t ON t."id" = pl."taskId"
+		WHERE t."campaignId" = $1 AND pl."taskId" <> $2
+	`, campaignID, excludeTaskID).Scan(&spent); err != nil {
+		return spent, fmt.Errorf("GetSpent fail: %v", err)
+	}
+
+	return spent, nil
+}
+
+func (m *Manager) setTaskIDs(ctx context.Context, campaigns []model.Campaign) error {
+	index := make(map[string]int, len(campaigns))
+	for i := range campaigns {
+		index[campaigns[i].ID] = i
+		campaigns[i].TaskIDs = []string{}
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "campaignId", "id" FROM "task"
+		WHERE "campaignId" IS NOT NULL
+		ORDER BY "startAt", "id"
+	`)
+	if err != nil {
+		return fmt.Errorf("query campaign tasks fail: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var campaignID, taskID string
+		if err := rows.Scan(&campaignID, &taskID); err != nil {
+			return fmt.Errorf("scan campaign tasks fail: %v", err)
+		}
+		if i, ok := index[campaignID]; ok {
+			campaigns[i].TaskIDs = append(campaigns[i].TaskIDs, taskID)
+		}
+	}
+
+	return rows.Err()
+}
+
+func requireRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
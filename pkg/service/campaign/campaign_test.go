@@ -0,0 +1,206 @@
+package campaign
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/campaign"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service/task"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Campaign(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	taskMgr := task.NewManager(d)
+	mgr := Manager{db: d, taskMgr: taskMgr}
+
+	seasonStart := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	budget := decimal.NewFromInt(15000)
+	c, err := mgr.CreateCampaign(ctx, model.Campaign{
+		Name:    "season 1",
+		StartAt: seasonStart,
+		EndAt:   seasonStart.AddDate(0, 3, 0),
+		Budget:  &budget,
+	})
+	if err != nil {
+		t.Errorf("CreateCampaign err: %v", err)
+		return
+	}
+	if _, err := mgr.CreateCampaign(ctx, model.Campaign{Name: "invalid", StartAt: seasonStart, EndAt: seasonStart}); err == nil {
+		t.Errorf("CreateCampaign should reject a campaign ending at its start")
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	if err := taskMgr.CreateSharePoolTask(ctx, pair, seasonStart, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	if err := taskMgr.CreateSharePoolTask(ctx, pair, seasonStart.AddDate(1, 0, 0), model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil || !assert.Equal(t, 2, len(tasks)) {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+	inSeason, outOfSeason := tasks[0], tasks[1]
+	if !inSeason.StartAt.Equal(seasonStart) {
+		inSeason, outOfSeason = outOfSeason, inSeason
+	}
+
+	if err := mgr.AttachTask(ctx, c.ID, inSeason.ID); err != nil {
+		t.Errorf("AttachTask err: %v", err)
+		return
+	}
+	err = mgr.AttachTask(ctx, c.ID, outOfSeason.ID)
+	assert.True(t, errors.Is(err, campaign.ErrInvalid), "got: %v", err)
+	// onboarding awards are not capped by the budget
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt") VALUES ('onboardingTask', 'onboarding', $1)
+	`, seasonStart); err != nil {
+		t.Errorf("insert task err: %v", err)
+		return
+	}
+	err = mgr.AttachTask(ctx, c.ID, "onboardingTask")
+	assert.True(t, errors.Is(err, campaign.ErrInvalid), "got: %v", err)
+	_, err = mgr.GetCampaign(ctx, "not exist")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	got, err := mgr.GetCampaign(ctx, c.ID)
+	if err != nil {
+		t.Errorf("GetCampaign err: %v", err)
+		return
+	}
+	assert.Equal(t, "season 1", got.Name)
+	assert.True(t, budget.Equal(*got.Budget))
+	assert.Equal(t, []string{inSeason.ID}, got.TaskIDs)
+
+	attached, err := taskMgr.GetTask(ctx, inSeason.ID)
+	if err != nil {
+		t.Errorf("GetTask err: %v", err)
+		return
+	}
+	assert.Equal(t, c.ID, attached.CampaignID.String)
+
+	got.Name = "season one"
+	got.Budget = nil
+	if err := mgr.UpdateCampaign(ctx, got); err != nil {
+		t.Errorf("UpdateCampaign err: %v", err)
+		return
+	}
+	campaigns, err := mgr.GetCampaigns(ctx)
+	if err != nil {
+		t.Errorf("GetCampaigns err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(campaigns)) {
+		assert.Equal(t, "season one", campaigns[0].Name)
+		assert.Nil(t, campaigns[0].Budget)
+		assert.Equal(t, 1, len(campaigns[0].TaskIDs))
+	}
+
+	if err := mgr.DetachTask(ctx, c.ID, inSeason.ID); err != nil {
+		t.Errorf("DetachTask err: %v", err)
+		return
+	}
+	assert.Equal(t, sql.ErrNoRows, mgr.DetachTask(ctx, c.ID, inSeason.ID))
+
+	if err := mgr.AttachTask(ctx, c.ID, inSeason.ID); err != nil {
+		t.Errorf("AttachTask err: %v", err)
+		return
+	}
+	if err := mgr.DeleteCampaign(ctx, c.ID); err != nil {
+		t.Errorf("DeleteCampaign err: %v", err)
+		return
+	}
+	detached, err := taskMgr.GetTask(ctx, inSeason.ID)
+	if err != nil {
+		t.Errorf("GetTask err: %v", err)
+		return
+	}
+	assert.False(t, detached.CampaignID.Valid)
+	assert.Equal(t, sql.ErrNoRows, mgr.DeleteCampaign(ctx, c.ID))
+}
+
+func TestManager_GetSpent(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	taskMgr := task.NewManager(d)
+	mgr := Manager{db: d, taskMgr: taskMgr}
+
+	seasonStart := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	c, err := mgr.CreateCampaign(ctx, model.Campaign{Name: "season 1", StartAt: seasonStart, EndAt: seasonStart.AddDate(0, 3, 0)})
+	if err != nil {
+		t.Errorf("CreateCampaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId") VALUES
+		('task1', 'share_pool', $1, $2),
+		('task2', 'share_pool', $1, $2)
+	`, seasonStart, c.ID); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "pointLedger" ("id", "userAddress", "taskId", "epoch", "point", "reason") VALUES
+		('l1', '0x01', 'task1', 0, 100, 'share_pool_epoch'),
+		('l2', '0x02', 'task1', 1, 50, 'share_pool_epoch'),
+		('l3', '0x01', 'task2', 0, 30, 'share_pool_epoch')
+	`); err != nil {
+		t.Errorf("insert ledger err: %v", err)
+		return
+	}
+
+	spent, err := mgr.GetSpent(ctx, c.ID, "task2")
+	if err != nil {
+		t.Errorf("GetSpent err: %v", err)
+		return
+	}
+	assert.True(t, decimal.NewFromInt(150).Equal(spent), "got: %v", spent)
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		t.Errorf("BeginTx err: %v", err)
+		return
+	}
+	defer tx.Rollback()
+	spent, err = mgr.GetSpentTx(ctx, tx, c.ID, "task1")
+	if err != nil {
+		t.Errorf("GetSpentTx err: %v", err)
+		return
+	}
+	assert.True(t, decimal.NewFromInt(30).Equal(spent), "got: %v", spent)
+
+	spent, err = mgr.GetSpent(ctx, "not exist", "task2")
+	if err != nil {
+		t.Errorf("GetSpent err: %v", err)
+		return
+	}
+	assert.True(t, spent.IsZero())
+}
@@ -0,0 +1,13 @@
+package campaign
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB, taskMgr iface.TaskManager) iface.CampaignManager {
+	return &Manager{
+		db,
+		taskMgr,
+	}
+}
@@ -0,0 +1,28 @@
+package campaign
+
+import (
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/service/task"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewManager(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	taskMgr := task.NewManager(d)
+	manager := NewManager(d, taskMgr)
+	mgr := manager.(*Manager)
+
+	assert.Equal(t, d, mgr.db)
+	assert.Equal(t, taskMgr, mgr.taskMgr)
+}
@@ -0,0 +1,275 @@
+package claim
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/claim"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/settlement"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type Manager struct {
+	db            *sql.DB
+	campaignMgr   iface.CampaignManager
+	taskMgr       iface.TaskManager
+	userPointMgr  iface.UserPointManager
+	settlementMgr iface.SettlementManager
+}
+
+// CreateSnapshot freezes the points of the ended campaign, builds the Merkle tree of the claimable amounts
+// and saves its root with the proof of every user. A campaign is snapshot once, it returns claim.ErrExists after that.
+// Every epoch of the tasks of the campaign must be committed, claim.ErrNotSettled otherwise, they are finalized
+// before the points are read so no settlement can change them after the snapshot.
+func (m *Manager) CreateSnapshot(ctx context.Context, campaignID string, decimals int32) (model.ClaimSnapshot, error) {
+	snapshot := model.ClaimSnapshot{
+		ID:         utils.GenDBID(),
+		CampaignID: campaignID,
+		Decimals:   decimals,
+		CreatedAt:  time.Now(),
+	}
+
+	c, err := m.campaignMgr.GetCampaign(ctx, campaignID)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.CreatedAt.Before(c.EndAt) {
+		return snapshot, fmt.Errorf("%w: campaign %s ends at %s", claim.ErrNotEnded, campaignID, c.EndAt.Format(time.RFC3339))
+	}
+	if _, err := m.GetSnapshot(ctx, campaignID); err == nil {
+		return snapshot, claim.ErrExists
+	} else if err != sql.ErrNoRows {
+		return snapshot, err
+	}
+	if err := m.finalizeEpochs(ctx, c); err != nil {
+		return snapshot, err
+	}
+
+	points, err := m.userPointMgr.GetUserPointsForCampaign(ctx, campaignID)
+	if err != nil {
+		return snapshot, err
+	}
+
+	leaves := make([]claim.Leaf, 0, len(points))
+	claims := make([]model.Claim, 0, len(points))
+	snapshot.TotalPoint, snapshot.TotalAmount = decimal.Zero, decimal.Zero
+	for _, p := range points {
+		if !common.IsHexAddress(p.UserAddress) {
+			return snapshot, fmt.Errorf("CreateSnapshot invalid address %q", p.UserAddress)
+		}
+		amount := claim.Amount(p.Point, decimals)
+		// users without a claimable amount are left out of the tree
+		if amount.Sign() <= 0 {
+			continue
+		}
+
+		leaf := claim.Leaf{Address: common.HexToAddress(p.UserAddress), Amount: amount}
+		leaves = append(leaves, leaf)
+		claims = append(claims, model.Claim{
+			SnapshotID:  snapshot.ID,
+			CampaignID:  campaignID,
+			UserAddress: leaf.Address.Hex(),
+			Point:       p.Point,
+			Amount:      decimal.NewFromBigInt(amount, 0),
+		})
+		snapshot.TotalPoint = snapshot.TotalPoint.Add(p.Point)
+		snapshot.TotalAmount = snapshot.TotalAmount.Add(decimal.NewFromBigInt(amount, 0))
+	}
+
+	tree, err := claim.NewTree(leaves)
+	if err != nil {
+		return snapshot, fmt.Errorf("CreateSnapshot campaign %s: %v", campaignID, err)
+	}
+	snapshot.Root = tree.Root().Hex()
+	snapshot.Users = len(claims)
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return snapshot, fmt.Errorf("CreateSnapshot begin fail: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "claimSnapshot" ("id", "campaignId", "root", "decimals", "users", "totalPoint", "totalAmount", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, snapshot.ID, campaignID, snapshot.Root, decimals, snapshot.Users,
+		snapshot.TotalPoint, snapshot.TotalAmount, snapshot.CreatedAt); err != nil {
+		return snapshot, fmt.Errorf("CreateSnapshot insert snapshot fail: %v", err)
+	}
+
+	for i, cl := range claims {
+		proof := make(pq.StringArray, 0)
+		for _, hash := range tree.Proof(i) {
+			proof = append(proof, hash.Hex())
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "claim" ("id", "snapshotId", "userAddress", "point", "amount", "proof")
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, utils.GenDBID(), snapshot.ID, cl.UserAddress, cl.Point, cl.Amount, proof); err != nil {
+			return snapshot, fmt.Errorf("CreateSnapshot insert claim fail: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return snapshot, fmt.Errorf("CreateSnapshot commit fail: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+// finalizeEpochs checks that every epoch of the tasks of the campaign is committed and finalizes them.
+func (m *Manager) finalizeEpochs(ctx context.Context, c model.Campaign) error {
+	type pending struct {
+		taskID string
+		epoch  int
+	}
+	var committed []pending
+
+	for _, taskID := range c.TaskIDs {
+		t, err := m.taskMgr.GetTask(ctx, taskID)
+		if err != nil {
+			return fmt.Errorf("CreateSnapshot get task %s: %v", taskID, err)
+		}
+		// onboarding and referral tasks award points as they happen, they have no epochs
+		switch t.Name.String {
+		case "share_pool", "streak", "lp_provider":
+		default:
+			continue
+		}
+
+		epochs, err := epoch.Schedule(t)
+		if err != nil {
+			return fmt.Errorf("CreateSnapshot schedule epochs of task %s: %v", taskID, err)
+		}
+		runs, err := m.settlementMgr.GetRuns(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		// runs are ordered by epoch with the latest run of every epoch first
+		latest := make(map[int]string)
+		for _, run := range runs {
+			if _, ok := latest[run.Epoch]; !ok {
+				latest[run.Epoch] = run.State
+			}
+		}
+
+		for _, e := range epochs {
+			state := latest[e.Index]
+			if !settlement.Settled(state) {
+				return fmt.Errorf("%w: epoch %d of task %s is %q", claim.ErrNotSettled, e.Index, taskID, state)
+			}
+			if state == settlement.StateCommitted {
+				committed = append(committed, pending{taskID, e.Index})
+			}
+		}
+	}
+
+	for _, p := range committed {
+		if _, err := m.settlementMgr.Finalize(ctx, p.taskID, p.epoch); err != nil {
+			return fmt.Errorf("CreateSnapshot finalize epoch %d of task %s: %w", p.epoch, p.taskID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetSnapshot returns the snapshot of the campaign, sql.ErrNoRows when it is not snapshot yet.
+func (m *Manager) GetSnapshot(ctx context.Context, campaignID string) (model.ClaimSnapshot, error) {
+	var snapshot model.ClaimSnapshot
+	err := m.db.QueryRowContext(ctx, `
+		SELECT "id", "campaignId", "root", "decimals", "users", "totalPoint", "totalAmount", "createdAt"
+		FROM "claimSnapshot"
+		WHERE "campaignId" = $1
+	`, campaignID).Scan(
+		&snapshot.ID, &snapshot.CampaignID, &snapshot.Root, &snapshot.Decimals, &snapshot.Users,
+		&snapshot.TotalPoint, &snapshot.TotalAmount, &snapshot.CreatedAt,
+	)
+
+	return snapshot, err
+}
+
+// GetClaims returns the claims of the address in every snapshot, the latest first.
+func (m *Manager) GetClaims(ctx context.Context, address string) ([]model.Claim, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT s."id", s."campaignId", s."root", c."userAddress", c."point", c."amount", c."proof"
+		FROM "claim" c
+		JOIN "claimSnapshot" s ON s."id" = c."snapshotId"
+		WHERE c."userAddress" = $1
+		ORDER BY s."createdAt" DESC, s."id"
+	`, common.HexToAddress(address).Hex())
+	if err != nil {
+		return nil, fmt.Errorf("GetClaims query fail: %v", err)
+	}
+	defer rows.Close()
+
+	claims, err := scanClaims(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetClaims scan fail: %v", err)
+	}
+
+	return claims, nil
+}
+
+// GetSnapshotClaims returns every claim of the snapshot of the campaign, by address.
+func (m *Manager) GetSnapshotClaims(ctx context.Context, campaignID string) ([]model.Claim, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT s."id", s."campaignId", s."root", c."userAddress", c."point", c."amount", c."proof"
+		FROM "claim" c
+		JOIN "claimSnapshot" s ON s."id" = c."snapshotId"
+		WHERE s."campaignId" = $1
+		ORDER BY c."userAddress"
+	`, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSnapshotClaims query fail: %v", err)
+	}
+	defer rows.Close()
+
+	claims, err := scanClaims(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetSnapshotClaims scan fail: %v", err)
+	}
+
+	return claims, nil
+}
+
+func scanClaims(rows *sql.Rows) ([]model.Claim, error) {
+	claims := make([]model.Claim, 0)
+	for rows.Next() {
+		var cl model.Claim
+		var proof pq.StringArray
+		if err := rows.Scan(&cl.SnapshotID, &cl.CampaignID, &cl.Root, &cl.UserAddress, &cl.Point, &cl.Amount, &proof); err != nil {
+			return nil, err
+		}
+		cl.Proof = []string(proof)
+		claims = append(claims, cl)
+	}
+
+	return claims, rows.Err()
+}
+
+// GetClaim returns the claim of the address in the snapshot of the campaign, sql.ErrNoRows when it has none.
+func (m *Manager) GetClaim(ctx context.Context, campaignID string, address string) (model.Claim, error) {
+	var cl model.Claim
+	var proof pq.StringArray
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT s."id", s."campaignId", s."root", c."userAddress", c."point", c."amount", c."proof"
+		FROM "claim" c
+		JOIN "claimSnapshot" s ON s."id" = c."snapshotId"
+		WHERE s."campaignId" = $1 AND c."userAddress" = $2
+	`, campaignID, common.HexToAddress(address).Hex()).Scan(
+		&cl.SnapshotID, &cl.CampaignID, &cl.Root, &cl.UserAddress, &cl.Point, &cl.Amount, &proof,
+	); err != nil {
+		return cl, err
+	}
+	cl.Proof = []string(proof)
+
+	return cl, nil
+}
@@ -0,0 +1,164 @@
+package claim
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/claim"
+	settlementCore "tradingAce/pkg/core/settlement"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_CreateSnapshot(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	userPointMgr := userpoint.NewManager(d)
+	taskMgr := task.NewManager(d)
+	settlementMgr := settlement.NewManager(d)
+	mgr := Manager{
+		db:            d,
+		campaignMgr:   campaign.NewManager(d, taskMgr),
+		taskMgr:       taskMgr,
+		userPointMgr:  userPointMgr,
+		settlementMgr: settlementMgr,
+	}
+
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES
+		('campaign1', 'season 1', $1, $2),
+		('campaign2', 'season 2', $2, $3)
+	`, now.AddDate(0, -3, 0), now.AddDate(0, 0, -1), now.AddDate(0, 3, 0)); err != nil {
+		t.Errorf("insert campaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId", "config") VALUES
+		('task1', 'share_pool', $1, 'campaign1', '{"epoch": {"unit": "day", "length": 1, "count": 1}}')
+	`, now.AddDate(0, -3, 0)); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+
+	user1 := "0x0000000000000000000000000000000000000001"
+	user2 := "0x0000000000000000000000000000000000000002"
+	user3 := "0x0000000000000000000000000000000000000003"
+	for address, point := range map[string]string{user1: "10.5", user2: "20", user3: "0"} {
+		if err := userPointMgr.UpsertForUserTask(ctx, address, "task1", decimal.RequireFromString(point)); err != nil {
+			t.Errorf("UpsertForUserTask err: %v", err)
+			return
+		}
+	}
+
+	_, err = mgr.CreateSnapshot(ctx, "campaign2", claim.DefaultDecimals)
+	assert.ErrorIs(t, err, claim.ErrNotEnded)
+	_, err = mgr.CreateSnapshot(ctx, "notExist", claim.DefaultDecimals)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	// the epoch of the task is not settled yet
+	_, err = mgr.CreateSnapshot(ctx, "campaign1", claim.DefaultDecimals)
+	assert.ErrorIs(t, err, claim.ErrNotSettled)
+
+	// the only epoch of the task is settled
+	if _, err := d.Exec(`
+		INSERT INTO "settlementRun" ("id", "taskId", "epoch", "state", "createdAt", "updatedAt", "committedAt")
+		VALUES ('run1', 'task1', 0, 'committed', $1, $1, $1)
+	`, now); err != nil {
+		t.Errorf("insert settlement run err: %v", err)
+		return
+	}
+
+	snapshot, err := mgr.CreateSnapshot(ctx, "campaign1", claim.DefaultDecimals)
+	if err != nil {
+		t.Errorf("CreateSnapshot err: %v", err)
+		return
+	}
+	// the settled epoch is finalized, a forced settlement can not change the snapshot points
+	runs, err := settlementMgr.GetRuns(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(runs)) {
+		assert.Equal(t, settlementCore.StateFinalized, runs[0].State)
+	}
+	assert.Equal(t, 2, snapshot.Users)
+	assert.True(t, decimal.RequireFromString("30.5").Equal(snapshot.TotalPoint), "total point: %v", snapshot.TotalPoint)
+	assert.Equal(t, "30500000000000000000", snapshot.TotalAmount.String())
+
+	// the points are frozen once
+	_, err = mgr.CreateSnapshot(ctx, "campaign1", claim.DefaultDecimals)
+	assert.ErrorIs(t, err, claim.ErrExists)
+
+	saved, err := mgr.GetSnapshot(ctx, "campaign1")
+	if err != nil {
+		t.Errorf("GetSnapshot err: %v", err)
+		return
+	}
+	assert.Equal(t, snapshot.Root, saved.Root)
+
+	claims, err := mgr.GetClaims(ctx, user1)
+	if err != nil {
+		t.Errorf("GetClaims err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 1, len(claims)) {
+		return
+	}
+	assert.Equal(t, "campaign1", claims[0].CampaignID)
+	assert.Equal(t, snapshot.Root, claims[0].Root)
+	assert.Equal(t, "10500000000000000000", claims[0].Amount.String())
+
+	proof := make([]common.Hash, 0, len(claims[0].Proof))
+	for _, hash := range claims[0].Proof {
+		proof = append(proof, common.HexToHash(hash))
+	}
+	amount, _ := new(big.Int).SetString(claims[0].Amount.String(), 10)
+	assert.True(t, claim.Verify(common.HexToHash(snapshot.Root), claim.Leaf{Address: common.HexToAddress(user1), Amount: amount}, proof))
+
+	cl, err := mgr.GetClaim(ctx, "campaign1", user2)
+	if err != nil {
+		t.Errorf("GetClaim err: %v", err)
+		return
+	}
+	assert.Equal(t, "20000000000000000000", cl.Amount.String())
+
+	claims, err = mgr.GetSnapshotClaims(ctx, "campaign1")
+	if err != nil {
+		t.Errorf("GetSnapshotClaims err: %v", err)
+		return
+	}
+	if assert.Equal(t, 2, len(claims)) {
+		assert.Equal(t, user1, claims[0].UserAddress)
+		assert.Equal(t, user2, claims[1].UserAddress)
+	}
+
+	// users without points have nothing to claim
+	_, err = mgr.GetClaim(ctx, "campaign1", user3)
+	assert.Equal(t, sql.ErrNoRows, err)
+	claims, err = mgr.GetClaims(ctx, user3)
+	if err != nil {
+		t.Errorf("GetClaims err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, len(claims))
+}
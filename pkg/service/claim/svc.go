@@ -0,0 +1,23 @@
+package claim
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(
+	db *sql.DB,
+	campaignMgr iface.CampaignManager,
+	taskMgr iface.TaskManager,
+	userPointMgr iface.UserPointManager,
+	settlementMgr iface.SettlementManager,
+) iface.ClaimManager {
+
+	return &Manager{
+		db,
+		campaignMgr,
+		taskMgr,
+		userPointMgr,
+		settlementMgr,
+	}
+}
@@ -0,0 +1,37 @@
+package claim
+
+import (
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewManager(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	taskMgr := task.NewManager(d)
+	campaignMgr := campaign.NewManager(d, taskMgr)
+	userPointMgr := userpoint.NewManager(d)
+	settlementMgr := settlement.NewManager(d)
+	manager := NewManager(d, campaignMgr, taskMgr, userPointMgr, settlementMgr)
+	mgr := manager.(*Manager)
+
+	assert.Equal(t, d, mgr.db)
+	assert.Equal(t, campaignMgr, mgr.campaignMgr)
+	assert.Equal(t, taskMgr, mgr.taskMgr)
+	assert.Equal(t, userPointMgr, mgr.userPointMgr)
+	assert.Equal(t, settlementMgr, mgr.settlementMgr)
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"tradingAce/pkg/core/export"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/shopspring/decimal"
+)
+
+type Manager struct {
+	db             *sql.DB
+	leaderboardMgr iface.LeaderboardManager
+}
+
+// Export writes the rows of the dataset selected by the options to w as they are read, nothing is held in memory.
+// It returns export.ErrInvalid before writing anything when the options can not be exported.
+func (m *Manager) Export(ctx context.Context, opt option.ExportOptions, w iface.RowWriter) error {
+	if !export.ValidDataset(opt.Dataset) {
+		return fmt.Errorf("%w: dataset %q", export.ErrInvalid, opt.Dataset)
+	}
+	if len(opt.TaskID) != 0 && len(opt.CampaignID) != 0 {
+		return fmt.Errorf("%w: task and campaign can not be combined", export.ErrInvalid)
+	}
+	if opt.From != nil && opt.To != nil && !opt.From.Before(*opt.To) {
+		return fmt.Errorf("%w: from must be before to", export.ErrInvalid)
+	}
+
+	switch opt.Dataset {
+	case export.DatasetUserPoint:
+		return m.exportUserPoints(ctx, opt, w)
+	case export.DatasetUserTask:
+		return m.exportUserTasks(ctx, opt, w)
+	case export.DatasetTransaction:
+		return m.exportTransactions(ctx, opt, w)
+	}
+
+	return m.exportLeaderboard(ctx, opt, w)
+}
+
+func (m *Manager) exportUserPoints(ctx context.Context, opt option.ExportOptions, w iface.RowWriter) error {
+	var c conditions
+	c.scope(opt, `up."taskId" = $%d`, `t."campaignId" = $%d`)
+	c.period(opt, `up."createdAt"`)
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT up."userAddress", up."taskId", COALESCE(t."campaignId", ''), up."point", up."createdAt"
+		FROM "userPoint" up
+		LEFT JOIN "task" t ON t."id" = up."taskId"
+		%s
+		ORDER BY up."createdAt", up."id"
+	`, c.where()), c.args...)
+	if err != nil {
+		return fmt.Errorf("exportUserPoints query fail: %v", err)
+	}
+	defer rows.Close()
+
+	if err := w.Header("userAddress", "taskId", "campaignId", "point", "createdAt"); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var userAddress, taskID, campaignID string
+		var point decimal.Decimal
+		var createdAt time.Time
+		if err := rows.Scan(&userAddress, &taskID, &campaignID, &point, &createdAt); err != nil {
+			return fmt.Errorf("exportUserPoints scan fail: %v", err)
+		}
+		if err := w.Write(userAddress, taskID, campaignID, point, createdAt); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (m *Manager) exportUserTasks(ctx context.Context, opt option.ExportOptions, w iface.RowWriter) error {
+	var c conditions
+	c.scope(opt, `ut."taskId" = $%d`, `t."campaignId" = $%d`)
+	c.period(opt, `ut."createdAt"`)
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT ut."userAddress", ut."taskId", COALESCE(t."campaignId", ''), ut."state", ut."reason", ut."amount",
+			ut."currentStreak", ut."longestStreak", ut."createdAt", ut."completedAt"
+		FROM "userTask" ut
+		LEFT JOIN "task" t ON t."id" = ut."taskId"
+		%s
+		ORDER BY ut."createdAt", ut."id"
+	`, c.where()), c.args...)
+	if err != nil {
+		return fmt.Errorf("exportUserTasks query fail: %v", err)
+	}
+	defer rows.Close()
+
+	if err := w.Header(
+		"userAddress", "taskId", "campaignId", "state", "reason", "amount",
+		"currentStreak", "longestStreak", "createdAt", "completedAt",
+	); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var userAddress, taskID, campaignID, state, reason string
+		var amount decimal.Decimal
+		var currentStreak, longestStreak int
+		var createdAt time.Time
+		var completedAt sql.NullTime
+		if err := rows.Scan(
+			&userAddress, &taskID, &campaignID, &state, &reason, &amount,
+			&currentStreak, &longestStreak, &createdAt, &completedAt,
+		); err != nil {
+			return fmt.Errorf("exportUserTasks scan fail: %v", err)
+		}
+
+		var completed *time.Time
+		if completedAt.Valid {
+			completed = &completedAt.Time
+		}
+		if err := w.Write(
+			userAddress, taskID, campaignID, state, reason, amount,
+			currentStreak, longestStreak, createdAt, completed,
+		); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// exportTransactions selects the swaps of the pairs of the task or of the tasks of the campaign.
+func (m *Manager) exportTransactions(ctx context.Context, opt option.ExportOptions, w iface.RowWriter) error {
+	var c conditions
+	c.scope(opt,
+		`tx."pairAddress" IN (SELECT "pairAddress" FROM "task" WHERE "id" = $%d)`,
+		`tx."pairAddress" IN (SELECT "pairAddress" FROM "task" WHERE "campaignId" = $%d)`,
+	)
+	c.period(opt, `tx."transactionAt"`)
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT tx."txHash", tx."logIndex", tx."blockNum", tx."pairAddress", tx."senderAddress", tx."receiverAddress",
+			tx."amount0In", tx."amount1In", tx."amount0Out", tx."amount1Out", tx."mevRole", tx."transactionAt"
+		FROM "transaction" tx
+		%s
+		ORDER BY tx."blockNum", tx."logIndex", tx."pairAddress"
+	`, c.where()), c.args...)
+	if err != nil {
+		return fmt.Errorf("exportTransactions query fail: %v", err)
+	}
+	defer rows.Close()
+
+	if err := w.Header(
+		"txHash", "logIndex", "blockNum", "pairAddress", "senderAddress", "receiverAddress",
+		"amount0In", "amount1In", "amount0Out", "amount1Out", "mevRole", "transactionAt",
+	); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var txHash, pairAddress, senderAddress, receiverAddress, mevRole string
+		var logIndex int
+		var blockNum int64
+		var amount0In, amount1In, amount0Out, amount1Out decimal.Decimal
+		var transactionAt time.Time
+		if err := rows.Scan(
+			&txHash, &logIndex, &blockNum, &pairAddress, &senderAddress, &receiverAddress,
+			&amount0In, &amount1In, &amount0Out, &amount1Out, &mevRole, &transactionAt,
+		); err != nil {
+			return fmt.Errorf("exportTransactions scan fail: %v", err)
+		}
+		if err := w.Write(
+			txHash, logIndex, blockNum, pairAddress, senderAddress, receiverAddress,
+			amount0In, amount1In, amount0Out, amount1Out, mevRole, transactionAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// exportLeaderboard writes the whole leaderboard of the task, the campaign or of all tasks.
+func (m *Manager) exportLeaderboard(ctx context.Context, opt option.ExportOptions, w iface.RowWriter) error {
+	if opt.From != nil || opt.To != nil {
+		return fmt.Errorf("%w: the leaderboard has no date range", export.ErrInvalid)
+	}
+
+	if err := w.Header("rank", "percentile", "userAddress", "point", "volume"); err != nil {
+		return err
+	}
+
+	return m.leaderboardMgr.EachRank(ctx, option.LeaderboardOptions{
+		TaskID:     opt.TaskID,
+		CampaignID: opt.CampaignID,
+	}, func(entry model.LeaderboardEntry) error {
+		return w.Write(entry.Rank, entry.Percentile, entry.UserAddress, entry.Point, entry.Volume)
+	})
+}
+
+// conditions builds the WHERE clause of an export with numbered arguments.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends the clause with its $%d replaced by the number of the argument.
+func (c *conditions) add(clause string, arg interface{}) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, len(c.args)))
+}
+
+func (c *conditions) scope(opt option.ExportOptions, task string, campaign string) {
+	if len(opt.TaskID) != 0 {
+		c.add(task, opt.TaskID)
+	}
+	if len(opt.CampaignID) != 0 {
+		c.add(campaign, opt.CampaignID)
+	}
+}
+
+func (c *conditions) period(opt option.ExportOptions, column string) {
+	if opt.From != nil {
+		c.add(column+` >= $%d`, *opt.From)
+	}
+	if opt.To != nil {
+		c.add(column+` < $%d`, *opt.To)
+	}
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(c.clauses, " AND ")
+}
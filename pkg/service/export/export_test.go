@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/export"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service/leaderboard"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Export(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	userPointMgr := userpoint.NewManager(d)
+	mgr := Manager{db: d, leaderboardMgr: leaderboard.NewManager(d)}
+
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES ('campaign1', 'season 1', $1, $2)
+	`, now.AddDate(0, -3, 0), now.AddDate(0, 3, 0)); err != nil {
+		t.Errorf("insert campaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "pairAddress", "startAt", "campaignId") VALUES
+		('task1', 'share_pool', '0xpair1', $1, 'campaign1'),
+		('task2', 'share_pool', '0xpair2', $1, NULL)
+	`, now.AddDate(0, -3, 0)); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "transaction" ("id", "blockNum", "pairAddress", "senderAddress", "receiverAddress", "amount1In", "transactionAt", "txHash", "logIndex") VALUES
+		('tx1', 1, '0xpair1', '0xuser1', '0xuser1', 1.5, '2024-07-01T00:00:00Z', '0xhash1', 0),
+		('tx2', 2, '0xpair2', '0xuser2', '0xuser2', 2, '2024-07-02T00:00:00Z', '0xhash2', 0),
+		('tx3', 3, '0xpair1', '0xuser2', '0xuser2', 3, '2024-07-03T00:00:00Z', '0xhash3', 1)
+	`); err != nil {
+		t.Errorf("insert transactions err: %v", err)
+		return
+	}
+	for _, p := range []struct {
+		address string
+		taskID  string
+		point   int64
+	}{{"0xuser1", "task1", 10}, {"0xuser2", "task1", 20}, {"0xuser2", "task2", 5}} {
+		if err := userPointMgr.UpsertForUserTask(ctx, p.address, p.taskID, decimal.NewFromInt(p.point)); err != nil {
+			t.Errorf("UpsertForUserTask err: %v", err)
+			return
+		}
+	}
+
+	exportLines := func(opt option.ExportOptions, format string) ([]string, error) {
+		var buf bytes.Buffer
+		w, err := export.NewWriter(&buf, format)
+		if err != nil {
+			return nil, err
+		}
+		if err := mgr.Export(ctx, opt, w); err != nil {
+			return nil, err
+		}
+		if err := w.Flush(); err != nil {
+			return nil, err
+		}
+		return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+	}
+
+	lines, err := exportLines(option.ExportOptions{Dataset: export.DatasetUserPoint, CampaignID: "campaign1"}, export.FormatCSV)
+	if err != nil {
+		t.Errorf("Export err: %v", err)
+		return
+	}
+	if assert.Equal(t, 3, len(lines)) {
+		assert.Equal(t, "userAddress,taskId,campaignId,point,createdAt", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "0xuser1,task1,campaign1,10,"), lines[1])
+	}
+
+	from := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+	lines, err = exportLines(option.ExportOptions{Dataset: export.DatasetTransaction, TaskID: "task1", From: &from}, export.FormatJSONL)
+	if err != nil {
+		t.Errorf("Export err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], `{"txHash":"0xhash3","logIndex":1,"blockNum":3,"pairAddress":"0xpair1"`), lines[0])
+	}
+
+	lines, err = exportLines(option.ExportOptions{Dataset: export.DatasetLeaderboard}, export.FormatCSV)
+	if err != nil {
+		t.Errorf("Export err: %v", err)
+		return
+	}
+	if assert.Equal(t, 3, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[1], "1,100,0xuser2,25,"), lines[1])
+	}
+
+	for _, opt := range []option.ExportOptions{
+		{Dataset: "pointLedger"},
+		{Dataset: export.DatasetUserTask, TaskID: "task1", CampaignID: "campaign1"},
+		{Dataset: export.DatasetUserTask, From: &from, To: &from},
+		{Dataset: export.DatasetLeaderboard, From: &from},
+	} {
+		_, err := exportLines(opt, export.FormatCSV)
+		assert.ErrorIs(t, err, export.ErrInvalid)
+	}
+}
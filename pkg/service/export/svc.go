@@ -0,0 +1,13 @@
+package export
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB, leaderboardMgr iface.LeaderboardManager) iface.ExportManager {
+	return &Manager{
+		db,
+		leaderboardMgr,
+	}
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/service/leaderboard"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewManager(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	leaderboardMgr := leaderboard.NewManager(d)
+	manager := NewManager(d, leaderboardMgr)
+	mgr := manager.(*Manager)
+
+	assert.Equal(t, d, mgr.db)
+	assert.Equal(t, leaderboardMgr, mgr.leaderboardMgr)
+}
@@ -0,0 +1,41 @@
+package ingestion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/model"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// SetCursor moves the cursor of the task forward to the block, an older block leaves it as it is.
+// Every block up to it must be ingested for the task, the tasks of a pair sync its history on their own.
+func (m *Manager) SetCursor(ctx context.Context, taskID string, pairAddress string, blockNum uint64, blockAt time.Time) error {
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "ingestionCursor" ("taskId", "pairAddress", "blockNum", "blockAt", "updatedAt")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("taskId")
+		DO UPDATE SET "blockNum" = EXCLUDED."blockNum", "blockAt" = EXCLUDED."blockAt", "updatedAt" = EXCLUDED."updatedAt"
+		WHERE "ingestionCursor"."blockNum" < EXCLUDED."blockNum"
+	`, taskID, pairAddress, blockNum, blockAt, time.Now()); err != nil {
+		return fmt.Errorf("SetCursor fail: %v", err)
+	}
+
+	return nil
+}
+
+// GetCursor returns the latest block ingested for the task, sql.ErrNoRows before its pair is ingested.
+func (m *Manager) GetCursor(ctx context.Context, taskID string) (model.IngestionCursor, error) {
+	var cursor model.IngestionCursor
+	err := m.db.QueryRowContext(ctx, `
+		SELECT "taskId", "pairAddress", "blockNum", "blockAt", "updatedAt"
+		FROM "ingestionCursor"
+		WHERE "taskId" = $1
+	`, taskID).Scan(&cursor.TaskID, &cursor.PairAddress, &cursor.BlockNum, &cursor.BlockAt, &cursor.UpdatedAt)
+
+	return cursor, err
+}
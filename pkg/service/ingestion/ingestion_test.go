@@ -0,0 +1,79 @@
+package ingestion
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Cursor(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	taskID := "task1"
+	blockAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err = mgr.GetCursor(ctx, taskID)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	if err := mgr.SetCursor(ctx, taskID, pair, 100, blockAt); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+	// an older block does not move the cursor back
+	if err := mgr.SetCursor(ctx, taskID, pair, 90, blockAt.Add(-time.Minute)); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+
+	cursor, err := mgr.GetCursor(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetCursor err: %v", err)
+		return
+	}
+	assert.Equal(t, uint64(100), cursor.BlockNum)
+	assert.True(t, blockAt.Equal(cursor.BlockAt))
+
+	if err := mgr.SetCursor(ctx, taskID, pair, 101, blockAt.Add(12*time.Second)); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+	cursor, err = mgr.GetCursor(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetCursor err: %v", err)
+		return
+	}
+	assert.Equal(t, uint64(101), cursor.BlockNum)
+
+	// another task of the pair that has only synced part of its history has its own cursor
+	if err := mgr.SetCursor(ctx, "task2", pair, 50, blockAt.Add(-time.Hour)); err != nil {
+		t.Errorf("SetCursor err: %v", err)
+		return
+	}
+	cursor, err = mgr.GetCursor(ctx, "task2")
+	if err != nil {
+		t.Errorf("GetCursor err: %v", err)
+		return
+	}
+	assert.Equal(t, uint64(50), cursor.BlockNum)
+	cursor, err = mgr.GetCursor(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetCursor err: %v", err)
+		return
+	}
+	assert.Equal(t, uint64(101), cursor.BlockNum)
+}
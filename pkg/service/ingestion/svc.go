@@ -0,0 +1,12 @@
+package ingestion
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.IngestionManager {
+	return &Manager{
+		db,
+	}
+}
@@ -0,0 +1,194 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// GetLeaderboard returns a page of the users with points in the scope, ordered by rank.
+func (m *Manager) GetLeaderboard(ctx context.Context, opt option.LeaderboardOptions) (model.Leaderboard, error) {
+	result := model.Leaderboard{Entries: make([]model.LeaderboardEntry, 0)}
+
+	query, args, err := rankedQuery(opt)
+	if err != nil {
+		return result, err
+	}
+
+	limit := opt.Limit
+	if limit <= 0 {
+		limit = constants.LeaderboardDefaultLimit
+	}
+	limit = min(limit, constants.LeaderboardMaxLimit)
+	query += fmt.Sprintf(`
+		SELECT "rank", "percentile", "userAddress", "point", "volume", "total"
+		FROM "ranked"
+		ORDER BY "rank", "userAddress"
+		LIMIT $%d OFFSET $%d
+	`, len(args)+1, len(args)+2)
+	args = append(args, limit, max(opt.Offset, 0))
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, fmt.Errorf("GetLeaderboard query fail: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(
+			&entry.Rank, &entry.Percentile, &entry.UserAddress, &entry.Point, &entry.Volume, &result.Total,
+		); err != nil {
+			return result, fmt.Errorf("GetLeaderboard scan fail: %v", err)
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	// a page past the last rank has no rows to read the total from
+	if len(result.Entries) == 0 && opt.Offset > 0 {
+		query, args, _ := rankedQuery(opt)
+		if err := m.db.QueryRowContext(ctx, query+`SELECT COUNT(*) FROM "ranked"`, args...).Scan(&result.Total); err != nil {
+			return result, fmt.Errorf("GetLeaderboard count fail: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// GetRank returns the standing of the user in the scope, sql.ErrNoRows when the user has no points in it.
+func (m *Manager) GetRank(ctx context.Context, opt option.LeaderboardOptions, address string) (model.LeaderboardEntry, error) {
+	var entry model.LeaderboardEntry
+
+	query, args, err := rankedQuery(opt)
+	if err != nil {
+		return entry, err
+	}
+	query += fmt.Sprintf(`
+		SELECT "rank", "percentile", "userAddress", "point", "volume"
+		FROM "ranked"
+		WHERE LOWER("userAddress") = LOWER($%d)
+	`, len(args)+1)
+	args = append(args, address)
+
+	if err := m.db.QueryRowContext(ctx, query, args...).Scan(
+		&entry.Rank, &entry.Percentile, &entry.UserAddress, &entry.Point, &entry.Volume,
+	); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// EachRank calls fn with every user of the scope by rank, reading one row at a time. Limit and Offset are ignored.
+func (m *Manager) EachRank(ctx context.Context, opt option.LeaderboardOptions, fn func(model.LeaderboardEntry) error) error {
+	query, args, err := rankedQuery(opt)
+	if err != nil {
+		return err
+	}
+	query += `
+		SELECT "rank", "percentile", "userAddress", "point", "volume"
+		FROM "ranked"
+		ORDER BY "rank", "userAddress"
+	`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("EachRank query fail: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.Percentile, &entry.UserAddress, &entry.Point, &entry.Volume); err != nil {
+			return fmt.Errorf("EachRank scan fail: %v", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// rankedQuery returns the common table expressions ranking the users of the scope.
+// Points come from the totals of userPoint, or from the ledger for one epoch. Volume sums the settled epochs of the task,
+// boards of a campaign or of all tasks only sum share pool tasks as a swap counts for every task of its pair.
+func rankedQuery(opt option.LeaderboardOptions) (string, []interface{}, error) {
+	var points, volumes string
+	var args []interface{}
+
+	switch {
+	case opt.Epoch != nil:
+		if len(opt.TaskID) == 0 {
+			return "", nil, fmt.Errorf("epoch leaderboard requires a task")
+		}
+		points = `
+			SELECT "userAddress", SUM("point") AS "point" FROM "pointLedger"
+			WHERE "taskId" = $1 AND "epoch" = $2
+			GROUP BY "userAddress"`
+		volumes = `
+			SELECT "userAddress", SUM("volume") AS "volume" FROM "epochVolume"
+			WHERE "taskId" = $1 AND "epoch" = $2
+			GROUP BY "userAddress"`
+		args = append(args, opt.TaskID, *opt.Epoch)
+	case len(opt.TaskID) != 0:
+		points = `
+			SELECT "userAddress", SUM("point") AS "point" FROM "userPoint"
+			WHERE "taskId" = $1
+			GROUP BY "userAddress"`
+		volumes = `
+			SELECT "userAddress", SUM("volume") AS "volume" FROM "epochVolume"
+			WHERE "taskId" = $1
+			GROUP BY "userAddress"`
+		args = append(args, opt.TaskID)
+	case len(opt.CampaignID) != 0:
+		points = `
+			SELECT up."userAddress", SUM(up."point") AS "point" FROM "userPoint" up
+			JOIN "task" t ON t."id" = up."taskId"
+			WHERE t."campaignId" = $1
+			GROUP BY up."userAddress"`
+		volumes = `
+			SELECT ev."userAddress", SUM(ev."volume") AS "volume" FROM "epochVolume" ev
+			JOIN "task" t ON t."id" = ev."taskId"
+			WHERE t."campaignId" = $1 AND t."name" = 'share_pool'
+			GROUP BY ev."userAddress"`
+		args = append(args, opt.CampaignID)
+	default:
+		points = `
+			SELECT "userAddress", SUM("point") AS "point" FROM "userPoint"
+			GROUP BY "userAddress"`
+		volumes = `
+			SELECT ev."userAddress", SUM(ev."volume") AS "volume" FROM "epochVolume" ev
+			JOIN "task" t ON t."id" = ev."taskId"
+			WHERE t."name" = 'share_pool'
+			GROUP BY ev."userAddress"`
+	}
+
+	query := fmt.Sprintf(`
+		WITH "points" AS (%s
+		), "volumes" AS (%s
+		), "ranked" AS (
+			SELECT
+				p."userAddress",
+				p."point",
+				COALESCE(v."volume", 0) AS "volume",
+				DENSE_RANK() OVER (ORDER BY p."point" DESC) AS "rank",
+				ROUND((CUME_DIST() OVER (ORDER BY p."point") * 100)::NUMERIC, 2) AS "percentile",
+				COUNT(*) OVER () AS "total"
+			FROM "points" p
+			LEFT JOIN "volumes" v ON v."userAddress" = p."userAddress"
+			WHERE p."point" > 0
+		)`, points, volumes)
+
+	return query, args, nil
+}
@@ -0,0 +1,202 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/model/option"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	user1 = "0x0000000000000000000000000000000000000001"
+	user2 = "0x0000000000000000000000000000000000000002"
+	user3 = "0x0000000000000000000000000000000000000003"
+	user4 = "0x0000000000000000000000000000000000000004"
+)
+
+func setupLeaderboard(d *sql.DB) error {
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES ('campaign1', 'season 1', $1, $2)
+	`, now, now.AddDate(0, 3, 0)); err != nil {
+		return err
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "pairAddress", "startAt", "campaignId") VALUES
+		('pool', 'share_pool', '0xpair', $1, 'campaign1'),
+		('streak', 'streak', '0xpair', $1, 'campaign1'),
+		('onboarding', 'onboarding', NULL, $1, NULL)
+	`, now); err != nil {
+		return err
+	}
+
+	// pool: user1 300, user2 and user3 tie at 200, user4 has no points
+	if _, err := d.Exec(`
+		INSERT INTO "userPoint" ("id", "userAddress", "taskId", "point") VALUES
+		('p1', $1, 'pool', 300), ('p2', $2, 'pool', 200), ('p3', $3, 'pool', 200), ('p4', $4, 'pool', 0),
+		('p5', $3, 'streak', 500),
+		('p6', $4, 'onboarding', 100)
+	`, user1, user2, user3, user4); err != nil {
+		return err
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "pointLedger" ("id", "userAddress", "taskId", "epoch", "point", "reason") VALUES
+		('l1', $1, 'pool', 0, 100, 'share_pool_epoch'), ('l2', $1, 'pool', 1, 200, 'share_pool_epoch'),
+		('l3', $2, 'pool', 0, 200, 'share_pool_epoch'),
+		('l4', $3, 'pool', 1, 200, 'share_pool_epoch')
+	`, user1, user2, user3); err != nil {
+		return err
+	}
+	_, err := d.Exec(`
+		INSERT INTO "epochVolume" ("userAddress", "taskId", "epoch", "volume") VALUES
+		($1, 'pool', 0, 1000), ($1, 'pool', 1, 2000), ($2, 'pool', 0, 1500), ($3, 'pool', 1, 800),
+		($3, 'streak', 1, 800)
+	`, user1, user2, user3)
+
+	return err
+}
+
+func TestManager_GetLeaderboard(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	if err := setupLeaderboard(d); err != nil {
+		t.Errorf("setup leaderboard err: %v", err)
+		return
+	}
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+	epoch := 0
+
+	type want struct {
+		address string
+		rank    int
+		point   int64
+		volume  int64
+	}
+	tests := []struct {
+		name string
+		opt  option.LeaderboardOptions
+		want []want
+	}{
+		{
+			name: "task",
+			opt:  option.LeaderboardOptions{TaskID: "pool"},
+			want: []want{{user1, 1, 300, 3000}, {user2, 2, 200, 1500}, {user3, 2, 200, 800}},
+		},
+		{
+			name: "epoch",
+			opt:  option.LeaderboardOptions{TaskID: "pool", Epoch: &epoch},
+			want: []want{{user2, 1, 200, 1500}, {user1, 2, 100, 1000}},
+		},
+		{
+			// volume of the streak task is not counted twice
+			name: "campaign",
+			opt:  option.LeaderboardOptions{CampaignID: "campaign1"},
+			want: []want{{user3, 1, 700, 800}, {user1, 2, 300, 3000}, {user2, 3, 200, 1500}},
+		},
+		{
+			name: "global",
+			opt:  option.LeaderboardOptions{},
+			want: []want{{user3, 1, 700, 800}, {user1, 2, 300, 3000}, {user2, 3, 200, 1500}, {user4, 4, 100, 0}},
+		},
+		{
+			name: "page",
+			opt:  option.LeaderboardOptions{Limit: 2, Offset: 1},
+			want: []want{{user1, 2, 300, 3000}, {user2, 3, 200, 1500}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := mgr.GetLeaderboard(ctx, tt.opt)
+			if err != nil {
+				t.Errorf("GetLeaderboard err: %v", err)
+				return
+			}
+			if !assert.Equal(t, len(tt.want), len(result.Entries)) {
+				return
+			}
+			for i, w := range tt.want {
+				entry := result.Entries[i]
+				assert.Equal(t, w.address, entry.UserAddress)
+				assert.Equal(t, w.rank, entry.Rank)
+				assert.True(t, decimal.NewFromInt(w.point).Equal(entry.Point), "%s point: %v", w.address, entry.Point)
+				assert.True(t, decimal.NewFromInt(w.volume).Equal(entry.Volume), "%s volume: %v", w.address, entry.Volume)
+			}
+		})
+	}
+
+	result, err := mgr.GetLeaderboard(ctx, option.LeaderboardOptions{TaskID: "pool"})
+	if err != nil {
+		t.Errorf("GetLeaderboard err: %v", err)
+		return
+	}
+	assert.Equal(t, 3, result.Total)
+	assert.True(t, decimal.NewFromInt(100).Equal(result.Entries[0].Percentile), "got: %v", result.Entries[0].Percentile)
+	assert.True(t, decimal.RequireFromString("66.67").Equal(result.Entries[1].Percentile), "got: %v", result.Entries[1].Percentile)
+
+	result, err = mgr.GetLeaderboard(ctx, option.LeaderboardOptions{TaskID: "pool", Offset: 10})
+	if err != nil {
+		t.Errorf("GetLeaderboard err: %v", err)
+		return
+	}
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 0, len(result.Entries))
+
+	if _, err := mgr.GetLeaderboard(ctx, option.LeaderboardOptions{Epoch: &epoch}); err == nil {
+		t.Errorf("GetLeaderboard should reject an epoch without task")
+	}
+}
+
+func TestManager_GetRank(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	if err := setupLeaderboard(d); err != nil {
+		t.Errorf("setup leaderboard err: %v", err)
+		return
+	}
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	entry, err := mgr.GetRank(ctx, option.LeaderboardOptions{TaskID: "pool"}, user3)
+	if err != nil {
+		t.Errorf("GetRank err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, entry.Rank)
+	assert.True(t, decimal.NewFromInt(200).Equal(entry.Point))
+
+	entry, err = mgr.GetRank(ctx, option.LeaderboardOptions{}, user4)
+	if err != nil {
+		t.Errorf("GetRank err: %v", err)
+		return
+	}
+	assert.Equal(t, 4, entry.Rank)
+	assert.True(t, decimal.NewFromInt(25).Equal(entry.Percentile), "got: %v", entry.Percentile)
+
+	// users without points are not ranked
+	_, err = mgr.GetRank(ctx, option.LeaderboardOptions{TaskID: "pool"}, user4)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
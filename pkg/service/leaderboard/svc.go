@@ -0,0 +1,12 @@
+package leaderboard
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.LeaderboardManager {
+	return &Manager{
+		db,
+	}
+}
@@ -0,0 +1,106 @@
+package liquidity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/utils"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// InsertEvent saves a Mint or Burn event, an event already saved from a previous sync is kept as is.
+func (m *Manager) InsertEvent(ctx context.Context, opt option.LiquidityEventUpsertOptions) error {
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "liquidityEvent" (
+			"id", "createdAt", "blockNum", "logIndex", "txHash", "pairAddress", "kind",
+			"senderAddress", "toAddress", "amount0", "amount1", "eventAt"
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT ("blockNum", "pairAddress", "logIndex") DO NOTHING
+	`,
+		utils.GenDBID(),
+		time.Now(),
+		opt.BlockNum,
+		opt.LogIndex,
+		opt.TxHash,
+		opt.PairAddress,
+		opt.Kind,
+		opt.SenderAddress,
+		opt.ToAddress,
+		opt.Amount0,
+		opt.Amount1,
+		opt.EventAt,
+	); err != nil {
+		return fmt.Errorf("InsertEvent insert liquidity event fail: %v", err)
+	}
+
+	return nil
+}
+
+// InsertTransfer saves a Transfer event of the LP token, a transfer already saved from a previous sync is kept as is.
+func (m *Manager) InsertTransfer(ctx context.Context, opt option.LPTransferUpsertOptions) error {
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "lpTransfer" (
+			"id", "createdAt", "blockNum", "logIndex", "txHash", "pairAddress",
+			"fromAddress", "toAddress", "value", "transferAt"
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT ("blockNum", "pairAddress", "logIndex") DO NOTHING
+	`,
+		utils.GenDBID(),
+		time.Now(),
+		opt.BlockNum,
+		opt.LogIndex,
+		opt.TxHash,
+		opt.PairAddress,
+		opt.FromAddress,
+		opt.ToAddress,
+		opt.Value,
+		opt.TransferAt,
+	); err != nil {
+		return fmt.Errorf("InsertTransfer insert lp transfer fail: %v", err)
+	}
+
+	return nil
+}
+
+// GetTransfers returns the LP token transfers of the pair before endAt, in chain order.
+func (m *Manager) GetTransfers(ctx context.Context, pairAddress string, endAt time.Time) ([]model.LPTransfer, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "blockNum", "logIndex", "txHash", "pairAddress", "fromAddress", "toAddress", "value", "transferAt"
+		FROM "lpTransfer"
+		WHERE LOWER("pairAddress") = LOWER($1) AND "transferAt" < $2
+		ORDER BY "blockNum", "logIndex"
+	`, pairAddress, endAt)
+	if err != nil {
+		return nil, fmt.Errorf("GetTransfers query fail: %v", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]model.LPTransfer, 0)
+	for rows.Next() {
+		var transfer model.LPTransfer
+		if err := rows.Scan(
+			&transfer.ID,
+			&transfer.BlockNum,
+			&transfer.LogIndex,
+			&transfer.TxHash,
+			&transfer.PairAddress,
+			&transfer.FromAddress,
+			&transfer.ToAddress,
+			&transfer.Value,
+			&transfer.TransferAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetTransfers scan fail: %v", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
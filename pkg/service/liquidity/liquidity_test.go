@@ -0,0 +1,67 @@
+package liquidity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/model/option"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_InsertAndGetTransfers(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	at := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	transfers := []option.LPTransferUpsertOptions{
+		{BlockNum: 2, LogIndex: 0, TxHash: "0x2", PairAddress: pair, FromAddress: "0x0", ToAddress: "0xbob", Value: decimal.NewFromInt(20), TransferAt: at.Add(time.Hour)},
+		{BlockNum: 1, LogIndex: 3, TxHash: "0x1", PairAddress: pair, FromAddress: "0x0", ToAddress: "0xalice", Value: decimal.NewFromInt(10), TransferAt: at},
+		{BlockNum: 3, LogIndex: 0, TxHash: "0x3", PairAddress: pair, FromAddress: "0xalice", ToAddress: "0xbob", Value: decimal.NewFromInt(5), TransferAt: at.Add(48 * time.Hour)},
+	}
+
+	// inserting twice keeps a single transfer
+	for i := 0; i < 2; i++ {
+		for _, transfer := range transfers {
+			if err := mgr.InsertTransfer(ctx, transfer); err != nil {
+				t.Errorf("InsertTransfer err: %v", err)
+				return
+			}
+		}
+	}
+
+	if err := mgr.InsertEvent(ctx, option.LiquidityEventUpsertOptions{
+		BlockNum: 1, LogIndex: 4, TxHash: "0x1", PairAddress: pair, Kind: "mint",
+		SenderAddress: "0xrouter", Amount0: decimal.NewFromInt(1), Amount1: decimal.NewFromInt(2), EventAt: at,
+	}); err != nil {
+		t.Errorf("InsertEvent err: %v", err)
+		return
+	}
+
+	result, err := mgr.GetTransfers(ctx, pair, at.Add(24*time.Hour))
+	if err != nil {
+		t.Errorf("GetTransfers err: %v", err)
+		return
+	}
+
+	assert.Equal(t, 2, len(result))
+	if len(result) == 2 {
+		assert.Equal(t, "0xalice", result[0].ToAddress)
+		assert.Equal(t, "0xbob", result[1].ToAddress)
+		assert.True(t, decimal.NewFromInt(20).Equal(result[1].Value))
+	}
+}
@@ -0,0 +1,12 @@
+package liquidity
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.LiquidityManager {
+	return &Manager{
+		db,
+	}
+}
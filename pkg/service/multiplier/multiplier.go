@@ -0,0 +1,127 @@
+package multiplier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/multiplier"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// CreateRule saves the rule with its member addresses, the saved rule is returned with its ID.
+func (m *Manager) CreateRule(ctx context.Context, rule model.MultiplierRule) (model.MultiplierRule, error) {
+	if err := multiplier.Validate(rule); err != nil {
+		return rule, fmt.Errorf("invalid multiplier rule: %w", err)
+	}
+
+	rule.ID = utils.GenDBID()
+	rule.CreatedAt = time.Now()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return rule, fmt.Errorf("CreateRule begin fail: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "multiplierRule" ("id", "createdAt", "name", "multiplier", "startAt", "endAt", "taskId", "pairAddress")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		rule.ID,
+		rule.CreatedAt,
+		rule.Name,
+		rule.Multiplier,
+		rule.StartAt,
+		rule.EndAt,
+		rule.TaskID,
+		rule.PairAddress,
+	); err != nil {
+		return rule, fmt.Errorf("CreateRule insert rule fail: %v", err)
+	}
+
+	for _, address := range rule.Addresses {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "multiplierAddress" ("ruleId", "address") VALUES ($1, $2)
+			ON CONFLICT ("ruleId", "address") DO NOTHING
+		`, rule.ID, address); err != nil {
+			return rule, fmt.Errorf("CreateRule insert address fail: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return rule, fmt.Errorf("CreateRule commit fail: %v", err)
+	}
+
+	return rule, nil
+}
+
+// GetRules returns every rule with its member addresses, oldest first.
+func (m *Manager) GetRules(ctx context.Context) ([]model.MultiplierRule, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "createdAt", "name", "multiplier", "startAt", "endAt", "taskId", "pairAddress"
+		FROM "multiplierRule"
+		ORDER BY "createdAt", "id"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("GetRules query fail: %v", err)
+	}
+	defer rows.Close()
+
+	rules := make([]model.MultiplierRule, 0)
+	index := make(map[string]int)
+	for rows.Next() {
+		var rule model.MultiplierRule
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.CreatedAt,
+			&rule.Name,
+			&rule.Multiplier,
+			&rule.StartAt,
+			&rule.EndAt,
+			&rule.TaskID,
+			&rule.PairAddress,
+		); err != nil {
+			return nil, fmt.Errorf("GetRules scan fail: %v", err)
+		}
+		index[rule.ID] = len(rules)
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	addressRows, err := m.db.QueryContext(ctx, `
+		SELECT "ruleId", "address" FROM "multiplierAddress" ORDER BY "ruleId", "address"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("GetRules query address fail: %v", err)
+	}
+	defer addressRows.Close()
+
+	for addressRows.Next() {
+		var ruleID, address string
+		if err := addressRows.Scan(&ruleID, &address); err != nil {
+			return nil, fmt.Errorf("GetRules scan address fail: %v", err)
+		}
+		if i, ok := index[ruleID]; ok {
+			rules[i].Addresses = append(rules[i].Addresses, address)
+		}
+	}
+
+	return rules, addressRows.Err()
+}
+
+// DeleteRule removes the rule, points already awarded with it are kept.
+func (m *Manager) DeleteRule(ctx context.Context, ruleID string) error {
+	if _, err := m.db.ExecContext(ctx, `DELETE FROM "multiplierRule" WHERE "id" = $1`, ruleID); err != nil {
+		return fmt.Errorf("DeleteRule fail: %v", err)
+	}
+
+	return nil
+}
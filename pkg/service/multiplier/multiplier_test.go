@@ -0,0 +1,78 @@
+package multiplier
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/model"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Rules(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	saturday := time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC)
+	monday := saturday.AddDate(0, 0, 2)
+
+	weekend, err := mgr.CreateRule(ctx, model.MultiplierRule{
+		Name:       "double points weekend",
+		Multiplier: decimal.NewFromInt(2),
+		StartAt:    &saturday,
+		EndAt:      &monday,
+	})
+	if err != nil {
+		t.Errorf("CreateRule err: %v", err)
+		return
+	}
+	if _, err := mgr.CreateRule(ctx, model.MultiplierRule{
+		Name:       "partner community",
+		Multiplier: decimal.NewFromFloat(1.5),
+		Addresses:  []string{"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"},
+	}); err != nil {
+		t.Errorf("CreateRule err: %v", err)
+		return
+	}
+	if _, err := mgr.CreateRule(ctx, model.MultiplierRule{Name: "invalid"}); err == nil {
+		t.Errorf("CreateRule should reject a rule without multiplier")
+	}
+
+	rules, err := mgr.GetRules(ctx)
+	if err != nil {
+		t.Errorf("GetRules err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 2, len(rules)) {
+		return
+	}
+	assert.Equal(t, weekend.ID, rules[0].ID)
+	assert.True(t, saturday.Equal(*rules[0].StartAt))
+	assert.Equal(t, 0, len(rules[0].Addresses))
+	assert.Nil(t, rules[1].StartAt)
+	assert.Equal(t, 2, len(rules[1].Addresses))
+	assert.True(t, decimal.NewFromFloat(1.5).Equal(rules[1].Multiplier))
+
+	if err := mgr.DeleteRule(ctx, weekend.ID); err != nil {
+		t.Errorf("DeleteRule err: %v", err)
+		return
+	}
+	rules, err = mgr.GetRules(ctx)
+	if err != nil {
+		t.Errorf("GetRules err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(rules))
+}
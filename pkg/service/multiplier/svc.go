@@ -0,0 +1,12 @@
+package multiplier
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.MultiplierManager {
+	return &Manager{
+		db,
+	}
+}
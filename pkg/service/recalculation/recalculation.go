@@ -0,0 +1,125 @@
+package recalculation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/lib/pq"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// RecordTx writes the recalculation and its entries in the transaction that settles the recalculated epochs.
+func (m *Manager) RecordTx(ctx context.Context, tx *sql.Tx, rec model.Recalculation) error {
+	epochs := make(pq.Int64Array, 0, len(rec.Epochs))
+	for _, e := range rec.Epochs {
+		epochs = append(epochs, int64(e))
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "recalculation" ("id", "taskId", "ruleVersion", "epochs", "reason", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, rec.ID, rec.TaskID, rec.RuleVersion, epochs, rec.Reason, rec.CreatedAt); err != nil {
+		return fmt.Errorf("RecordTx insert recalculation fail: %v", err)
+	}
+
+	for _, entry := range rec.Entries {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "recalculationEntry" ("id", "recalculationId", "userAddress", "before", "after")
+			VALUES ($1, $2, $3, $4, $5)
+		`, utils.GenDBID(), rec.ID, entry.UserAddress, entry.Before, entry.After); err != nil {
+			return fmt.Errorf("RecordTx insert entry fail: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetRecalculations returns the recalculations of the task without their entries, the latest first.
+func (m *Manager) GetRecalculations(ctx context.Context, taskID string) ([]model.Recalculation, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "taskId", "ruleVersion", "epochs", "reason", "createdAt"
+		FROM "recalculation"
+		WHERE "taskId" = $1
+		ORDER BY "createdAt" DESC, "id"
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRecalculations query fail: %v", err)
+	}
+	defer rows.Close()
+
+	recs := make([]model.Recalculation, 0)
+	for rows.Next() {
+		rec, err := scanRecalculation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetRecalculations scan fail: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, rows.Err()
+}
+
+// GetRecalculation returns the recalculation with the points of every user before and after it.
+// It returns sql.ErrNoRows when the recalculation does not exist.
+func (m *Manager) GetRecalculation(ctx context.Context, recalculationID string) (model.Recalculation, error) {
+	rec, err := scanRecalculation(m.db.QueryRowContext(ctx, `
+		SELECT "id", "taskId", "ruleVersion", "epochs", "reason", "createdAt"
+		FROM "recalculation"
+		WHERE "id" = $1
+	`, recalculationID))
+	if err != nil {
+		return rec, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "userAddress", "before", "after"
+		FROM "recalculationEntry"
+		WHERE "recalculationId" = $1
+		ORDER BY "userAddress"
+	`, recalculationID)
+	if err != nil {
+		return rec, fmt.Errorf("GetRecalculation query entries fail: %v", err)
+	}
+	defer rows.Close()
+
+	rec.Entries = make([]model.RecalculationEntry, 0)
+	for rows.Next() {
+		var entry model.RecalculationEntry
+		if err := rows.Scan(&entry.UserAddress, &entry.Before, &entry.After); err != nil {
+			return rec, fmt.Errorf("GetRecalculation scan entry fail: %v", err)
+		}
+		rec.Entries = append(rec.Entries, entry)
+	}
+
+	return rec, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecalculation(row scanner) (model.Recalculation, error) {
+	var rec model.Recalculation
+	var ruleVersion sql.NullInt32
+	var epochs pq.Int64Array
+	if err := row.Scan(&rec.ID, &rec.TaskID, &ruleVersion, &epochs, &rec.Reason, &rec.CreatedAt); err != nil {
+		return rec, err
+	}
+
+	if ruleVersion.Valid {
+		v := int(ruleVersion.Int32)
+		rec.RuleVersion = &v
+	}
+	rec.Epochs = make([]int, 0, len(epochs))
+	for _, e := range epochs {
+		rec.Epochs = append(rec.Epochs, int(e))
+	}
+
+	return rec, nil
+}
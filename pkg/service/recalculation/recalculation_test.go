@@ -0,0 +1,86 @@
+package recalculation
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/model"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Recalculations(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	version := 2
+	recs := []model.Recalculation{
+		{ID: "rec1", TaskID: "task1", Epochs: []int{0, 1}, Reason: "volume fix", CreatedAt: time.Now().Add(-time.Hour)},
+		{
+			ID: "rec2", TaskID: "task1", RuleVersion: &version, Epochs: []int{1}, CreatedAt: time.Now(),
+			Entries: []model.RecalculationEntry{
+				{UserAddress: "0x2", Before: decimal.NewFromInt(7500), After: decimal.NewFromInt(5000)},
+				{UserAddress: "0x1", Before: decimal.NewFromInt(2500), After: decimal.NewFromInt(5000)},
+			},
+		},
+	}
+	for _, rec := range recs {
+		tx, err := d.BeginTx(ctx, nil)
+		if err != nil {
+			t.Errorf("BeginTx err: %v", err)
+			return
+		}
+		if err := mgr.RecordTx(ctx, tx, rec); err != nil {
+			tx.Rollback()
+			t.Errorf("RecordTx err: %v", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			t.Errorf("Commit err: %v", err)
+			return
+		}
+	}
+
+	result, err := mgr.GetRecalculations(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetRecalculations err: %v", err)
+		return
+	}
+	if assert.Equal(t, 2, len(result)) {
+		assert.Equal(t, "rec2", result[0].ID)
+		assert.Equal(t, &version, result[0].RuleVersion)
+		assert.Equal(t, "rec1", result[1].ID)
+		assert.Nil(t, result[1].RuleVersion)
+		assert.Equal(t, []int{0, 1}, result[1].Epochs)
+		assert.Equal(t, "volume fix", result[1].Reason)
+	}
+
+	rec, err := mgr.GetRecalculation(ctx, "rec2")
+	if err != nil {
+		t.Errorf("GetRecalculation err: %v", err)
+		return
+	}
+	assert.Equal(t, []int{1}, rec.Epochs)
+	if assert.Equal(t, 2, len(rec.Entries)) {
+		assert.Equal(t, "0x1", rec.Entries[0].UserAddress)
+		assert.True(t, decimal.NewFromInt(2500).Equal(rec.Entries[0].Before))
+		assert.True(t, decimal.NewFromInt(5000).Equal(rec.Entries[0].After))
+		assert.Equal(t, "0x2", rec.Entries[1].UserAddress)
+	}
+
+	_, err = mgr.GetRecalculation(ctx, "notExist")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
@@ -0,0 +1,12 @@
+package recalculation
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.RecalculationManager {
+	return &Manager{
+		db,
+	}
+}
@@ -0,0 +1,188 @@
+package referral
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/referral"
+	"tradingAce/pkg/core/volume"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type Manager struct {
+	db             *sql.DB
+	taskMgr        iface.TaskManager
+	transactionMgr iface.TransactionManager
+	userTaskMgr    iface.UserTaskManager
+	userPointMgr   iface.UserPointManager
+}
+
+// Register saves the referrer of the referee. Errors of referrals that are not allowed wrap referral.ErrInvalid:
+// a bad signature, self referral, a referee that is already registered or onboarded, and referral loops.
+func (m *Manager) Register(ctx context.Context, opt option.RegisterReferralOptions) error {
+	if err := referral.Verify(opt.Referee, opt.Referrer, opt.Signature); err != nil {
+		return err
+	}
+	referee := common.HexToAddress(opt.Referee).Hex()
+	referrer := common.HexToAddress(opt.Referrer).Hex()
+
+	var registered bool
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM "referral" WHERE "refereeAddress" = $1)
+	`, referee).Scan(&registered); err != nil {
+		return fmt.Errorf("Register query referral fail: %v", err)
+	}
+	if registered {
+		return fmt.Errorf("%w: referee already has a referrer", referral.ErrInvalid)
+	}
+
+	var onboarded bool
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM "userTask" ut JOIN task t ON ut."taskId" = t."id"
+			WHERE t."name" = 'onboarding' AND ut."userAddress" = $1 AND ut."state" = 'completed'
+		)
+	`, referee).Scan(&onboarded); err != nil {
+		return fmt.Errorf("Register query onboarding fail: %v", err)
+	}
+	if onboarded {
+		return fmt.Errorf("%w: referee is already onboarded", referral.ErrInvalid)
+	}
+
+	// the referee must not be up the referrer's chain, e.g. A refers B and B refers A
+	var loop bool
+	if err := m.db.QueryRowContext(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT "referrerAddress" FROM "referral" WHERE "refereeAddress" = $1
+			UNION
+			SELECT r."referrerAddress" FROM "referral" r JOIN chain c ON r."refereeAddress" = c."referrerAddress"
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE "referrerAddress" = $2)
+	`, referrer, referee).Scan(&loop); err != nil {
+		return fmt.Errorf("Register query referral chain fail: %v", err)
+	}
+	if loop {
+		return fmt.Errorf("%w: referral loop", referral.ErrInvalid)
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "referral" ("id", "createdAt", "refereeAddress", "referrerAddress", "signature")
+		VALUES ($1, $2, $3, $4, $5)
+	`, utils.GenDBID(), time.Now(), referee, referrer, opt.Signature); err != nil {
+		return fmt.Errorf("Register insert referral fail: %v", err)
+	}
+
+	return nil
+}
+
+// CheckReferee updates what the referee has earned for its referrer, then the points of the referrer in the referral task.
+// Only swaps on the supported pairs after the registration count toward the volume tiers.
+func (m *Manager) CheckReferee(ctx context.Context, referee string) error {
+	r, err := m.getReferral(ctx, referee)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("CheckReferee query referral fail: %v", err)
+	}
+
+	task, err := m.taskMgr.GetReferralTask(ctx)
+	if err != nil {
+		return fmt.Errorf("CheckReferee get referral task fail: %v", err)
+	}
+
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM "userTask" ut JOIN task t ON ut."taskId" = t."id"
+			WHERE t."name" = 'onboarding' AND ut."userAddress" = $1 AND ut."state" = 'completed'
+		)
+	`, r.RefereeAddress).Scan(&r.Onboarded); err != nil {
+		return fmt.Errorf("CheckReferee query onboarding fail: %v", err)
+	}
+
+	pairs := make([]string, 0, len(constants.SupportedPairs))
+	for pair := range constants.SupportedPairs {
+		pairs = append(pairs, pair)
+	}
+	swaps, err := m.transactionMgr.GetUserSwaps(ctx, option.GetUserSwapsOptions{
+		Address: r.RefereeAddress,
+		Pairs:   pairs,
+		StartAt: r.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("CheckReferee get swaps fail: %v", err)
+	}
+	r.VolumeUSD = volume.InputUSD(swaps, nil)
+	r.Point = referral.Reward(task.Config.Referral, r.Onboarded, r.VolumeUSD)
+
+	if _, err := m.db.ExecContext(ctx, `
+		UPDATE "referral" SET "onboarded" = $1, "volumeUsd" = $2, "point" = $3 WHERE "id" = $4
+	`, r.Onboarded, r.VolumeUSD, r.Point, r.ID); err != nil {
+		return fmt.Errorf("CheckReferee update referral fail: %v", err)
+	}
+
+	stats, err := m.GetStats(ctx, r.ReferrerAddress)
+	if err != nil {
+		return err
+	}
+	if err := m.userTaskMgr.Upsert(ctx, r.ReferrerAddress, task.ID, "pending", stats.VolumeUSD); err != nil {
+		return fmt.Errorf("CheckReferee upsert user task fail: %v", err)
+	}
+	if err := m.userPointMgr.SetEpochPoints(ctx, option.SetEpochPointsOptions{
+		Address: r.ReferrerAddress,
+		TaskID:  task.ID,
+		Point:   stats.Point,
+		Reason:  constants.PointReasonReferral,
+	}); err != nil {
+		return fmt.Errorf("CheckReferee set points fail: %v", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) GetStats(ctx context.Context, address string) (model.ReferralStats, error) {
+	stats := model.ReferralStats{Address: common.HexToAddress(address).Hex()}
+
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE "onboarded"), COALESCE(SUM("volumeUsd"), 0), COALESCE(SUM("point"), 0)
+		FROM "referral"
+		WHERE "referrerAddress" = $1
+	`, stats.Address).Scan(&stats.Referees, &stats.OnboardedReferees, &stats.VolumeUSD, &stats.Point); err != nil {
+		return stats, fmt.Errorf("GetStats query referees fail: %v", err)
+	}
+
+	r, err := m.getReferral(ctx, stats.Address)
+	if err == nil {
+		stats.Referrer = r.ReferrerAddress
+	} else if err != sql.ErrNoRows {
+		return stats, fmt.Errorf("GetStats query referrer fail: %v", err)
+	}
+
+	return stats, nil
+}
+
+func (m *Manager) getReferral(ctx context.Context, referee string) (model.Referral, error) {
+	var r model.Referral
+	err := m.db.QueryRowContext(ctx, `
+		SELECT "id", "createdAt", "refereeAddress", "referrerAddress", "signature", "onboarded", "volumeUsd", "point"
+		FROM "referral"
+		WHERE "refereeAddress" = $1
+	`, common.HexToAddress(referee).Hex()).Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.RefereeAddress,
+		&r.ReferrerAddress,
+		&r.Signature,
+		&r.Onboarded,
+		&r.VolumeUSD,
+		&r.Point,
+	)
+
+	return r, err
+}
@@ -0,0 +1,183 @@
+package referral
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/referral"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
+	"tradingAce/pkg/service/transaction"
+	"tradingAce/pkg/service/userpoint"
+	"tradingAce/pkg/service/usertask"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	key     *ecdsa.PrivateKey
+	address string
+}
+
+func newUser(t *testing.T) user {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user{key: key, address: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+}
+
+// refer returns the options of referee registering referrer
+func refer(t *testing.T, referee user, referrer user) option.RegisterReferralOptions {
+	sig, err := crypto.Sign(accounts.TextHash([]byte(referral.Message(referee.address, referrer.address))), referee.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return option.RegisterReferralOptions{Referee: referee.address, Referrer: referrer.address, Signature: hexutil.Encode(sig)}
+}
+
+func TestManager_Referral(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	config := model.TaskConfig{Referral: model.ReferralConfig{
+		Tiers: []model.ReferralTier{{VolumeUSD: decimal.NewFromInt(1000), Points: decimal.NewFromInt(100)}},
+	}}
+	for _, task := range []struct {
+		id     string
+		name   string
+		config model.TaskConfig
+	}{
+		{id: "onboardingtask", name: "onboarding"},
+		{id: "referraltask", name: "referral", config: config},
+	} {
+		if _, err := d.Exec(
+			`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt", "config") VALUES ($1, $2, $3, $4, $5, $6)`,
+			task.id, time.Now(), task.name, nil, time.Now(), task.config,
+		); err != nil {
+			t.Errorf("insert task err: %v", err)
+			return
+		}
+	}
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	userTaskMgr := usertask.NewManager(d, taskMgr, trMgr, userPointMgr, tradeflag.NewManager(d), addressinfo.NewManager(d), liquidity.NewManager(d), multiplier.NewManager(d), campaign.NewManager(d, taskMgr), settlement.NewManager(d), recalculation.NewManager(d))
+	mgr := NewManager(d, taskMgr, trMgr, userTaskMgr, userPointMgr)
+
+	alice, bob, carol := newUser(t), newUser(t), newUser(t)
+
+	// carol is onboarded before she registers
+	if err := userTaskMgr.Upsert(ctx, carol.address, "onboardingtask", "completed", decimal.NewFromInt(1000)); err != nil {
+		t.Errorf("Upsert err: %v", err)
+		return
+	}
+
+	if err := mgr.Register(ctx, refer(t, bob, alice)); err != nil {
+		t.Errorf("Register err: %v", err)
+		return
+	}
+
+	tests := []struct {
+		name string
+		opt  option.RegisterReferralOptions
+	}{
+		{name: "already registered", opt: refer(t, bob, carol)},
+		{name: "loop", opt: refer(t, alice, bob)},
+		{name: "self referral", opt: refer(t, alice, alice)},
+		{name: "already onboarded", opt: refer(t, carol, alice)},
+		{name: "signed by someone else", opt: option.RegisterReferralOptions{
+			Referee: carol.address, Referrer: alice.address, Signature: refer(t, bob, alice).Signature,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mgr.Register(ctx, tt.opt)
+			assert.True(t, errors.Is(err, referral.ErrInvalid), "err: %v", err)
+		})
+	}
+
+	// bob trades 2000 USD after registration and completes onboarding
+	if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+		BlockNum:        1,
+		PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+		SenderAddress:   bob.address,
+		Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(2000)),
+		ReceiverAddress: bob.address,
+		TransactionAt:   time.Now().Add(time.Minute),
+	}); err != nil {
+		t.Errorf("Upsert err: %v", err)
+		return
+	}
+	if err := userTaskMgr.Upsert(ctx, bob.address, "onboardingtask", "completed", decimal.NewFromInt(2000)); err != nil {
+		t.Errorf("Upsert err: %v", err)
+		return
+	}
+
+	if err := mgr.CheckReferee(ctx, bob.address); err != nil {
+		t.Errorf("CheckReferee err: %v", err)
+		return
+	}
+	// users without referrer are skipped
+	if err := mgr.CheckReferee(ctx, carol.address); err != nil {
+		t.Errorf("CheckReferee err: %v", err)
+		return
+	}
+
+	stats, err := mgr.GetStats(ctx, alice.address)
+	if err != nil {
+		t.Errorf("GetStats err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, stats.Referees)
+	assert.Equal(t, 1, stats.OnboardedReferees)
+	assert.True(t, decimal.NewFromInt(2000).Equal(stats.VolumeUSD), "volume: %v", stats.VolumeUSD)
+	// onboarding 50 + 1000 USD tier 100
+	assert.True(t, decimal.NewFromInt(150).Equal(stats.Point), "point: %v", stats.Point)
+
+	points, err := userPointMgr.GetUserPointsForTask(ctx, "referraltask")
+	if err != nil {
+		t.Errorf("GetUserPointsForTask err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(points))
+	assert.Equal(t, alice.address, points[0].UserAddress)
+	assert.True(t, decimal.NewFromInt(150).Equal(points[0].Point))
+
+	stats, err = mgr.GetStats(ctx, bob.address)
+	if err != nil {
+		t.Errorf("GetStats err: %v", err)
+		return
+	}
+	assert.Equal(t, alice.address, stats.Referrer)
+	assert.Equal(t, 0, stats.Referees)
+}
@@ -0,0 +1,22 @@
+package referral
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(
+	db *sql.DB,
+	taskMgr iface.TaskManager,
+	transactionMgr iface.TransactionManager,
+	userTaskMgr iface.UserTaskManager,
+	userPointMgr iface.UserPointManager,
+) iface.ReferralManager {
+	return &Manager{
+		db,
+		taskMgr,
+		transactionMgr,
+		userTaskMgr,
+		userPointMgr,
+	}
+}
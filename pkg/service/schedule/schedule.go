@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/schedule"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// StartRun records a running attempt to settle the epoch of the task and returns its ID.
+func (m *Manager) StartRun(ctx context.Context, taskID string, epoch int, attempt int) (string, error) {
+	id := utils.GenDBID()
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO "scheduleRun" ("id", "taskId", "epoch", "attempt", "state", "startedAt")
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, id, taskID, epoch, attempt, schedule.StateRunning, time.Now()); err != nil {
+		return "", fmt.Errorf("StartRun fail: %v", err)
+	}
+
+	return id, nil
+}
+
+// FinishRun records the outcome of the run, it failed when runErr is not nil.
+func (m *Manager) FinishRun(ctx context.Context, runID string, runErr error) error {
+	state, message := schedule.StateSucceeded, ""
+	if runErr != nil {
+		state, message = schedule.StateFailed, runErr.Error()
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		UPDATE "scheduleRun" SET "state" = $2, "error" = $3, "finishedAt" = $4
+		WHERE "id" = $1
+	`, runID, state, message, time.Now()); err != nil {
+		return fmt.Errorf("FinishRun fail: %v", err)
+	}
+
+	return nil
+}
+
+// GetRuns returns the run history of the task, the latest attempt of every epoch first.
+func (m *Manager) GetRuns(ctx context.Context, taskID string) ([]model.ScheduleRun, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "taskId", "epoch", "attempt", "state", "error", "startedAt", "finishedAt"
+		FROM "scheduleRun"
+		WHERE "taskId" = $1
+		ORDER BY "epoch", "attempt" DESC
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRuns query fail: %v", err)
+	}
+	defer rows.Close()
+
+	runs := make([]model.ScheduleRun, 0)
+	for rows.Next() {
+		var run model.ScheduleRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(
+			&run.ID, &run.TaskID, &run.Epoch, &run.Attempt, &run.State, &run.Error, &run.StartedAt, &finishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetRuns scan fail: %v", err)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetLatestRuns returns the latest attempt of every epoch of the task, by epoch index.
+func (m *Manager) GetLatestRuns(ctx context.Context, taskID string) (map[int]model.ScheduleRun, error) {
+	runs, err := m.GetRuns(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]model.ScheduleRun)
+	for _, run := range runs {
+		if _, ok := latest[run.Epoch]; !ok {
+			latest[run.Epoch] = run
+		}
+	}
+
+	return latest, nil
+}
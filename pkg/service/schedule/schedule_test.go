@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/schedule"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Runs(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	failed, err := mgr.StartRun(ctx, "task1", 0, 1)
+	if err != nil {
+		t.Errorf("StartRun err: %v", err)
+		return
+	}
+	if err := mgr.FinishRun(ctx, failed, errors.New("rpc timeout")); err != nil {
+		t.Errorf("FinishRun err: %v", err)
+		return
+	}
+	succeeded, err := mgr.StartRun(ctx, "task1", 0, 2)
+	if err != nil {
+		t.Errorf("StartRun err: %v", err)
+		return
+	}
+	if err := mgr.FinishRun(ctx, succeeded, nil); err != nil {
+		t.Errorf("FinishRun err: %v", err)
+		return
+	}
+	if _, err := mgr.StartRun(ctx, "task1", 1, 1); err != nil {
+		t.Errorf("StartRun err: %v", err)
+		return
+	}
+
+	runs, err := mgr.GetRuns(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 3, len(runs)) {
+		return
+	}
+	assert.Equal(t, succeeded, runs[0].ID)
+	assert.Equal(t, schedule.StateFailed, runs[1].State)
+	assert.Equal(t, "rpc timeout", runs[1].Error)
+	assert.NotNil(t, runs[1].FinishedAt)
+
+	latest, err := mgr.GetLatestRuns(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetLatestRuns err: %v", err)
+		return
+	}
+	assert.Equal(t, schedule.StateSucceeded, latest[0].State)
+	assert.Equal(t, 2, latest[0].Attempt)
+	assert.Equal(t, schedule.StateRunning, latest[1].State)
+	assert.Nil(t, latest[1].FinishedAt)
+}
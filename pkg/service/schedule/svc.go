@@ -0,0 +1,12 @@
+package schedule
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.ScheduleManager {
+	return &Manager{
+		db,
+	}
+}
@@ -2,18 +2,50 @@ package service
 
 import (
 	"database/sql"
+	"log"
+	voucherCore "tradingAce/pkg/core/voucher"
 	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/allocation"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/claim"
+	"tradingAce/pkg/service/export"
+	"tradingAce/pkg/service/ingestion"
+	"tradingAce/pkg/service/leaderboard"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/referral"
+	"tradingAce/pkg/service/schedule"
+	"tradingAce/pkg/service/settlement"
 	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
 	"tradingAce/pkg/service/transaction"
 	"tradingAce/pkg/service/userpoint"
 	"tradingAce/pkg/service/usertask"
+	"tradingAce/pkg/service/voucher"
 )
 
 type Service struct {
-	Task        iface.TaskManager
-	Transaction iface.TransactionManager
-	UserTask    iface.UserTaskManager
-	UserPoint   iface.UserPointManager
+	Task          iface.TaskManager
+	Transaction   iface.TransactionManager
+	UserTask      iface.UserTaskManager
+	UserPoint     iface.UserPointManager
+	TradeFlag     iface.TradeFlagManager
+	AddressInfo   iface.AddressInfoManager
+	Referral      iface.ReferralManager
+	Liquidity     iface.LiquidityManager
+	Multiplier    iface.MultiplierManager
+	Campaign      iface.CampaignManager
+	Leaderboard   iface.LeaderboardManager
+	Ingestion     iface.IngestionManager
+	Schedule      iface.ScheduleManager
+	Settlement    iface.SettlementManager
+	Recalculation iface.RecalculationManager
+	Claim         iface.ClaimManager
+	Voucher       iface.VoucherManager
+	Allocation    iface.AllocationManager
+	Export        iface.ExportManager
 }
 
 func NewService(db *sql.DB) *Service {
@@ -22,7 +54,27 @@ func NewService(db *sql.DB) *Service {
 	s.Task = task.NewManager(db)
 	s.Transaction = transaction.NewManager(db)
 	s.UserPoint = userpoint.NewManager(db)
-	s.UserTask = usertask.NewManager(db, s.Task, s.Transaction, s.UserPoint)
+	s.TradeFlag = tradeflag.NewManager(db)
+	s.AddressInfo = addressinfo.NewManager(db)
+	s.Liquidity = liquidity.NewManager(db)
+	s.Multiplier = multiplier.NewManager(db)
+	s.Campaign = campaign.NewManager(db, s.Task)
+	s.Leaderboard = leaderboard.NewManager(db)
+	s.Ingestion = ingestion.NewManager(db)
+	s.Schedule = schedule.NewManager(db)
+	s.Settlement = settlement.NewManager(db)
+	s.Recalculation = recalculation.NewManager(db)
+	s.Claim = claim.NewManager(db, s.Campaign, s.Task, s.UserPoint, s.Settlement)
+	voucherConfig, err := voucherCore.ConfigFromEnv()
+	if err != nil {
+		// commands without vouchers still run, issuing one fails with the same error
+		log.Printf("vouchers are disabled: %v", err)
+	}
+	s.Voucher = voucher.NewManager(db, s.Claim, voucherConfig)
+	s.Allocation = allocation.NewManager(db, s.Claim)
+	s.Export = export.NewManager(db, s.Leaderboard)
+	s.UserTask = usertask.NewManager(db, s.Task, s.Transaction, s.UserPoint, s.TradeFlag, s.AddressInfo, s.Liquidity, s.Multiplier, s.Campaign, s.Settlement, s.Recalculation)
+	s.Referral = referral.NewManager(db, s.Task, s.Transaction, s.UserTask, s.UserPoint)
 
 	return s
 }
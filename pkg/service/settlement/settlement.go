@@ -0,0 +1,291 @@
+package settlement
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/settlement"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/lib/pq"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// StartRuns creates a pending run for every epoch of the task that is not settled yet, or that is committed when forced.
+// Unfinished runs of these epochs are superseded and can no longer be committed, no run is returned when all are settled.
+func (m *Manager) StartRuns(ctx context.Context, taskID string, epochs []int, force bool) ([]model.SettlementRun, error) {
+	var runs []model.SettlementRun
+	err := m.withTaskLock(ctx, taskID, func(tx *sql.Tx) error {
+		latest, err := getLatestRuns(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, e := range settlement.Unsettled(epochs, latest, force) {
+			if run, ok := latest[e]; ok && settlement.CanTransition(run.State, settlement.StateFailed) {
+				if err := setState(ctx, tx, run.ID, settlement.StateFailed, "superseded", now); err != nil {
+					return err
+				}
+			}
+
+			run := model.SettlementRun{
+				ID:        utils.GenDBID(),
+				TaskID:    taskID,
+				Epoch:     e,
+				State:     settlement.StatePending,
+				Forced:    force,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO "settlementRun" ("id", "taskId", "epoch", "state", "forced", "createdAt", "updatedAt")
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, run.ID, run.TaskID, run.Epoch, run.State, run.Forced, run.CreatedAt, run.UpdatedAt); err != nil {
+				return err
+			}
+			runs = append(runs, run)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("StartRuns fail: %v", err)
+	}
+
+	return runs, nil
+}
+
+// SetState moves the runs to the state, it fails with settlement.ErrTransition when a run cannot reach it.
+func (m *Manager) SetState(ctx context.Context, runs []model.SettlementRun, state string) error {
+	if err := m.transition(ctx, runs, state, ""); err != nil {
+		return fmt.Errorf("SetState fail: %w", err)
+	}
+
+	return nil
+}
+
+// Fail marks the unsettled runs as failed with the error.
+func (m *Manager) Fail(ctx context.Context, runs []model.SettlementRun, runErr error) error {
+	if err := m.transition(ctx, runs, settlement.StateFailed, runErr.Error()); err != nil {
+		return fmt.Errorf("Fail fail: %w", err)
+	}
+
+	return nil
+}
+
+// Commit calls write and moves the computed runs to committed in one transaction, so either every point of the runs is
+// written or none is. The runs are marked as failed when it does not succeed, superseded runs cannot be committed.
+func (m *Manager) Commit(ctx context.Context, runs []model.SettlementRun, write func(tx *sql.Tx) error) error {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	err := m.withTaskLock(ctx, runs[0].TaskID, func(tx *sql.Tx) error {
+		if err := transitionTx(ctx, tx, runs, settlement.StateCommitted, ""); err != nil {
+			return err
+		}
+
+		return write(tx)
+	})
+	if err != nil {
+		if failErr := m.Fail(ctx, runs, err); failErr != nil {
+			return fmt.Errorf("Commit fail: %v, %v", err, failErr)
+		}
+		return fmt.Errorf("Commit fail: %w", err)
+	}
+
+	return nil
+}
+
+// Finalize locks the committed epoch of the task, it is not settled again even when forced.
+// It returns sql.ErrNoRows when the epoch has no run.
+func (m *Manager) Finalize(ctx context.Context, taskID string, epoch int) (model.SettlementRun, error) {
+	var run model.SettlementRun
+	err := m.withTaskLock(ctx, taskID, func(tx *sql.Tx) error {
+		latest, err := getLatestRuns(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if run, ok = latest[epoch]; !ok {
+			return sql.ErrNoRows
+		}
+		if err := transitionTx(ctx, tx, []model.SettlementRun{run}, settlement.StateFinalized, ""); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		run.State, run.UpdatedAt, run.FinalizedAt = settlement.StateFinalized, now, &now
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		return run, err
+	}
+	if err != nil {
+		return run, fmt.Errorf("Finalize fail: %w", err)
+	}
+
+	return run, nil
+}
+
+// GetRuns returns the runs of the task by epoch, the latest run of every epoch first.
+func (m *Manager) GetRuns(ctx context.Context, taskID string) ([]model.SettlementRun, error) {
+	runs, err := getRuns(ctx, m.db, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRuns fail: %v", err)
+	}
+
+	return runs, nil
+}
+
+func (m *Manager) transition(ctx context.Context, runs []model.SettlementRun, state string, message string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := transitionTx(ctx, tx, runs, state, message); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transitionTx locks the runs and moves them to the state, settled runs are left as they are when failing.
+func transitionTx(ctx context.Context, tx *sql.Tx, runs []model.SettlementRun, state string, message string) error {
+	ids := make([]string, 0, len(runs))
+	for _, run := range runs {
+		ids = append(ids, run.ID)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT "id", "state" FROM "settlementRun" WHERE "id" = ANY($1) FOR UPDATE
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string, len(ids))
+	for rows.Next() {
+		var id, s string
+		if err := rows.Scan(&id, &s); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = s
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		from, ok := current[id]
+		if !ok {
+			return fmt.Errorf("settlement run %s not found", id)
+		}
+		if state == settlement.StateFailed && (settlement.Settled(from) || from == settlement.StateFailed) {
+			continue
+		}
+		if !settlement.CanTransition(from, state) {
+			return fmt.Errorf("%w: run %s from %s to %s", settlement.ErrTransition, id, from, state)
+		}
+		if err := setState(ctx, tx, id, state, message, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setState(ctx context.Context, tx *sql.Tx, runID string, state string, message string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE "settlementRun" SET "state" = $2, "error" = $3, "updatedAt" = $4,
+			"committedAt" = CASE WHEN $2 = 'committed' THEN $4 ELSE "committedAt" END,
+			"finalizedAt" = CASE WHEN $2 = 'finalized' THEN $4 ELSE "finalizedAt" END
+		WHERE "id" = $1
+	`, runID, state, message, at)
+	return err
+}
+
+// withTaskLock serializes the settlement of one task, so an epoch is never committed twice by concurrent runs.
+func (m *Manager) withTaskLock(ctx context.Context, taskID string, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "settlement:"+taskID); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func getRuns(ctx context.Context, q querier, taskID string) ([]model.SettlementRun, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT "id", "taskId", "epoch", "state", "forced", "error", "createdAt", "updatedAt", "committedAt", "finalizedAt"
+		FROM "settlementRun"
+		WHERE "taskId" = $1
+		ORDER BY "epoch", "createdAt" DESC, "id" DESC
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]model.SettlementRun, 0)
+	for rows.Next() {
+		var run model.SettlementRun
+		var committedAt, finalizedAt sql.NullTime
+		if err := rows.Scan(
+			&run.ID, &run.TaskID, &run.Epoch, &run.State, &run.Forced, &run.Error,
+			&run.CreatedAt, &run.UpdatedAt, &committedAt, &finalizedAt,
+		); err != nil {
+			return nil, err
+		}
+		if committedAt.Valid {
+			run.CommittedAt = &committedAt.Time
+		}
+		if finalizedAt.Valid {
+			run.FinalizedAt = &finalizedAt.Time
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// getLatestRuns returns the latest run of every epoch of the task, by epoch index.
+func getLatestRuns(ctx context.Context, q querier, taskID string) (map[int]model.SettlementRun, error) {
+	runs, err := getRuns(ctx, q, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]model.SettlementRun)
+	for _, run := range runs {
+		if _, ok := latest[run.Epoch]; !ok {
+			latest[run.Epoch] = run
+		}
+	}
+
+	return latest, nil
+}
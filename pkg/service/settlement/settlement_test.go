@@ -0,0 +1,131 @@
+package settlement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/settlement"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Runs(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	runs, err := mgr.StartRuns(ctx, "task1", []int{0, 1}, false)
+	if err != nil {
+		t.Errorf("StartRuns err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 2, len(runs)) {
+		return
+	}
+	// runs are committed only once computed
+	assert.ErrorIs(t, mgr.SetState(ctx, runs, settlement.StateComputed), settlement.ErrTransition)
+	if err := mgr.SetState(ctx, runs, settlement.StateComputing); err != nil {
+		t.Errorf("SetState err: %v", err)
+		return
+	}
+	if err := mgr.SetState(ctx, runs, settlement.StateComputed); err != nil {
+		t.Errorf("SetState err: %v", err)
+		return
+	}
+
+	// a failing write rolls back and fails the runs
+	err = mgr.Commit(ctx, runs, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE "settlementRun" SET "forced" = TRUE WHERE "taskId" = 'task1'`); err != nil {
+			return err
+		}
+		return errors.New("write fail")
+	})
+	assert.Error(t, err)
+
+	all, err := mgr.GetRuns(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	for _, run := range all {
+		assert.Equal(t, settlement.StateFailed, run.State)
+		assert.False(t, run.Forced)
+		assert.Contains(t, run.Error, "write fail")
+	}
+
+	// failed epochs are settled again
+	runs, err = mgr.StartRuns(ctx, "task1", []int{0, 1}, false)
+	if err != nil {
+		t.Errorf("StartRuns err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 2, len(runs)) {
+		return
+	}
+	mgr.SetState(ctx, runs, settlement.StateComputing)
+	mgr.SetState(ctx, runs, settlement.StateComputed)
+	if err := mgr.Commit(ctx, runs, func(tx *sql.Tx) error { return nil }); err != nil {
+		t.Errorf("Commit err: %v", err)
+		return
+	}
+
+	// committed epochs are skipped unless forced
+	again, err := mgr.StartRuns(ctx, "task1", []int{0, 1, 2}, false)
+	if err != nil {
+		t.Errorf("StartRuns err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(again)) {
+		assert.Equal(t, 2, again[0].Epoch)
+	}
+
+	if _, err := mgr.Finalize(ctx, "task1", 0); err != nil {
+		t.Errorf("Finalize err: %v", err)
+		return
+	}
+	_, err = mgr.Finalize(ctx, "task1", 2)
+	assert.ErrorIs(t, err, settlement.ErrTransition)
+	_, err = mgr.Finalize(ctx, "task1", 3)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	forced, err := mgr.StartRuns(ctx, "task1", []int{0, 1, 2}, true)
+	if err != nil {
+		t.Errorf("StartRuns err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 2, len(forced)) {
+		return
+	}
+	assert.Equal(t, 1, forced[0].Epoch)
+	assert.True(t, forced[0].Forced)
+	// the superseded run of epoch 2 can no longer be committed
+	assert.Error(t, mgr.Commit(ctx, again, func(tx *sql.Tx) error { return nil }))
+
+	all, err = mgr.GetRuns(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	latest := make(map[int]string)
+	for _, run := range all {
+		if _, ok := latest[run.Epoch]; !ok {
+			latest[run.Epoch] = run.State
+		}
+	}
+	assert.Equal(t, map[int]string{
+		0: settlement.StateFinalized,
+		1: settlement.StatePending,
+		2: settlement.StatePending,
+	}, latest)
+}
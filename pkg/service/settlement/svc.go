@@ -0,0 +1,12 @@
+package settlement
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.SettlementManager {
+	return &Manager{
+		db,
+	}
+}
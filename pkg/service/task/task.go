@@ -5,6 +5,13 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/prerequisite"
+	"tradingAce/pkg/core/streak"
+	"tradingAce/pkg/core/taskrule"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/core/washtrade"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/utils"
 )
@@ -14,36 +21,89 @@ type Manager struct {
 }
 
 func (m *Manager) GetOnboardingTask(ctx context.Context) (model.Task, error) {
+	return m.getTaskByName(ctx, "onboarding")
+}
+
+func (m *Manager) GetReferralTask(ctx context.Context) (model.Task, error) {
+	return m.getTaskByName(ctx, "referral")
+}
+
+// getTaskByName returns the task of a name without pair, e.g. onboarding
+func (m *Manager) getTaskByName(ctx context.Context, name string) (model.Task, error) {
 	query := `
-		SELECT "id", "createdAt", "name", "pairAddress", "startAt"
+		SELECT "id", "createdAt", "name", "pairAddress", "startAt", "config", "campaignId"
 		FROM "task"
 		WHERE "name" = $1;
     `
 
 	var task model.Task
-	err := m.db.QueryRowContext(ctx, query, "onboarding").Scan(
+	err := m.db.QueryRowContext(ctx, query, name).Scan(
 		&task.ID,
 		&task.CreatedAt,
 		&task.Name,
 		&task.PairAddress,
 		&task.StartAt,
+		&task.Config,
+		&task.CampaignID,
 	)
 
 	return task, err
 }
 
 func (m *Manager) GetSharePoolTask(ctx context.Context) ([]model.Task, error) {
+	tasks, err := m.getTasksByName(ctx, "share_pool")
+	if err != nil {
+		return tasks, fmt.Errorf("GetSharePoolTask fail: %v", err)
+	}
+
+	return tasks, nil
+}
+
+func (m *Manager) GetStreakTasks(ctx context.Context) ([]model.Task, error) {
+	tasks, err := m.getTasksByName(ctx, "streak")
+	if err != nil {
+		return tasks, fmt.Errorf("GetStreakTasks fail: %v", err)
+	}
+
+	return tasks, nil
+}
+
+func (m *Manager) GetLPTasks(ctx context.Context) ([]model.Task, error) {
+	tasks, err := m.getTasksByName(ctx, "lp_provider")
+	if err != nil {
+		return tasks, fmt.Errorf("GetLPTasks fail: %v", err)
+	}
+
+	return tasks, nil
+}
+
+// GetTasks returns every task, oldest first.
+func (m *Manager) GetTasks(ctx context.Context) ([]model.Task, error) {
+	tasks, err := m.queryTasks(ctx, `ORDER BY "createdAt", "id"`)
+	if err != nil {
+		return tasks, fmt.Errorf("GetTasks fail: %v", err)
+	}
+
+	return tasks, nil
+}
+
+func (m *Manager) getTasksByName(ctx context.Context, name string) ([]model.Task, error) {
+	return m.queryTasks(ctx, `WHERE "name" = $1`, name)
+}
+
+func (m *Manager) queryTasks(ctx context.Context, condition string, args ...interface{}) ([]model.Task, error) {
 	query := `
-		SELECT "id", "createdAt", "name", "pairAddress", "startAt"
+		SELECT "id", "createdAt", "name", "pairAddress", "startAt", "config", "campaignId"
 		FROM "task"
-		WHERE "name" = $1;
-    `
+		` + condition
 
 	tasks := make([]model.Task, 0)
-	rows, err := m.db.Query(query, "share_pool")
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return tasks, fmt.Errorf("GetSharePoolTask query fail: %v", err)
+		return tasks, fmt.Errorf("query fail: %v", err)
 	}
+	defer rows.Close()
+
 	for rows.Next() {
 		var task model.Task
 		err := rows.Scan(
@@ -52,31 +112,99 @@ func (m *Manager) GetSharePoolTask(ctx context.Context) ([]model.Task, error) {
 			&task.Name,
 			&task.PairAddress,
 			&task.StartAt,
+			&task.Config,
+			&task.CampaignID,
 		)
 		if err != nil {
-			return tasks, fmt.Errorf("GetSharePoolTask scan fail: %v", err)
+			return tasks, fmt.Errorf("scan fail: %v", err)
 		}
 
 		tasks = append(tasks, task)
 	}
 
-	return tasks, err
+	return tasks, rows.Err()
+}
+
+func (m *Manager) GetTask(ctx context.Context, taskID string) (model.Task, error) {
+	query := `
+		SELECT "id", "createdAt", "name", "pairAddress", "startAt", "config", "campaignId"
+		FROM "task"
+		WHERE "id" = $1;
+    `
+
+	var task model.Task
+	err := m.db.QueryRowContext(ctx, query, taskID).Scan(
+		&task.ID,
+		&task.CreatedAt,
+		&task.Name,
+		&task.PairAddress,
+		&task.StartAt,
+		&task.Config,
+		&task.CampaignID,
+	)
+
+	return task, err
+}
+
+func (m *Manager) CreateSharePoolTask(
+	ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig,
+) error {
+
+	return m.createTask(ctx, "share_pool", pairAddress, startAt, config)
+}
+
+// CreateStreakTask creates a streak task of the pair, the config needs at least one streak milestone.
+func (m *Manager) CreateStreakTask(
+	ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig,
+) error {
+
+	if err := streak.Validate(config.Streak); err != nil {
+		return fmt.Errorf("invalid streak config: %w", err)
+	}
+
+	return m.createTask(ctx, "streak", pairAddress, startAt, config)
+}
+
+// CreateLPTask creates a liquidity provider task of the pair, points of every epoch are distributed by liquidity-seconds.
+func (m *Manager) CreateLPTask(
+	ctx context.Context, pairAddress string, startAt time.Time, config model.TaskConfig,
+) error {
+
+	return m.createTask(ctx, "lp_provider", pairAddress, startAt, config)
 }
 
-func (m *Manager) CreateSharePoolTask(ctx context.Context, pairAddress string, startAt time.Time) error {
+// createTask creates a task of the pair, there is at most one task of a name per pair
+func (m *Manager) createTask(
+	ctx context.Context, name string, pairAddress string, startAt time.Time, config model.TaskConfig,
+) error {
+
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+	if _, err := epoch.Schedule(model.Task{StartAt: startAt, Config: config}); err != nil {
+		return fmt.Errorf("invalid epoch config: %w", err)
+	}
+
+	id := utils.GenDBID()
+	if err := m.validatePrerequisites(ctx, id, config.Prerequisites); err != nil {
+		return fmt.Errorf("invalid prerequisites: %w", err)
+	}
+
 	query := `
-		SELECT "id", "createdAt", "name", "pairAddress", "startAt"
+		SELECT "id", "createdAt", "name", "pairAddress", "startAt", "config", "campaignId"
 		FROM "task"
 		WHERE "name" = $1 AND "pairAddress" = $2;
 	`
 
 	var task model.Task
-	qErr := m.db.QueryRowContext(ctx, query, "share_pool", pairAddress).Scan(
+	qErr := m.db.QueryRowContext(ctx, query, name, pairAddress).Scan(
 		&task.ID,
 		&task.CreatedAt,
 		&task.Name,
 		&task.PairAddress,
 		&task.StartAt,
+		&task.Config,
+		&task.CampaignID,
 	)
 	if qErr != sql.ErrNoRows {
 		return fmt.Errorf("task pairAddress exist: %s", pairAddress)
@@ -85,14 +213,188 @@ func (m *Manager) CreateSharePoolTask(ctx context.Context, pairAddress string, s
 	}
 
 	insertQuery := `
-		INSERT INTO task ("id", "createdAt", "name", "pairAddress", "startAt")
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO task ("id", "createdAt", "name", "pairAddress", "startAt", "config")
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := m.db.ExecContext(ctx, insertQuery, utils.GenDBID(), time.Now(), "share_pool", pairAddress, startAt)
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, insertQuery, id, now, name, pairAddress, startAt, config); err != nil {
 		return fmt.Errorf("failed to insert task: %w", err)
 	}
+	// the rules the task is created with are its first version
+	if err := insertRuleVersion(ctx, tx, model.TaskRuleVersion{
+		ID:          utils.GenDBID(),
+		TaskID:      id,
+		Version:     1,
+		Config:      config,
+		EffectiveAt: startAt,
+		CreatedAt:   now,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateRuleVersion adds the next version of the rules of the task, effective for the epochs starting at or after effectiveAt.
+// The config of the task becomes the new rules, the epoch schedule cannot change.
+// It returns sql.ErrNoRows when the task does not exist and taskrule.ErrInvalid for invalid rules.
+func (m *Manager) CreateRuleVersion(
+	ctx context.Context, taskID string, config model.TaskConfig, effectiveAt time.Time, note string,
+) (model.TaskRuleVersion, error) {
+
+	task, err := m.GetTask(ctx, taskID)
+	if err != nil {
+		return model.TaskRuleVersion{}, err
+	}
+
+	if err := validateConfig(config); err != nil {
+		return model.TaskRuleVersion{}, fmt.Errorf("%w: %v", taskrule.ErrInvalid, err)
+	}
+	if task.Name.String == "streak" {
+		if err := streak.Validate(config.Streak); err != nil {
+			return model.TaskRuleVersion{}, fmt.Errorf("%w: invalid streak config: %v", taskrule.ErrInvalid, err)
+		}
+	}
+	if err := m.validatePrerequisites(ctx, taskID, config.Prerequisites); err != nil {
+		return model.TaskRuleVersion{}, fmt.Errorf("%w: invalid prerequisites: %v", taskrule.ErrInvalid, err)
+	}
+	if err := taskrule.Validate(task.Config, config); err != nil {
+		return model.TaskRuleVersion{}, err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.TaskRuleVersion{}, err
+	}
+	defer tx.Rollback()
+
+	// the task row serializes the versions of the task
+	if _, err := tx.ExecContext(ctx, `SELECT "id" FROM "task" WHERE "id" = $1 FOR UPDATE`, taskID); err != nil {
+		return model.TaskRuleVersion{}, fmt.Errorf("CreateRuleVersion lock task fail: %v", err)
+	}
+
+	version := model.TaskRuleVersion{
+		ID:          utils.GenDBID(),
+		TaskID:      taskID,
+		Config:      config,
+		EffectiveAt: effectiveAt,
+		Note:        note,
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX("version"), 0) + 1 FROM "taskRuleVersion" WHERE "taskId" = $1
+	`, taskID).Scan(&version.Version); err != nil {
+		return model.TaskRuleVersion{}, fmt.Errorf("CreateRuleVersion next version fail: %v", err)
+	}
+	if err := insertRuleVersion(ctx, tx, version); err != nil {
+		return model.TaskRuleVersion{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE "task" SET "config" = $2 WHERE "id" = $1`, taskID, config); err != nil {
+		return model.TaskRuleVersion{}, fmt.Errorf("CreateRuleVersion update task fail: %v", err)
+	}
+
+	return version, tx.Commit()
+}
+
+// GetRuleVersions returns the rule versions of the task, the first version first.
+func (m *Manager) GetRuleVersions(ctx context.Context, taskID string) ([]model.TaskRuleVersion, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "taskId", "version", "config", "effectiveAt", "note", "createdAt"
+		FROM "taskRuleVersion"
+		WHERE "taskId" = $1
+		ORDER BY "version"
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRuleVersions query fail: %v", err)
+	}
+	defer rows.Close()
+
+	versions := make([]model.TaskRuleVersion, 0)
+	for rows.Next() {
+		var v model.TaskRuleVersion
+		if err := rows.Scan(&v.ID, &v.TaskID, &v.Version, &v.Config, &v.EffectiveAt, &v.Note, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("GetRuleVersions scan fail: %v", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func insertRuleVersion(ctx context.Context, tx *sql.Tx, v model.TaskRuleVersion) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "taskRuleVersion" ("id", "taskId", "version", "config", "effectiveAt", "note", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, v.ID, v.TaskID, v.Version, v.Config, v.EffectiveAt, v.Note, v.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert task rule version: %v", err)
+	}
+
+	return nil
+}
+
+// validatePrerequisites checks that the prerequisite tasks exist and that the task with them forms no cycle.
+func (m *Manager) validatePrerequisites(ctx context.Context, taskID string, cfg model.PrerequisiteConfig) error {
+	if len(cfg.TaskIDs) == 0 {
+		return nil
+	}
+
+	tasks, err := m.GetTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	graph := map[string][]string{taskID: cfg.TaskIDs}
+	for _, task := range tasks {
+		graph[task.ID] = task.Config.Prerequisites.TaskIDs
+	}
+	for _, id := range cfg.TaskIDs {
+		if _, ok := graph[id]; !ok || id == taskID {
+			return fmt.Errorf("prerequisite task not found: %s", id)
+		}
+	}
+
+	return prerequisite.CheckCycle(graph)
+}
+
+func validateConfig(config model.TaskConfig) error {
+	if err := epoch.Validate(config.Epoch); err != nil {
+		return fmt.Errorf("invalid epoch config: %w", err)
+	}
+	if config.PointPrecision < 0 || config.PointPrecision > distribution.MaxPlaces {
+		return fmt.Errorf("point precision must be between 0 and %d", distribution.MaxPlaces)
+	}
+	if config.EpochPoints != nil && config.EpochPoints.IsNegative() {
+		return fmt.Errorf("epoch points can not be negative")
+	}
+	// the pool is fully allocated, it can not have more places than the points
+	if config.EpochPoints != nil && !config.EpochPoints.Equal(config.EpochPoints.Truncate(config.PointPrecision)) {
+		return fmt.Errorf("epoch points can not have more than %d decimal places", config.PointPrecision)
+	}
+	if err := distribution.Validate(config.Distribution); err != nil {
+		return fmt.Errorf("invalid distribution config: %w", err)
+	}
+	if err := volume.Validate(config.VolumeMode); err != nil {
+		return fmt.Errorf("invalid volume mode: %w", err)
+	}
+	if err := washtrade.Validate(config.WashTrading); err != nil {
+		return fmt.Errorf("invalid wash trading config: %w", err)
+	}
+	if err := volume.ValidateActivity(config.Activity); err != nil {
+		return fmt.Errorf("invalid activity config: %w", err)
+	}
+	if err := volume.ValidatePairs(config.Onboarding.Pairs); err != nil {
+		return fmt.Errorf("invalid onboarding config: %w", err)
+	}
+	if err := prerequisite.Validate(config.Prerequisites); err != nil {
+		return fmt.Errorf("invalid prerequisites: %w", err)
+	}
 
 	return nil
 }
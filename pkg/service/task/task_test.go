@@ -2,12 +2,15 @@ package task
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/taskrule"
 	"tradingAce/pkg/model"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/slices"
 )
@@ -62,14 +65,14 @@ func TestManager_GetSharePoolTask(t *testing.T) {
 	}
 
 	if _, err := d.Exec(
-		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("pairAddress") DO NOTHING;`,
+		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("name", "pairAddress") DO NOTHING;`,
 		"bbb", time.Now(), "share_pool", "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", "2024-07-02",
 	); err != nil {
 		t.Error(err)
 		return
 	}
 	if _, err := d.Exec(
-		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("pairAddress") DO NOTHING;`,
+		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("name", "pairAddress") DO NOTHING;`,
 		"ccc", time.Now(), "share_pool", "0xhihihihhihihihi", "2024-08-02",
 	); err != nil {
 		t.Error(err)
@@ -105,7 +108,7 @@ func TestManager_CreateSharePoolTask(t *testing.T) {
 	}
 
 	mgr := Manager{db: d}
-	err = mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt)
+	err = mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt, model.TaskConfig{})
 	if err != nil {
 		t.Errorf("CreateSharePoolTask fail: %s", err)
 		return
@@ -151,13 +154,281 @@ func TestManager_CreateSharePoolTaskIfExist(t *testing.T) {
 	}
 
 	mgr := Manager{db: d}
-	err = mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt)
+	err = mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt, model.TaskConfig{})
 	if err != nil {
 		t.Errorf("CreateSharePoolTask fail: %s", err)
 		return
 	}
 
-	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt)
+	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt, model.TaskConfig{})
 
 	assert.True(t, resultErr != nil)
 }
+
+func TestManager_GetTask(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-02")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	mgr := Manager{db: d}
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "day", Length: 2, Count: 5, Timezone: "Asia/Taipei"}}
+	if err := mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt, config); err != nil {
+		t.Errorf("CreateSharePoolTask fail: %s", err)
+		return
+	}
+
+	tasks, err := mgr.GetSharePoolTask(context.Background())
+	if err != nil {
+		t.Errorf("GetSharePoolTask fail: %s", err)
+		return
+	}
+
+	task, err := mgr.GetTask(context.Background(), tasks[0].ID)
+	if err != nil {
+		t.Errorf("GetTask fail: %s", err)
+		return
+	}
+	assert.Equal(t, "0xabc", task.PairAddress.String)
+	assert.Equal(t, config, task.Config)
+
+	_, notFoundErr := mgr.GetTask(context.Background(), "notExist")
+	assert.EqualError(t, notFoundErr, sql.ErrNoRows.Error())
+}
+
+func TestManager_CreateSharePoolTaskInvalidEpoch(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "month"}}
+	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", time.Now(), config)
+
+	assert.True(t, resultErr != nil)
+}
+
+func TestManager_CreateSharePoolTaskTooManyEpochs(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	// hourly epochs for five years
+	endAt := startAt.AddDate(5, 0, 0)
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "hour", Length: 1, EndAt: &endAt}}
+	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", startAt, config)
+
+	assert.ErrorContains(t, resultErr, "invalid epoch config")
+}
+
+func TestManager_CreateSharePoolTaskUnsupportedOnboardingPair(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	config := model.TaskConfig{Onboarding: model.OnboardingConfig{Pairs: []string{"0xabc"}}}
+	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", time.Now(), config)
+
+	assert.ErrorContains(t, resultErr, "unsupported pair")
+}
+
+func TestManager_CreateSharePoolTaskNegativeEpochPoints(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	negative := decimal.NewFromInt(-1)
+	config := model.TaskConfig{EpochPoints: &negative}
+	resultErr := mgr.CreateSharePoolTask(context.Background(), "0xabc", time.Now(), config)
+
+	assert.ErrorContains(t, resultErr, "epoch points")
+}
+
+func TestManager_CreateStreakTask(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	mgr := Manager{db: d}
+
+	// milestones are required
+	assert.Error(t, mgr.CreateStreakTask(ctx, "0xabc", startAt, model.TaskConfig{}))
+
+	config := model.TaskConfig{
+		Streak: model.StreakConfig{Milestones: []model.StreakMilestone{{Length: 3, Points: decimal.NewFromInt(100)}}},
+	}
+	if err := mgr.CreateStreakTask(ctx, "0xabc", startAt, config); err != nil {
+		t.Errorf("CreateStreakTask fail: %s", err)
+		return
+	}
+	// a share pool task of the same pair is another task
+	if err := mgr.CreateSharePoolTask(ctx, "0xabc", startAt, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask fail: %s", err)
+		return
+	}
+	assert.Error(t, mgr.CreateStreakTask(ctx, "0xabc", startAt, config))
+
+	tasks, err := mgr.GetStreakTasks(ctx)
+	if err != nil {
+		t.Errorf("GetStreakTasks fail: %s", err)
+		return
+	}
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, "streak", tasks[0].Name.String)
+	assert.Equal(t, "0xabc", tasks[0].PairAddress.String)
+	assert.Equal(t, 3, tasks[0].Config.Streak.Milestones[0].Length)
+}
+
+func TestManager_CreateTaskPrerequisites(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	mgr := Manager{db: d}
+
+	// prerequisite tasks have to exist
+	assert.Error(t, mgr.CreateSharePoolTask(ctx, "0xabc", startAt, model.TaskConfig{
+		Prerequisites: model.PrerequisiteConfig{TaskIDs: []string{"unknown"}},
+	}))
+	assert.Error(t, mgr.CreateSharePoolTask(ctx, "0xabc", startAt, model.TaskConfig{
+		Prerequisites: model.PrerequisiteConfig{Mode: "xor"},
+	}))
+
+	if err := mgr.CreateSharePoolTask(ctx, "0xabc", startAt, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask fail: %s", err)
+		return
+	}
+	tasks, err := mgr.GetTasks(ctx)
+	if err != nil {
+		t.Errorf("GetTasks fail: %s", err)
+		return
+	}
+
+	config := model.TaskConfig{
+		Prerequisites: model.PrerequisiteConfig{TaskIDs: []string{tasks[0].ID}, Timing: "before_epoch"},
+	}
+	if err := mgr.CreateSharePoolTask(ctx, "0xdef", startAt, config); err != nil {
+		t.Errorf("CreateSharePoolTask fail: %s", err)
+		return
+	}
+
+	tasks, err = mgr.GetTasks(ctx)
+	if err != nil {
+		t.Errorf("GetTasks fail: %s", err)
+		return
+	}
+	assert.Equal(t, 2, len(tasks))
+	assert.Equal(t, config.Prerequisites, tasks[1].Config.Prerequisites)
+}
+
+func TestManager_CreateRuleVersion(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	mgr := Manager{db: d}
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	config := model.TaskConfig{Epoch: model.EpochConfig{Unit: "day", Length: 1, Count: 7}}
+	if err := mgr.CreateSharePoolTask(ctx, "0xabc", startAt, config); err != nil {
+		t.Errorf("CreateSharePoolTask fail: %s", err)
+		return
+	}
+	tasks, err := mgr.GetSharePoolTask(ctx)
+	if err != nil {
+		t.Errorf("GetSharePoolTask fail: %s", err)
+		return
+	}
+	taskID := tasks[0].ID
+
+	// the epoch schedule of a task cannot change
+	_, err = mgr.CreateRuleVersion(ctx, taskID, model.TaskConfig{Epoch: model.EpochConfig{Unit: "week", Length: 1, Count: 1}}, startAt, "")
+	assert.ErrorIs(t, err, taskrule.ErrInvalid)
+	_, err = mgr.CreateRuleVersion(ctx, "notExist", config, startAt, "")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	next := config
+	next.VolumeMode = "max"
+	effectiveAt := startAt.AddDate(0, 0, 3)
+	version, err := mgr.CreateRuleVersion(ctx, taskID, next, effectiveAt, "max volume")
+	if err != nil {
+		t.Errorf("CreateRuleVersion fail: %s", err)
+		return
+	}
+	assert.Equal(t, 2, version.Version)
+
+	versions, err := mgr.GetRuleVersions(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetRuleVersions fail: %s", err)
+		return
+	}
+	if assert.Equal(t, 2, len(versions)) {
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, config, versions[0].Config)
+		assert.True(t, startAt.Equal(versions[0].EffectiveAt))
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Equal(t, next, versions[1].Config)
+		assert.True(t, effectiveAt.Equal(versions[1].EffectiveAt))
+		assert.Equal(t, "max volume", versions[1].Note)
+	}
+
+	task, err := mgr.GetTask(ctx, taskID)
+	if err != nil {
+		t.Errorf("GetTask fail: %s", err)
+		return
+	}
+	assert.Equal(t, next, task.Config)
+}
@@ -0,0 +1,12 @@
+package tradeflag
+
+import (
+	"database/sql"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB) iface.TradeFlagManager {
+	return &Manager{
+		db,
+	}
+}
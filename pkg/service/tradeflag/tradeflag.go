@@ -0,0 +1,72 @@
+package tradeflag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+)
+
+type Manager struct {
+	db *sql.DB
+}
+
+// Record saves the flags, flags already recorded for the same address, transaction and reason are kept as is.
+func (m *Manager) Record(ctx context.Context, flags []model.TradeFlag) error {
+	for _, flag := range flags {
+		if _, err := m.db.ExecContext(ctx, `
+			INSERT INTO "tradeFlag" ("id", "createdAt", "userAddress", "transactionId", "reason", "detail")
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT ("userAddress", "transactionId", "reason") DO NOTHING
+		`,
+			utils.GenDBID(),
+			time.Now(),
+			flag.UserAddress,
+			flag.TransactionID,
+			flag.Reason,
+			flag.Detail,
+		); err != nil {
+			return fmt.Errorf("Record insert trade flag fail: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetFlags returns the flags of the address, or every flag when address is empty, newest first.
+func (m *Manager) GetFlags(ctx context.Context, address string) ([]model.TradeFlag, error) {
+	query := `SELECT "id", "createdAt", "userAddress", "transactionId", "reason", "detail" FROM "tradeFlag"`
+
+	var args []interface{}
+	if len(address) != 0 {
+		query += ` WHERE "userAddress" = $1`
+		args = append(args, address)
+	}
+	query += ` ORDER BY "createdAt" DESC, "id"`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetFlags query fail: %v", err)
+	}
+	defer rows.Close()
+
+	flags := make([]model.TradeFlag, 0)
+	for rows.Next() {
+		var flag model.TradeFlag
+		if err := rows.Scan(
+			&flag.ID,
+			&flag.CreatedAt,
+			&flag.UserAddress,
+			&flag.TransactionID,
+			&flag.Reason,
+			&flag.Detail,
+		); err != nil {
+			return nil, fmt.Errorf("GetFlags scan fail: %v", err)
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
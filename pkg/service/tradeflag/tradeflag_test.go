@@ -0,0 +1,64 @@
+package tradeflag
+
+import (
+	"context"
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/model"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_RecordAndGetFlags(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	flags := []model.TradeFlag{
+		{
+			UserAddress:   "0x0000000000000000000000000000000000000000",
+			TransactionID: "tx1",
+			Reason:        constants.FlagReasonRoundTrip,
+			Detail:        "buy and sell in block 1",
+		},
+		{
+			UserAddress: "0x0000000000000000000000000000000000000001",
+			Reason:      constants.FlagReasonFundingCluster,
+		},
+	}
+
+	// recording twice keeps a single flag
+	for i := 0; i < 2; i++ {
+		if err := mgr.Record(ctx, flags); err != nil {
+			t.Errorf("Record err: %v", err)
+			return
+		}
+	}
+
+	all, err := mgr.GetFlags(ctx, "")
+	if err != nil {
+		t.Errorf("GetFlags err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, len(all))
+
+	result, err := mgr.GetFlags(ctx, "0x0000000000000000000000000000000000000000")
+	if err != nil {
+		t.Errorf("GetFlags err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "tx1", result[0].TransactionID)
+	assert.Equal(t, constants.FlagReasonRoundTrip, result[0].Reason)
+	assert.Equal(t, "buy and sell in block 1", result[0].Detail)
+}
@@ -4,22 +4,49 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/mev"
+	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
 	"tradingAce/pkg/utils"
 
-	"github.com/shopspring/decimal"
+	"github.com/lib/pq"
 )
 
 type Manager struct {
 	db *sql.DB
 }
 
+// Upsert saves the swap by its block, pair and log index.
+// Swaps ingested before log indexes were recorded have no txHash and log index 0, one per block and pair.
+// The history sync ingests their blocks again with the real log indexes, so the legacy swap of the block is
+// replaced, with its trade flags, instead of being counted twice.
 func (m *Manager) Upsert(ctx context.Context, opt option.TransactionUpsertOptions) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(opt.TxHash) != 0 {
+		if _, err := tx.ExecContext(ctx, `
+			WITH "legacy" AS (
+				DELETE FROM transaction
+				WHERE "blockNum" = $1 AND "pairAddress" = $2 AND "txHash" = ''
+				RETURNING "id"
+			)
+			DELETE FROM "tradeFlag" WHERE "transactionId" IN (SELECT "id" FROM "legacy")
+		`, opt.BlockNum, opt.PairAddress); err != nil {
+			return fmt.Errorf("Upsert delete legacy swap fail: %v", err)
+		}
+	}
+
 	query := `
 		INSERT INTO transaction ("id", "blockNum", "pairAddress", "senderAddress", "amount0In", "amount1In", 
-        	"amount0Out", "amount1Out", "receiverAddress", "transactionAt")
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT ("blockNum", "pairAddress") 
+        	"amount0Out", "amount1Out", "receiverAddress", "transactionAt", "txHash", "logIndex")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT ("blockNum", "pairAddress", "logIndex") 
 		DO UPDATE SET
 			"senderAddress" = EXCLUDED."senderAddress",
 			"amount0In" = EXCLUDED."amount0In",
@@ -27,10 +54,11 @@ func (m *Manager) Upsert(ctx context.Context, opt option.TransactionUpsertOption
 			"amount0Out" = EXCLUDED."amount0Out",
 			"amount1Out" = EXCLUDED."amount1Out",
 			"receiverAddress" = EXCLUDED."receiverAddress",
-			"transactionAt" = EXCLUDED."transactionAt"
+			"transactionAt" = EXCLUDED."transactionAt",
+			"txHash" = EXCLUDED."txHash"
 	`
 
-	_, err := m.db.ExecContext(
+	if _, err := tx.ExecContext(
 		ctx,
 		query,
 		utils.GenDBID(),
@@ -43,31 +71,141 @@ func (m *Manager) Upsert(ctx context.Context, opt option.TransactionUpsertOption
 		opt.Amount1Out,
 		opt.ReceiverAddress,
 		opt.TransactionAt,
-	)
+		opt.TxHash,
+		opt.LogIndex,
+	); err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
 }
 
-func (m *Manager) GetUserUSDC(ctx context.Context, address string) (decimal.Decimal, error) {
-	query := `
-		SELECT SUM("amount0In") AS amount
-		FROM transaction
-		WHERE "senderAddress" = $1;
-    `
+// GetUserSwaps returns the swaps sent by the user on the pairs in the time range, ordered by block.
+// Pair addresses are matched case-insensitively.
+func (m *Manager) GetUserSwaps(ctx context.Context, opt option.GetUserSwapsOptions) ([]model.Transaction, error) {
+	pairs := make([]string, 0, len(opt.Pairs))
+	for _, pair := range opt.Pairs {
+		pairs = append(pairs, strings.ToLower(pair))
+	}
+
+	var endAt sql.NullTime
+	if opt.EndAt != nil {
+		endAt = sql.NullTime{Time: *opt.EndAt, Valid: true}
+	}
 
-	var totalAmount string
-	err := m.db.QueryRow(query, address).Scan(&totalAmount)
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "id", "blockNum", "logIndex", "txHash", "pairAddress", "senderAddress", "receiverAddress",
+			"amount0In", "amount1In", "amount0Out", "amount1Out", "transactionAt"
+		FROM transaction
+		WHERE "senderAddress" = $1
+			AND LOWER("pairAddress") = ANY($2::VARCHAR[])
+			AND "transactionAt" >= $3
+			AND ($4::TIMESTAMPTZ IS NULL OR "transactionAt" < $4)
+		ORDER BY "blockNum", "logIndex"
+	`, opt.Address, pq.Array(pairs), opt.StartAt, endAt)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			totalAmount = "0"
+		return nil, fmt.Errorf("GetUserSwaps query fail: %v", err)
+	}
+	defer rows.Close()
+
+	swaps := make([]model.Transaction, 0)
+	for rows.Next() {
+		var swap model.Transaction
+		if err := rows.Scan(
+			&swap.ID,
+			&swap.BlockNum,
+			&swap.LogIndex,
+			&swap.TxHash,
+			&swap.PairAddress,
+			&swap.SenderAddress,
+			&swap.ReceiverAddress,
+			&swap.Amount0In,
+			&swap.Amount1In,
+			&swap.Amount0Out,
+			&swap.Amount1Out,
+			&swap.TransactionAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetUserSwaps scan fail: %v", err)
 		}
-		return decimal.Decimal{}, fmt.Errorf("failed to query total amount0In: %v", err)
+		swaps = append(swaps, swap)
 	}
 
-	decAmount, err := decimal.NewFromString(totalAmount)
+	return swaps, rows.Err()
+}
+
+// MarkSandwiches analyzes the swaps of the pair in the block range and saves their role in sandwiches.
+// Roles of the range are rebuilt on every call, so it can be re-run as blocks fill up. It returns the number of sandwiches.
+func (m *Manager) MarkSandwiches(ctx context.Context, pairAddress string, fromBlock uint64, toBlock uint64) (int, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
-		return decimal.Decimal{}, fmt.Errorf("failed to convert amount0In to decimal: %v", err)
+		return 0, fmt.Errorf("MarkSandwiches begin fail: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT "id", "blockNum", "logIndex", "txHash", "senderAddress",
+			"amount0In", "amount1In", "amount0Out", "amount1Out"
+		FROM transaction
+		WHERE "pairAddress" = $1 AND "blockNum" BETWEEN $2 AND $3
+		ORDER BY "blockNum", "logIndex"
+	`, pairAddress, fromBlock, toBlock)
+	if err != nil {
+		return 0, fmt.Errorf("MarkSandwiches query fail: %v", err)
+	}
+
+	var swaps []model.Transaction
+	for rows.Next() {
+		var swap model.Transaction
+		if err := rows.Scan(
+			&swap.ID,
+			&swap.BlockNum,
+			&swap.LogIndex,
+			&swap.TxHash,
+			&swap.SenderAddress,
+			&swap.Amount0In,
+			&swap.Amount1In,
+			&swap.Amount0Out,
+			&swap.Amount1Out,
+		); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("MarkSandwiches scan fail: %v", err)
+		}
+		swaps = append(swaps, swap)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE transaction SET "mevRole" = ''
+		WHERE "pairAddress" = $1 AND "blockNum" BETWEEN $2 AND $3 AND "mevRole" <> ''
+	`, pairAddress, fromBlock, toBlock); err != nil {
+		return 0, fmt.Errorf("MarkSandwiches reset fail: %v", err)
+	}
+
+	sandwiches := mev.DetectSandwiches(swaps)
+	for _, s := range sandwiches {
+		roles := map[string]string{
+			s.FrontRun.ID: constants.MevRoleFrontRun,
+			s.BackRun.ID:  constants.MevRoleBackRun,
+		}
+		for _, v := range s.Victims {
+			roles[v.ID] = constants.MevRoleVictim
+		}
+
+		for id, role := range roles {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE transaction SET "mevRole" = $1 WHERE "id" = $2
+			`, role, id); err != nil {
+				return 0, fmt.Errorf("MarkSandwiches update fail: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("MarkSandwiches commit fail: %v", err)
 	}
 
-	return decAmount, nil
+	return len(sandwiches), nil
 }
@@ -2,9 +2,11 @@ package transaction
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 	"tradingAce/internal/testutils"
+	"tradingAce/pkg/constants"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
 
@@ -138,7 +140,7 @@ func TestManager_Upsert(t *testing.T) {
 	}
 }
 
-func TestManager_GetUserUSDC(t *testing.T) {
+func TestManager_UpsertLegacy(t *testing.T) {
 	godotenv.Load("../../../.env/.env")
 
 	d, err := testutils.GetTestDb(t, "../../../migrations")
@@ -149,39 +151,187 @@ func TestManager_GetUserUSDC(t *testing.T) {
 	defer d.Close()
 
 	mgr := Manager{db: d}
+	ctx := context.TODO()
+	sender := "0x0000000000000000000000000000000000000111"
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	transactionAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// a swap ingested before log indexes were recorded
+	if _, err := d.Exec(`
+		INSERT INTO transaction ("id", "blockNum", "pairAddress", "senderAddress", "receiverAddress", "amount0In", "transactionAt")
+		VALUES ('legacy', 1, $1, $2, $2, 200, $3)
+	`, pair, sender, transactionAt); err != nil {
+		t.Errorf("insert legacy swap err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "tradeFlag" ("id", "userAddress", "transactionId", "reason") VALUES ('flag', $1, 'legacy', 'ping_pong')
+	`, sender); err != nil {
+		t.Errorf("insert trade flag err: %v", err)
+		return
+	}
+
+	// the history sync ingests the swaps of the block again with their log indexes
+	for i, amount := range []int64{100, 200} {
+		if err := mgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        1,
+			PairAddress:     pair,
+			SenderAddress:   sender,
+			ReceiverAddress: sender,
+			Amount0In:       decimal.NewFromInt(amount),
+			TransactionAt:   transactionAt,
+			TxHash:          "0x01",
+			LogIndex:        uint(3 + i),
+		}); err != nil {
+			t.Errorf("Upsert() error = %v", err)
+			return
+		}
+	}
+
+	swaps, err := mgr.GetUserSwaps(ctx, option.GetUserSwapsOptions{Address: sender, Pairs: []string{pair}, StartAt: transactionAt})
+	if err != nil {
+		t.Errorf("GetUserSwaps() error = %v", err)
+		return
+	}
+	if assert.Equal(t, 2, len(swaps)) {
+		assert.Equal(t, uint(3), swaps[0].LogIndex)
+		assert.Equal(t, uint(4), swaps[1].LogIndex)
+	}
+
+	var flags int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM "tradeFlag"`).Scan(&flags); err != nil {
+		t.Errorf("count trade flags err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, flags)
+}
+
+func TestManager_GetUserSwaps(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	ctx := context.TODO()
+	sender := "0x0000000000000000000000000000000000000111"
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
 
 	// init data
-	opt1 := option.TransactionUpsertOptions{
-		BlockNum:        1,
-		PairAddress:     "0x0000000000000000000000000000000000000000",
-		SenderAddress:   "0x0000000000000000000000000000000000000111",
-		Amount0In:       decimal.NewFromInt(100),
-		Amount1In:       decimal.NewFromInt(55),
-		Amount0Out:      decimal.NewFromInt(66),
-		Amount1Out:      decimal.NewFromInt(77),
-		ReceiverAddress: "0x0000000000000000000000000000000000000000",
-	}
-	if err := mgr.Upsert(context.TODO(), opt1); err != nil {
-		t.Errorf("GetUserUSDC() error = %v", err)
-	}
-	opt2 := option.TransactionUpsertOptions{
-		BlockNum:        2,
-		PairAddress:     "0x0000000000000000000000000000000000000000",
-		SenderAddress:   "0x0000000000000000000000000000000000000111",
-		Amount0In:       decimal.NewFromInt(200),
-		Amount1In:       decimal.NewFromInt(55),
-		Amount0Out:      decimal.NewFromInt(66),
-		Amount1Out:      decimal.NewFromInt(77),
-		ReceiverAddress: "0x0000000000000000000000000000000000000000",
-	}
-	if err := mgr.Upsert(context.TODO(), opt2); err != nil {
-		t.Errorf("GetUserUSDC() error = %v", err)
-	}
-
-	result, err := mgr.GetUserUSDC(context.TODO(), "0x0000000000000000000000000000000000000111")
+	for _, opt := range []option.TransactionUpsertOptions{
+		{BlockNum: 1, PairAddress: pair, SenderAddress: sender, Amount0In: decimal.NewFromInt(100), TransactionAt: startAt},
+		{BlockNum: 2, PairAddress: pair, SenderAddress: sender, Amount0In: decimal.NewFromInt(200), TransactionAt: startAt.Add(time.Hour)},
+		// before the start
+		{BlockNum: 3, PairAddress: pair, SenderAddress: sender, Amount0In: decimal.NewFromInt(300), TransactionAt: startAt.Add(-time.Hour)},
+		// other pair
+		{BlockNum: 4, PairAddress: "0x0000000000000000000000000000000000000000", SenderAddress: sender, Amount0In: decimal.NewFromInt(400), TransactionAt: startAt},
+		// other sender
+		{BlockNum: 5, PairAddress: pair, SenderAddress: "0x0000000000000000000000000000000000000222", Amount0In: decimal.NewFromInt(500), TransactionAt: startAt},
+	} {
+		if err := mgr.Upsert(ctx, opt); err != nil {
+			t.Errorf("Upsert() error = %v", err)
+		}
+	}
+
+	result, err := mgr.GetUserSwaps(ctx, option.GetUserSwapsOptions{
+		Address: sender,
+		Pairs:   []string{strings.ToLower(pair)},
+		StartAt: startAt,
+	})
 	if err != nil {
-		t.Errorf("GetUserUSDC() error = %v", err)
+		t.Errorf("GetUserSwaps() error = %v", err)
+	}
+	assert.Equal(t, 2, len(result))
+	for i, want := range []int64{100, 200} {
+		assert.True(t, decimal.NewFromInt(want).Equal(result[i].Amount0In))
+		assert.Equal(t, pair, result[i].PairAddress)
+	}
+
+	endAt := startAt.Add(time.Hour)
+	result, err = mgr.GetUserSwaps(ctx, option.GetUserSwapsOptions{
+		Address: sender,
+		Pairs:   []string{pair},
+		StartAt: startAt,
+		EndAt:   &endAt,
+	})
+	if err != nil {
+		t.Errorf("GetUserSwaps() error = %v", err)
+	}
+	assert.Equal(t, 1, len(result))
+}
+
+func TestManager_MarkSandwiches(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	bot := "0x0000000000000000000000000000000000000001"
+	user := "0x0000000000000000000000000000000000000002"
+	usdc := decimal.NewFromInt(10000e6)
+	eth := decimal.NewFromInt(5e18)
+
+	swaps := []option.TransactionUpsertOptions{
+		{BlockNum: 10, LogIndex: 1, TxHash: "0x01", SenderAddress: bot, Amount0In: usdc, Amount1Out: eth},
+		{BlockNum: 10, LogIndex: 2, TxHash: "0x02", SenderAddress: user, Amount0In: decimal.NewFromInt(2000e6), Amount1Out: decimal.NewFromInt(98e16)},
+		{BlockNum: 10, LogIndex: 3, TxHash: "0x03", SenderAddress: bot, Amount1In: eth, Amount0Out: usdc},
+		// a normal swap in the next block
+		{BlockNum: 11, LogIndex: 1, TxHash: "0x04", SenderAddress: user, Amount0In: decimal.NewFromInt(2000e6), Amount1Out: decimal.NewFromInt(98e16)},
+	}
+	for _, swap := range swaps {
+		swap.PairAddress = pair
+		swap.ReceiverAddress = swap.SenderAddress
+		swap.TransactionAt = time.Now()
+		if err := mgr.Upsert(ctx, swap); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	// re-running keeps the same roles
+	for i := 0; i < 2; i++ {
+		count, err := mgr.MarkSandwiches(ctx, pair, 10, 11)
+		if err != nil {
+			t.Errorf("MarkSandwiches err: %v", err)
+			return
+		}
+		assert.Equal(t, 1, count)
+	}
+
+	rows, err := d.Query(`SELECT "txHash", "mevRole" FROM transaction ORDER BY "blockNum", "logIndex"`)
+	if err != nil {
+		t.Errorf("query err: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	roles := make(map[string]string)
+	for rows.Next() {
+		var txHash, role string
+		if err := rows.Scan(&txHash, &role); err != nil {
+			t.Errorf("scan err: %v", err)
+			return
+		}
+		roles[txHash] = role
 	}
 
-	assert.True(t, decimal.NewFromInt(300).Equal(result))
+	assert.Equal(t, map[string]string{
+		"0x01": constants.MevRoleFrontRun,
+		"0x02": constants.MevRoleVictim,
+		"0x03": constants.MevRoleBackRun,
+		"0x04": "",
+	}, roles)
 }
@@ -5,23 +5,38 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+	"tradingAce/pkg/constants"
 	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
 	"tradingAce/pkg/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 type Manager struct {
 	db *sql.DB
 }
 
-func (m *Manager) UpsertForUserTask(ctx context.Context, address string, taskId string, point int) error {
-	query := `
-		INSERT INTO "userPoint" ("id", "userAddress", "taskId", "point", "createdAt")
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT ("userAddress", "taskId")
-		DO UPDATE SET "point" = EXCLUDED."point"
-	`
+// UpsertForUserTask sets the balance of the user for the task, the difference is recorded as an adjustment.
+func (m *Manager) UpsertForUserTask(ctx context.Context, address string, taskId string, point decimal.Decimal) error {
+	err := m.withUserTaskLock(ctx, address, taskId, func(tx *sql.Tx) error {
+		var current decimal.Decimal
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM("point"), 0) FROM "pointLedger"
+			WHERE "userAddress" = $1 AND "taskId" = $2
+		`, address, taskId).Scan(&current); err != nil {
+			return err
+		}
 
-	_, err := m.db.ExecContext(ctx, query, utils.GenDBID(), address, taskId, point, time.Now())
+		entry := model.PointLedgerEntry{
+			UserAddress: address,
+			TaskID:      taskId,
+			Point:       point.Sub(current),
+			Reason:      constants.PointReasonAdjustment,
+			Multiplier:  decimal.NewFromInt(1),
+		}
+		return appendEntry(ctx, tx, entry)
+	})
 	if err != nil {
 		return fmt.Errorf("UpsertForUserTask failed to upsert user task: %v", err)
 	}
@@ -29,13 +44,39 @@ func (m *Manager) UpsertForUserTask(ctx context.Context, address string, taskId
 	return nil
 }
 
+// SetEpochPoints appends the difference between opt.Point and the ledger total of the epoch, nothing is
+// written when they are equal so settlements can be re-run safely.
+func (m *Manager) SetEpochPoints(ctx context.Context, opt option.SetEpochPointsOptions) error {
+	err := m.withUserTaskLock(ctx, opt.Address, opt.TaskID, func(tx *sql.Tx) error {
+		return setEpochPoints(ctx, tx, opt)
+	})
+	if err != nil {
+		return fmt.Errorf("SetEpochPoints failed: %v", err)
+	}
+
+	return nil
+}
+
+// SetEpochPointsTx is SetEpochPoints within the transaction of a settlement run.
+// It takes no lock of the user task, the caller serializes the settlements of the task.
+func (m *Manager) SetEpochPointsTx(ctx context.Context, tx *sql.Tx, opt option.SetEpochPointsOptions) error {
+	if err := setEpochPoints(ctx, tx, opt); err != nil {
+		return fmt.Errorf("SetEpochPointsTx failed: %v", err)
+	}
+
+	return nil
+}
+
 func (m *Manager) GetUserPointsForTask(ctx context.Context, taskID string) ([]model.UserPoint, error) {
-	query := `SELECT "id", "userAddress", "createdAt", "taskId", "point" FROM "userPoint"`
+	query := `
+		SELECT up."id", up."userAddress", up."createdAt", up."taskId", COALESCE(t."campaignId", ''), up."point"
+		FROM "userPoint" up
+		LEFT JOIN "task" t ON t."id" = up."taskId"`
 
 	var args []interface{}
 
 	if len(taskID) != 0 {
-		query += ` WHERE "taskId" = $1`
+		query += ` WHERE up."taskId" = $1`
 		args = append(args, taskID)
 	}
 
@@ -54,7 +95,9 @@ func (m *Manager) GetUserPointsForTask(ctx context.Context, taskID string) ([]mo
 	var userPoints []model.UserPoint
 	for rows.Next() {
 		var userPoint model.UserPoint
-		if err := rows.Scan(&userPoint.ID, &userPoint.UserAddress, &userPoint.CreatedAt, &userPoint.TaskID, &userPoint.Point); err != nil {
+		if err := rows.Scan(
+			&userPoint.ID, &userPoint.UserAddress, &userPoint.CreatedAt, &userPoint.TaskID, &userPoint.CampaignID, &userPoint.Point,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
 		}
 		userPoints = append(userPoints, userPoint)
@@ -64,5 +107,205 @@ func (m *Manager) GetUserPointsForTask(ctx context.Context, taskID string) ([]mo
 		return nil, fmt.Errorf("rows iteration error: %v", err)
 	}
 
+	epochs, err := m.getEpochPoints(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range userPoints {
+		userPoints[i].Epochs = epochs[userPoints[i].UserAddress+userPoints[i].TaskID]
+	}
+
 	return userPoints, nil
 }
+
+// GetUserPointsForCampaign returns the total points of every user over the tasks of the campaign, the highest first.
+func (m *Manager) GetUserPointsForCampaign(ctx context.Context, campaignID string) ([]model.UserPoint, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT up."userAddress", MIN(up."createdAt"), SUM(up."point")
+		FROM "userPoint" up
+		JOIN "task" t ON t."id" = up."taskId"
+		WHERE t."campaignId" = $1
+		GROUP BY up."userAddress"
+		ORDER BY SUM(up."point") DESC, up."userAddress"
+	`, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserPointsForCampaign query fail: %v", err)
+	}
+	defer rows.Close()
+
+	userPoints := make([]model.UserPoint, 0)
+	for rows.Next() {
+		userPoint := model.UserPoint{CampaignID: campaignID}
+		if err := rows.Scan(&userPoint.UserAddress, &userPoint.CreatedAt, &userPoint.Point); err != nil {
+			return nil, fmt.Errorf("GetUserPointsForCampaign scan fail: %v", err)
+		}
+		userPoints = append(userPoints, userPoint)
+	}
+
+	return userPoints, rows.Err()
+}
+
+// GetLedger returns the ledger entries of the user in the order they were written.
+func (m *Manager) GetLedger(ctx context.Context, address string, taskID string) ([]model.PointLedgerEntry, error) {
+	query := `
+		SELECT "id", "createdAt", "userAddress", "taskId", "epoch", "point", "reason", "settlementRunId", "multiplier"
+		FROM "pointLedger"
+		WHERE "userAddress" = $1`
+	args := []interface{}{address}
+
+	if len(taskID) != 0 {
+		query += ` AND "taskId" = $2`
+		args = append(args, taskID)
+	}
+	query += ` ORDER BY "createdAt", "id"`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetLedger query fail: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.PointLedgerEntry, 0)
+	for rows.Next() {
+		var entry model.PointLedgerEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.UserAddress,
+			&entry.TaskID,
+			&entry.Epoch,
+			&entry.Point,
+			&entry.Reason,
+			&entry.SettlementRunID,
+			&entry.Multiplier,
+		); err != nil {
+			return nil, fmt.Errorf("GetLedger scan fail: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// getEpochPoints returns the per-epoch totals keyed by userAddress+taskId
+func (m *Manager) getEpochPoints(ctx context.Context, taskID string) (map[string][]model.UserPointEpoch, error) {
+	query := `
+		SELECT "userAddress", "taskId", "epoch", SUM("point")
+		FROM "pointLedger"`
+
+	var args []interface{}
+	if len(taskID) != 0 {
+		query += ` WHERE "taskId" = $1`
+		args = append(args, taskID)
+	}
+	query += ` GROUP BY "userAddress", "taskId", "epoch" ORDER BY "epoch"`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query epoch points: %v", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]model.UserPointEpoch)
+	for rows.Next() {
+		var address, task string
+		var e model.UserPointEpoch
+		if err := rows.Scan(&address, &task, &e.Epoch, &e.Point); err != nil {
+			return nil, fmt.Errorf("failed to scan epoch points: %v", err)
+		}
+		result[address+task] = append(result[address+task], e)
+	}
+
+	return result, rows.Err()
+}
+
+// withUserTaskLock serializes ledger writes of one user task, so the computed differences stay correct.
+func (m *Manager) withUserTaskLock(ctx context.Context, address string, taskID string, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, address+taskID); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func setEpochPoints(ctx context.Context, tx *sql.Tx, opt option.SetEpochPointsOptions) error {
+	var current decimal.Decimal
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM("point"), 0) FROM "pointLedger"
+		WHERE "userAddress" = $1 AND "taskId" = $2 AND "epoch" = $3
+	`, opt.Address, opt.TaskID, opt.Epoch).Scan(&current); err != nil {
+		return err
+	}
+
+	multiplier := opt.Multiplier
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromInt(1)
+	}
+
+	entry := model.PointLedgerEntry{
+		UserAddress: opt.Address,
+		TaskID:      opt.TaskID,
+		Epoch:       opt.Epoch,
+		Point:       opt.Point.Sub(current),
+		Reason:      opt.Reason,
+		SettlementRunID: sql.NullString{
+			String: opt.SettlementRunID,
+			Valid:  len(opt.SettlementRunID) != 0,
+		},
+		Multiplier: multiplier,
+	}
+	return appendEntry(ctx, tx, entry)
+}
+
+// appendEntry writes a non-zero ledger entry and refreshes the balance in "userPoint"
+func appendEntry(ctx context.Context, tx *sql.Tx, entry model.PointLedgerEntry) error {
+	if !entry.Point.IsZero() {
+		if err := insertEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "userPoint" ("id", "userAddress", "taskId", "point", "createdAt")
+		SELECT $1::VARCHAR, $2::VARCHAR, $3::VARCHAR, COALESCE(SUM("point"), 0), $4::TIMESTAMP WITH TIME ZONE
+		FROM "pointLedger"
+		WHERE "userAddress" = $2 AND "taskId" = $3
+		ON CONFLICT ("userAddress", "taskId")
+		DO UPDATE SET "point" = EXCLUDED."point"
+	`, utils.GenDBID(), entry.UserAddress, entry.TaskID, time.Now()); err != nil {
+		return fmt.Errorf("refresh user point: %v", err)
+	}
+
+	return nil
+}
+
+func insertEntry(ctx context.Context, tx *sql.Tx, entry model.PointLedgerEntry) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "pointLedger" ("id", "createdAt", "userAddress", "taskId", "epoch", "point", "reason", "settlementRunId", "multiplier")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`,
+		utils.GenDBID(),
+		time.Now(),
+		entry.UserAddress,
+		entry.TaskID,
+		entry.Epoch,
+		entry.Point,
+		entry.Reason,
+		entry.SettlementRunID,
+		entry.Multiplier,
+	); err != nil {
+		return fmt.Errorf("insert point ledger: %v", err)
+	}
+
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"time"
 	"tradingAce/internal/testutils"
 	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,7 +27,7 @@ func TestManager_UpsertForUserTask(t *testing.T) {
 		ctx     context.Context
 		address string
 		taskId  string
-		point   int
+		point   decimal.Decimal
 	}
 	tests := []struct {
 		name string
@@ -38,12 +40,12 @@ func TestManager_UpsertForUserTask(t *testing.T) {
 				ctx:     context.TODO(),
 				address: "0x0000000000000000000000000000000000000000",
 				taskId:  "task1",
-				point:   10,
+				point:   decimal.NewFromInt(10),
 			},
 			want: model.UserPoint{
 				UserAddress: "0x0000000000000000000000000000000000000000",
 				TaskID:      "task1",
-				Point:       10,
+				Point:       decimal.NewFromInt(10),
 			},
 		},
 		{
@@ -52,12 +54,12 @@ func TestManager_UpsertForUserTask(t *testing.T) {
 				ctx:     context.TODO(),
 				address: "0x0000000000000000000000000000000000000000",
 				taskId:  "task1",
-				point:   88,
+				point:   decimal.NewFromInt(88),
 			},
 			want: model.UserPoint{
 				UserAddress: "0x0000000000000000000000000000000000000000",
 				TaskID:      "task1",
-				Point:       88,
+				Point:       decimal.NewFromInt(88),
 			},
 		},
 	}
@@ -87,7 +89,7 @@ func TestManager_UpsertForUserTask(t *testing.T) {
 
 			assert.Equal(t, tt.want.UserAddress, result.UserAddress)
 			assert.Equal(t, tt.want.TaskID, result.TaskID)
-			assert.Equal(t, tt.want.Point, result.Point)
+			assert.True(t, tt.want.Point.Equal(result.Point))
 		})
 	}
 }
@@ -108,7 +110,7 @@ func TestManager_GetUserPointsForTask(t *testing.T) {
 	data1 := model.UserPoint{
 		UserAddress: "0x0000000000000000000000000000000000000000",
 		TaskID:      "task1",
-		Point:       10,
+		Point:       decimal.NewFromInt(10),
 		CreatedAt:   now,
 	}
 
@@ -146,7 +148,7 @@ func TestManager_GetUserPointsForTask(t *testing.T) {
 
 			assert.Equal(t, tt.want.UserAddress, result[0].UserAddress)
 			assert.Equal(t, tt.want.TaskID, result[0].TaskID)
-			assert.Equal(t, tt.want.Point, result[0].Point)
+			assert.True(t, tt.want.Point.Equal(result[0].Point))
 		})
 	}
 }
@@ -167,13 +169,13 @@ func TestManager_GetUserPointsForTasWithoutTaskID(t *testing.T) {
 	data1 := model.UserPoint{
 		UserAddress: "0x0000000000000000000000000000000000000000",
 		TaskID:      "task1",
-		Point:       10,
+		Point:       decimal.NewFromInt(10),
 		CreatedAt:   now,
 	}
 	data2 := model.UserPoint{
 		UserAddress: "0x0000000000000000000000000000000000000001",
 		TaskID:      "task1",
-		Point:       30,
+		Point:       decimal.NewFromInt(30),
 		CreatedAt:   now,
 	}
 
@@ -202,3 +204,168 @@ func TestManager_GetUserPointsForTasWithoutTaskID(t *testing.T) {
 		assert.True(t, found)
 	}
 }
+
+func TestManager_GetUserPointsForCampaign(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+	mgr := Manager{db: d}
+
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES ('campaign1', 'season 1', $1, $2)
+	`, now, now.AddDate(0, 3, 0)); err != nil {
+		t.Errorf("insert campaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId") VALUES
+		('task1', 'share_pool', $1, 'campaign1'),
+		('task2', 'streak', $1, 'campaign1'),
+		('task3', 'share_pool', $1, NULL)
+	`, now); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+
+	user1 := "0x0000000000000000000000000000000000000001"
+	user2 := "0x0000000000000000000000000000000000000002"
+	for _, p := range []struct {
+		address string
+		taskID  string
+		point   int64
+	}{
+		{user1, "task1", 10},
+		{user1, "task2", 5},
+		{user1, "task3", 100},
+		{user2, "task1", 20},
+	} {
+		if err := mgr.UpsertForUserTask(ctx, p.address, p.taskID, decimal.NewFromInt(p.point)); err != nil {
+			t.Errorf("UpsertForUserTask() error = %v", err)
+			return
+		}
+	}
+
+	result, err := mgr.GetUserPointsForCampaign(ctx, "campaign1")
+	if err != nil {
+		t.Errorf("GetUserPointsForCampaign() error = %v", err)
+		return
+	}
+	if !assert.Equal(t, 2, len(result)) {
+		return
+	}
+	assert.Equal(t, user2, result[0].UserAddress)
+	assert.True(t, decimal.NewFromInt(20).Equal(result[0].Point))
+	assert.Equal(t, user1, result[1].UserAddress)
+	assert.True(t, decimal.NewFromInt(15).Equal(result[1].Point))
+	assert.Equal(t, "campaign1", result[1].CampaignID)
+
+	taskPoints, err := mgr.GetUserPointsForTask(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetUserPointsForTask() error = %v", err)
+		return
+	}
+	for _, p := range taskPoints {
+		assert.Equal(t, "campaign1", p.CampaignID)
+	}
+}
+
+func TestManager_SetEpochPoints(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	ctx := context.TODO()
+	address := "0x0000000000000000000000000000000000000000"
+
+	opts := []option.SetEpochPointsOptions{
+		{Address: address, TaskID: "task1", Epoch: 0, Point: decimal.NewFromInt(100), Reason: "share_pool_epoch", SettlementRunID: "run1"},
+		{Address: address, TaskID: "task1", Epoch: 1, Point: decimal.NewFromInt(50), Reason: "share_pool_epoch", SettlementRunID: "run1"},
+		// re-run with the same result writes nothing
+		{Address: address, TaskID: "task1", Epoch: 0, Point: decimal.NewFromInt(100), Reason: "share_pool_epoch", SettlementRunID: "run2"},
+		// recomputed epoch appends the difference
+		{Address: address, TaskID: "task1", Epoch: 1, Point: decimal.NewFromInt(80), Reason: "share_pool_epoch", SettlementRunID: "run2", Multiplier: decimal.NewFromInt(2)},
+	}
+	for _, opt := range opts {
+		if err := mgr.SetEpochPoints(ctx, opt); err != nil {
+			t.Errorf("SetEpochPoints() error = %v", err)
+			return
+		}
+	}
+
+	entries, err := mgr.GetLedger(ctx, address, "task1")
+	if err != nil {
+		t.Errorf("GetLedger() error = %v", err)
+		return
+	}
+	assert.Equal(t, 3, len(entries))
+	assert.True(t, decimal.NewFromInt(30).Equal(entries[2].Point))
+	assert.Equal(t, 1, entries[2].Epoch)
+	assert.Equal(t, "run2", entries[2].SettlementRunID.String)
+	assert.True(t, decimal.NewFromInt(1).Equal(entries[0].Multiplier))
+	assert.True(t, decimal.NewFromInt(2).Equal(entries[2].Multiplier))
+
+	result, err := mgr.GetUserPointsForTask(ctx, "task1")
+	if err != nil {
+		t.Errorf("GetUserPointsForTask() error = %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(result))
+	assert.True(t, decimal.NewFromInt(180).Equal(result[0].Point))
+	assert.Equal(t, 2, len(result[0].Epochs))
+	assert.True(t, decimal.NewFromInt(100).Equal(result[0].Epochs[0].Point))
+	assert.True(t, decimal.NewFromInt(80).Equal(result[0].Epochs[1].Point))
+}
+
+func TestManager_GetLedger(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	mgr := Manager{db: d}
+	ctx := context.TODO()
+	address := "0x0000000000000000000000000000000000000000"
+
+	if err := mgr.UpsertForUserTask(ctx, address, "task1", decimal.NewFromInt(10)); err != nil {
+		t.Errorf("UpsertForUserTask() error = %v", err)
+		return
+	}
+	if err := mgr.UpsertForUserTask(ctx, address, "task2", decimal.NewFromInt(20)); err != nil {
+		t.Errorf("UpsertForUserTask() error = %v", err)
+		return
+	}
+
+	all, err := mgr.GetLedger(ctx, address, "")
+	if err != nil {
+		t.Errorf("GetLedger() error = %v", err)
+		return
+	}
+	assert.Equal(t, 2, len(all))
+
+	filtered, err := mgr.GetLedger(ctx, address, "task2")
+	if err != nil {
+		t.Errorf("GetLedger() error = %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(filtered))
+	assert.Equal(t, "adjustment", filtered[0].Reason)
+	assert.True(t, decimal.NewFromInt(20).Equal(filtered[0].Point))
+}
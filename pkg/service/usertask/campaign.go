@@ -0,0 +1,49 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tradingAce/pkg/core/campaign"
+	"tradingAce/pkg/model"
+
+	"github.com/shopspring/decimal"
+)
+
+type spentFunc func(ctx context.Context, campaignID string, excludeTaskID string) (decimal.Decimal, error)
+
+// applyBudget caps the points of the task, by user and epoch, to what its campaign has left.
+// Points of the other tasks of the campaign are spent first, tasks without campaign or budget are not capped.
+func (m *Manager) applyBudget(ctx context.Context, task model.Task, points map[string]map[int]decimal.Decimal) error {
+	return m.capBudget(ctx, task, points, m.campaignMgr.GetSpent)
+}
+
+// applyBudgetTx is applyBudget again in the commit transaction of the settlement, with the budget of the campaign
+// locked until it ends, so what other tasks of the campaign committed since the points were computed is spent first.
+func (m *Manager) applyBudgetTx(ctx context.Context, tx *sql.Tx, task model.Task, points map[string]map[int]decimal.Decimal) error {
+	return m.capBudget(ctx, task, points, func(ctx context.Context, campaignID string, excludeTaskID string) (decimal.Decimal, error) {
+		return m.campaignMgr.GetSpentTx(ctx, tx, campaignID, excludeTaskID)
+	})
+}
+
+func (m *Manager) capBudget(ctx context.Context, task model.Task, points map[string]map[int]decimal.Decimal, getSpent spentFunc) error {
+	if !task.CampaignID.Valid {
+		return nil
+	}
+
+	c, err := m.campaignMgr.GetCampaign(ctx, task.CampaignID.String)
+	if err != nil {
+		return fmt.Errorf("applyBudget get campaign: %v", err)
+	}
+	if c.Budget == nil {
+		return nil
+	}
+
+	spent, err := getSpent(ctx, c.ID, task.ID)
+	if err != nil {
+		return fmt.Errorf("applyBudget: %v", err)
+	}
+
+	campaign.Cap(c.Budget.Sub(spent), points, task.Config.PointPrecision)
+	return nil
+}
@@ -0,0 +1,81 @@
+package usertask
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/settlement"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/shopspring/decimal"
+)
+
+// DiffSharePoolTask computes the settlement of every closed epoch of the share pool task in memory and compares it
+// with the current user tasks and points of the task. Nothing is written, not even the flags of detected wash trades.
+func (m *Manager) DiffSharePoolTask(ctx context.Context, task model.Task) (model.SettlementDiff, error) {
+	if onboardingTask == nil {
+		if err := m.setOnboardingTask(ctx); err != nil {
+			return model.SettlementDiff{}, err
+		}
+	}
+
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		return model.SettlementDiff{}, fmt.Errorf("DiffSharePoolTask schedule epochs: %v", err)
+	}
+
+	result, err := m.computeSharePool(ctx, task, epochs, settledEpochs(epochs, time.Now()), option.SettleTaskOptions{}, false)
+	if err != nil {
+		return model.SettlementDiff{}, err
+	}
+
+	next := make(map[string]model.UserSettlement, len(result.userTasks))
+	for sender, userTask := range result.userTasks {
+		point := decimal.Zero
+		for _, p := range result.points[sender] {
+			point = point.Add(p)
+		}
+		next[sender] = model.UserSettlement{
+			UserAddress: sender,
+			State:       userTask.State,
+			Amount:      userTask.Amount,
+			Point:       point,
+		}
+	}
+
+	current, err := m.getUserSettlements(ctx, task.ID)
+	if err != nil {
+		return model.SettlementDiff{}, err
+	}
+
+	return settlement.Diff(task.ID, current, next), nil
+}
+
+// getUserSettlements returns the current user task and points of every user of the task with either, by address.
+func (m *Manager) getUserSettlements(ctx context.Context, taskID string) (map[string]model.UserSettlement, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT COALESCE(ut."userAddress", up."userAddress"), COALESCE(ut."state", ''),
+			COALESCE(ut."amount", 0), COALESCE(up."point", 0)
+		FROM (SELECT * FROM "userTask" WHERE "taskId" = $1) ut
+		FULL JOIN (SELECT * FROM "userPoint" WHERE "taskId" = $1) up
+			ON ut."userAddress" = up."userAddress"
+		WHERE ut."userAddress" IS NOT NULL OR up."point" <> 0
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("getUserSettlements query fail: %v", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]model.UserSettlement)
+	for rows.Next() {
+		var s model.UserSettlement
+		if err := rows.Scan(&s.UserAddress, &s.State, &s.Amount, &s.Point); err != nil {
+			return nil, fmt.Errorf("getUserSettlements scan fail: %v", err)
+		}
+		result[s.UserAddress] = s
+	}
+
+	return result, rows.Err()
+}
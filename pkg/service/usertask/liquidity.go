@@ -0,0 +1,186 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/liquidity"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/shopspring/decimal"
+)
+
+func (m *Manager) CheckLPTasks(ctx context.Context) error {
+	tasks, err := m.taskMgr.GetLPTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	if onboardingTask == nil {
+		if err := m.setOnboardingTask(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, task := range tasks {
+		if err := m.checkLPTask(ctx, task, option.SettleTaskOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkLPTask distributes the points of every settled epoch by the liquidity-seconds of eligible providers who unlocked the task.
+// The amount of the user task is the time-weighted average LP token balance over the settled epochs.
+func (m *Manager) checkLPTask(ctx context.Context, task model.Task, opt option.SettleTaskOptions) error {
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		return fmt.Errorf("checkLPTask schedule epochs: %v", err)
+	}
+	settled := settledEpochs(epochs, opt.Until)
+	if len(settled) == 0 {
+		return nil
+	}
+
+	state := "pending"
+	var completedAt *time.Time
+	if len(settled) == len(epochs) {
+		// the task is completed when its last epoch ends
+		state, completedAt = "completed", &epochs[len(epochs)-1].EndAt
+	}
+
+	return m.settle(ctx, task, settled, opt, func() (settleFunc, error) {
+		tasks, err := m.epochTasks(ctx, task, settled, opt)
+		if err != nil {
+			return nil, fmt.Errorf("checkLPTask rule versions: %v", err)
+		}
+
+		transfers, err := m.liquidityMgr.GetTransfers(ctx, task.PairAddress.String, settled[len(settled)-1].EndAt)
+		if err != nil {
+			return nil, err
+		}
+		// LP tokens sent to the pair are burned, they are not provided liquidity
+		excluded := []string{constants.ZeroAddress, task.PairAddress.String}
+
+		senderPoints := make(map[string]map[int]decimal.Decimal)
+		senderSeconds := make(map[string]decimal.Decimal)
+		duration := decimal.Zero
+
+		for i, e := range settled {
+			duration = duration.Add(decimal.NewFromFloat(e.EndAt.Sub(e.StartAt).Seconds()))
+
+			strategy, err := distribution.New(tasks[i].Config.Distribution)
+			if err != nil {
+				return nil, fmt.Errorf("checkLPTask distribution strategy: %v", err)
+			}
+			held, err := liquidity.Seconds(transfers, e.StartAt, e.EndAt, excluded)
+			if err != nil {
+				return nil, fmt.Errorf("checkLPTask liquidity-seconds of epoch %d: %w", e.Index, err)
+			}
+			seconds, err := m.getEligibleLiquidity(ctx, tasks[i], e, held)
+			if err != nil {
+				return nil, err
+			}
+
+			epochPoints := strategy.Distribute(epoch.Points(tasks[i].Config, e.Index), seconds, tasks[i].Config.PointPrecision)
+			for sender, s := range seconds {
+				if _, ok := senderPoints[sender]; !ok {
+					senderPoints[sender] = make(map[int]decimal.Decimal)
+				}
+
+				senderPoints[sender][e.Index] = epochPoints[sender]
+				senderSeconds[sender] = senderSeconds[sender].Add(s)
+			}
+		}
+		if err := m.applyBudget(ctx, task, senderPoints); err != nil {
+			return nil, err
+		}
+
+		return func(tx *sql.Tx, runIDs map[int]string) error {
+			if err := m.applyBudgetTx(ctx, tx, task, senderPoints); err != nil {
+				return err
+			}
+			for sender, epochPoints := range senderPoints {
+				if err := m.upsert(ctx, tx, model.UserTask{
+					UserAddress: sender,
+					TaskID:      task.ID,
+					State:       state,
+					Amount:      senderSeconds[sender].Div(duration).Div(constants.LPTokenPrecision),
+					CompletedAt: completedAt,
+				}); err != nil {
+					return fmt.Errorf("checkLPTask upsert user task: %v", err)
+				}
+
+				for epochIndex, points := range epochPoints {
+					runID, ok := runIDs[epochIndex]
+					if !ok {
+						continue
+					}
+					if err := m.userPointMgr.SetEpochPointsTx(ctx, tx, option.SetEpochPointsOptions{
+						Address:         sender,
+						TaskID:          task.ID,
+						Epoch:           epochIndex,
+						Point:           points,
+						Reason:          constants.PointReasonLPProvider,
+						SettlementRunID: runID,
+					}); err != nil {
+						return fmt.Errorf("checkLPTask set epoch point: %v", err)
+					}
+				}
+			}
+
+			users := make([]string, 0, len(senderPoints))
+			for sender := range senderPoints {
+				users = append(users, sender)
+			}
+			return m.clearDropped(ctx, tx, task.ID, constants.PointReasonLPProvider, runIDs, senderPoints, nil, users)
+		}, nil
+	})
+}
+
+// getEligibleLiquidity keeps the liquidity of eligible providers who unlocked the task in the epoch.
+func (m *Manager) getEligibleLiquidity(
+	ctx context.Context, task model.Task, e model.Epoch, seconds map[string]decimal.Decimal,
+) (map[string]decimal.Decimal, error) {
+
+	holders := make([]string, 0, len(seconds))
+	for holder, s := range seconds {
+		if s.IsPositive() {
+			holders = append(holders, holder)
+		}
+	}
+	if len(holders) == 0 {
+		return map[string]decimal.Decimal{}, nil
+	}
+
+	unlocked, err := m.getUnlocked(ctx, task, holders, e.StartAt)
+	if err != nil {
+		return nil, err
+	}
+	providers := make([]string, 0, len(holders))
+	for _, holder := range holders {
+		if unlocked[holder] {
+			providers = append(providers, holder)
+		}
+	}
+
+	ineligible, err := m.addressInfoMgr.GetIneligible(ctx, providers, task.Config.AllowContracts)
+	if err != nil {
+		return nil, fmt.Errorf("getEligibleLiquidity check eligibility: %v", err)
+	}
+
+	eligible := make(map[string]decimal.Decimal, len(providers))
+	for _, address := range providers {
+		if _, excluded := ineligible[address]; !excluded {
+			eligible[address] = seconds[address]
+		}
+	}
+
+	return eligible, nil
+}
@@ -0,0 +1,145 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/prerequisite"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/lib/pq"
+)
+
+// tasks settled by the task engine, a user takes part once their prerequisites are completed
+var gatedTasks = map[string]bool{
+	"share_pool":  true,
+	"streak":      true,
+	"lp_provider": true,
+}
+
+// onboardingTaskID is the default prerequisite, empty while there is no onboarding task
+func onboardingTaskID() string {
+	if onboardingTask == nil {
+		return ""
+	}
+
+	return onboardingTask.ID
+}
+
+// getUnlocked reports for every address whether the prerequisites of the task unlock the epoch starting at epochStart.
+// Tasks without prerequisites require onboarding.
+func (m *Manager) getUnlocked(
+	ctx context.Context, task model.Task, addresses []string, epochStart time.Time,
+) (map[string]bool, error) {
+
+	unlocked := make(map[string]bool, len(addresses))
+	if len(addresses) == 0 {
+		return unlocked, nil
+	}
+
+	cfg := task.Config.Prerequisites
+	// the completion time of legacy rows is unknown until it is taken from the chain again, the creation time stands in for it
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT "userAddress", "taskId", COALESCE("completedAt", "createdAt")
+		FROM "userTask"
+		WHERE "state" = 'completed' AND "taskId" = ANY($1::VARCHAR[]) AND "userAddress" = ANY($2::VARCHAR[]);
+	`, pq.Array(prerequisite.TaskIDs(cfg, onboardingTaskID())), pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("getUnlocked query user task: %v", err)
+	}
+	defer rows.Close()
+
+	completed := make(map[string]map[string]time.Time)
+	for rows.Next() {
+		var address, taskID string
+		var completedAt time.Time
+		if err := rows.Scan(&address, &taskID, &completedAt); err != nil {
+			return nil, fmt.Errorf("getUnlocked scan error: %v", err)
+		}
+		if _, ok := completed[address]; !ok {
+			completed[address] = make(map[string]time.Time)
+		}
+		completed[address][taskID] = completedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, address := range addresses {
+		unlocked[address] = prerequisite.Met(cfg, onboardingTaskID(), completed[address], epochStart)
+	}
+
+	return unlocked, nil
+}
+
+// setLocks marks whether the gated tasks are locked for the user, and lists the gated tasks the user has not started yet.
+// With prerequisites completed before epoch start, the current epoch of the task is checked.
+func (m *Manager) setLocks(
+	ctx context.Context, address string, userTasks []option.GetUserTaskPoint,
+) ([]option.GetUserTaskPoint, error) {
+
+	if onboardingTask == nil {
+		if err := m.setOnboardingTask(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return userTasks, err
+		}
+	}
+
+	tasks, err := m.taskMgr.GetTasks(ctx)
+	if err != nil {
+		return userTasks, err
+	}
+
+	started := make(map[string]int, len(userTasks))
+	for i, userTask := range userTasks {
+		started[userTask.TaskID] = i
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		if !gatedTasks[task.Name.String] {
+			continue
+		}
+
+		at := now
+		if epochs, err := epoch.Schedule(task); err == nil {
+			for _, e := range epochs {
+				if !now.Before(e.StartAt) && now.Before(e.EndAt) {
+					at = e.StartAt
+				}
+			}
+		}
+
+		unlocked, err := m.getUnlocked(ctx, task, []string{address}, at)
+		if err != nil {
+			return userTasks, err
+		}
+		locked := !unlocked[address]
+		prerequisites := prerequisite.TaskIDs(task.Config.Prerequisites, onboardingTaskID())
+
+		if i, ok := started[task.ID]; ok {
+			userTasks[i].Locked = &locked
+			userTasks[i].Prerequisites = prerequisites
+			continue
+		}
+
+		state := "unlocked"
+		if locked {
+			state = "locked"
+		}
+		userTasks = append(userTasks, option.GetUserTaskPoint{
+			UserAddress:   address,
+			TaskID:        task.ID,
+			State:         state,
+			TaskName:      task.Name.String,
+			PairAddress:   task.PairAddress.String,
+			Locked:        &locked,
+			Prerequisites: prerequisites,
+		})
+	}
+
+	return userTasks, nil
+}
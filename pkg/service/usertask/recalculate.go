@@ -0,0 +1,120 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+	"tradingAce/pkg/core/taskrule"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/utils"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// Recalculate settles the chosen epochs of the task again, under the chosen rule version or the versions in effect,
+// and records the points of every user before and after it in the same transaction. Finalized epochs are kept.
+func (m *Manager) Recalculate(ctx context.Context, task model.Task, opt option.RecalculateOptions) (model.Recalculation, error) {
+	rec := model.Recalculation{
+		ID:        utils.GenDBID(),
+		TaskID:    task.ID,
+		Reason:    opt.Reason,
+		CreatedAt: time.Now(),
+	}
+
+	settleOpt := option.SettleTaskOptions{Force: true, Epochs: opt.Epochs, Recalculation: &rec}
+	if opt.RuleVersion != 0 {
+		versions, err := m.taskMgr.GetRuleVersions(ctx, task.ID)
+		if err != nil {
+			return rec, err
+		}
+		v, ok := taskrule.Find(versions, opt.RuleVersion)
+		if !ok {
+			return rec, fmt.Errorf("%w: version %d of task %s not found", taskrule.ErrInvalid, opt.RuleVersion, task.ID)
+		}
+		rec.RuleVersion = &v.Version
+		settleOpt.Config = &v.Config
+	}
+
+	if err := m.SettleTask(ctx, task, settleOpt); err != nil {
+		return rec, err
+	}
+	if len(rec.Epochs) == 0 {
+		return rec, fmt.Errorf("task %s has no settled epoch to recalculate, finalized epochs are kept", task.ID)
+	}
+
+	return rec, nil
+}
+
+// audit calls write and records the points of every user over the epochs of the runs before and after it.
+func (m *Manager) audit(
+	ctx context.Context, tx *sql.Tx, rec *model.Recalculation, runs []model.SettlementRun, write func() error,
+) error {
+
+	rec.Epochs = make([]int, 0, len(runs))
+	for _, run := range runs {
+		rec.Epochs = append(rec.Epochs, run.Epoch)
+	}
+	sort.Ints(rec.Epochs)
+
+	before, err := getEpochsPoints(ctx, tx, rec.TaskID, rec.Epochs)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := getEpochsPoints(ctx, tx, rec.TaskID, rec.Epochs)
+	if err != nil {
+		return err
+	}
+
+	rec.Entries = make([]model.RecalculationEntry, 0, len(after))
+	for address, point := range after {
+		rec.Entries = append(rec.Entries, model.RecalculationEntry{UserAddress: address, Before: before[address], After: point})
+	}
+	for address, point := range before {
+		if _, ok := after[address]; !ok {
+			rec.Entries = append(rec.Entries, model.RecalculationEntry{UserAddress: address, Before: point, After: decimal.Zero})
+		}
+	}
+	sort.Slice(rec.Entries, func(i, j int) bool {
+		return rec.Entries[i].UserAddress < rec.Entries[j].UserAddress
+	})
+
+	return m.recalculationMgr.RecordTx(ctx, tx, *rec)
+}
+
+// getEpochsPoints returns the ledger total of every user of the task over the epochs.
+func getEpochsPoints(ctx context.Context, tx *sql.Tx, taskID string, epochs []int) (map[string]decimal.Decimal, error) {
+	indexes := make(pq.Int64Array, 0, len(epochs))
+	for _, e := range epochs {
+		indexes = append(indexes, int64(e))
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT "userAddress", SUM("point")
+		FROM "pointLedger"
+		WHERE "taskId" = $1 AND "epoch" = ANY($2)
+		GROUP BY "userAddress"
+	`, taskID, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("getEpochsPoints query fail: %v", err)
+	}
+	defer rows.Close()
+
+	points := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var address string
+		var point decimal.Decimal
+		if err := rows.Scan(&address, &point); err != nil {
+			return nil, fmt.Errorf("getEpochsPoints scan fail: %v", err)
+		}
+		points[address] = point
+	}
+
+	return points, rows.Err()
+}
@@ -0,0 +1,209 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/core/settlement"
+	"tradingAce/pkg/core/taskrule"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// execer runs the writes of a user task on the database or in the transaction of a settlement run
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// settleFunc writes the points of the epochs settled by runIDs, keyed by epoch index, in the transaction of the runs
+type settleFunc func(tx *sql.Tx, runIDs map[int]string) error
+
+// settle runs compute for the settled epochs of the task selected by opt and commits what it returns in one transaction.
+// Nothing is computed when every selected epoch is committed, unless forced, so settling again is a no-op.
+func (m *Manager) settle(
+	ctx context.Context, task model.Task, settled []model.Epoch, opt option.SettleTaskOptions, compute func() (settleFunc, error),
+) error {
+
+	indexes := make([]int, 0, len(settled))
+	for _, e := range settled {
+		if selected(opt.Epochs, e.Index) {
+			indexes = append(indexes, e.Index)
+		}
+	}
+
+	runs, err := m.settlementMgr.StartRuns(ctx, task.ID, indexes, opt.Force)
+	if err != nil || len(runs) == 0 {
+		return err
+	}
+
+	if err := m.settlementMgr.SetState(ctx, runs, settlement.StateComputing); err != nil {
+		return err
+	}
+	write, err := compute()
+	if err != nil {
+		if failErr := m.settlementMgr.Fail(ctx, runs, err); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+	if err := m.settlementMgr.SetState(ctx, runs, settlement.StateComputed); err != nil {
+		return err
+	}
+
+	runIDs := make(map[int]string, len(runs))
+	for _, run := range runs {
+		runIDs[run.Epoch] = run.ID
+	}
+
+	return m.settlementMgr.Commit(ctx, runs, func(tx *sql.Tx) error {
+		if opt.Recalculation == nil {
+			return write(tx, runIDs)
+		}
+		return m.audit(ctx, tx, opt.Recalculation, runs, func() error {
+			return write(tx, runIDs)
+		})
+	})
+}
+
+// clearDropped undoes the settlement of the epochs of runIDs for users it no longer has a result for,
+// e.g. once their swaps are excluded by wash-trading, MEV or eligibility rules. Their points of those epochs are zeroed
+// and their volumes deleted, the user task of users left without any result or points is deleted.
+// points are keyed by user and epoch index, volumes by epoch index and user, nil when the task records none.
+func (m *Manager) clearDropped(
+	ctx context.Context, tx *sql.Tx, taskID string, reason string, runIDs map[int]string,
+	points map[string]map[int]decimal.Decimal, volumes map[int]map[string]decimal.Decimal, users []string,
+) error {
+
+	for epochIndex, runID := range runIDs {
+		settled, err := getEpochUsers(ctx, tx, taskID, epochIndex)
+		if err != nil {
+			return err
+		}
+		for _, address := range settled {
+			if _, ok := points[address][epochIndex]; ok {
+				continue
+			}
+			if err := m.userPointMgr.SetEpochPointsTx(ctx, tx, option.SetEpochPointsOptions{
+				Address:         address,
+				TaskID:          taskID,
+				Epoch:           epochIndex,
+				Point:           decimal.Zero,
+				Reason:          reason,
+				SettlementRunID: runID,
+			}); err != nil {
+				return fmt.Errorf("clearDropped zero epoch point: %v", err)
+			}
+		}
+
+		if volumes == nil {
+			continue
+		}
+		kept := make(pq.StringArray, 0, len(volumes[epochIndex]))
+		for address := range volumes[epochIndex] {
+			kept = append(kept, address)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM "epochVolume"
+			WHERE "taskId" = $1 AND "epoch" = $2 AND NOT ("userAddress" = ANY($3))
+		`, taskID, epochIndex, kept); err != nil {
+			return fmt.Errorf("clearDropped delete epoch volume: %v", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM "userTask"
+		WHERE "taskId" = $1 AND NOT ("userAddress" = ANY($2))
+			AND "userAddress" NOT IN (SELECT "userAddress" FROM "userPoint" WHERE "taskId" = $1 AND "point" <> 0)
+	`, taskID, pq.StringArray(users)); err != nil {
+		return fmt.Errorf("clearDropped delete user task: %v", err)
+	}
+
+	return nil
+}
+
+// getEpochUsers returns the users with points in the epoch of the task.
+func getEpochUsers(ctx context.Context, tx *sql.Tx, taskID string, epochIndex int) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT "userAddress"
+		FROM "pointLedger"
+		WHERE "taskId" = $1 AND "epoch" = $2
+		GROUP BY "userAddress"
+		HAVING SUM("point") <> 0
+	`, taskID, epochIndex)
+	if err != nil {
+		return nil, fmt.Errorf("getEpochUsers query fail: %v", err)
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("getEpochUsers scan fail: %v", err)
+		}
+		users = append(users, address)
+	}
+
+	return users, rows.Err()
+}
+
+// epochTasks returns the task of every settled epoch with the rules of the version in effect when the epoch starts,
+// or with opt.Config for the epochs selected by opt. The epoch schedule is the one of the task.
+func (m *Manager) epochTasks(
+	ctx context.Context, task model.Task, settled []model.Epoch, opt option.SettleTaskOptions,
+) ([]model.Task, error) {
+
+	versions, err := m.taskMgr.GetRuleVersions(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]model.Task, 0, len(settled))
+	for _, e := range settled {
+		t := task
+		t.Config = taskrule.Resolve(versions, e.StartAt, task.Config)
+		if opt.Config != nil && selected(opt.Epochs, e.Index) {
+			t.Config = *opt.Config
+		}
+		t.Config.Epoch = task.Config.Epoch
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
+// selected reports whether the epoch is one of epochs, every epoch is when it is empty.
+func selected(epochs []int, index int) bool {
+	if len(epochs) == 0 {
+		return true
+	}
+
+	for _, e := range epochs {
+		if e == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// settledEpochs returns the epochs that ended by until, by now when it is zero, in order.
+func settledEpochs(epochs []model.Epoch, until time.Time) []model.Epoch {
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	settled := make([]model.Epoch, 0, len(epochs))
+	for _, e := range epochs {
+		if until.Before(e.EndAt) {
+			break
+		}
+		settled = append(settled, e)
+	}
+
+	return settled
+}
@@ -0,0 +1,157 @@
+package usertask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/streak"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/model/option"
+
+	"github.com/shopspring/decimal"
+)
+
+func (m *Manager) CheckStreakTasks(ctx context.Context) error {
+	tasks, err := m.taskMgr.GetStreakTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	if onboardingTask == nil {
+		if err := m.setOnboardingTask(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, task := range tasks {
+		if err := m.checkStreakTask(ctx, task, option.SettleTaskOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkStreakTask rebuilds the streak of every user over the settled epochs of the task.
+// A user is active in an epoch with the minimum activity of the task, milestone points are set on the epoch they are reached.
+func (m *Manager) checkStreakTask(ctx context.Context, task model.Task, opt option.SettleTaskOptions) error {
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		return fmt.Errorf("checkStreakTask schedule epochs: %v", err)
+	}
+
+	settled := settledEpochs(epochs, opt.Until)
+	state := "pending"
+	var completedAt *time.Time
+	if len(epochs) != 0 && len(settled) == len(epochs) {
+		// the task is completed when its last epoch ends
+		state, completedAt = "completed", &epochs[len(epochs)-1].EndAt
+	}
+
+	return m.settle(ctx, task, settled, opt, func() (settleFunc, error) {
+		tasks, err := m.epochTasks(ctx, task, settled, opt)
+		if err != nil {
+			return nil, fmt.Errorf("checkStreakTask rule versions: %v", err)
+		}
+		// milestones of the rules of the latest settled epoch
+		milestones := tasks[len(tasks)-1].Config.Streak.Milestones
+
+		epochVolumes := make(map[int]map[string]decimal.Decimal)
+		senderActive := make(map[string][]bool)
+		senderAmounts := make(map[string]decimal.Decimal)
+
+		for i, e := range settled {
+			swaps, err := m.getTaskSwaps(ctx, tasks[i], e, true)
+			if err != nil {
+				return nil, err
+			}
+
+			senderVolumes := volume.Volumes(tasks[i].Config.VolumeMode, swaps)
+			epochVolumes[e.Index] = senderVolumes
+			qualified, _ := volume.Qualify(tasks[i].Config.Activity, senderVolumes, volume.TradeCounts(swaps))
+			for sender, v := range senderVolumes {
+				if _, ok := senderActive[sender]; !ok {
+					senderActive[sender] = make([]bool, len(settled))
+				}
+				_, senderActive[sender][i] = qualified[sender]
+				senderAmounts[sender] = senderAmounts[sender].Add(v)
+			}
+		}
+
+		senderProgress := make(map[string]streak.Progress)
+		// milestone points by the position of the epoch, in the order the budget pays them
+		senderPoints := make(map[string]map[int]decimal.Decimal)
+		for sender, active := range senderActive {
+			progress := streak.Compute(active, milestones)
+			senderProgress[sender] = progress
+			senderPoints[sender] = progress.Points
+		}
+		if err := m.applyBudget(ctx, task, senderPoints); err != nil {
+			return nil, err
+		}
+
+		return func(tx *sql.Tx, runIDs map[int]string) error {
+			if err := m.applyBudgetTx(ctx, tx, task, senderPoints); err != nil {
+				return err
+			}
+			for epochIndex := range runIDs {
+				if err := m.setEpochVolumes(ctx, tx, task.ID, epochIndex, epochVolumes[epochIndex]); err != nil {
+					return err
+				}
+			}
+
+			for sender, progress := range senderProgress {
+				userTask := model.UserTask{
+					UserAddress:   sender,
+					TaskID:        task.ID,
+					State:         state,
+					Amount:        senderAmounts[sender],
+					CurrentStreak: progress.Current,
+					LongestStreak: progress.Longest,
+					CompletedAt:   completedAt,
+				}
+				if progress.Completed >= 0 {
+					// completed by the epoch the last milestone is reached in
+					userTask.State = "completed"
+					userTask.CompletedAt = &settled[progress.Completed].EndAt
+				}
+				if err := m.upsert(ctx, tx, userTask); err != nil {
+					return fmt.Errorf("checkStreakTask upsert user task: %v", err)
+				}
+
+				for i, points := range senderPoints[sender] {
+					runID, ok := runIDs[settled[i].Index]
+					if !ok {
+						continue
+					}
+					if err := m.userPointMgr.SetEpochPointsTx(ctx, tx, option.SetEpochPointsOptions{
+						Address:         sender,
+						TaskID:          task.ID,
+						Epoch:           settled[i].Index,
+						Point:           points,
+						Reason:          constants.PointReasonStreak,
+						SettlementRunID: runID,
+					}); err != nil {
+						return fmt.Errorf("checkStreakTask set epoch point: %v", err)
+					}
+				}
+			}
+
+			// milestone points are keyed by the position of the epoch, the ledger by its index
+			epochPoints := make(map[string]map[int]decimal.Decimal, len(senderPoints))
+			users := make([]string, 0, len(senderProgress))
+			for sender := range senderProgress {
+				epochPoints[sender] = make(map[int]decimal.Decimal, len(senderPoints[sender]))
+				for i, points := range senderPoints[sender] {
+					epochPoints[sender][settled[i].Index] = points
+				}
+				users = append(users, sender)
+			}
+			return m.clearDropped(ctx, tx, task.ID, constants.PointReasonStreak, runIDs, epochPoints, epochVolumes, users)
+		}, nil
+	})
+}
@@ -10,6 +10,13 @@ func NewManager(
 	taskMgr iface.TaskManager,
 	transactionMgr iface.TransactionManager,
 	userPointMgr iface.UserPointManager,
+	tradeFlagMgr iface.TradeFlagManager,
+	addressInfoMgr iface.AddressInfoManager,
+	liquidityMgr iface.LiquidityManager,
+	multiplierMgr iface.MultiplierManager,
+	campaignMgr iface.CampaignManager,
+	settlementMgr iface.SettlementManager,
+	recalculationMgr iface.RecalculationManager,
 ) iface.UserTaskManager {
 
 	return &Manager{
@@ -17,5 +24,12 @@ func NewManager(
 		taskMgr,
 		transactionMgr,
 		userPointMgr,
+		tradeFlagMgr,
+		addressInfoMgr,
+		liquidityMgr,
+		multiplierMgr,
+		campaignMgr,
+		settlementMgr,
+		recalculationMgr,
 	}
 }
@@ -3,7 +3,14 @@ package usertask
 import (
 	"testing"
 	"tradingAce/internal/testutils"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/settlement"
 	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
 	"tradingAce/pkg/service/transaction"
 	"tradingAce/pkg/service/userpoint"
 
@@ -24,11 +31,28 @@ func Test_NewManager(t *testing.T) {
 	taskMgr := task.NewManager(d)
 	transactionMgr := transaction.NewManager(d)
 	userPointMgr := userpoint.NewManager(d)
-	manager := NewManager(d, taskMgr, transactionMgr, userPointMgr)
+	tradeFlagMgr := tradeflag.NewManager(d)
+	addressInfoMgr := addressinfo.NewManager(d)
+	liquidityMgr := liquidity.NewManager(d)
+	multiplierMgr := multiplier.NewManager(d)
+	campaignMgr := campaign.NewManager(d, taskMgr)
+	settlementMgr := settlement.NewManager(d)
+	recalculationMgr := recalculation.NewManager(d)
+	manager := NewManager(
+		d, taskMgr, transactionMgr, userPointMgr, tradeFlagMgr, addressInfoMgr, liquidityMgr, multiplierMgr, campaignMgr,
+		settlementMgr, recalculationMgr,
+	)
 	mgr := manager.(*Manager)
 
 	assert.Equal(t, d, mgr.db)
 	assert.Equal(t, taskMgr, mgr.taskMgr)
 	assert.Equal(t, transactionMgr, mgr.transactionMgr)
 	assert.Equal(t, userPointMgr, mgr.userPointMgr)
+	assert.Equal(t, tradeFlagMgr, mgr.tradeFlagMgr)
+	assert.Equal(t, addressInfoMgr, mgr.addressInfoMgr)
+	assert.Equal(t, liquidityMgr, mgr.liquidityMgr)
+	assert.Equal(t, multiplierMgr, mgr.multiplierMgr)
+	assert.Equal(t, campaignMgr, mgr.campaignMgr)
+	assert.Equal(t, settlementMgr, mgr.settlementMgr)
+	assert.Equal(t, recalculationMgr, mgr.recalculationMgr)
 }
@@ -7,6 +7,12 @@ import (
 	"log"
 	"time"
 	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/distribution"
+	"tradingAce/pkg/core/epoch"
+	"tradingAce/pkg/core/multiplier"
+	"tradingAce/pkg/core/streak"
+	"tradingAce/pkg/core/volume"
+	"tradingAce/pkg/core/washtrade"
 	iface "tradingAce/pkg/interface"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
@@ -16,10 +22,17 @@ import (
 )
 
 type Manager struct {
-	db             *sql.DB
-	taskMgr        iface.TaskManager
-	transactionMgr iface.TransactionManager
-	userPointMgr   iface.UserPointManager
+	db               *sql.DB
+	taskMgr          iface.TaskManager
+	transactionMgr   iface.TransactionManager
+	userPointMgr     iface.UserPointManager
+	tradeFlagMgr     iface.TradeFlagManager
+	addressInfoMgr   iface.AddressInfoManager
+	liquidityMgr     iface.LiquidityManager
+	multiplierMgr    iface.MultiplierManager
+	campaignMgr      iface.CampaignManager
+	settlementMgr    iface.SettlementManager
+	recalculationMgr iface.RecalculationManager
 }
 
 // cache onboarding task
@@ -57,25 +70,54 @@ func (m *Manager) CheckOnboardingTask(ctx context.Context, address string) error
 	}
 
 	if userTask.State == "completed" {
-		return nil
+		if userTask.CompletedAt != nil {
+			return nil
+		}
+		// the completion time of rows completed before it was recorded is unknown, it is taken from the swaps again
+		_, completedAt, err := m.getOnboardingAmount(ctx, address)
+		if err != nil || completedAt == nil {
+			return err
+		}
+		return m.setCompletedAt(ctx, address, onboardingTask.ID, *completedAt)
 	}
 
-	amount, err := m.transactionMgr.GetUserUSDC(ctx, address)
+	ineligible, err := m.addressInfoMgr.GetIneligible(ctx, []string{address}, onboardingTask.Config.AllowContracts)
 	if err != nil {
-		return fmt.Errorf("failed to GetUserUSDC: %v", err)
+		return fmt.Errorf("failed to check eligibility: %v", err)
+	}
+	if reason, ok := ineligible[address]; ok {
+		log.Printf("CheckOnboardingTask skip %s: %s", address, reason)
+		return nil
 	}
-	userTask.Amount = amount.Div(constants.UsdcPrecision)
 
-	threshold := decimal.NewFromFloat(1000.00).Mul(constants.UsdcPrecision) // 1000 USDC
-	if amount.GreaterThanOrEqual(threshold) {
+	amount, completedAt, err := m.getOnboardingAmount(ctx, address)
+	if err != nil {
+		return err
+	}
+	userTask.Amount = amount
+	if completedAt != nil {
 		userTask.State = "completed"
+		userTask.CompletedAt = completedAt
 	}
 
-	if err := m.Upsert(ctx, userTask.UserAddress, userTask.TaskID, userTask.State, userTask.Amount); err != nil {
+	if err := m.upsert(ctx, m.db, userTask); err != nil {
 		return fmt.Errorf("failed to create user task: %v", err)
 	}
 	if userTask.State == "completed" {
-		if err := m.userPointMgr.UpsertForUserTask(ctx, userTask.UserAddress, onboardingTask.ID, constants.OnboardingPoint); err != nil {
+		rules, err := m.multiplierMgr.GetRules(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get multiplier rules: %v", err)
+		}
+		// the award is boosted by the rules in effect when the completing swap is made
+		boost := multiplier.Resolve(rules, userTask.UserAddress, *onboardingTask, *userTask.CompletedAt)
+
+		if err := m.userPointMgr.SetEpochPoints(ctx, option.SetEpochPointsOptions{
+			Address:    userTask.UserAddress,
+			TaskID:     onboardingTask.ID,
+			Point:      decimal.NewFromInt(constants.OnboardingPoint).Mul(boost),
+			Reason:     constants.PointReasonOnboarding,
+			Multiplier: boost,
+		}); err != nil {
 			log.Printf("checkSharePoolTask upsert point fail: %v", err)
 			return err
 		}
@@ -84,6 +126,50 @@ func (m *Manager) CheckOnboardingTask(ctx context.Context, address string) error
 	return nil
 }
 
+// getOnboardingAmount returns the USD value the user paid in on the onboarding pairs since the onboarding task started,
+// and the time of the swap that reached the threshold, nil until it is reached.
+func (m *Manager) getOnboardingAmount(ctx context.Context, address string) (decimal.Decimal, *time.Time, error) {
+	cfg := onboardingTask.Config.Onboarding
+
+	// the config is edited in the database, its pairs are checked here as well
+	if err := volume.ValidatePairs(cfg.Pairs); err != nil {
+		return decimal.Decimal{}, nil, fmt.Errorf("invalid onboarding config: %v", err)
+	}
+	threshold := constants.OnboardingThresholdUSD
+	if cfg.ThresholdUSD != nil {
+		threshold = *cfg.ThresholdUSD
+	}
+
+	pairs := cfg.Pairs
+	if len(pairs) == 0 {
+		for pair := range constants.SupportedPairs {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	swaps, err := m.transactionMgr.GetUserSwaps(ctx, option.GetUserSwapsOptions{
+		Address: address,
+		Pairs:   pairs,
+		StartAt: onboardingTask.StartAt,
+		EndAt:   cfg.EndAt,
+	})
+	if err != nil {
+		return decimal.Decimal{}, nil, fmt.Errorf("failed to GetUserSwaps: %v", err)
+	}
+
+	// swaps are in chain order, the completing swap is the one the running total reaches the threshold with
+	amount := decimal.Zero
+	var completedAt *time.Time
+	for i := range swaps {
+		amount = amount.Add(volume.InputUSD(swaps[i:i+1], cfg.Tokens))
+		if completedAt == nil && amount.GreaterThanOrEqual(threshold) {
+			completedAt = &swaps[i].TransactionAt
+		}
+	}
+
+	return amount, completedAt, nil
+}
+
 func (m *Manager) CheckSharePoolTasks(ctx context.Context) error {
 	tasks, getSharePoolErr := m.taskMgr.GetSharePoolTask(ctx)
 	if getSharePoolErr != nil {
@@ -97,7 +183,7 @@ func (m *Manager) CheckSharePoolTasks(ctx context.Context) error {
 	}
 
 	for _, task := range tasks {
-		err := m.checkSharePoolTask(ctx, task)
+		err := m.checkSharePoolTask(ctx, task, option.SettleTaskOptions{})
 		if err != nil {
 			return err
 		}
@@ -106,6 +192,26 @@ func (m *Manager) CheckSharePoolTasks(ctx context.Context) error {
 	return nil
 }
 
+// SettleTask settles the closed epochs of a share pool, streak or LP provider task.
+func (m *Manager) SettleTask(ctx context.Context, task model.Task, opt option.SettleTaskOptions) error {
+	if onboardingTask == nil {
+		if err := m.setOnboardingTask(ctx); err != nil {
+			return err
+		}
+	}
+
+	switch task.Name.String {
+	case "share_pool":
+		return m.checkSharePoolTask(ctx, task, opt)
+	case "streak":
+		return m.checkStreakTask(ctx, task, opt)
+	case "lp_provider":
+		return m.checkLPTask(ctx, task, opt)
+	default:
+		return fmt.Errorf("task %s of type %s has no epochs to settle", task.ID, task.Name.String)
+	}
+}
+
 func (m *Manager) GetUserTasks(ctx context.Context, address string) ([]option.GetUserTaskPoint, error) {
 	query := `
 SELECT 
@@ -114,10 +220,14 @@ SELECT
     ut."userAddress" AS "userAddress",
     ut."taskId" AS "taskID",
     ut."state" AS "state",
+    ut."reason" AS "reason",
     ut."amount" AS "amount",
-    up."point" AS "point",
+    COALESCE(up."point", 0) AS "point",
     t."name" AS "taskName",
-    t."pairAddress" AS "pairAddress"
+    COALESCE(t."pairAddress", '') AS "pairAddress",
+    ut."currentStreak" AS "currentStreak",
+    ut."longestStreak" AS "longestStreak",
+    t."config" AS "config"
 FROM 
     "userTask" ut
 LEFT JOIN 
@@ -147,122 +257,307 @@ WHERE
 
 	for rows.Next() {
 		var data option.GetUserTaskPoint
+		var current, longest int
+		var config model.TaskConfig
 		rows.Scan(
 			&data.ID,
 			&data.CreatedAt,
 			&data.UserAddress,
 			&data.TaskID,
 			&data.State,
+			&data.Reason,
 			&data.Amount,
 			&data.Point,
 			&data.TaskName,
 			&data.PairAddress,
+			&current,
+			&longest,
+			&config,
 		)
+		if data.TaskName == "streak" {
+			data.Streak = &model.StreakProgress{
+				Current:       current,
+				Longest:       longest,
+				NextMilestone: streak.NextMilestone(longest, config.Streak.Milestones),
+			}
+		}
 
 		result = append(result, data)
 	}
 
-	return result, nil
+	return m.setLocks(ctx, address, result)
 }
 
-func (m *Manager) checkSharePoolTask(ctx context.Context, task model.Task) error {
-	startTime := task.StartAt
-	senderPoints := make(map[string]decimal.Decimal)
-	senderAmounts := make(map[string]decimal.Decimal)
-	state := "pending"
-
-	for week := 1; week <= 4; week++ {
-		endTime := utils.GetLastTimeOfWeek(startTime)
-		if time.Now().Before(endTime) {
-			continue
-		}
-		if week == 4 {
-			state = "completed"
-		}
+// checkSharePoolTask distributes the points of every settled epoch by the volume of the users.
+// The epochs are committed in one settlement transaction, committed epochs are only settled again when forced.
+func (m *Manager) checkSharePoolTask(ctx context.Context, task model.Task, opt option.SettleTaskOptions) error {
+	epochs, err := epoch.Schedule(task)
+	if err != nil {
+		return fmt.Errorf("checkSharePoolTask schedule epochs: %v", err)
+	}
 
-		rows, err := m.db.Query(`
-			SELECT t."senderAddress" AS "senderAddress", 
-				SUM(t."amount0In") AS "totalAmount0In", 
-				SUM(t."amount1In") AS "totalAmount1In"
-			FROM transaction t
-			JOIN "userTask" ut 
-				ON t."senderAddress" = ut."userAddress"
-			WHERE t."transactionAt" >= $1 
-				AND t."transactionAt" < $2
-				AND t."pairAddress" = $3
-				AND ut."taskId" = $4
-				AND ut.state = 'completed'
-			GROUP BY t."senderAddress";
-		`, startTime, endTime, task.PairAddress, onboardingTask.ID)
+	settled := settledEpochs(epochs, opt.Until)
+	return m.settle(ctx, task, settled, opt, func() (settleFunc, error) {
+		result, err := m.computeSharePool(ctx, task, epochs, settled, opt, true)
 		if err != nil {
-			return fmt.Errorf("checkSharePoolTask query sum transaction: %v", err)
+			return nil, err
 		}
-		defer rows.Close()
 
-		totalVolumeUSD := decimal.NewFromFloat(0)
-		senderVolumes := make(map[string]decimal.Decimal)
+		return func(tx *sql.Tx, runIDs map[int]string) error {
+			if err := m.applyBudgetTx(ctx, tx, task, result.points); err != nil {
+				return err
+			}
+			for epochIndex := range runIDs {
+				if err := m.setEpochVolumes(ctx, tx, task.ID, epochIndex, result.volumes[epochIndex]); err != nil {
+					return err
+				}
+			}
 
-		for rows.Next() {
-			var sender string
-			var totalAmount0In, totalAmount1In decimal.Decimal
+			for sender, epochPoints := range result.points {
+				if err := m.upsert(ctx, tx, result.userTasks[sender]); err != nil {
+					return fmt.Errorf("checkSharePoolTask upsert user task: %v", err)
+				}
+
+				for epochIndex, points := range epochPoints {
+					runID, ok := runIDs[epochIndex]
+					if !ok {
+						continue
+					}
+					if err := m.userPointMgr.SetEpochPointsTx(ctx, tx, option.SetEpochPointsOptions{
+						Address:         sender,
+						TaskID:          task.ID,
+						Epoch:           epochIndex,
+						Point:           points,
+						Reason:          constants.PointReasonSharePool,
+						SettlementRunID: runID,
+						Multiplier:      result.boosts[sender][epochIndex],
+					}); err != nil {
+						return fmt.Errorf("checkSharePoolTask set epoch point: %v", err)
+					}
+				}
+			}
 
-			err := rows.Scan(&sender, &totalAmount0In, &totalAmount1In)
-			if err != nil {
-				return fmt.Errorf("CheckSharePoolTask scan error: %v", err)
+			users := make([]string, 0, len(result.userTasks))
+			for sender := range result.userTasks {
+				users = append(users, sender)
 			}
+			return m.clearDropped(ctx, tx, task.ID, constants.PointReasonSharePool, runIDs, result.points, result.volumes, users)
+		}, nil
+	})
+}
+
+// sharePoolResult is the settlement of a share pool task computed in memory, keyed by user and epoch index
+type sharePoolResult struct {
+	volumes   map[int]map[string]decimal.Decimal
+	userTasks map[string]model.UserTask
+	points    map[string]map[int]decimal.Decimal
+	boosts    map[string]map[int]decimal.Decimal
+}
+
+// computeSharePool computes the settlement of the settled epochs of the share pool task without writing it,
+// every epoch with the rules selected by opt. Wash trades detected on the way are only recorded with recordFlags.
+func (m *Manager) computeSharePool(
+	ctx context.Context, task model.Task, epochs []model.Epoch, settled []model.Epoch, opt option.SettleTaskOptions, recordFlags bool,
+) (sharePoolResult, error) {
+
+	result := sharePoolResult{
+		volumes:   make(map[int]map[string]decimal.Decimal),
+		userTasks: make(map[string]model.UserTask),
+		points:    make(map[string]map[int]decimal.Decimal),
+		boosts:    make(map[string]map[int]decimal.Decimal),
+	}
+
+	tasks, err := m.epochTasks(ctx, task, settled, opt)
+	if err != nil {
+		return result, fmt.Errorf("checkSharePoolTask rule versions: %v", err)
+	}
+	rules, err := m.multiplierMgr.GetRules(ctx)
+	if err != nil {
+		return result, fmt.Errorf("checkSharePoolTask multiplier rules: %v", err)
+	}
+
+	state := "pending"
+	var completedAt *time.Time
+	if len(epochs) != 0 && len(settled) == len(epochs) {
+		// the task is completed when its last epoch ends
+		state, completedAt = "completed", &epochs[len(epochs)-1].EndAt
+	}
 
-			// To USD
-			totalAmount0InUSD := totalAmount0In.Div(constants.UsdcPrecision).Mul(constants.UsdcPrice)
-			totalAmount1InUSD := totalAmount1In.Div(constants.EthPrecision).Mul(constants.EthPrice)
+	senderAmounts := make(map[string]decimal.Decimal)
+	// users that qualified in any epoch, otherwise the reason of the latest epoch
+	senderQualified := make(map[string]bool)
+	senderReasons := make(map[string]string)
 
-			totalAmountUSD := totalAmount0InUSD.Add(totalAmount1InUSD)
-			senderVolumes[sender] = totalAmountUSD
+	for i, e := range settled {
+		cfg := tasks[i].Config
+		strategy, err := distribution.New(cfg.Distribution)
+		if err != nil {
+			return result, fmt.Errorf("checkSharePoolTask distribution strategy: %v", err)
+		}
 
-			// sum all amount
-			totalVolumeUSD = totalVolumeUSD.Add(totalAmountUSD)
+		swaps, err := m.getTaskSwaps(ctx, tasks[i], e, recordFlags)
+		if err != nil {
+			return result, err
 		}
 
-		for sender, volume := range senderVolumes {
-			if !totalVolumeUSD.IsZero() {
-				proportion := volume.Div(totalVolumeUSD)
-				points := proportion.Mul(constants.PointsPerWeek)
-				senderPoints[sender] = senderPoints[sender].Add(points)
-				senderAmounts[sender] = senderAmounts[sender].Add(volume)
+		senderVolumes := volume.Volumes(cfg.VolumeMode, swaps)
+		result.volumes[e.Index] = senderVolumes
+		qualified, reasons := volume.Qualify(cfg.Activity, senderVolumes, volume.TradeCounts(swaps))
+		// every swap is boosted by the rules in effect when it is made, weighted by its volume
+		boosts := multiplier.Weighted(rules, task, swaps, func(tx model.Transaction) decimal.Decimal {
+			return volume.Volumes(cfg.VolumeMode, []model.Transaction{tx})[tx.SenderAddress]
+		})
+
+		epochPoints := strategy.Distribute(epoch.Points(cfg, e.Index), qualified, cfg.PointPrecision)
+		for sender, v := range senderVolumes {
+			if _, ok := result.points[sender]; !ok {
+				result.points[sender] = make(map[int]decimal.Decimal)
+				result.boosts[sender] = make(map[int]decimal.Decimal)
+			}
+
+			boost := boosts[sender]
+			result.points[sender][e.Index] = epochPoints[sender].Mul(boost).Truncate(cfg.PointPrecision)
+			result.boosts[sender][e.Index] = boost
+			senderAmounts[sender] = senderAmounts[sender].Add(v)
+			if reason, ok := reasons[sender]; ok {
+				senderReasons[sender] = reason
 			} else {
-				senderPoints[sender] = decimal.NewFromInt(0)
-				senderAmounts[sender] = decimal.NewFromInt(0)
+				senderQualified[sender] = true
 			}
 		}
+	}
+	if err := m.applyBudget(ctx, task, result.points); err != nil {
+		return result, err
+	}
 
-		// to next week
-		startTime = endTime.AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	for sender := range result.points {
+		userTask := model.UserTask{
+			UserAddress: sender,
+			TaskID:      task.ID,
+			State:       state,
+			Amount:      senderAmounts[sender],
+			CompletedAt: completedAt,
+		}
+		if !senderQualified[sender] {
+			userTask.State = "ineligible"
+			userTask.Reason = senderReasons[sender]
+			userTask.CompletedAt = nil
+		}
+		result.userTasks[sender] = userTask
 	}
 
-	// save point to
-	for sender, points := range senderPoints {
-		if err := m.Upsert(ctx, sender, task.ID, state, senderAmounts[sender]); err != nil {
-			log.Printf("checkSharePoolTask upsert user task fail: %v", err)
-			continue
+	return result, nil
+}
+
+// getTaskSwaps returns the swaps of eligible users who unlocked the task on the pair of the task in the epoch.
+// With wash-trading detection enabled the swaps of the pair are checked first and flagged swaps are left out,
+// the flags are recorded for review with recordFlags.
+func (m *Manager) getTaskSwaps(
+	ctx context.Context, task model.Task, e model.Epoch, recordFlags bool,
+) ([]model.Transaction, error) {
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT t."id", t."blockNum", t."logIndex", t."senderAddress", t."receiverAddress",
+			t."amount0In", t."amount1In", t."amount0Out", t."amount1Out", t."mevRole", t."transactionAt"
+		FROM transaction t
+		WHERE t."transactionAt" >= $1 
+			AND t."transactionAt" < $2
+			AND t."pairAddress" = $3
+		ORDER BY t."blockNum", t."logIndex";
+	`, e.StartAt, e.EndAt, task.PairAddress)
+	if err != nil {
+		return nil, fmt.Errorf("getTaskSwaps query transaction: %v", err)
+	}
+	defer rows.Close()
+
+	var txs []model.Transaction
+	var senders []string
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var tx model.Transaction
+		err := rows.Scan(
+			&tx.ID,
+			&tx.BlockNum,
+			&tx.LogIndex,
+			&tx.SenderAddress,
+			&tx.ReceiverAddress,
+			&tx.Amount0In,
+			&tx.Amount1In,
+			&tx.Amount0Out,
+			&tx.Amount1Out,
+			&tx.MevRole,
+			&tx.TransactionAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("getTaskSwaps scan error: %v", err)
+		}
+		tx.PairAddress = task.PairAddress.String
+		txs = append(txs, tx)
+		if !seen[tx.SenderAddress] {
+			seen[tx.SenderAddress] = true
+			senders = append(senders, tx.SenderAddress)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if task.Config.WashTrading.Enabled {
+		flags := washtrade.Detect(txs, task.Config.WashTrading)
+		if recordFlags {
+			if err := m.tradeFlagMgr.Record(ctx, flags); err != nil {
+				return nil, fmt.Errorf("getTaskSwaps record trade flags: %v", err)
+			}
 		}
+		txs = washtrade.Exclude(txs, flags)
+	}
 
-		if err := m.userPointMgr.UpsertForUserTask(ctx, sender, task.ID, int(points.IntPart())); err != nil {
-			log.Printf("checkSharePoolTask upsert point fail: %v", err)
+	unlocked, err := m.getUnlocked(ctx, task, senders, e.StartAt)
+	if err != nil {
+		return nil, err
+	}
+	ineligible, err := m.addressInfoMgr.GetIneligible(ctx, senders, task.Config.AllowContracts)
+	if err != nil {
+		return nil, fmt.Errorf("getTaskSwaps check eligibility: %v", err)
+	}
+
+	eligible := make([]model.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if task.Config.ExcludeMev && (tx.MevRole == constants.MevRoleFrontRun || tx.MevRole == constants.MevRoleBackRun) {
 			continue
 		}
+		if _, excluded := ineligible[tx.SenderAddress]; unlocked[tx.SenderAddress] && !excluded {
+			eligible = append(eligible, tx)
+		}
 	}
 
-	return nil
+	return eligible, nil
 }
 
 func (m *Manager) Upsert(ctx context.Context, address string, taskId string, state string, amount decimal.Decimal) error {
+	return m.upsert(ctx, m.db, model.UserTask{UserAddress: address, TaskID: taskId, State: state, Amount: amount})
+}
+
+// upsert saves the state, amount, reason and streak of the user task, the reason is cleared when empty.
+// completedAt is cleared when the task is no longer completed. The CompletedAt of the user task is computed from the chain
+// and replaces the stored time, otherwise the time the task was first completed is kept, the current time for a new completion.
+func (m *Manager) upsert(ctx context.Context, q execer, userTask model.UserTask) error {
 	query := `
-		INSERT INTO "userTask" ("id", "userAddress", "taskId", "state", "createdAt", "amount")
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO "userTask" ("id", "userAddress", "taskId", "state", "createdAt", "amount", "reason",
+			"currentStreak", "longestStreak", "completedAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			CASE WHEN $4 = 'completed' THEN COALESCE($10::TIMESTAMP WITH TIME ZONE, $5::TIMESTAMP WITH TIME ZONE) END)
 		ON CONFLICT ("userAddress", "taskId")
-		DO UPDATE SET "state" = EXCLUDED."state", "amount" = EXCLUDED."amount"
+		DO UPDATE SET "state" = EXCLUDED."state", "amount" = EXCLUDED."amount", "reason" = EXCLUDED."reason",
+			"currentStreak" = EXCLUDED."currentStreak", "longestStreak" = EXCLUDED."longestStreak",
+			"completedAt" = CASE WHEN EXCLUDED."state" = 'completed'
+				THEN COALESCE($10::TIMESTAMP WITH TIME ZONE, "userTask"."completedAt", EXCLUDED."completedAt") END
 	`
 
-	_, err := m.db.ExecContext(ctx, query, utils.GenDBID(), address, taskId, state, time.Now(), amount)
+	_, err := q.ExecContext(
+		ctx, query, utils.GenDBID(), userTask.UserAddress, userTask.TaskID, userTask.State, time.Now(), userTask.Amount, userTask.Reason,
+		userTask.CurrentStreak, userTask.LongestStreak, userTask.CompletedAt,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to upsert user task: %w", err)
 	}
@@ -270,9 +565,41 @@ func (m *Manager) Upsert(ctx context.Context, address string, taskId string, sta
 	return nil
 }
 
+// setEpochVolumes records the volume of every user in a settled epoch of the task for the leaderboards.
+func (m *Manager) setEpochVolumes(ctx context.Context, q execer, taskID string, epochIndex int, volumes map[string]decimal.Decimal) error {
+	query := `
+		INSERT INTO "epochVolume" ("userAddress", "taskId", "epoch", "volume", "updatedAt")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("taskId", "epoch", "userAddress")
+		DO UPDATE SET "volume" = EXCLUDED."volume", "updatedAt" = EXCLUDED."updatedAt"
+	`
+
+	now := time.Now()
+	for address, v := range volumes {
+		if _, err := q.ExecContext(ctx, query, address, taskID, epochIndex, v, now); err != nil {
+			return fmt.Errorf("failed to set epoch volume: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// setCompletedAt replaces the completion time of a completed user task.
+func (m *Manager) setCompletedAt(ctx context.Context, address string, taskID string, completedAt time.Time) error {
+	if _, err := m.db.ExecContext(ctx, `
+		UPDATE "userTask" SET "completedAt" = $3
+		WHERE "userAddress" = $1 AND "taskId" = $2 AND "state" = 'completed'
+	`, address, taskID, completedAt); err != nil {
+		return fmt.Errorf("failed to set completedAt: %v", err)
+	}
+
+	return nil
+}
+
 func (m *Manager) getUserTask(ctx context.Context, address string, taskId string) (model.UserTask, error) {
 	query := `
-		SELECT "id", "createdAt", "userAddress", "taskId", "state", "amount"
+		SELECT "id", "createdAt", "userAddress", "taskId", "state", "amount", "reason",
+			"currentStreak", "longestStreak", "completedAt"
 		FROM "userTask"
 		WHERE "userAddress" = $1 AND "taskId" = $2;
 	`
@@ -285,6 +612,10 @@ func (m *Manager) getUserTask(ctx context.Context, address string, taskId string
 		&userTask.TaskID,
 		&userTask.State,
 		&userTask.Amount,
+		&userTask.Reason,
+		&userTask.CurrentStreak,
+		&userTask.LongestStreak,
+		&userTask.CompletedAt,
 	)
 
 	return userTask, err
@@ -3,16 +3,27 @@ package usertask
 import (
 	"context"
 	"database/sql"
+	"math/big"
 	"testing"
 	"time"
 	"tradingAce/internal/testutils"
 	"tradingAce/pkg/constants"
+	"tradingAce/pkg/core/taskrule"
+	"tradingAce/pkg/core/volume"
 	"tradingAce/pkg/model"
 	"tradingAce/pkg/model/option"
+	"tradingAce/pkg/service/addressinfo"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/liquidity"
+	"tradingAce/pkg/service/multiplier"
+	"tradingAce/pkg/service/recalculation"
+	"tradingAce/pkg/service/settlement"
 	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/tradeflag"
 	"tradingAce/pkg/service/transaction"
 	"tradingAce/pkg/service/userpoint"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/joho/godotenv"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +35,8 @@ func setOnbardingTask() *model.Task {
 		CreatedAt:   time.Now(),
 		Name:        sql.NullString{String: "onboarding", Valid: true},
 		PairAddress: sql.NullString{},
-		StartAt:     time.Now(),
+		// swaps of the tests start on 2024-07-02
+		StartAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	return onboardingTask
@@ -162,6 +174,9 @@ func TestManager_checkSharePoolTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -313,7 +328,7 @@ func TestManager_checkSharePoolTask(t *testing.T) {
 		},
 		StartAt: startAt,
 	}
-	if err := mgr.checkSharePoolTask(ctx, sharePoolTask); err != nil {
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
 		t.Errorf("checkSharePoolTask err: %v", err)
 	}
 
@@ -339,7 +354,7 @@ func TestManager_checkSharePoolTask(t *testing.T) {
 	}
 	assert.Equal(t, sender1, result1.UserAddress)
 	assert.Equal(t, sharePoolTask.ID, result1.TaskID)
-	assert.Equal(t, 18080, result1.Point)
+	assert.True(t, decimal.NewFromInt(18080).Equal(result1.Point))
 
 	ut2, ut2Err := mgr.getUserTask(ctx, sender2, sharePoolTask.ID)
 	if ut2Err != nil {
@@ -363,12 +378,212 @@ func TestManager_checkSharePoolTask(t *testing.T) {
 	}
 	assert.Equal(t, sender2, result2.UserAddress)
 	assert.Equal(t, sharePoolTask.ID, result2.TaskID)
-	assert.Equal(t, 1920, result2.Point)
+	assert.True(t, decimal.NewFromInt(1920).Equal(result2.Point))
+
+	userPoints, err := mgr.userPointMgr.GetUserPointsForTask(ctx, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("GetUserPointsForTask err: %v", err)
+		return
+	}
+	for _, up := range userPoints {
+		if up.UserAddress != sender1 {
+			continue
+		}
+		assert.Equal(t, 2, len(up.Epochs))
+		assert.True(t, decimal.NewFromInt(8080).Equal(up.Epochs[0].Point))
+		assert.True(t, decimal.NewFromInt(10000).Equal(up.Epochs[1].Point))
+	}
 
 	_, ut3Err := mgr.getUserTask(ctx, senderNoOnboarding, sharePoolTask.ID)
 	assert.EqualError(t, ut3Err, sql.ErrNoRows.Error())
 }
 
+func TestManager_checkSharePoolTaskSettled(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	settlementMgr := settlement.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlementMgr,
+	}
+
+	sender1 := "0x0000000000000000000000000000000000000001"
+	sender2 := "0x0000000000000000000000000000000000000002"
+	twoWeeksAgo := time.Now().AddDate(0, 0, -14)
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{sender1, sender2} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+	if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+		BlockNum:        1,
+		PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+		SenderAddress:   sender1,
+		Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(1000)),
+		ReceiverAddress: sender1,
+		TransactionAt:   twoWeeksAgo.AddDate(0, 0, 1),
+	}); err != nil {
+		t.Errorf("Upsert err: %v", err)
+		return
+	}
+
+	sharePoolTask := model.Task{
+		ID:        "checkSharePoolTaskSettled",
+		CreatedAt: time.Now(),
+		Name:      sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{
+			String: "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			Valid:  true,
+		},
+		StartAt: twoWeeksAgo,
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	// a swap ingested late is not settled into the committed epoch
+	if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+		BlockNum:        2,
+		PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+		SenderAddress:   sender2,
+		Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(1000)),
+		ReceiverAddress: sender2,
+		TransactionAt:   twoWeeksAgo.AddDate(0, 0, 2),
+	}); err != nil {
+		t.Errorf("Upsert err: %v", err)
+		return
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	ledger, err := mgr.userPointMgr.GetLedger(ctx, sender1, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("GetLedger err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 1, len(ledger)) {
+		return
+	}
+	assert.True(t, decimal.NewFromInt(10000).Equal(ledger[0].Point), "point: %v", ledger[0].Point)
+	_, err = mgr.getUserTask(ctx, sender2, sharePoolTask.ID)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	runs, err := settlementMgr.GetRuns(ctx, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	if !assert.Equal(t, 1, len(runs)) {
+		return
+	}
+	assert.Equal(t, 0, runs[0].Epoch)
+	assert.Equal(t, "committed", runs[0].State)
+	assert.Equal(t, runs[0].ID, ledger[0].SettlementRunID.String)
+
+	// the dry run shows what forcing would change without writing it
+	diff, err := mgr.DiffSharePoolTask(ctx, sharePoolTask)
+	if err != nil {
+		t.Errorf("DiffSharePoolTask err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, diff.Added)
+	assert.Equal(t, 1, diff.Changed)
+	assert.True(t, decimal.NewFromInt(10000).Equal(diff.CurrentTotal), "current total: %v", diff.CurrentTotal)
+	assert.True(t, decimal.NewFromInt(10000).Equal(diff.NewTotal), "new total: %v", diff.NewTotal)
+	if assert.Equal(t, 2, len(diff.Users)) {
+		assert.Equal(t, sender1, diff.Users[0].UserAddress)
+		assert.True(t, decimal.NewFromInt(5000).Equal(diff.Users[0].NewPoint), "point: %v", diff.Users[0].NewPoint)
+		assert.Equal(t, sender2, diff.Users[1].UserAddress)
+		assert.Equal(t, "added", diff.Users[1].Change)
+	}
+	runs, err = settlementMgr.GetRuns(ctx, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("GetRuns err: %v", err)
+		return
+	}
+	assert.Equal(t, 1, len(runs))
+
+	// forcing settles the epoch again with the late swap
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{Force: true}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+	for _, sender := range []string{sender1, sender2} {
+		ledger, err := mgr.userPointMgr.GetLedger(ctx, sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("GetLedger err: %v", err)
+			return
+		}
+		total := decimal.Zero
+		for _, entry := range ledger {
+			total = total.Add(entry.Point)
+		}
+		assert.True(t, decimal.NewFromInt(5000).Equal(total), "%s point: %v", sender, total)
+	}
+
+	// a user whose swaps are excluded by the new rules drops out of the settled epoch
+	if _, err := d.Exec(`UPDATE "transaction" SET "mevRole" = $1 WHERE "senderAddress" = $2`, constants.MevRoleFrontRun, sender2); err != nil {
+		t.Errorf("update transaction err: %v", err)
+		return
+	}
+	sharePoolTask.Config.ExcludeMev = true
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{Force: true}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+	for sender, want := range map[string]int64{sender1: 10000, sender2: 0} {
+		ledger, err := mgr.userPointMgr.GetLedger(ctx, sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("GetLedger err: %v", err)
+			return
+		}
+		total := decimal.Zero
+		for _, entry := range ledger {
+			total = total.Add(entry.Point)
+		}
+		assert.True(t, decimal.NewFromInt(want).Equal(total), "%s point: %v", sender, total)
+	}
+	_, err = mgr.getUserTask(ctx, sender2, sharePoolTask.ID)
+	assert.Equal(t, sql.ErrNoRows, err)
+	var volumes int
+	if err := d.QueryRow(`
+		SELECT COUNT(*) FROM "epochVolume" WHERE "taskId" = $1 AND "userAddress" = $2
+	`, sharePoolTask.ID, sender2).Scan(&volumes); err != nil {
+		t.Errorf("count epoch volume err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, volumes)
+
+	diff, err = mgr.DiffSharePoolTask(ctx, sharePoolTask)
+	if err != nil {
+		t.Errorf("DiffSharePoolTask err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, diff.Removed)
+	assert.Equal(t, 1, diff.Unchanged)
+}
+
 func TestManager_checkSharePoolTaskNotFinished(t *testing.T) {
 	godotenv.Load("../../../.env/.env")
 
@@ -387,6 +602,9 @@ func TestManager_checkSharePoolTaskNotFinished(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -428,7 +646,7 @@ func TestManager_checkSharePoolTaskNotFinished(t *testing.T) {
 		StartAt: twoWeeksAgo,
 	}
 
-	if err := mgr.checkSharePoolTask(ctx, sharePoolTask); err != nil {
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
 		t.Errorf("checkSharePoolTask err: %v", err)
 		return
 	}
@@ -455,7 +673,7 @@ func TestManager_checkSharePoolTaskNotFinished(t *testing.T) {
 	}
 	assert.Equal(t, sender1, result1.UserAddress)
 	assert.Equal(t, sharePoolTask.ID, result1.TaskID)
-	assert.Equal(t, 10000, result1.Point)
+	assert.True(t, decimal.NewFromInt(10000).Equal(result1.Point))
 }
 
 func TestManager_CheckOnboardingTask(t *testing.T) {
@@ -476,6 +694,9 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -569,7 +790,8 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 	}
 	assert.Equal(t, onboardingTask.ID, ut1.TaskID)
 	assert.Equal(t, "completed", ut1.State)
-	assert.True(t, decimal.NewFromInt(1100).Equal(ut1.Amount), "amount should be 1100")
+	// 700 USDC + 50 ETH + 400 USDC
+	assert.True(t, decimal.NewFromInt(101100).Equal(ut1.Amount), "amount should be 101100")
 	var result1 model.UserPoint
 	if err := d.QueryRow(
 		`SELECT "userAddress", "taskId", "point" FROM "userPoint" 
@@ -585,7 +807,7 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 	}
 	assert.Equal(t, sender1, result1.UserAddress)
 	assert.Equal(t, onboardingTask.ID, result1.TaskID)
-	assert.Equal(t, constants.OnboardingPoint, result1.Point)
+	assert.True(t, decimal.NewFromInt(constants.OnboardingPoint).Equal(result1.Point))
 
 	if err := mgr.CheckOnboardingTask(ctx, sender2); err != nil {
 		t.Errorf("CheckOnboardingTask err: %v", err)
@@ -598,7 +820,8 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 	}
 	assert.Equal(t, onboardingTask.ID, ut2.TaskID)
 	assert.Equal(t, "completed", ut2.State)
-	assert.True(t, decimal.NewFromInt(1000).Equal(ut2.Amount), "amount should be 1000")
+	// 1000 USDC + 10 ETH
+	assert.True(t, decimal.NewFromInt(21000).Equal(ut2.Amount), "amount should be 21000")
 	var result2 model.UserPoint
 	if err := d.QueryRow(
 		`SELECT "userAddress", "taskId", "point" FROM "userPoint" 
@@ -614,7 +837,7 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 	}
 	assert.Equal(t, sender2, result2.UserAddress)
 	assert.Equal(t, onboardingTask.ID, result2.TaskID)
-	assert.Equal(t, constants.OnboardingPoint, result2.Point)
+	assert.True(t, decimal.NewFromInt(constants.OnboardingPoint).Equal(result2.Point))
 
 	if err := mgr.CheckOnboardingTask(ctx, senderNoOnboarding); err != nil {
 		t.Errorf("CheckOnboardingTask err: %v", err)
@@ -627,7 +850,8 @@ func TestManager_CheckOnboardingTask(t *testing.T) {
 	}
 	assert.Equal(t, onboardingTask.ID, ut3.TaskID)
 	assert.Equal(t, "pending", ut3.State)
-	assert.True(t, decimal.NewFromInt(500).Equal(ut3.Amount), "amount should be 5000")
+	// the swap was before the onboarding task started
+	assert.True(t, decimal.Zero.Equal(ut3.Amount), "amount should be 0")
 	var result3 model.UserPoint
 	result3Err := d.QueryRow(
 		`SELECT "userAddress", "taskId", "point" FROM "userPoint" 
@@ -660,7 +884,7 @@ func TestManager_GetUserTasks(t *testing.T) {
 		Amount:      decimal.NewFromInt(1200),
 		CreatedAt:   now,
 		UserAddress: "0x123",
-		Point:       constants.OnboardingPoint,
+		Point:       decimal.NewFromInt(constants.OnboardingPoint),
 		TaskName:    "onboarding",
 	}
 
@@ -668,7 +892,7 @@ func TestManager_GetUserTasks(t *testing.T) {
 	userPointMgr := userpoint.NewManager(d)
 
 	if _, err := d.Exec(
-		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("pairAddress") DO NOTHING;`,
+		`INSERT INTO task("id", "createdAt", "name", "pairAddress", "startAt") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("name", "pairAddress") DO NOTHING;`,
 		data1.TaskID, time.Now(), data1.TaskName, nil, now,
 	); err != nil {
 		t.Errorf("insert task err: %v", err)
@@ -714,7 +938,7 @@ func TestManager_GetUserTasks(t *testing.T) {
 			assert.Equal(t, tt.want.State, result[0].State)
 			assert.True(t, tt.want.Amount.Equal(result[0].Amount))
 			assert.Equal(t, tt.want.UserAddress, result[0].UserAddress)
-			assert.Equal(t, tt.want.Point, result[0].Point)
+			assert.True(t, tt.want.Point.Equal(result[0].Point))
 			assert.Equal(t, tt.want.TaskName, result[0].TaskName)
 			assert.Equal(t, tt.want.PairAddress, result[0].PairAddress)
 		})
@@ -739,6 +963,9 @@ func TestManager_CheckOnboardingTaskNonExistTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 	onboardingTask = nil
 	err = mgr.CheckOnboardingTask(ctx, "0x123")
@@ -763,6 +990,9 @@ func TestManager_CheckOnboardingTaskUpdateExistUserTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -821,7 +1051,8 @@ func TestManager_CheckOnboardingTaskUpdateExistUserTask(t *testing.T) {
 	}
 	assert.Equal(t, onboardingTask.ID, ut1.TaskID)
 	assert.Equal(t, "completed", ut1.State)
-	assert.True(t, decimal.NewFromInt(1100).Equal(ut1.Amount), "amount should be 1100")
+	// 700 USDC + 50 ETH + 400 USDC
+	assert.True(t, decimal.NewFromInt(101100).Equal(ut1.Amount), "amount should be 101100")
 	var result1 model.UserPoint
 	if err := d.QueryRow(
 		`SELECT "userAddress", "taskId", "point" FROM "userPoint" 
@@ -837,7 +1068,7 @@ func TestManager_CheckOnboardingTaskUpdateExistUserTask(t *testing.T) {
 	}
 	assert.Equal(t, sender1, result1.UserAddress)
 	assert.Equal(t, onboardingTask.ID, result1.TaskID)
-	assert.Equal(t, constants.OnboardingPoint, result1.Point)
+	assert.True(t, decimal.NewFromInt(constants.OnboardingPoint).Equal(result1.Point))
 }
 
 func TestManager_CheckFinishedOnboardingTask(t *testing.T) {
@@ -858,6 +1089,9 @@ func TestManager_CheckFinishedOnboardingTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -901,6 +1135,9 @@ func TestManager_CheckSharePoolTask(t *testing.T) {
 		taskMgr:        task.NewManager(d),
 		transactionMgr: trMgr,
 		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
 	}
 
 	sender1 := "0x0000000000000000000000000000000000000000"
@@ -948,7 +1185,7 @@ func TestManager_CheckSharePoolTask(t *testing.T) {
 		},
 		StartAt: startAt,
 	}
-	if err := mgr.taskMgr.CreateSharePoolTask(ctx, sharePoolTask.PairAddress.String, sharePoolTask.StartAt); err != nil {
+	if err := mgr.taskMgr.CreateSharePoolTask(ctx, sharePoolTask.PairAddress.String, sharePoolTask.StartAt, sharePoolTask.Config); err != nil {
 		t.Errorf("CreateSharePoolTask err: %v", err)
 		return
 	}
@@ -983,3 +1220,1449 @@ func TestManager_CheckSharePoolTask(t *testing.T) {
 	}
 	assert.Equal(t, "completed", ut1.State)
 }
+
+func TestManager_checkSharePoolTaskVolumeMode(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	buyer := "0x0000000000000000000000000000000000000000"
+	seller := "0x0000000000000000000000000000000000000001"
+
+	transactionAt, parseErr := time.Parse("2006-01-02", "2024-07-02")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-01")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	swaps := []option.TransactionUpsertOptions{
+		// buy 0.49 ETH with 1000 USDC
+		{
+			BlockNum:        1,
+			PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			SenderAddress:   buyer,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(1000)),
+			Amount1Out:      constants.EthPrecision.Mul(decimal.RequireFromString("0.49")),
+			ReceiverAddress: buyer,
+			TransactionAt:   transactionAt,
+		},
+		// sell 0.5 ETH for 990 USDC
+		{
+			BlockNum:        2,
+			PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			SenderAddress:   seller,
+			Amount1In:       constants.EthPrecision.Mul(decimal.RequireFromString("0.5")),
+			Amount0Out:      constants.UsdcPrecision.Mul(decimal.NewFromInt(990)),
+			ReceiverAddress: seller,
+			TransactionAt:   transactionAt,
+		},
+	}
+	for _, swap := range swaps {
+		if err := trMgr.Upsert(ctx, swap); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{buyer, seller} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	tests := []struct {
+		mode   string
+		buyer  decimal.Decimal
+		seller decimal.Decimal
+	}{
+		{mode: volume.ModeInput, buyer: decimal.NewFromInt(1000), seller: decimal.NewFromInt(1000)},
+		{mode: volume.ModeOutput, buyer: decimal.NewFromInt(980), seller: decimal.NewFromInt(990)},
+		{mode: volume.ModeMax, buyer: decimal.NewFromInt(1000), seller: decimal.NewFromInt(1000)},
+		{mode: volume.ModeUSDC, buyer: decimal.NewFromInt(1000), seller: decimal.NewFromInt(990)},
+		{mode: volume.ModeNet, buyer: decimal.NewFromInt(1000), seller: decimal.NewFromInt(990)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			sharePoolTask := model.Task{
+				ID:        "checkSharePoolTaskVolumeMode" + tt.mode,
+				CreatedAt: time.Now(),
+				Name:      sql.NullString{String: "share_pool", Valid: true},
+				PairAddress: sql.NullString{
+					String: "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+					Valid:  true,
+				},
+				StartAt: startAt,
+				Config:  model.TaskConfig{VolumeMode: tt.mode},
+			}
+
+			if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+				t.Errorf("checkSharePoolTask err: %v", err)
+				return
+			}
+
+			buyerTask, err := mgr.getUserTask(ctx, buyer, sharePoolTask.ID)
+			if err != nil {
+				t.Errorf("getUserTask buyer err: %v", err)
+				return
+			}
+			sellerTask, err := mgr.getUserTask(ctx, seller, sharePoolTask.ID)
+			if err != nil {
+				t.Errorf("getUserTask seller err: %v", err)
+				return
+			}
+
+			assert.True(t, tt.buyer.Equal(buyerTask.Amount), "buyer want: %v, got: %v", tt.buyer, buyerTask.Amount)
+			assert.True(t, tt.seller.Equal(sellerTask.Amount), "seller want: %v, got: %v", tt.seller, sellerTask.Amount)
+		})
+	}
+}
+
+func TestManager_checkSharePoolTaskWashTrading(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	tradeFlagMgr := tradeflag.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+		tradeFlagMgr:   tradeFlagMgr,
+	}
+
+	washer := "0x0000000000000000000000000000000000000000"
+	trader := "0x0000000000000000000000000000000000000001"
+	// the outputs go to a wallet that does not trade
+	wallet := "0x0000000000000000000000000000000000000002"
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+	transactionAt, parseErr := time.Parse("2006-01-02", "2024-07-02")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-01")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	usdc := constants.UsdcPrecision.Mul(decimal.NewFromInt(1000))
+	eth := constants.EthPrecision.Mul(decimal.RequireFromString("0.5"))
+	swaps := []option.TransactionUpsertOptions{
+		// buy and sell back in block 1
+		{BlockNum: 1, LogIndex: 0, PairAddress: pair, SenderAddress: washer, Amount0In: usdc, Amount1Out: eth},
+		{BlockNum: 1, LogIndex: 1, PairAddress: pair, SenderAddress: washer, Amount1In: eth, Amount0Out: usdc},
+		{BlockNum: 2, LogIndex: 0, PairAddress: pair, SenderAddress: washer, Amount0In: usdc, Amount1Out: eth},
+		{BlockNum: 3, LogIndex: 0, PairAddress: pair, SenderAddress: trader, Amount0In: usdc, Amount1Out: eth},
+	}
+	onboardingTask := setOnbardingTask()
+	for _, swap := range swaps {
+		swap.ReceiverAddress = wallet
+		swap.TransactionAt = transactionAt
+		if err := trMgr.Upsert(ctx, swap); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+		if err := mgr.Upsert(ctx, swap.SenderAddress, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	sharePoolTask := model.Task{
+		ID:        "checkSharePoolTaskWashTrading",
+		CreatedAt: time.Now(),
+		Name:      sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{
+			String: pair,
+			Valid:  true,
+		},
+		StartAt: startAt,
+		Config:  model.TaskConfig{WashTrading: model.WashTradingConfig{Enabled: true}},
+	}
+
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	// only the swap of block 2 counts for the washer
+	washerTask, err := mgr.getUserTask(ctx, washer, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask washer err: %v", err)
+		return
+	}
+	assert.True(t, decimal.NewFromInt(1000).Equal(washerTask.Amount), "washer amount: %v", washerTask.Amount)
+
+	flags, err := tradeFlagMgr.GetFlags(ctx, washer)
+	if err != nil {
+		t.Errorf("GetFlags err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, len(flags))
+	for _, flag := range flags {
+		assert.Equal(t, constants.FlagReasonRoundTrip, flag.Reason)
+	}
+
+	points, err := mgr.userPointMgr.GetUserPointsForTask(ctx, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("GetUserPointsForTask err: %v", err)
+		return
+	}
+	for _, p := range points {
+		assert.True(t, decimal.NewFromInt(5000).Equal(p.Point), "%s point: %v", p.UserAddress, p.Point)
+	}
+}
+
+type codeReader struct {
+	contracts map[common.Address]bool
+}
+
+func (r codeReader) CodeAt(_ context.Context, account common.Address, _ *big.Int) ([]byte, error) {
+	if r.contracts[account] {
+		return []byte{0x60, 0x80}, nil
+	}
+	return nil, nil
+}
+
+func TestManager_CheckOnboardingTaskIneligible(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	addressInfoMgr := addressinfo.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressInfoMgr,
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	contract := "0x0000000000000000000000000000000000000002"
+	bot := "0x0000000000000000000000000000000000000003"
+	reader := codeReader{contracts: map[common.Address]bool{common.HexToAddress(contract): true}}
+
+	transactionAt, parseErr := time.Parse("2006-01-02", "2024-07-02")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	for i, sender := range []string{contract, bot} {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			SenderAddress:   sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(2000)),
+			ReceiverAddress: sender,
+			TransactionAt:   transactionAt,
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+		if _, err := addressInfoMgr.EnsureAddressInfo(ctx, sender, reader); err != nil {
+			t.Errorf("EnsureAddressInfo err: %v", err)
+			return
+		}
+	}
+	if err := addressInfoMgr.SetListEntry(ctx, bot, constants.AddressListDeny, "mev bot"); err != nil {
+		t.Errorf("SetListEntry err: %v", err)
+		return
+	}
+
+	onboardingTask := setOnbardingTask()
+
+	for _, sender := range []string{contract, bot} {
+		if err := mgr.CheckOnboardingTask(ctx, sender); err != nil {
+			t.Errorf("CheckOnboardingTask err: %v", err)
+			return
+		}
+
+		_, err := mgr.getUserTask(ctx, sender, onboardingTask.ID)
+		assert.Equal(t, sql.ErrNoRows, err, "%s should not take part in onboarding", sender)
+	}
+}
+
+func TestManager_checkSharePoolTaskExcludeMev(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	bot := "0x0000000000000000000000000000000000000001"
+	victim := "0x0000000000000000000000000000000000000002"
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+
+	transactionAt, parseErr := time.Parse("2006-01-02", "2024-07-02")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+	startAt, parseErr := time.Parse("2006-01-02", "2024-07-01")
+	if parseErr != nil {
+		t.Errorf("parse time err: %v", parseErr)
+		return
+	}
+
+	usdc := constants.UsdcPrecision.Mul(decimal.NewFromInt(10000))
+	eth := constants.EthPrecision.Mul(decimal.NewFromInt(5))
+	swaps := []option.TransactionUpsertOptions{
+		{BlockNum: 1, LogIndex: 1, TxHash: "0x01", SenderAddress: bot, Amount0In: usdc, Amount1Out: eth},
+		{
+			BlockNum: 1, LogIndex: 2, TxHash: "0x02", SenderAddress: victim,
+			Amount0In:  constants.UsdcPrecision.Mul(decimal.NewFromInt(2000)),
+			Amount1Out: constants.EthPrecision.Mul(decimal.RequireFromString("0.98")),
+		},
+		{BlockNum: 1, LogIndex: 3, TxHash: "0x03", SenderAddress: bot, Amount1In: eth, Amount0Out: usdc},
+	}
+	onboardingTask := setOnbardingTask()
+	for _, swap := range swaps {
+		swap.PairAddress = pair
+		swap.ReceiverAddress = swap.SenderAddress
+		swap.TransactionAt = transactionAt
+		if err := trMgr.Upsert(ctx, swap); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+		if err := mgr.Upsert(ctx, swap.SenderAddress, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+	if _, err := trMgr.MarkSandwiches(ctx, pair, 1, 1); err != nil {
+		t.Errorf("MarkSandwiches err: %v", err)
+		return
+	}
+
+	sharePoolTask := model.Task{
+		ID:        "checkSharePoolTaskExcludeMev",
+		CreatedAt: time.Now(),
+		Name:      sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{
+			String: pair,
+			Valid:  true,
+		},
+		StartAt: startAt,
+		Config:  model.TaskConfig{ExcludeMev: true},
+	}
+
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	victimTask, err := mgr.getUserTask(ctx, victim, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask victim err: %v", err)
+		return
+	}
+	assert.True(t, decimal.NewFromInt(2000).Equal(victimTask.Amount), "victim amount: %v", victimTask.Amount)
+
+	_, err = mgr.getUserTask(ctx, bot, sharePoolTask.ID)
+	assert.Equal(t, sql.ErrNoRows, err, "the attacker should have no volume")
+}
+
+func TestManager_CheckOnboardingTaskScope(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	sender := "0x0000000000000000000000000000000000000000"
+	transactionAt := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, opt := range []option.TransactionUpsertOptions{
+		// 600 USDC + 1 ETH on the tracked pair, the address is stored in lower case
+		{
+			BlockNum:      1,
+			PairAddress:   "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc",
+			SenderAddress: sender,
+			Amount0In:     constants.UsdcPrecision.Mul(decimal.NewFromInt(600)),
+			Amount1In:     constants.EthPrecision.Mul(decimal.NewFromInt(1)),
+			TransactionAt: transactionAt,
+		},
+		// a large token0 trade on a pair that is not part of onboarding
+		{
+			BlockNum:      2,
+			PairAddress:   "0x000000000000000000000000000000000000dEaD",
+			SenderAddress: sender,
+			Amount0In:     constants.UsdcPrecision.Mul(decimal.NewFromInt(99999)),
+			TransactionAt: transactionAt,
+		},
+	} {
+		if err := trMgr.Upsert(ctx, opt); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	onboardingTask.Config.Onboarding = model.OnboardingConfig{
+		Pairs:  []string{"0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"},
+		Tokens: []string{constants.TokenUSDC},
+	}
+
+	if err := mgr.CheckOnboardingTask(ctx, sender); err != nil {
+		t.Errorf("CheckOnboardingTask err: %v", err)
+		return
+	}
+	ut, err := mgr.getUserTask(ctx, sender, onboardingTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask err: %v", err)
+		return
+	}
+	assert.Equal(t, "pending", ut.State)
+	assert.True(t, decimal.NewFromInt(600).Equal(ut.Amount), "amount should be 600")
+
+	// ETH counts as well with every token of the pair
+	onboardingTask.Config.Onboarding.Tokens = nil
+	if err := mgr.CheckOnboardingTask(ctx, sender); err != nil {
+		t.Errorf("CheckOnboardingTask err: %v", err)
+		return
+	}
+	ut, err = mgr.getUserTask(ctx, sender, onboardingTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask err: %v", err)
+		return
+	}
+	assert.Equal(t, "completed", ut.State)
+	assert.True(t, decimal.NewFromInt(2600).Equal(ut.Amount), "amount should be 2600")
+	// completed by the swap, not by the time it was processed
+	if assert.NotNil(t, ut.CompletedAt) {
+		assert.True(t, transactionAt.Equal(*ut.CompletedAt), "completedAt: %v", ut.CompletedAt)
+	}
+
+	// a legacy row without a completion time gets the time of the completing swap
+	if _, err := d.Exec(
+		`UPDATE "userTask" SET "completedAt" = NULL WHERE "userAddress" = $1 AND "taskId" = $2`, sender, onboardingTask.ID,
+	); err != nil {
+		t.Errorf("clear completedAt err: %v", err)
+		return
+	}
+	if err := mgr.CheckOnboardingTask(ctx, sender); err != nil {
+		t.Errorf("CheckOnboardingTask err: %v", err)
+		return
+	}
+	ut, err = mgr.getUserTask(ctx, sender, onboardingTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask err: %v", err)
+		return
+	}
+	if assert.NotNil(t, ut.CompletedAt) {
+		assert.True(t, transactionAt.Equal(*ut.CompletedAt), "completedAt: %v", ut.CompletedAt)
+	}
+}
+
+func TestManager_checkSharePoolTaskActivity(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	whale := "0x0000000000000000000000000000000000000001"
+	once := "0x0000000000000000000000000000000000000002"
+	dust := "0x0000000000000000000000000000000000000003"
+	transactionAt := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	swaps := []struct {
+		sender string
+		usdc   int64
+	}{
+		{whale, 1000}, {whale, 1000}, {once, 1000}, {dust, 1}, {dust, 1},
+	}
+	for i, swap := range swaps {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			SenderAddress:   swap.sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(swap.usdc)),
+			ReceiverAddress: swap.sender,
+			TransactionAt:   transactionAt,
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{whale, once, dust} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	minVolume := decimal.NewFromInt(100)
+	sharePoolTask := model.Task{
+		ID:        "checkSharePoolTaskActivity",
+		CreatedAt: time.Now(),
+		Name:      sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{
+			String: "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+			Valid:  true,
+		},
+		StartAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Config: model.TaskConfig{
+			Activity: model.ActivityConfig{MinVolumeUSD: &minVolume, MinTradeCount: 2},
+		},
+	}
+
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	tests := []struct {
+		sender string
+		state  string
+		reason string
+		amount int64
+		point  int64
+	}{
+		{sender: whale, state: "completed", amount: 2000, point: 10000},
+		{sender: once, state: "ineligible", reason: constants.IneligibleMinTradeCount, amount: 1000},
+		{sender: dust, state: "ineligible", reason: constants.IneligibleMinVolume, amount: 2},
+	}
+	for _, tt := range tests {
+		ut, err := mgr.getUserTask(ctx, tt.sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("getUserTask %s err: %v", tt.sender, err)
+			return
+		}
+		assert.Equal(t, tt.state, ut.State, tt.sender)
+		assert.Equal(t, tt.reason, ut.Reason, tt.sender)
+		assert.True(t, decimal.NewFromInt(tt.amount).Equal(ut.Amount), "%s amount: %v", tt.sender, ut.Amount)
+
+		var point decimal.Decimal
+		if err := d.QueryRow(
+			`SELECT "point" FROM "userPoint" WHERE "userAddress"=$1 AND "taskId"=$2`,
+			tt.sender, sharePoolTask.ID,
+		).Scan(&point); err != nil {
+			t.Errorf("get user point query error = %v", err)
+			return
+		}
+		assert.True(t, decimal.NewFromInt(tt.point).Equal(point), "%s point: %v", tt.sender, point)
+	}
+}
+
+func TestManager_checkStreakTask(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        taskMgr,
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	steady := "0x0000000000000000000000000000000000000001"
+	once := "0x0000000000000000000000000000000000000002"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// steady trades on day 0, 1, 2 and 4, once trades on day 0
+	swaps := []struct {
+		sender string
+		day    int
+	}{
+		{steady, 0}, {steady, 1}, {steady, 2}, {steady, 4}, {once, 0},
+	}
+	for i, swap := range swaps {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   swap.sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(100)),
+			ReceiverAddress: swap.sender,
+			TransactionAt:   startAt.AddDate(0, 0, swap.day).Add(time.Hour),
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{steady, once} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	if err := taskMgr.CreateStreakTask(ctx, pair, startAt, model.TaskConfig{
+		Epoch: model.EpochConfig{Unit: "day", Length: 1, Count: 5},
+		Streak: model.StreakConfig{Milestones: []model.StreakMilestone{
+			{Length: 2, Points: decimal.NewFromInt(100)},
+			{Length: 3, Points: decimal.NewFromInt(200)},
+			{Length: 5, Points: decimal.NewFromInt(1000)},
+		}},
+	}); err != nil {
+		t.Errorf("CreateStreakTask err: %v", err)
+		return
+	}
+	if err := mgr.CheckStreakTasks(ctx); err != nil {
+		t.Errorf("CheckStreakTasks err: %v", err)
+		return
+	}
+	// settling again does not grant milestones twice
+	if err := mgr.CheckStreakTasks(ctx); err != nil {
+		t.Errorf("CheckStreakTasks err: %v", err)
+		return
+	}
+
+	result, err := mgr.GetUserTasks(ctx, steady)
+	if err != nil {
+		t.Errorf("GetUserTasks err: %v", err)
+		return
+	}
+	var streakTask *option.GetUserTaskPoint
+	for i := range result {
+		if result[i].TaskName == "streak" {
+			streakTask = &result[i]
+		}
+	}
+	if !assert.NotNil(t, streakTask) {
+		return
+	}
+	assert.Equal(t, "completed", streakTask.State)
+	assert.Equal(t, &model.StreakProgress{Current: 1, Longest: 3, NextMilestone: 5}, streakTask.Streak)
+	assert.True(t, decimal.NewFromInt(300).Equal(streakTask.Point), "point: %v", streakTask.Point)
+	assert.True(t, decimal.NewFromInt(400).Equal(streakTask.Amount), "amount: %v", streakTask.Amount)
+
+	ut, err := mgr.getUserTask(ctx, once, streakTask.TaskID)
+	if err != nil {
+		t.Errorf("getUserTask err: %v", err)
+		return
+	}
+	assert.Equal(t, 0, ut.CurrentStreak)
+	assert.Equal(t, 1, ut.LongestStreak)
+}
+
+func TestManager_checkLPTask(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	taskMgr := task.NewManager(d)
+	liquidityMgr := liquidity.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        taskMgr,
+		transactionMgr: transaction.NewManager(d),
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+		liquidityMgr:   liquidityMgr,
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	early := "0x0000000000000000000000000000000000000001"
+	late := "0x0000000000000000000000000000000000000002"
+	senderNoOnboarding := "0x0000000000000000000000000000000000000003"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	lpTokens := constants.LPTokenPrecision.Mul(decimal.NewFromInt(10))
+
+	// early provides before the task starts, late from the second day
+	transfers := []struct {
+		to string
+		at time.Time
+	}{
+		{early, startAt.Add(-time.Hour)},
+		{senderNoOnboarding, startAt.Add(-time.Hour)},
+		{late, startAt.AddDate(0, 0, 1)},
+	}
+	for i, transfer := range transfers {
+		if err := liquidityMgr.InsertTransfer(ctx, option.LPTransferUpsertOptions{
+			BlockNum:    uint64(i + 1),
+			PairAddress: pair,
+			FromAddress: constants.ZeroAddress,
+			ToAddress:   transfer.to,
+			Value:       lpTokens,
+			TransferAt:  transfer.at,
+		}); err != nil {
+			t.Errorf("InsertTransfer err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{early, late} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	// every daily epoch distributes a full pool
+	epochPoints := decimal.NewFromInt(10000)
+	if err := taskMgr.CreateLPTask(ctx, pair, startAt, model.TaskConfig{
+		Epoch:       model.EpochConfig{Unit: "day", Length: 1, Count: 2},
+		EpochPoints: &epochPoints,
+	}); err != nil {
+		t.Errorf("CreateLPTask err: %v", err)
+		return
+	}
+	if err := mgr.CheckLPTasks(ctx); err != nil {
+		t.Errorf("CheckLPTasks err: %v", err)
+		return
+	}
+
+	expected := map[string]struct {
+		point  int64
+		amount int64
+	}{
+		// the whole first day and half of the second
+		early: {15000, 10},
+		late:  {5000, 5},
+	}
+	for sender, e := range expected {
+		result, err := mgr.GetUserTasks(ctx, sender)
+		if err != nil {
+			t.Errorf("GetUserTasks err: %v", err)
+			return
+		}
+		var lpTask *option.GetUserTaskPoint
+		for i := range result {
+			if result[i].TaskName == "lp_provider" {
+				lpTask = &result[i]
+			}
+		}
+		if !assert.NotNil(t, lpTask, sender) {
+			continue
+		}
+		assert.Equal(t, "completed", lpTask.State)
+		assert.True(t, decimal.NewFromInt(e.point).Equal(lpTask.Point), "%s point: %v", sender, lpTask.Point)
+		assert.True(t, decimal.NewFromInt(e.amount).Equal(lpTask.Amount), "%s amount: %v", sender, lpTask.Amount)
+	}
+
+	// the provider without onboarding did not unlock the task
+	result, err := mgr.GetUserTasks(ctx, senderNoOnboarding)
+	if err != nil {
+		t.Errorf("GetUserTasks err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(result)) {
+		assert.Equal(t, "locked", result[0].State)
+		assert.Equal(t, "lp_provider", result[0].TaskName)
+	}
+}
+
+func TestManager_checkSharePoolTaskMultiplier(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	multiplierMgr := multiplier.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userPointMgr,
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplierMgr,
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	partner := "0x0000000000000000000000000000000000000001"
+	other := "0x0000000000000000000000000000000000000002"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, sender := range []string{partner, other, partner, other} {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(100)),
+			ReceiverAddress: sender,
+			TransactionAt:   startAt.AddDate(0, 0, i/2).Add(time.Hour),
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{partner, other} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	// double points on the second day, partners get 1.5x on every day
+	boostStart := startAt.AddDate(0, 0, 1)
+	boostEnd := startAt.AddDate(0, 0, 2)
+	rules := []model.MultiplierRule{
+		{Name: "double points day", Multiplier: decimal.NewFromInt(2), StartAt: &boostStart, EndAt: &boostEnd, PairAddress: pair},
+		{Name: "partner community", Multiplier: decimal.NewFromFloat(1.5), Addresses: []string{partner}},
+	}
+	for _, rule := range rules {
+		if _, err := multiplierMgr.CreateRule(ctx, rule); err != nil {
+			t.Errorf("CreateRule err: %v", err)
+			return
+		}
+	}
+
+	// every daily epoch distributes a full pool
+	epochPoints := decimal.NewFromInt(10000)
+	sharePoolTask := model.Task{
+		ID:          "checkSharePoolTaskMultiplier",
+		CreatedAt:   time.Now(),
+		Name:        sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{String: pair, Valid: true},
+		StartAt:     startAt,
+		Config: model.TaskConfig{
+			Epoch:       model.EpochConfig{Unit: "day", Length: 1, Count: 2},
+			EpochPoints: &epochPoints,
+		},
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	tests := []struct {
+		sender      string
+		multipliers []int64
+		points      []int64
+	}{
+		// 5000 points of every epoch, boosted by 1.5 and by 2 * 1.5
+		{sender: partner, multipliers: []int64{15, 30}, points: []int64{7500, 15000}},
+		{sender: other, multipliers: []int64{10, 20}, points: []int64{5000, 10000}},
+	}
+	for _, tt := range tests {
+		entries, err := userPointMgr.GetLedger(ctx, tt.sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("GetLedger err: %v", err)
+			return
+		}
+		if !assert.Equal(t, 2, len(entries), tt.sender) {
+			continue
+		}
+		for _, entry := range entries {
+			wantMultiplier := decimal.New(tt.multipliers[entry.Epoch], -1)
+			assert.True(t, wantMultiplier.Equal(entry.Multiplier), "%s epoch %d multiplier: %v", tt.sender, entry.Epoch, entry.Multiplier)
+			assert.True(t, decimal.NewFromInt(tt.points[entry.Epoch]).Equal(entry.Point), "%s epoch %d point: %v", tt.sender, entry.Epoch, entry.Point)
+		}
+	}
+
+	// the volume of every settled epoch is recorded for the leaderboards
+	var epochs int
+	var total decimal.Decimal
+	if err := d.QueryRow(`
+		SELECT COUNT(*), SUM("volume") FROM "epochVolume" WHERE "taskId" = $1 AND "userAddress" = $2
+	`, sharePoolTask.ID, partner).Scan(&epochs, &total); err != nil {
+		t.Errorf("query epoch volume err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, epochs)
+	assert.True(t, decimal.NewFromInt(200).Equal(total), "got: %v", total)
+}
+
+func TestManager_checkSharePoolTaskMultiplierWindow(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	multiplierMgr := multiplier.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        task.NewManager(d),
+		transactionMgr: trMgr,
+		userPointMgr:   userPointMgr,
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplierMgr,
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	inside := "0x0000000000000000000000000000000000000001"
+	outside := "0x0000000000000000000000000000000000000002"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// the boost window is the first six hours of the epoch
+	boostStart := startAt
+	boostEnd := startAt.Add(6 * time.Hour)
+	swaps := []struct {
+		sender string
+		at     time.Time
+	}{
+		{inside, startAt.Add(time.Hour)},
+		{outside, startAt.Add(12 * time.Hour)},
+	}
+	for i, swap := range swaps {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   swap.sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(100)),
+			ReceiverAddress: swap.sender,
+			TransactionAt:   swap.at,
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{inside, outside} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+	if _, err := multiplierMgr.CreateRule(ctx, model.MultiplierRule{
+		Name: "happy hours", Multiplier: decimal.NewFromInt(2), StartAt: &boostStart, EndAt: &boostEnd, PairAddress: pair,
+	}); err != nil {
+		t.Errorf("CreateRule err: %v", err)
+		return
+	}
+
+	epochPoints := decimal.NewFromInt(10000)
+	sharePoolTask := model.Task{
+		ID:          "checkSharePoolTaskMultiplierWindow",
+		CreatedAt:   time.Now(),
+		Name:        sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{String: pair, Valid: true},
+		StartAt:     startAt,
+		Config: model.TaskConfig{
+			Epoch:       model.EpochConfig{Unit: "day", Length: 1, Count: 1},
+			EpochPoints: &epochPoints,
+		},
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	// only the swap made in the window is boosted
+	tests := []struct {
+		sender     string
+		multiplier int64
+		point      int64
+	}{
+		{sender: inside, multiplier: 2, point: 10000},
+		{sender: outside, multiplier: 1, point: 5000},
+	}
+	for _, tt := range tests {
+		entries, err := userPointMgr.GetLedger(ctx, tt.sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("GetLedger err: %v", err)
+			return
+		}
+		if !assert.Equal(t, 1, len(entries), tt.sender) {
+			continue
+		}
+		assert.True(t, decimal.NewFromInt(tt.multiplier).Equal(entries[0].Multiplier), "%s multiplier: %v", tt.sender, entries[0].Multiplier)
+		assert.True(t, decimal.NewFromInt(tt.point).Equal(entries[0].Point), "%s point: %v", tt.sender, entries[0].Point)
+	}
+}
+
+func TestManager_checkSharePoolTaskPrerequisites(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        taskMgr,
+		transactionMgr: trMgr,
+		userPointMgr:   userpoint.NewManager(d),
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	early := "0x0000000000000000000000000000000000000001"
+	late := "0x0000000000000000000000000000000000000002"
+	onboardedOnly := "0x0000000000000000000000000000000000000003"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, sender := range []string{early, late, onboardedOnly} {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(100)),
+			ReceiverAddress: sender,
+			TransactionAt:   startAt.Add(time.Hour),
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	// the prerequisite is a share pool task of another pair
+	if err := taskMgr.CreateSharePoolTask(ctx, "0x0000000000000000000000000000000000000abc", startAt, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+	required := tasks[0]
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{early, late, onboardedOnly} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+	for _, sender := range []string{early, late} {
+		if err := mgr.Upsert(ctx, sender, required.ID, "completed", decimal.NewFromInt(100)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+	// early completed the prerequisite before the epoch started
+	if _, err := d.Exec(
+		`UPDATE "userTask" SET "completedAt" = $1 WHERE "userAddress" = $2 AND "taskId" = $3`,
+		startAt.Add(-time.Hour), early, required.ID,
+	); err != nil {
+		t.Errorf("update completedAt err: %v", err)
+		return
+	}
+
+	// every daily epoch distributes a full pool
+	epochPoints := decimal.NewFromInt(10000)
+	sharePoolTask := model.Task{
+		ID:          "checkSharePoolTaskPrerequisites",
+		CreatedAt:   time.Now(),
+		Name:        sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{String: pair, Valid: true},
+		StartAt:     startAt,
+		Config: model.TaskConfig{
+			Epoch:       model.EpochConfig{Unit: "day", Length: 1, Count: 1},
+			EpochPoints: &epochPoints,
+			Prerequisites: model.PrerequisiteConfig{
+				TaskIDs: []string{required.ID},
+				Timing:  "before_epoch",
+			},
+		},
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	ut, err := mgr.getUserTask(ctx, early, sharePoolTask.ID)
+	if err != nil {
+		t.Errorf("getUserTask err: %v", err)
+		return
+	}
+	assert.Equal(t, "completed", ut.State)
+	assert.True(t, decimal.NewFromInt(100).Equal(ut.Amount), "amount: %v", ut.Amount)
+
+	// late completed it after the epoch started, onboarding alone does not unlock explicit prerequisites
+	for _, sender := range []string{late, onboardedOnly} {
+		_, err := mgr.getUserTask(ctx, sender, sharePoolTask.ID)
+		assert.Equal(t, sql.ErrNoRows, err, sender)
+	}
+
+	// the prerequisite share pool task is locked until onboarding is completed
+	result, err := mgr.GetUserTasks(ctx, "0x0000000000000000000000000000000000000004")
+	if err != nil {
+		t.Errorf("GetUserTasks err: %v", err)
+		return
+	}
+	if assert.Equal(t, 1, len(result)) {
+		assert.Equal(t, required.ID, result[0].TaskID)
+		assert.Equal(t, "locked", result[0].State)
+		assert.Equal(t, []string{onboardingTask.ID}, result[0].Prerequisites)
+	}
+
+	result, err = mgr.GetUserTasks(ctx, early)
+	if err != nil {
+		t.Errorf("GetUserTasks err: %v", err)
+		return
+	}
+	for _, userTask := range result {
+		if userTask.TaskID == required.ID {
+			assert.False(t, *userTask.Locked)
+		}
+	}
+}
+
+func TestManager_checkSharePoolTaskBudget(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	userPointMgr := userpoint.NewManager(d)
+	campaignMgr := campaign.NewManager(d, taskMgr)
+	mgr := Manager{
+		db:             d,
+		taskMgr:        taskMgr,
+		transactionMgr: trMgr,
+		userPointMgr:   userPointMgr,
+		addressInfoMgr: addressinfo.NewManager(d),
+		multiplierMgr:  multiplier.NewManager(d),
+		settlementMgr:  settlement.NewManager(d),
+		campaignMgr:    campaignMgr,
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	sender1 := "0x0000000000000000000000000000000000000001"
+	sender2 := "0x0000000000000000000000000000000000000002"
+	startAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, sender := range []string{sender1, sender2, sender1, sender2} {
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(100)),
+			ReceiverAddress: sender,
+			TransactionAt:   startAt.AddDate(0, 0, i/2).Add(time.Hour),
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	onboardingTask := setOnbardingTask()
+	for _, sender := range []string{sender1, sender2} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	budget := decimal.NewFromInt(15000)
+	c, err := campaignMgr.CreateCampaign(ctx, model.Campaign{
+		Name:    "season 1",
+		StartAt: startAt,
+		EndAt:   startAt.AddDate(0, 1, 0),
+		Budget:  &budget,
+	})
+	if err != nil {
+		t.Errorf("CreateCampaign err: %v", err)
+		return
+	}
+
+	// another task of the campaign already spent 2000 points
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId") VALUES ('spentTask', 'streak', $1, $2)
+	`, startAt, c.ID); err != nil {
+		t.Errorf("insert task err: %v", err)
+		return
+	}
+	if err := userPointMgr.SetEpochPoints(ctx, option.SetEpochPointsOptions{
+		Address: sender1,
+		TaskID:  "spentTask",
+		Point:   decimal.NewFromInt(2000),
+		Reason:  constants.PointReasonStreak,
+	}); err != nil {
+		t.Errorf("SetEpochPoints err: %v", err)
+		return
+	}
+
+	// every daily epoch distributes a full pool
+	epochPoints := decimal.NewFromInt(10000)
+	sharePoolTask := model.Task{
+		ID:          "checkSharePoolTaskBudget",
+		CreatedAt:   time.Now(),
+		Name:        sql.NullString{String: "share_pool", Valid: true},
+		PairAddress: sql.NullString{String: pair, Valid: true},
+		StartAt:     startAt,
+		CampaignID:  sql.NullString{String: c.ID, Valid: true},
+		Config: model.TaskConfig{
+			Epoch:       model.EpochConfig{Unit: "day", Length: 1, Count: 2},
+			EpochPoints: &epochPoints,
+		},
+	}
+	if err := mgr.checkSharePoolTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("checkSharePoolTask err: %v", err)
+		return
+	}
+
+	// the first epoch is paid in full, the second one shares the 3000 points left
+	for _, sender := range []string{sender1, sender2} {
+		entries, err := userPointMgr.GetLedger(ctx, sender, sharePoolTask.ID)
+		if err != nil {
+			t.Errorf("GetLedger err: %v", err)
+			return
+		}
+		if !assert.Equal(t, 2, len(entries), sender) {
+			continue
+		}
+		points := []int64{5000, 1500}
+		for _, entry := range entries {
+			assert.True(t, decimal.NewFromInt(points[entry.Epoch]).Equal(entry.Point), "%s epoch %d point: %v", sender, entry.Epoch, entry.Point)
+		}
+	}
+}
+
+func TestManager_Recalculate(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	taskMgr := task.NewManager(d)
+	trMgr := transaction.NewManager(d)
+	recalculationMgr := recalculation.NewManager(d)
+	mgr := Manager{
+		db:               d,
+		taskMgr:          taskMgr,
+		transactionMgr:   trMgr,
+		userPointMgr:     userpoint.NewManager(d),
+		addressInfoMgr:   addressinfo.NewManager(d),
+		multiplierMgr:    multiplier.NewManager(d),
+		settlementMgr:    settlement.NewManager(d),
+		recalculationMgr: recalculationMgr,
+	}
+
+	pair := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	sender1 := "0x0000000000000000000000000000000000000001"
+	sender2 := "0x0000000000000000000000000000000000000002"
+	twoWeeksAgo := time.Now().AddDate(0, 0, -14)
+
+	onboardingTask := setOnbardingTask()
+	for i, sender := range []string{sender1, sender2} {
+		if err := mgr.Upsert(ctx, sender, onboardingTask.ID, "completed", decimal.NewFromInt(1000)); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+		if err := trMgr.Upsert(ctx, option.TransactionUpsertOptions{
+			BlockNum:        uint64(i + 1),
+			PairAddress:     pair,
+			SenderAddress:   sender,
+			Amount0In:       constants.UsdcPrecision.Mul(decimal.NewFromInt(int64(1000 + 2000*i))),
+			ReceiverAddress: sender,
+			TransactionAt:   twoWeeksAgo.AddDate(0, 0, 1),
+		}); err != nil {
+			t.Errorf("Upsert err: %v", err)
+			return
+		}
+	}
+
+	if err := taskMgr.CreateSharePoolTask(ctx, pair, twoWeeksAgo, model.TaskConfig{}); err != nil {
+		t.Errorf("CreateSharePoolTask err: %v", err)
+		return
+	}
+	tasks, err := taskMgr.GetSharePoolTask(ctx)
+	if err != nil || !assert.Equal(t, 1, len(tasks)) {
+		t.Errorf("GetSharePoolTask err: %v", err)
+		return
+	}
+	sharePoolTask := tasks[0]
+	if err := mgr.SettleTask(ctx, sharePoolTask, option.SettleTaskOptions{}); err != nil {
+		t.Errorf("SettleTask err: %v", err)
+		return
+	}
+
+	// the new version applies to future epochs only, until the settled one is recalculated under it
+	maxShare := decimal.NewFromFloat(0.5)
+	version, err := taskMgr.CreateRuleVersion(ctx, sharePoolTask.ID, model.TaskConfig{
+		Distribution: model.DistributionConfig{MaxShare: &maxShare},
+	}, time.Now().AddDate(0, 1, 0), "cap the share of a user")
+	if err != nil {
+		t.Errorf("CreateRuleVersion err: %v", err)
+		return
+	}
+	assert.Equal(t, 2, version.Version)
+
+	_, err = mgr.Recalculate(ctx, sharePoolTask, option.RecalculateOptions{RuleVersion: 3})
+	assert.ErrorIs(t, err, taskrule.ErrInvalid)
+
+	rec, err := mgr.Recalculate(ctx, sharePoolTask, option.RecalculateOptions{
+		Epochs:      []int{0},
+		RuleVersion: version.Version,
+		Reason:      "share cap",
+	})
+	if err != nil {
+		t.Errorf("Recalculate err: %v", err)
+		return
+	}
+	assert.Equal(t, []int{0}, rec.Epochs)
+
+	result, err := recalculationMgr.GetRecalculation(ctx, rec.ID)
+	if err != nil {
+		t.Errorf("GetRecalculation err: %v", err)
+		return
+	}
+	assert.Equal(t, "share cap", result.Reason)
+	if assert.NotNil(t, result.RuleVersion) {
+		assert.Equal(t, 2, *result.RuleVersion)
+	}
+	if !assert.Equal(t, 2, len(result.Entries)) {
+		return
+	}
+	assert.Equal(t, sender1, result.Entries[0].UserAddress)
+	assert.True(t, decimal.NewFromInt(2500).Equal(result.Entries[0].Before), "before: %v", result.Entries[0].Before)
+	assert.True(t, decimal.NewFromInt(5000).Equal(result.Entries[0].After), "after: %v", result.Entries[0].After)
+	assert.Equal(t, sender2, result.Entries[1].UserAddress)
+	assert.True(t, decimal.NewFromInt(7500).Equal(result.Entries[1].Before), "before: %v", result.Entries[1].Before)
+	assert.True(t, decimal.NewFromInt(5000).Equal(result.Entries[1].After), "after: %v", result.Entries[1].After)
+}
@@ -0,0 +1,15 @@
+package voucher
+
+import (
+	"database/sql"
+	"tradingAce/pkg/core/voucher"
+	iface "tradingAce/pkg/interface"
+)
+
+func NewManager(db *sql.DB, claimMgr iface.ClaimManager, config voucher.Config) iface.VoucherManager {
+	return &Manager{
+		db:       db,
+		claimMgr: claimMgr,
+		config:   config,
+	}
+}
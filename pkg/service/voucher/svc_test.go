@@ -0,0 +1,35 @@
+package voucher
+
+import (
+	"testing"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/voucher"
+	"tradingAce/pkg/service/campaign"
+	"tradingAce/pkg/service/claim"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewManager(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	taskMgr := task.NewManager(d)
+	claimMgr := claim.NewManager(d, campaign.NewManager(d, taskMgr), taskMgr, userpoint.NewManager(d), settlement.NewManager(d))
+	manager := NewManager(d, claimMgr, voucher.DefaultConfig)
+	mgr := manager.(*Manager)
+
+	assert.Equal(t, d, mgr.db)
+	assert.Equal(t, claimMgr, mgr.claimMgr)
+	assert.Equal(t, voucher.DefaultConfig, mgr.config)
+}
@@ -0,0 +1,297 @@
+package voucher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"tradingAce/pkg/core/voucher"
+	iface "tradingAce/pkg/interface"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type Manager struct {
+	db       *sql.DB
+	claimMgr iface.ClaimManager
+	config   voucher.Config
+
+	// the signing key is decrypted by the first voucher, commands issuing none do not need it
+	keyOnce sync.Once
+	key     *ecdsa.PrivateKey
+	keyErr  error
+
+	// the chain is dialed by the first redemption or expired voucher
+	clientOnce sync.Once
+	client     chainReader
+	clientErr  error
+}
+
+type chainReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+func (m *Manager) signingKey() (*ecdsa.PrivateKey, error) {
+	m.keyOnce.Do(func() {
+		m.key, m.keyErr = voucher.LoadKey(m.config)
+	})
+
+	return m.key, m.keyErr
+}
+
+func (m *Manager) chainClient() (chainReader, error) {
+	m.clientOnce.Do(func() {
+		if m.client != nil {
+			return
+		}
+		if len(m.config.RPCURL) == 0 {
+			m.clientErr = voucher.ErrNoChain
+			return
+		}
+		m.client, m.clientErr = ethclient.Dial(m.config.RPCURL)
+	})
+
+	return m.client, m.clientErr
+}
+
+// IssueVoucher signs the claim of the address in the snapshot of the campaign.
+// The voucher of the user is returned while it can be redeemed, a new one with the next nonce is signed once it expires
+// and the chain shows its nonce was not claimed. An expired voucher claimed on chain is recorded as redeemed,
+// whether the redemption was reported or not.
+// It returns sql.ErrNoRows when the user has no claim and voucher.ErrRedeemed once the user has redeemed it.
+func (m *Manager) IssueVoucher(ctx context.Context, campaignID string, address string) (model.Voucher, error) {
+	var result model.Voucher
+
+	key, err := m.signingKey()
+	if err != nil {
+		return result, err
+	}
+	cl, err := m.claimMgr.GetClaim(ctx, campaignID, address)
+	if err != nil {
+		return result, err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("IssueVoucher begin fail: %v", err)
+	}
+	defer tx.Rollback()
+
+	// the nonces of a user are issued one at a time
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "voucher:"+cl.UserAddress); err != nil {
+		return result, fmt.Errorf("IssueVoucher lock fail: %v", err)
+	}
+
+	now := time.Now()
+	latest, err := scanVoucher(tx.QueryRowContext(ctx, selectVoucher+`
+		WHERE "campaignId" = $1 AND "userAddress" = $2
+		ORDER BY "nonce" DESC
+		LIMIT 1
+	`, campaignID, cl.UserAddress))
+	if err == nil {
+		if latest.State == voucher.StateRedeemed {
+			return latest, voucher.ErrRedeemed
+		}
+		if now.Before(latest.Deadline) {
+			return latest, nil
+		}
+		// the expired voucher may have been redeemed without reporting it, its nonce must not be replaced then
+		redeemed, err := m.claimedOnChain(ctx, tx, latest)
+		if err != nil {
+			return result, err
+		}
+		if redeemed != nil {
+			if err := tx.Commit(); err != nil {
+				return result, fmt.Errorf("IssueVoucher commit fail: %v", err)
+			}
+			return *redeemed, voucher.ErrRedeemed
+		}
+	} else if err != sql.ErrNoRows {
+		return result, fmt.Errorf("IssueVoucher query latest fail: %v", err)
+	}
+
+	var nonce uint64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX("nonce") + 1, 0) FROM "voucher" WHERE "userAddress" = $1
+	`, cl.UserAddress).Scan(&nonce); err != nil {
+		return result, fmt.Errorf("IssueVoucher query nonce fail: %v", err)
+	}
+
+	result = model.Voucher{
+		ID:          utils.GenDBID(),
+		CampaignID:  campaignID,
+		UserAddress: cl.UserAddress,
+		Amount:      cl.Amount,
+		Nonce:       nonce,
+		Deadline:    now.Add(m.config.TTL).Truncate(time.Second),
+		Signer:      crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		State:       voucher.StateIssued,
+		CreatedAt:   now,
+	}
+	sig, err := voucher.Sign(key, m.config.Domain, voucher.Voucher{
+		User:     common.HexToAddress(result.UserAddress),
+		Campaign: campaignID,
+		Amount:   result.Amount.BigInt(),
+		Nonce:    result.Nonce,
+		Deadline: result.Deadline.Unix(),
+	})
+	if err != nil {
+		return result, fmt.Errorf("IssueVoucher sign fail: %v", err)
+	}
+	result.Signature = hexutil.Encode(sig)
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "voucher" ("id", "campaignId", "userAddress", "amount", "nonce", "deadline", "signer", "signature", "state", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, result.ID, campaignID, result.UserAddress, result.Amount, int64(result.Nonce), result.Deadline,
+		result.Signer, result.Signature, result.State, result.CreatedAt); err != nil {
+		return result, fmt.Errorf("IssueVoucher insert fail: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("IssueVoucher commit fail: %v", err)
+	}
+
+	return result, nil
+}
+
+// claimedOnChain looks for the Claimed event of the voucher nonce and records the voucher as redeemed by its transaction
+// when there is one, it returns nil when the nonce is not claimed.
+func (m *Manager) claimedOnChain(ctx context.Context, tx *sql.Tx, v model.Voucher) (*model.Voucher, error) {
+	client, err := m.chainClient()
+	if err != nil {
+		return nil, err
+	}
+	logs, err := client.FilterLogs(ctx, voucher.ClaimedQuery(m.config.Domain, common.HexToAddress(v.UserAddress), v.Nonce, m.config.FromBlock))
+	if err != nil {
+		return nil, fmt.Errorf("IssueVoucher filter claimed fail: %v", err)
+	}
+
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		result, err := scanVoucher(tx.QueryRowContext(ctx, `
+			UPDATE "voucher" SET "state" = $2, "txHash" = $3, "redeemedAt" = $4
+			WHERE "id" = $1
+			RETURNING "id", "campaignId", "userAddress", "amount", "nonce", "deadline", "signer", "signature", "state", "txHash", "createdAt", "redeemedAt"
+		`, v.ID, voucher.StateRedeemed, l.TxHash.Hex(), time.Now()))
+		if err != nil {
+			return nil, fmt.Errorf("IssueVoucher update redeemed fail: %v", err)
+		}
+		return &result, nil
+	}
+
+	return nil, nil
+}
+
+// GetVouchers returns the vouchers issued to the address, the latest first.
+func (m *Manager) GetVouchers(ctx context.Context, address string) ([]model.Voucher, error) {
+	rows, err := m.db.QueryContext(ctx, selectVoucher+`
+		WHERE "userAddress" = $1
+		ORDER BY "nonce" DESC
+	`, common.HexToAddress(address).Hex())
+	if err != nil {
+		return nil, fmt.Errorf("GetVouchers query fail: %v", err)
+	}
+	defer rows.Close()
+
+	vouchers := make([]model.Voucher, 0)
+	for rows.Next() {
+		v, err := scanVoucher(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetVouchers scan fail: %v", err)
+		}
+		vouchers = append(vouchers, v)
+	}
+
+	return vouchers, rows.Err()
+}
+
+// Redeem records the transaction redeeming the voucher on chain, once its receipt shows the claim contract
+// emitted Claimed for the user, nonce and amount of the voucher.
+// It returns sql.ErrNoRows when the voucher does not exist, voucher.ErrRedeemed when it is redeemed already
+// and voucher.ErrNotRedeemed when the transaction is not mined or did not redeem it.
+func (m *Manager) Redeem(ctx context.Context, voucherID string, txHash string) (model.Voucher, error) {
+	v, err := scanVoucher(m.db.QueryRowContext(ctx, selectVoucher+`WHERE "id" = $1`, voucherID))
+	if err != nil {
+		return v, err
+	}
+	if v.State == voucher.StateRedeemed {
+		return v, voucher.ErrRedeemed
+	}
+
+	client, err := m.chainClient()
+	if err != nil {
+		return v, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return v, fmt.Errorf("%w: transaction %s is not mined", voucher.ErrNotRedeemed, txHash)
+	} else if err != nil {
+		return v, fmt.Errorf("Redeem get receipt fail: %v", err)
+	}
+	if err := voucher.VerifyReceipt(m.config.Domain, receipt, voucher.Voucher{
+		User:     common.HexToAddress(v.UserAddress),
+		Campaign: v.CampaignID,
+		Amount:   v.Amount.BigInt(),
+		Nonce:    v.Nonce,
+		Deadline: v.Deadline.Unix(),
+	}); err != nil {
+		return v, err
+	}
+
+	result, err := scanVoucher(m.db.QueryRowContext(ctx, `
+		UPDATE "voucher" SET "state" = $2, "txHash" = $3, "redeemedAt" = $4
+		WHERE "id" = $1 AND "state" = $5
+		RETURNING "id", "campaignId", "userAddress", "amount", "nonce", "deadline", "signer", "signature", "state", "txHash", "createdAt", "redeemedAt"
+	`, voucherID, voucher.StateRedeemed, txHash, time.Now(), voucher.StateIssued))
+	if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	if _, err := scanVoucher(m.db.QueryRowContext(ctx, selectVoucher+`WHERE "id" = $1`, voucherID)); err != nil {
+		return result, err
+	}
+
+	return result, voucher.ErrRedeemed
+}
+
+const selectVoucher = `
+	SELECT "id", "campaignId", "userAddress", "amount", "nonce", "deadline", "signer", "signature", "state", "txHash", "createdAt", "redeemedAt"
+	FROM "voucher"
+`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVoucher(row scanner) (model.Voucher, error) {
+	var v model.Voucher
+	var txHash sql.NullString
+	var redeemedAt sql.NullTime
+	if err := row.Scan(
+		&v.ID, &v.CampaignID, &v.UserAddress, &v.Amount, &v.Nonce, &v.Deadline,
+		&v.Signer, &v.Signature, &v.State, &txHash, &v.CreatedAt, &redeemedAt,
+	); err != nil {
+		return v, err
+	}
+
+	v.TxHash = txHash.String
+	if redeemedAt.Valid {
+		v.RedeemedAt = &redeemedAt.Time
+	}
+
+	return v, nil
+}
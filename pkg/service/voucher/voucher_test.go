@@ -0,0 +1,296 @@
+package voucher
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+	"tradingAce/internal/testutils"
+	"tradingAce/pkg/core/claim"
+	"tradingAce/pkg/core/voucher"
+	"tradingAce/pkg/model"
+	"tradingAce/pkg/service/campaign"
+	claimSvc "tradingAce/pkg/service/claim"
+	"tradingAce/pkg/service/settlement"
+	"tradingAce/pkg/service/task"
+	"tradingAce/pkg/service/userpoint"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChain struct {
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *fakeChain) TransactionReceipt(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := c.receipts[txHash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// FilterLogs returns the logs of the receipts of the addresses and topics of the query
+func (c *fakeChain) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	logs := make([]types.Log, 0)
+	for txHash, receipt := range c.receipts {
+		for _, l := range receipt.Logs {
+			if matchLog(q, l) {
+				found := *l
+				found.TxHash = txHash
+				logs = append(logs, found)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matchLog(q ethereum.FilterQuery, l *types.Log) bool {
+	if len(q.Addresses) != 0 && !slices.Contains(q.Addresses, l.Address) {
+		return false
+	}
+	if len(q.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, topics := range q.Topics {
+		if len(topics) != 0 && !slices.Contains(topics, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// claimed is the receipt of the claim contract redeeming the voucher
+func claimed(contract common.Address, v model.Voucher) *types.Receipt {
+	return &types.Receipt{
+		Status: types.ReceiptStatusSuccessful,
+		Logs: []*types.Log{{
+			Address: contract,
+			Topics: []common.Hash{
+				voucher.ClaimedTopic,
+				common.BytesToHash(common.HexToAddress(v.UserAddress).Bytes()),
+				common.BigToHash(new(big.Int).SetUint64(v.Nonce)),
+			},
+			Data: common.BigToHash(v.Amount.BigInt()).Bytes(),
+		}},
+	}
+}
+
+func TestManager_IssueVoucher(t *testing.T) {
+	godotenv.Load("../../../.env/.env")
+
+	d, err := testutils.GetTestDb(t, "../../../migrations")
+	if err != nil {
+		t.Errorf("setup db err: %v", err)
+		return
+	}
+	defer d.Close()
+
+	ctx := context.TODO()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	config := voucher.DefaultConfig
+	config.Domain.VerifyingContract = contract
+	config.Keystore = filepath.Join(t.TempDir(), "keystore.json")
+	config.Password = "secret"
+	if err := os.WriteFile(config.Keystore, keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	userPointMgr := userpoint.NewManager(d)
+	taskMgr := task.NewManager(d)
+	claimMgr := claimSvc.NewManager(d, campaign.NewManager(d, taskMgr), taskMgr, userPointMgr, settlement.NewManager(d))
+	chain := &fakeChain{receipts: map[common.Hash]*types.Receipt{}}
+	mgr := &Manager{db: d, claimMgr: claimMgr, config: config, client: chain}
+
+	now := time.Now()
+	if _, err := d.Exec(`
+		INSERT INTO "campaign" ("id", "name", "startAt", "endAt") VALUES
+		('campaign1', 'season 1', $1, $2),
+		('campaign2', 'season 2', $1, $2)
+	`, now.AddDate(0, -3, 0), now.AddDate(0, 0, -1)); err != nil {
+		t.Errorf("insert campaign err: %v", err)
+		return
+	}
+	if _, err := d.Exec(`
+		INSERT INTO "task" ("id", "name", "startAt", "campaignId", "config") VALUES
+		('task1', 'share_pool', $1, 'campaign1', '{"epoch": {"unit": "day", "length": 1, "count": 1}}'),
+		('task2', 'share_pool', $1, 'campaign2', '{"epoch": {"unit": "day", "length": 1, "count": 1}}')
+	`, now.AddDate(0, -3, 0)); err != nil {
+		t.Errorf("insert tasks err: %v", err)
+		return
+	}
+	// the only epoch of the tasks is settled
+	if _, err := d.Exec(`
+		INSERT INTO "settlementRun" ("id", "taskId", "epoch", "state", "createdAt", "updatedAt", "committedAt") VALUES
+		('run1', 'task1', 0, 'committed', $1, $1, $1),
+		('run2', 'task2', 0, 'committed', $1, $1, $1)
+	`, now); err != nil {
+		t.Errorf("insert settlement run err: %v", err)
+		return
+	}
+	user1 := "0x0000000000000000000000000000000000000001"
+	user2 := "0x0000000000000000000000000000000000000002"
+	if err := userPointMgr.UpsertForUserTask(ctx, user1, "task1", decimal.NewFromInt(10)); err != nil {
+		t.Errorf("UpsertForUserTask err: %v", err)
+		return
+	}
+	if err := userPointMgr.UpsertForUserTask(ctx, user1, "task2", decimal.NewFromInt(20)); err != nil {
+		t.Errorf("UpsertForUserTask err: %v", err)
+		return
+	}
+	for _, campaignID := range []string{"campaign1", "campaign2"} {
+		if _, err := claimMgr.CreateSnapshot(ctx, campaignID, claim.DefaultDecimals); err != nil {
+			t.Errorf("CreateSnapshot err: %v", err)
+			return
+		}
+	}
+
+	// users without a claim get no voucher
+	_, err = mgr.IssueVoucher(ctx, "campaign1", user2)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	v, err := mgr.IssueVoucher(ctx, "campaign1", user1)
+	if err != nil {
+		t.Errorf("IssueVoucher err: %v", err)
+		return
+	}
+	assert.Equal(t, "10000000000000000000", v.Amount.String())
+	assert.Equal(t, uint64(0), v.Nonce)
+	assert.Equal(t, voucher.StateIssued, v.State)
+
+	sig, err := hexutil.Decode(v.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := voucher.Recover(config.Domain, voucher.Voucher{
+		User:     common.HexToAddress(user1),
+		Campaign: "campaign1",
+		Amount:   v.Amount.BigInt(),
+		Nonce:    v.Nonce,
+		Deadline: v.Deadline.Unix(),
+	}, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), signer)
+	assert.Equal(t, signer.Hex(), v.Signer)
+
+	// the voucher is returned again while it can be redeemed
+	again, err := mgr.IssueVoucher(ctx, "campaign1", user1)
+	if err != nil {
+		t.Errorf("IssueVoucher err: %v", err)
+		return
+	}
+	assert.Equal(t, v.ID, again.ID)
+
+	// the nonces of a user are global across campaigns
+	second, err := mgr.IssueVoucher(ctx, "campaign2", user1)
+	if err != nil {
+		t.Errorf("IssueVoucher err: %v", err)
+		return
+	}
+	assert.Equal(t, "20000000000000000000", second.Amount.String())
+	assert.Equal(t, uint64(1), second.Nonce)
+
+	// an expired voucher whose nonce is not claimed on chain is replaced with the next nonce
+	if _, err := d.Exec(`UPDATE "voucher" SET "deadline" = $1`, now.Add(-time.Minute)); err != nil {
+		t.Errorf("expire voucher err: %v", err)
+		return
+	}
+	renewed, err := mgr.IssueVoucher(ctx, "campaign1", user1)
+	if err != nil {
+		t.Errorf("IssueVoucher err: %v", err)
+		return
+	}
+	assert.NotEqual(t, v.ID, renewed.ID)
+	assert.Equal(t, uint64(2), renewed.Nonce)
+
+	// the transaction has to be mined and redeem the voucher
+	txHash := "0x" + common.Bytes2Hex(crypto.Keccak256([]byte("tx")))
+	_, err = mgr.Redeem(ctx, renewed.ID, txHash)
+	assert.ErrorIs(t, err, voucher.ErrNotRedeemed)
+	chain.receipts[common.HexToHash(txHash)] = claimed(contract, v)
+	_, err = mgr.Redeem(ctx, renewed.ID, txHash)
+	assert.ErrorIs(t, err, voucher.ErrNotRedeemed)
+	chain.receipts[common.HexToHash(txHash)] = claimed(contract, renewed)
+
+	redeemed, err := mgr.Redeem(ctx, renewed.ID, txHash)
+	if err != nil {
+		t.Errorf("Redeem err: %v", err)
+		return
+	}
+	assert.Equal(t, voucher.StateRedeemed, redeemed.State)
+	assert.Equal(t, txHash, redeemed.TxHash)
+	assert.NotNil(t, redeemed.RedeemedAt)
+
+	_, err = mgr.Redeem(ctx, renewed.ID, txHash)
+	assert.ErrorIs(t, err, voucher.ErrRedeemed)
+	_, err = mgr.Redeem(ctx, "notExist", txHash)
+	assert.Equal(t, sql.ErrNoRows, err)
+	_, err = mgr.IssueVoucher(ctx, "campaign1", user1)
+	assert.ErrorIs(t, err, voucher.ErrRedeemed)
+
+	// an expired voucher claimed on chain without reporting it is recorded as redeemed instead of replaced
+	unreported := common.BytesToHash(crypto.Keccak256([]byte("unreported")))
+	chain.receipts[unreported] = claimed(contract, second)
+	recorded, err := mgr.IssueVoucher(ctx, "campaign2", user1)
+	assert.ErrorIs(t, err, voucher.ErrRedeemed)
+	assert.Equal(t, second.ID, recorded.ID)
+	assert.Equal(t, voucher.StateRedeemed, recorded.State)
+	assert.Equal(t, unreported.Hex(), recorded.TxHash)
+
+	vouchers, err := mgr.GetVouchers(ctx, user1)
+	if err != nil {
+		t.Errorf("GetVouchers err: %v", err)
+		return
+	}
+	if assert.Equal(t, 3, len(vouchers)) {
+		assert.Equal(t, renewed.ID, vouchers[0].ID)
+		assert.Equal(t, second.ID, vouchers[1].ID)
+		assert.Equal(t, voucher.StateRedeemed, vouchers[1].State)
+		assert.Equal(t, v.ID, vouchers[2].ID)
+	}
+
+	// expired vouchers are not replaced without checking the chain
+	if _, err := d.Exec(`UPDATE "voucher" SET "state" = $1, "txHash" = NULL, "redeemedAt" = NULL WHERE "id" = $2`, voucher.StateIssued, second.ID); err != nil {
+		t.Errorf("reset voucher err: %v", err)
+		return
+	}
+	_, err = (&Manager{db: d, claimMgr: claimMgr, config: config}).IssueVoucher(ctx, "campaign2", user1)
+	assert.ErrorIs(t, err, voucher.ErrNoChain)
+
+	// vouchers are not issued without a signing key or a claim contract
+	unsigned := config
+	unsigned.Keystore = ""
+	_, err = (&Manager{db: d, claimMgr: claimMgr, config: unsigned}).IssueVoucher(ctx, "campaign1", user1)
+	assert.ErrorIs(t, err, voucher.ErrNoSigner)
+	noContract := config
+	noContract.Domain.VerifyingContract = common.Address{}
+	_, err = (&Manager{db: d, claimMgr: claimMgr, config: noContract}).IssueVoucher(ctx, "campaign1", user1)
+	assert.ErrorIs(t, err, voucher.ErrNoContract)
+}